@@ -0,0 +1,356 @@
+// Package app builds the go-stations server from a config.Config.
+// It is shared by the server (main) and the administration CLI
+// (cmd/go-stations-cli), so both use the same storage and service layer.
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/config"
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/handler/render"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+	"github.com/TechBowl-japan/go-stations/jobs"
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/repository/cache"
+	"github.com/TechBowl-japan/go-stations/repository/encrypt"
+	"github.com/TechBowl-japan/go-stations/repository/eventlog"
+	"github.com/TechBowl-japan/go-stations/repository/memory"
+	"github.com/TechBowl-japan/go-stations/repository/sqlrepo"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// Init sets up the default logger, the local time zone and the time format
+// of TODOs.
+func Init(cfg *config.Config) error {
+	//リクエストのコンテキストで出力したログには、リクエストIDが付く
+	slog.SetDefault(logging.New(os.Stderr, cfg.LogFormat, cfg.LogLevel))
+
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		return fmt.Errorf("app: failed to load time zone: %w", err)
+	}
+	time.Local = loc
+
+	//TODOの日時の形式を、既存のクライアントに合わせて変更する
+	var zone *time.Location
+	if cfg.TimeZone != "" {
+		if zone, err = time.LoadLocation(cfg.TimeZone); err != nil {
+			return fmt.Errorf("app: failed to load time-zone: %w", err)
+		}
+	}
+	model.SetTimeFormat(cfg.TimeFormat, zone)
+	return nil
+}
+
+// OpenRepository opens the storage configured by cfg and applies the pending
+// migrations. The returned function releases it.
+func OpenRepository(cfg *config.Config) (repository.Repository, func() error, error) {
+	s, err := openStore(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.repo, s.release, nil
+}
+
+// A store is the storage configured by a config.Config.
+type store struct {
+	repo repository.Repository
+	//SQLのストアの場合のみ設定される
+	db *sql.DB
+	//暗号化の鍵が設定されている場合のみ設定される
+	encrypted *encrypt.Repository
+	release   func() error
+}
+
+func openStore(cfg *config.Config) (*store, error) {
+	var (
+		repo    repository.Repository
+		todoDB  *sql.DB
+		release = func() error { return nil }
+	)
+	switch cfg.Store {
+	case "sql":
+		var err error
+		todoDB, err = db.Open(cfg.DBDriver, cfg.DSN())
+		if err != nil {
+			return nil, fmt.Errorf("app: failed to initialize %s database: %w", cfg.DBDriver, err)
+		}
+		// 長時間の稼働でも接続が劣化しないよう、接続数と寿命を制限して定期的に疎通を確認する
+		dbOpts := db.Options{
+			MaxOpenConns:    cfg.DBMaxOpenConns,
+			MaxIdleConns:    cfg.DBMaxIdleConns,
+			ConnMaxLifetime: cfg.DBConnMaxLifetime,
+			ConnMaxIdleTime: cfg.DBConnMaxIdleTime,
+			PingInterval:    cfg.DBPingInterval,
+		}
+		dbOpts.Apply(todoDB)
+		ctx, stop := context.WithCancel(context.Background())
+		go db.Monitor(ctx, todoDB, dbOpts)
+		repo = sqlrepo.New(todoDB)
+		release = func() error {
+			stop()
+			return todoDB.Close()
+		}
+	case "memory":
+		slog.Warn("Using in-memory storage, data is lost on exit")
+		repo = memory.New()
+	case "eventlog":
+		//全ての書き込みをイベントとしてファイルに追記し、起動時に再生して状態を復元する
+		if err := os.MkdirAll(filepath.Dir(cfg.EventLogPath), 0o755); err != nil {
+			return nil, fmt.Errorf("app: failed to create event log directory: %w", err)
+		}
+		eventLog, err := eventlog.OpenFileLog(cfg.EventLogPath)
+		if err != nil {
+			return nil, fmt.Errorf("app: failed to open event log: %w", err)
+		}
+		if repo, err = eventlog.Open(eventLog); err != nil {
+			eventLog.Close()
+			return nil, fmt.Errorf("app: failed to replay event log: %w", err)
+		}
+		release = eventLog.Close
+	default:
+		return nil, fmt.Errorf("app: unknown store %q", cfg.Store)
+	}
+
+	//キャッシュには復号した値を保持するため、暗号化はキャッシュより内側に置く
+	var encrypted *encrypt.Repository
+	if len(cfg.EncryptionKeys) > 0 {
+		keys, err := encrypt.ParseKeys(cfg.EncryptionKeys)
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("app: invalid encryption-keys: %w", err)
+		}
+		encrypted = encrypt.New(repo, encrypt.NewCipher(keys))
+		repo = encrypted
+	}
+	if cfg.CacheSize > 0 {
+		repo = cache.New(repo, cfg.CacheSize)
+	}
+	return &store{repo: repo, db: todoDB, encrypted: encrypted, release: release}, nil
+}
+
+// vacuumInterval is the period of VACUUM on SQLite. It rewrites the whole
+// database file, so it runs less often than the other jobs.
+const vacuumInterval = 24 * time.Hour
+
+// Jobs returns the background maintenance jobs of the storage configured by cfg.
+// db is the database of repo, or nil for the memory store.
+func Jobs(cfg *config.Config, repo repository.Repository, db *sql.DB) *jobs.Runner {
+	//間隔が0の場合は定期実行せず、/admin/jobsからの実行のみ受け付ける
+	every := func(d time.Duration) time.Duration {
+		if cfg.MaintenanceInterval == 0 {
+			return 0
+		}
+		return d
+	}
+	list := []jobs.Job{
+		jobs.ExpireUndo(repo, service.TODOOptions{UndoWindow: cfg.UndoWindow}, cfg.MaintenanceInterval),
+	}
+	if cfg.ArchiveRetention > 0 {
+		list = append(list, jobs.PurgeArchived(repo, cfg.ArchiveRetention, cfg.MaintenanceInterval))
+	}
+	if db != nil && cfg.DBDriver == "sqlite3" {
+		list = append(list, jobs.VacuumSQLite(db, every(vacuumInterval)))
+	}
+	return jobs.NewRunner(list...)
+}
+
+// Handler returns the routes backed by repo wrapped in the middleware configured by cfg.
+// The admin API runs runner's jobs; if it is nil, the job endpoints are not registered.
+func Handler(cfg *config.Config, repo repository.Repository, runner *jobs.Runner) http.Handler {
+	// NOTE: 新しいエンドポイントの登録はrouter.NewRouterの内部で行うようにする
+	mux := router.NewRouterWithOptions(repo, router.Options{
+		AdminToken:    cfg.AdminToken,
+		RequireAPIKey: cfg.RequireAPIKey,
+		Dev:           cfg.Dev,
+		Profiling:     cfg.Profiling,
+		UndoWindow:    cfg.UndoWindow,
+		Jobs:          runner,
+		//担当者の変更はログに記録する
+		AssignHooks: []service.AssignHook{service.LogAssignment},
+
+		SlackSigningSecret: cfg.SlackSigningSecret,
+		MCPScope:           cfg.MCPScope,
+	})
+
+	// 処理時間とリクエストボディの大きさは設定値で制限する(ストリームは処理時間を制限しない)
+	// レスポンスはクライアントが対応していればgzipで圧縮する
+	format := render.JSON
+	if cfg.JSONAPI {
+		format = render.JSONAPI
+	}
+
+	// レスポンスの形式はAcceptヘッダーで選び、ミドルウェアのエラーにも適用する
+	// ?fields= が指定された場合は、レスポンスのリソースをその項目だけにする
+	// CSRFの検証はCORSのプリフライトへの応答より後に行う
+	// セキュリティヘッダーとリクエストIDは全てのレスポンスに付くよう外側で付与する
+	var h http.Handler = mux
+	h = middleware.BodyLimit(cfg.MaxBodySize)(h)
+	h = middleware.Timeout(cfg.RequestTimeout, router.IsStream)(h)
+	h = middleware.Compress(middleware.DefaultCompressMinSize)(h)
+	h = middleware.CSRF(h)
+	h = middleware.CORS(cfg.CORSOrigins)(h)
+	h = middleware.Fields(h)
+	h = middleware.Negotiate(format)(h)
+	h = middleware.Language(h)
+	h = middleware.SecurityHeaders(cfg.TLS())(h)
+	h = middleware.RequestID(h)
+	return h
+}
+
+// A Server is the HTTP handler of the whole API on the storage configured by
+// a config.Config. Close releases the storage.
+type Server struct {
+	http.Handler
+	// Repository is the storage the handler is backed by.
+	Repository repository.Repository
+	// Jobs are the maintenance jobs of the storage. They only run periodically
+	// while Jobs.Run is running.
+	Jobs  *jobs.Runner
+	close func() error
+}
+
+// NewServer opens the storage configured by cfg and returns the Server on it.
+func NewServer(cfg *config.Config) (*Server, error) {
+	s, err := openStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	runner := Jobs(cfg, s.repo, s.db)
+	return &Server{Handler: Handler(cfg, s.repo, runner), Repository: s.repo, Jobs: runner, close: s.release}, nil
+}
+
+// Close releases the storage of s.
+func (s *Server) Close() error {
+	return s.close()
+}
+
+// Run serves s with Serve while running s.Jobs, until ctx is done or Serve
+// fails, and returns the error of Serve after the jobs have stopped.
+func (s *Server) Run(ctx context.Context, cfg *config.Config) error {
+	//Serveが起動に失敗して戻った場合も、ジョブを止めてから返す
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobsDone := make(chan struct{})
+	go func() {
+		defer close(jobsDone)
+		s.Jobs.Run(ctx)
+	}()
+	err := Serve(ctx, cfg, s)
+	cancel()
+	<-jobsDone
+	return err
+}
+
+// Serve serves h on cfg.Port until ctx is done, then shuts down gracefully,
+// waiting up to cfg.ShutdownTimeout for the running requests.
+// If cfg has a certificate, h is served over HTTPS with HTTP/2, and plain HTTP
+// on cfg.HTTPRedirectPort (if set) is redirected to it.
+func Serve(ctx context.Context, cfg *config.Config, h http.Handler) error {
+	srv := &http.Server{
+		Addr:         cfg.Port,
+		Handler:      h,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+		//HTTP/2はTLSNextProtoを設定しない限りServeTLSで自動的に有効になる
+		TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+	servers := []*http.Server{srv}
+	var redirect *http.Server
+	if cfg.HTTPRedirectPort != "" {
+		redirect = &http.Server{
+			Addr:         cfg.HTTPRedirectPort,
+			Handler:      redirectHTTPS(cfg.Port),
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+		}
+		servers = append(servers, redirect)
+	}
+
+	//どちらかのサーバーが起動に失敗した場合も、もう一方を止める
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		for _, s := range servers {
+			if err := s.Shutdown(shutdownCtx); err != nil {
+				slog.Error("Failed to shut down server", "addr", s.Addr, "err", err)
+			}
+		}
+	}()
+
+	redirectErr := make(chan error, 1)
+	if redirect != nil {
+		slog.Info("Redirecting HTTP to HTTPS", "port", cfg.HTTPRedirectPort)
+		go func() {
+			err := redirect.ListenAndServe()
+			if !errors.Is(err, http.ErrServerClosed) {
+				redirectErr <- fmt.Errorf("redirect server failed to start on %s: %w", cfg.HTTPRedirectPort, err)
+				cancel()
+			}
+		}()
+	}
+
+	slog.Info("Starting server", "port", cfg.Port, "tls", cfg.TLS())
+	var err error
+	if cfg.TLS() {
+		err = srv.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		cancel()
+		<-shutdown
+		return fmt.Errorf("server failed to start on %s: %w", cfg.Port, err)
+	}
+	//DBを閉じる前に、処理中のリクエストが終わるのを待つ
+	<-shutdown
+	select {
+	case err := <-redirectErr:
+		return err
+	default:
+		return nil
+	}
+}
+
+// redirectHTTPS returns the handler redirecting every request to the same URL
+// over HTTPS on port, keeping the method with 308 Permanent Redirect.
+func redirectHTTPS(port string) http.Handler {
+	_, tlsPort, _ := net.SplitHostPort(port)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			//ポートなしのHostヘッダー
+			host = strings.Trim(r.Host, "[]")
+		}
+		if tlsPort != "443" {
+			host = net.JoinHostPort(host, tlsPort)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		u := url.URL{Scheme: "https", Host: host, Path: r.URL.Path, RawPath: r.URL.RawPath, RawQuery: r.URL.RawQuery}
+		http.Redirect(w, r, u.String(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,119 @@
+package app_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/app/apptest"
+)
+
+// TestContract runs every endpoint in order and compares the responses with
+// the golden files in testdata/golden. Run with -update after changing the API.
+func TestContract(t *testing.T) {
+	t.Parallel()
+
+	h := apptest.New(t, "-admin-token", "secret", "-max-body-size", "256", "-dev")
+	admin := []string{"Authorization", "Bearer secret"}
+	jsonAPI := []string{"Accept", "application/vnd.api+json"}
+
+	steps := []struct {
+		Name    string
+		Method  string
+		Path    string
+		Body    string
+		Headers []string
+	}{
+		{Name: "healthz", Method: http.MethodGet, Path: "/healthz"},
+
+		{Name: "create_project", Method: http.MethodPost, Path: "/projects", Body: `{"name":"home","description":"chores"}`},
+		{Name: "read_projects", Method: http.MethodGet, Path: "/projects"},
+		{Name: "read_project", Method: http.MethodGet, Path: "/projects/1"},
+		{Name: "update_project", Method: http.MethodPut, Path: "/projects/1", Body: `{"name":"house","description":"chores"}`},
+
+		{Name: "create_todo", Method: http.MethodPost, Path: "/todos", Body: `{"subject":"buy milk","priority":"high","project_id":1}`},
+		{Name: "create_todo_2", Method: http.MethodPost, Path: "/todos", Body: `{"subject":"wash dishes"}`},
+		{Name: "create_todo_3", Method: http.MethodPost, Path: "/todos", Body: `{"subject":"pay rent","description":"by Friday"}`},
+		{Name: "read_todos", Method: http.MethodGet, Path: "/todos?size=2"},
+		{Name: "read_todos_page", Method: http.MethodGet, Path: "/todos?page=2&per_page=2"},
+		{Name: "read_todos_page_jsonapi", Method: http.MethodGet, Path: "/todos?page=1&per_page=2", Headers: jsonAPI},
+		{Name: "read_todo", Method: http.MethodGet, Path: "/todos/1"},
+		{Name: "read_todos_fields", Method: http.MethodGet, Path: "/todos?size=2&fields=subject,priority"},
+		{Name: "read_todo_fields", Method: http.MethodGet, Path: "/todos/1?fields=project_id"},
+		{Name: "error_unknown_field", Method: http.MethodGet, Path: "/todos?fields=subject,owner"},
+		{Name: "error_nothing_to_undo", Method: http.MethodPost, Path: "/todos/undo"},
+		{Name: "update_todo", Method: http.MethodPut, Path: "/todos", Body: `{"id":2,"subject":"wash dishes","done":true}`},
+		{Name: "reorder_todos", Method: http.MethodPut, Path: "/todos/reorder", Body: `{"ids":[3,1,2]}`},
+		{Name: "read_todos_position", Method: http.MethodGet, Path: "/todos?sort=position"},
+		{Name: "archive_todo", Method: http.MethodPost, Path: "/todos/3/archive"},
+		{Name: "unarchive_todo", Method: http.MethodPost, Path: "/todos/3/unarchive"},
+		{Name: "claim_todo", Method: http.MethodPost, Path: "/todos/3/claim", Body: `{"ttl_seconds":60}`},
+		{Name: "release_todo", Method: http.MethodPost, Path: "/todos/3/release"},
+		{Name: "read_project_todos", Method: http.MethodGet, Path: "/projects/1/todos"},
+		{Name: "read_todo_stats", Method: http.MethodGet, Path: "/todos/stats"},
+
+		{Name: "create_comment", Method: http.MethodPost, Path: "/todos/1/comments", Body: `{"body":"2 bottles"}`},
+		{Name: "clone_todo", Method: http.MethodPost, Path: "/todos/1/clone?comments=true"},
+		{Name: "read_comments", Method: http.MethodGet, Path: "/todos/1/comments"},
+		{Name: "delete_comment", Method: http.MethodDelete, Path: "/todos/1/comments/1"},
+
+		{Name: "create_api_key", Method: http.MethodPost, Path: "/admin/apikeys", Body: `{"name":"ci","role":"member","scopes":["read","write"]}`, Headers: admin},
+		{Name: "read_api_keys", Method: http.MethodGet, Path: "/admin/apikeys", Headers: admin},
+		{Name: "create_workspace", Method: http.MethodPost, Path: "/admin/workspaces", Body: `{"name":"team"}`, Headers: admin},
+		{Name: "read_workspaces", Method: http.MethodGet, Path: "/admin/workspaces", Headers: admin},
+		{Name: "error_anonymous_workspace", Method: http.MethodGet, Path: "/todos", Headers: []string{"X-Workspace", "1"}},
+		{Name: "add_workspace_member", Method: http.MethodPut, Path: "/admin/workspaces/1/members/1", Headers: admin},
+		{Name: "remove_workspace_member", Method: http.MethodDelete, Path: "/admin/workspaces/1/members/1", Headers: admin},
+		{Name: "run_job", Method: http.MethodPost, Path: "/admin/jobs/expire_undo/run", Headers: admin},
+		{Name: "read_jobs", Method: http.MethodGet, Path: "/admin/jobs", Headers: admin},
+		{Name: "error_run_job_not_found", Method: http.MethodPost, Path: "/admin/jobs/nope/run", Headers: admin},
+		{Name: "revoke_api_key", Method: http.MethodPost, Path: "/admin/apikeys/1/revoke", Headers: admin},
+
+		{Name: "delete_todo", Method: http.MethodDelete, Path: "/todos", Body: `{"ids":[2]}`},
+		{Name: "read_todo_changes", Method: http.MethodGet, Path: "/todos/changes?since=2024-01-01T00:00:00Z"},
+		{Name: "undo_delete_todo", Method: http.MethodPost, Path: "/todos/undo"},
+		{Name: "undo_update_todo", Method: http.MethodPost, Path: "/todos/undo"},
+		{Name: "unassign_todo", Method: http.MethodPut, Path: "/todos/1/assignee", Body: `{"assignee_id":0}`},
+		{Name: "read_todos_unassigned", Method: http.MethodGet, Path: "/todos?assignee=none&fields=subject"},
+		{Name: "error_assign_revoked_key", Method: http.MethodPut, Path: "/todos/1/assignee", Body: `{"assignee_id":1}`},
+
+		{Name: "create_template", Method: http.MethodPost, Path: "/templates", Body: `{"name":"weekly","todo_ids":[1,3]}`},
+		{Name: "create_template_items", Method: http.MethodPost, Path: "/templates", Body: `{"name":"release","items":[{"subject":"tag","priority":"high"},{"subject":"announce","description":"blog"}]}`},
+		{Name: "read_templates", Method: http.MethodGet, Path: "/templates"},
+		{Name: "read_template", Method: http.MethodGet, Path: "/templates/1"},
+		{Name: "instantiate_template", Method: http.MethodPost, Path: "/templates/2/instantiate"},
+		{Name: "delete_template", Method: http.MethodDelete, Path: "/templates/1"},
+		{Name: "delete_project", Method: http.MethodDelete, Path: "/projects/1"},
+
+		{Name: "error_not_found", Method: http.MethodGet, Path: "/todos/99"},
+		{Name: "error_not_found_jsonapi", Method: http.MethodGet, Path: "/todos/99", Headers: jsonAPI},
+		{Name: "error_not_found_ja", Method: http.MethodGet, Path: "/todos/99", Headers: []string{"Accept-Language", "ja"}},
+		{Name: "error_invalid_json", Method: http.MethodPost, Path: "/todos", Body: `{`},
+		{Name: "error_validation", Method: http.MethodPost, Path: "/todos", Body: `{"subject":"a","priority":"urgent"}`},
+		{Name: "error_validation_ja", Method: http.MethodPost, Path: "/todos", Body: `{"subject":"a","priority":"urgent"}`, Headers: []string{"Accept-Language", "ja-JP,ja;q=0.9,en;q=0.8"}},
+		{Name: "error_too_large", Method: http.MethodPost, Path: "/todos", Body: `{"subject":"` + strings.Repeat("a", 256) + `"}`},
+		{Name: "error_unauthorized", Method: http.MethodGet, Path: "/admin/apikeys"},
+		{Name: "error_invalid_api_key", Method: http.MethodGet, Path: "/todos", Headers: []string{"X-API-Key", "gs_invalid"}},
+		{Name: "error_unknown_workspace", Method: http.MethodGet, Path: "/todos", Headers: []string{"X-Workspace", "99"}},
+
+		{Name: "error_clone_not_found", Method: http.MethodPost, Path: "/todos/99/clone"},
+		{Name: "error_template_validation", Method: http.MethodPost, Path: "/templates", Body: `{"name":"empty"}`},
+		{Name: "error_invalid_since", Method: http.MethodGet, Path: "/todos/changes?since=yesterday"},
+
+		{Name: "batch", Method: http.MethodPost, Path: "/batch", Body: `{"requests":[{"method":"PUT","path":"/todos","body":{"id":1,"subject":"buy oat milk"}},{"method":"GET","path":"/todos/99"}]}`},
+		{Name: "batch_atomic", Method: http.MethodPost, Path: "/batch", Body: `{"atomic":true,"requests":[{"method":"PUT","path":"/todos","body":{"id":1,"subject":"rolled back"}},{"method":"GET","path":"/todos/99"},{"method":"GET","path":"/todos/1"}]}`},
+		{Name: "read_todo_after_batch", Method: http.MethodGet, Path: "/todos/1?fields=subject"},
+		{Name: "error_nested_batch", Method: http.MethodPost, Path: "/batch", Body: `{"requests":[{"method":"POST","path":"/batch"}]}`},
+
+		{Name: "seed", Method: http.MethodPost, Path: "/debug/seed"},
+		{Name: "seed_todos", Method: http.MethodGet, Path: "/todos?status=done&size=3"},
+	}
+
+	for _, s := range steps {
+		res := h.Do(t, s.Method, s.Path, s.Body, s.Headers...)
+		if got := res.Header.Get("X-Request-ID"); got == "" {
+			t.Errorf("%s: X-Request-ID is missing", s.Name)
+		}
+		h.Golden(t, s.Name, res)
+	}
+}
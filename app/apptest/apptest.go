@@ -0,0 +1,166 @@
+// Package apptest runs the whole API on in-memory storage for end-to-end
+// tests, and compares the responses with golden files.
+//
+//	h := apptest.New(t)
+//	res := h.Do(t, http.MethodPost, "/todos", `{"subject":"a"}`)
+//	h.Golden(t, "create_todo", res)
+//
+// Golden files are read from testdata/golden/<name>.json of the test package.
+// Run the tests with -update to rewrite them from the actual responses.
+package apptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/app"
+	"github.com/TechBowl-japan/go-stations/config"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files from the actual responses")
+
+// volatileFields are the JSON fields whose values differ on every run.
+// ゴールデンファイルでは値を固定の文字列に置き換えて比較する
+var volatileFields = map[string]bool{
+	"created_at":   true,
+	"updated_at":   true,
+	"last_used_at": true,
+	"revoked_at":   true,
+	"last_run_at":  true,
+	"deleted_at":   true,
+	"next_since":   true,
+	"expires_at":   true,
+	"date":         true,
+	"request_id":   true,
+	"key":          true,
+	"prefix":       true,
+}
+
+// A Harness serves the API backed by in-memory storage.
+type Harness struct {
+	*app.Server
+	Config *config.Config
+}
+
+// New returns a Harness. args are server flags applied on top of "-store memory",
+// e.g. "-admin-token", "secret".
+func New(t testing.TB, args ...string) *Harness {
+	t.Helper()
+
+	cfg, _, err := config.Load(append([]string{"-store", "memory"}, args...), func(string) string { return "" })
+	if err != nil {
+		t.Fatal("apptest: failed to load config, err =", err)
+	}
+	srv, err := app.NewServer(cfg)
+	if err != nil {
+		t.Fatal("apptest: failed to create server, err =", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return &Harness{Server: srv, Config: cfg}
+}
+
+// A Response is a response recorded by Harness.Do.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Decode decodes the JSON body into v.
+func (r *Response) Decode(t testing.TB, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("apptest: failed to decode %q, err = %v", r.Body, err)
+	}
+}
+
+// Do sends a request with the body and headers given as name-value pairs.
+func (h *Harness) Do(t testing.TB, method, path, body string, headers ...string) *Response {
+	t.Helper()
+
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, r)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return &Response{StatusCode: rec.Code, Header: rec.Header(), Body: rec.Body.Bytes()}
+}
+
+// Golden compares the status code and the body of res with testdata/golden/<name>.json.
+func (h *Harness) Golden(t testing.TB, name string, res *Response) {
+	t.Helper()
+
+	got, err := normalize(res)
+	if err != nil {
+		t.Fatalf("apptest: %s: failed to normalize %q, err = %v", name, res.Body, err)
+	}
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("apptest: %s: failed to read golden file (run with -update to create it), err = %v", name, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("apptest: %s: response differs from %s\ngot:\n%s\nwant:\n%s", name, path, got, want)
+	}
+}
+
+// normalize returns the indented JSON of the status and the body, with the
+// values of volatileFields replaced.
+func normalize(res *Response) ([]byte, error) {
+	var body interface{}
+	if len(bytes.TrimSpace(res.Body)) > 0 {
+		if err := json.Unmarshal(res.Body, &body); err != nil {
+			return nil, err
+		}
+	}
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(map[string]interface{}{
+		"status": res.StatusCode,
+		"body":   mask(body),
+	})
+	return out.Bytes(), err
+}
+
+func mask(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if volatileFields[k] && child != nil {
+				v[k] = "<" + k + ">"
+				continue
+			}
+			v[k] = mask(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = mask(child)
+		}
+	}
+	return v
+}
@@ -0,0 +1,65 @@
+package app_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/app/apptest"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestAssignTODO(t *testing.T) {
+	t.Parallel()
+
+	h := apptest.New(t, "-admin-token", "secret")
+	keys := map[string][]string{}
+	ids := map[string]int64{}
+	for _, name := range []string{"alice", "bob"} {
+		res := h.Do(t, http.MethodPost, "/admin/apikeys", `{"name":"`+name+`","role":"member","scopes":["read","write"]}`, "Authorization", "Bearer secret")
+		var created model.CreateAPIKeyResponse
+		res.Decode(t, &created)
+		if res.StatusCode != http.StatusOK || created.Key == "" {
+			t.Fatalf("failed to create API key, status = %d, body = %s", res.StatusCode, res.Body)
+		}
+		keys[name] = []string{"X-API-Key", created.Key}
+		ids[name] = created.APIKey.ID
+	}
+	for _, subject := range []string{"a", "b"} {
+		if res := h.Do(t, http.MethodPost, "/todos", `{"subject":"`+subject+`"}`, keys["alice"]...); res.StatusCode != http.StatusOK {
+			t.Fatalf("failed to create TODO, status = %d, body = %s", res.StatusCode, res.Body)
+		}
+	}
+
+	res := h.Do(t, http.MethodPut, "/todos/1/assignee", `{"assignee_id":`+strconv.FormatInt(ids["bob"], 10)+`}`, keys["alice"]...)
+	var assigned model.AssignTODOResponse
+	res.Decode(t, &assigned)
+	if res.StatusCode != http.StatusOK || assigned.TODO.AssigneeID != ids["bob"] {
+		t.Fatalf("failed to assign TODO, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+
+	//assignee=meはリクエストのAPIキーが担当するTODOのみを返す
+	for name, want := range map[string]int{"alice": 0, "bob": 1} {
+		res := h.Do(t, http.MethodGet, "/todos?assignee=me", "", keys[name]...)
+		var read model.ReadTODOResponse
+		res.Decode(t, &read)
+		if res.StatusCode != http.StatusOK || len(read.TODOs) != want {
+			t.Errorf("%s: unexpected TODOs, status = %d, body = %s", name, res.StatusCode, res.Body)
+		}
+	}
+
+	for _, step := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodGet, "/todos?assignee=me", "", http.StatusBadRequest},
+		{http.MethodGet, "/todos?assignee=someone", "", http.StatusBadRequest},
+		{http.MethodPut, "/todos/1/assignee", `{"assignee_id":99}`, http.StatusBadRequest},
+		{http.MethodPut, "/todos/1/assignee", `{"assignee_id":"1"}`, http.StatusBadRequest},
+		{http.MethodPut, "/todos/9/assignee", `{"assignee_id":0}`, http.StatusNotFound},
+	} {
+		if res := h.Do(t, step.method, step.path, step.body); res.StatusCode != step.status {
+			t.Errorf("%s %s: unexpected status, got = %d, body = %s", step.method, step.path, res.StatusCode, res.Body)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package app_test
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/app/apptest"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestBatch_AtomicSQL(t *testing.T) {
+	t.Parallel()
+
+	h := apptest.New(t, "-store", "sql", "-db-path", filepath.Join(t.TempDir(), "todo.db"))
+
+	//2件目が失敗するため、1件目の作成も取り消される
+	res := h.Do(t, http.MethodPost, "/batch", `{"atomic":true,"requests":[
+		{"method":"POST","path":"/todos","body":{"subject":"kept?"}},
+		{"method":"POST","path":"/todos","body":{"subject":""}}
+	]}`)
+	var batch model.BatchResponse
+	res.Decode(t, &batch)
+	if res.StatusCode != http.StatusOK || !batch.RolledBack || len(batch.Results) != 2 ||
+		batch.Results[0].Status != http.StatusOK || batch.Results[1].Status != http.StatusBadRequest {
+		t.Fatalf("unexpected atomic batch, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+
+	res = h.Do(t, http.MethodPost, "/batch", `{"requests":[
+		{"method":"POST","path":"/todos","body":{"subject":"a"}},
+		{"method":"POST","path":"/todos","body":{"subject":""}},
+		{"method":"POST","path":"/todos","body":{"subject":"b"}}
+	]}`)
+	var partial model.BatchResponse
+	res.Decode(t, &partial)
+	if partial.RolledBack || len(partial.Results) != 3 || partial.Results[2].Status != http.StatusOK {
+		t.Fatalf("unexpected batch, body = %s", res.Body)
+	}
+
+	var todos model.ReadTODOResponse
+	h.Do(t, http.MethodGet, "/todos", "").Decode(t, &todos)
+	if len(todos.TODOs) != 2 || todos.TODOs[0].Subject != "b" || todos.TODOs[1].Subject != "a" {
+		t.Errorf("unexpected TODOs after batches, got = %+v", todos.TODOs)
+	}
+}
+
+func TestBatch_Nested(t *testing.T) {
+	t.Parallel()
+
+	h := apptest.New(t)
+	//どのバージョンのパスでも、バッチの中にバッチは入れられない
+	for _, outer := range []string{"/batch", "/v1/batch", "/v2/batch"} {
+		for _, inner := range []string{"/batch", "/v1/batch", "/v2/batch", "/v1/batch/", "/v2/../v1/batch"} {
+			body := `{"requests":[{"method":"POST","path":"` + inner + `","body":{"requests":[{"method":"POST","path":"/todos","body":{"subject":"nested"}}]}}]}`
+			if res := h.Do(t, http.MethodPost, outer, body); res.StatusCode != http.StatusBadRequest {
+				t.Errorf("%s in %s: unexpected status, got = %d, body = %s", inner, outer, res.StatusCode, res.Body)
+			}
+		}
+	}
+
+	var todos model.ReadTODOResponse
+	h.Do(t, http.MethodGet, "/todos", "").Decode(t, &todos)
+	if len(todos.TODOs) != 0 {
+		t.Errorf("nested batch created TODOs, got = %+v", todos.TODOs)
+	}
+}
@@ -0,0 +1,47 @@
+package app_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/app/apptest"
+)
+
+func TestProfiling(t *testing.T) {
+	t.Parallel()
+
+	h := apptest.New(t, "-admin-token", "secret", "-profiling")
+	admin := []string{"Authorization", "Bearer secret"}
+
+	//管理者以外には公開しない
+	if res := h.Do(t, http.MethodGet, "/debug/vars", ""); res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unexpected status without token, got = %d", res.StatusCode)
+	}
+
+	res := h.Do(t, http.MethodGet, "/debug/vars", "", admin...)
+	var vars struct {
+		Goroutines *int                   `json:"goroutines"`
+		Heap       map[string]uint64      `json:"heap"`
+		MemStats   map[string]interface{} `json:"memstats"`
+	}
+	res.Decode(t, &vars)
+	if res.StatusCode != http.StatusOK || vars.Goroutines == nil || *vars.Goroutines <= 0 ||
+		vars.Heap["alloc_bytes"] == 0 || vars.MemStats == nil {
+		t.Errorf("unexpected vars, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+
+	res = h.Do(t, http.MethodGet, "/debug/pprof/goroutine?debug=1", "", admin...)
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("unexpected goroutine profile, status = %d, content type = %q", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	res = h.Do(t, http.MethodGet, "/debug/pprof/trace?seconds=0.1", "", admin...)
+	if res.StatusCode != http.StatusOK || len(res.Body) == 0 {
+		t.Errorf("unexpected trace, status = %d, size = %d", res.StatusCode, len(res.Body))
+	}
+
+	//フラグを指定しない場合は登録しない
+	off := apptest.New(t, "-admin-token", "secret")
+	if res := off.Do(t, http.MethodGet, "/debug/vars", "", admin...); res.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected status without -profiling, got = %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,39 @@
+package app_test
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/app/apptest"
+)
+
+func TestEventLogStore(t *testing.T) {
+	t.Parallel()
+
+	args := []string{"-store", "eventlog", "-event-log-path", filepath.Join(t.TempDir(), "log", "events.jsonl")}
+	h := apptest.New(t, args...)
+	for _, step := range []struct{ method, path, body string }{
+		{http.MethodPost, "/todos", `{"subject":"buy milk","priority":"high"}`},
+		{http.MethodPost, "/todos", `{"subject":"wash dishes"}`},
+		{http.MethodPut, "/todos", `{"id":2,"subject":"wash dishes","done":true}`},
+		{http.MethodPost, "/todos/1/comments", `{"body":"2 bottles"}`},
+		{http.MethodDelete, "/todos", `{"ids":[1]}`},
+		{http.MethodPost, "/todos/undo", ""},
+	} {
+		if res := h.Do(t, step.method, step.path, step.body); res.StatusCode != http.StatusOK {
+			t.Fatalf("%s %s: unexpected status, got = %d, body = %s", step.method, step.path, res.StatusCode, res.Body)
+		}
+	}
+	want := h.Do(t, http.MethodGet, "/todos", "")
+
+	//同じイベントログで起動し直すと、同じ状態に戻る
+	restarted := apptest.New(t, args...)
+	if got := restarted.Do(t, http.MethodGet, "/todos", ""); got.StatusCode != http.StatusOK || !bytes.Equal(got.Body, want.Body) {
+		t.Errorf("unexpected TODOs after restart\ngot  = %s\nwant = %s", got.Body, want.Body)
+	}
+	if got := restarted.Do(t, http.MethodGet, "/todos/1/comments", ""); !bytes.Contains(got.Body, []byte("2 bottles")) {
+		t.Errorf("unexpected comments after restart, got = %s", got.Body)
+	}
+}
@@ -0,0 +1,158 @@
+package app_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/app/apptest"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// decodingEndpoints are the endpoints decoding a JSON request body.
+var decodingEndpoints = []struct {
+	Method, Path string
+}{
+	{http.MethodPost, "/todos"},
+	{http.MethodPut, "/todos"},
+	{http.MethodDelete, "/todos"},
+	{http.MethodPut, "/todos/reorder"},
+	{http.MethodPost, "/todos/1/comments"},
+	{http.MethodPost, "/todos/1/claim"},
+	{http.MethodPut, "/todos/1/assignee"},
+	{http.MethodPost, "/projects"},
+	{http.MethodPut, "/projects/1"},
+	{http.MethodPost, "/templates"},
+	{http.MethodPost, "/templates/1/instantiate"},
+	{http.MethodPost, "/batch"},
+	{http.MethodPost, "/mcp"},
+	{http.MethodPost, "/admin/apikeys"},
+	{http.MethodPost, "/admin/workspaces"},
+}
+
+// FuzzDecode sends arbitrary bodies to every endpoint decoding JSON, which
+// must answer with a JSON error or success and never fail with 5xx or panic.
+//
+//	go test ./app -run '^$' -fuzz FuzzDecode
+func FuzzDecode(f *testing.F) {
+	for _, seed := range []string{
+		``,
+		`{`,
+		`null`,
+		`[]`,
+		`"subject"`,
+		`{"subject":"a"}`,
+		`{"subject":1,"description":[],"done":"yes"}`,
+		`{"id":1,"subject":"a","description":"b","done":true,"priority":"high"}`,
+		`{"id":-1,"subject":"` + strings.Repeat("a", 4096) + `"}`,
+		`{"ids":[1,1,9223372036854775807]}`,
+		`{"id":1,"before":1}`,
+		`{"name":"n","items":[{"subject":""}],"todo_ids":[1]}`,
+		`{"requests":[{"method":"POST","path":"/batch"}]}`,
+		`{"requests":[{"method":"GET","path":"://"}],"atomic":true}`,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"create_todo","arguments":{"subject":{}}}}`,
+		`{"name":"ci","role":"owner","scopes":["all"]}`,
+		`{"subject":"\u0000\ud800"}`,
+		"{\"subject\":\"\xff\xfe\"}",
+	} {
+		f.Add(seed)
+	}
+
+	h := apptest.New(f, "-admin-token", "secret", "-max-body-size", "8192", "-mcp-scope", "write")
+	//更新や削除が対象を見つけられるよう、TODOなどを先に作成しておく
+	for _, step := range []struct{ path, body string }{
+		{"/projects", `{"name":"p"}`},
+		{"/todos", `{"subject":"a"}`},
+		{"/templates", `{"name":"t","items":[{"subject":"a"}]}`},
+	} {
+		if res := h.Do(f, http.MethodPost, step.path, step.body); res.StatusCode >= 300 {
+			f.Fatalf("failed to set up %s, status = %d, body = %s", step.path, res.StatusCode, res.Body)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, body string) {
+		for _, e := range decodingEndpoints {
+			res := h.Do(t, e.Method, e.Path, body, "Authorization", "Bearer secret")
+			if res.StatusCode >= http.StatusInternalServerError {
+				t.Fatalf("%s %s: unexpected status %d for %q, body = %s", e.Method, e.Path, res.StatusCode, body, res.Body)
+			}
+			if !json.Valid(res.Body) && res.StatusCode != http.StatusAccepted {
+				t.Fatalf("%s %s: response to %q is not JSON, body = %q", e.Method, e.Path, body, res.Body)
+			}
+		}
+	})
+}
+
+// FuzzTODORoundTrip asserts that a TODO accepted by POST /todos reads back
+// with the same values, through both the in-memory and the SQL store with
+// encryption at rest, and that only requests without a subject or with an
+// unknown priority are rejected.
+//
+//	go test ./app -run '^$' -fuzz FuzzTODORoundTrip
+func FuzzTODORoundTrip(f *testing.F) {
+	for _, seed := range []struct {
+		subject, description, priority string
+		done                           bool
+	}{
+		{"buy milk", "", "", false},
+		{"pay rent", "by Friday", "high", true},
+		{"", "no subject", "", false},
+		{"a", "b", "urgent", false},
+		{"日本語の件名", "説明\n改行\tタブ", "low", false},
+		{"<script>alert(1)</script>", `"quoted" \ backslash`, "medium", true},
+		{"\u0000", "  ", "", false},
+		{"\xff invalid utf-8", "\xc3\x28", "", false},
+		{strings.Repeat("long ", 1000), strings.Repeat("説明", 1000), "", false},
+	} {
+		f.Add(seed.subject, seed.description, seed.priority, seed.done)
+	}
+
+	key := "k1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	stores := map[string]*apptest.Harness{
+		"memory": apptest.New(f),
+		"sql":    apptest.New(f, "-store", "sql", "-db-path", filepath.Join(f.TempDir(), "todo.db"), "-encryption-keys", key),
+	}
+
+	f.Fuzz(func(t *testing.T, subject, description, priority string, done bool) {
+		body, err := json.Marshal(&model.CreateTODORequest{Subject: subject, Description: description, Priority: priority, Done: done})
+		if err != nil {
+			t.Fatal("failed to marshal request, err =", err)
+		}
+		//JSONにできない文字(不正なUTF-8など)は置き換えられるため、サーバーが受け取る値と比べる
+		var want model.CreateTODORequest
+		if err := json.Unmarshal(body, &want); err != nil {
+			t.Fatal("failed to unmarshal request, err =", err)
+		}
+		valid := want.Subject != "" && (want.Priority == "" || model.PriorityRank(want.Priority) > 0)
+
+		for name, h := range stores {
+			res := h.Do(t, http.MethodPost, "/todos", string(body))
+			if !valid {
+				if res.StatusCode != http.StatusBadRequest {
+					t.Fatalf("%s: invalid request %s got status %d, body = %s", name, body, res.StatusCode, res.Body)
+				}
+				continue
+			}
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("%s: valid request %s got status %d, body = %s", name, body, res.StatusCode, res.Body)
+			}
+			var created model.CreateTODOResponse
+			res.Decode(t, &created)
+
+			res = h.Do(t, http.MethodGet, fmt.Sprintf("/todos/%d", created.TODO.ID), "")
+			var read model.ReadTODOByIDResponse
+			res.Decode(t, &read)
+			for _, got := range []model.TODO{created.TODO, read.TODO} {
+				if got.Subject != want.Subject || got.Description != want.Description ||
+					got.Priority != want.Priority || got.Done != want.Done {
+					t.Fatalf("%s: TODO %+v does not match request %s", name, got, body)
+				}
+			}
+		}
+	})
+}
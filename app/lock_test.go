@@ -0,0 +1,96 @@
+package app_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/app/apptest"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestClaimTODO(t *testing.T) {
+	t.Parallel()
+
+	h := apptest.New(t, "-admin-token", "secret")
+	keys := map[string][]string{}
+	for _, name := range []string{"alice", "bob"} {
+		res := h.Do(t, http.MethodPost, "/admin/apikeys", `{"name":"`+name+`","role":"member","scopes":["read","write"]}`, "Authorization", "Bearer secret")
+		var created model.CreateAPIKeyResponse
+		res.Decode(t, &created)
+		if res.StatusCode != http.StatusOK || created.Key == "" {
+			t.Fatalf("failed to create API key, status = %d, body = %s", res.StatusCode, res.Body)
+		}
+		keys[name] = []string{"X-API-Key", created.Key}
+	}
+	if res := h.Do(t, http.MethodPost, "/todos", `{"subject":"a"}`, keys["alice"]...); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to create TODO, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+	if res := h.Do(t, http.MethodPost, "/todos/1/claim", "", keys["alice"]...); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to claim TODO, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+
+	//他のAPIキーからの更新・削除は423で拒否する
+	for _, step := range []struct{ method, path, body string }{
+		{http.MethodPut, "/todos", `{"id":1,"subject":"b"}`},
+		{http.MethodPost, "/todos/1/archive", ""},
+		{http.MethodDelete, "/todos", `{"ids":[1]}`},
+		{http.MethodPost, "/todos/1/claim", ""},
+		{http.MethodPost, "/todos/1/release", ""},
+	} {
+		res := h.Do(t, step.method, step.path, step.body, keys["bob"]...)
+		var e model.ErrorResponse
+		res.Decode(t, &e)
+		if res.StatusCode != http.StatusLocked || e.Error.Code != "locked" {
+			t.Errorf("%s %s: unexpected response, status = %d, body = %s", step.method, step.path, res.StatusCode, res.Body)
+		}
+	}
+	if res := h.Do(t, http.MethodPut, "/todos", `{"id":1,"subject":"b"}`, keys["alice"]...); res.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status of the holder's update, got = %d, body = %s", res.StatusCode, res.Body)
+	}
+
+	if res := h.Do(t, http.MethodPost, "/todos/1/release", "", keys["alice"]...); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to release TODO, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+	if res := h.Do(t, http.MethodPut, "/todos", `{"id":1,"subject":"c"}`, keys["bob"]...); res.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status after release, got = %d, body = %s", res.StatusCode, res.Body)
+	}
+
+	for body, status := range map[string]int{
+		`{"ttl_seconds":-1}`:    http.StatusBadRequest,
+		`{"ttl_seconds":90000}`: http.StatusBadRequest,
+		`{"ttl_seconds":"1"}`:   http.StatusBadRequest,
+	} {
+		if res := h.Do(t, http.MethodPost, "/todos/1/claim", body, keys["bob"]...); res.StatusCode != status {
+			t.Errorf("%s: unexpected status, got = %d, body = %s", body, res.StatusCode, res.Body)
+		}
+	}
+	if res := h.Do(t, http.MethodPost, "/todos/9/claim", "", keys["bob"]...); res.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected status of a missing TODO, got = %d", res.StatusCode)
+	}
+
+	//更新を元に戻す場合も、他のAPIキーが確保したTODOは書き換えない
+	if res := h.Do(t, http.MethodPut, "/todos", `{"id":1,"subject":"d"}`, keys["alice"]...); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to update TODO, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+	if res := h.Do(t, http.MethodPost, "/todos/1/claim", "", keys["bob"]...); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to claim TODO, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+	if res := h.Do(t, http.MethodPost, "/todos/undo", "", keys["alice"]...); res.StatusCode != http.StatusLocked {
+		t.Errorf("unexpected status of undo, got = %d, body = %s", res.StatusCode, res.Body)
+	}
+	var read model.ReadTODOByIDResponse
+	h.Do(t, http.MethodGet, "/todos/1", "", keys["alice"]...).Decode(t, &read)
+	if read.TODO.Subject != "d" {
+		t.Errorf("undo overwrote the claimed TODO, got = %+v", read.TODO)
+	}
+	//確保が解除されれば、残していた操作を元に戻せる
+	if res := h.Do(t, http.MethodPost, "/todos/1/release", "", keys["bob"]...); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to release TODO, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+	res := h.Do(t, http.MethodPost, "/todos/undo", "", keys["alice"]...)
+	var undone model.UndoTODOResponse
+	res.Decode(t, &undone)
+	if res.StatusCode != http.StatusOK || len(undone.TODOs) != 1 || undone.TODOs[0].Subject != "c" {
+		t.Errorf("unexpected undo after release, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+}
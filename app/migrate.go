@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+
+	"github.com/TechBowl-japan/go-stations/config"
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/db/migrations"
+)
+
+// Migrate runs the "migrate" subcommand on the database configured by cfg.
+// The status is printed to w.
+//
+//	migrate up        apply all pending migrations
+//	migrate down [n]  roll back the latest n migrations (default 1)
+//	migrate status    print applied and pending migrations
+func Migrate(cfg *config.Config, args []string, w io.Writer) error {
+	todoDB, err := db.Connect(cfg.DBDriver, cfg.DSN())
+	if err != nil {
+		return err
+	}
+	defer todoDB.Close()
+
+	m, err := migrations.NewMigrator(todoDB)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	switch cmd {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			slog.Info("Applied migration", "version", mig.Version, "name", mig.Name)
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("migrate: invalid step count %q", args[1])
+			}
+		}
+		rolledBack, err := m.Down(ctx, steps)
+		for _, mig := range rolledBack {
+			slog.Info("Rolled back migration", "version", mig.Version, "name", mig.Name)
+		}
+		return err
+	case "status":
+		applied, err := m.Applied(ctx)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.Migrations() {
+			status := "pending"
+			if at, ok := applied[mig.Version]; ok {
+				status = "applied at " + at.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d_%s\t%s\n", mig.Version, mig.Name, status)
+		}
+		return nil
+	}
+	return fmt.Errorf("migrate: unknown command %q (expected up, down or status)", cmd)
+}
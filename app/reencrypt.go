@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/TechBowl-japan/go-stations/config"
+)
+
+// Reencrypt runs the "reencrypt" subcommand, encrypting the descriptions of
+// TODOs and template items which are plain text or encrypted with an old key
+// with the current key of cfg.EncryptionKeys. The number of TODOs and
+// templates rewritten is printed to w.
+func Reencrypt(ctx context.Context, cfg *config.Config, w io.Writer) error {
+	s, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := s.release(); err != nil {
+			slog.Error("Failed to close storage", "err", err)
+		}
+	}()
+	if s.encrypted == nil {
+		return errors.New("reencrypt: encryption-keys is not set")
+	}
+
+	n, err := s.encrypted.Reencrypt(ctx)
+	//途中で失敗しても、それまでに書き換えた件数を表示する
+	fmt.Fprintf(w, "Re-encrypted %d TODOs and templates\n", n)
+	return err
+}
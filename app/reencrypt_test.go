@@ -0,0 +1,80 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/app"
+	"github.com/TechBowl-japan/go-stations/app/apptest"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestReencrypt(t *testing.T) {
+	t.Parallel()
+
+	dbPath := filepath.Join(t.TempDir(), "todo.db")
+	key := "k1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+
+	//暗号化を有効にする前に作成したTODOと、それを元にしたテンプレート
+	plain := apptest.New(t, "-store", "sql", "-db-path", dbPath)
+	plain.Do(t, http.MethodPost, "/todos", `{"subject":"plan","description":"top secret"}`)
+	plain.Do(t, http.MethodPost, "/templates", `{"name":"plain","todo_ids":[1]}`)
+
+	h := apptest.New(t, "-store", "sql", "-db-path", dbPath, "-encryption-keys", key)
+	var out bytes.Buffer
+	if err := app.Reencrypt(context.Background(), h.Config, &out); err != nil || out.String() != "Re-encrypted 2 TODOs and templates\n" {
+		t.Fatalf("unexpected re-encryption, out = %q, err = %v", out.String(), err)
+	}
+
+	raw, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	var description string
+	if err := raw.QueryRow(`SELECT description FROM todos WHERE id = 1`).Scan(&description); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(description, "enc:v2:k1:") {
+		t.Errorf("description is not encrypted at rest, got = %q", description)
+	}
+
+	var res model.ReadTODOByIDResponse
+	h.Do(t, http.MethodGet, "/todos/1", "").Decode(t, &res)
+	if res.TODO.Description != "top secret" {
+		t.Errorf("unexpected description, got = %q", res.TODO.Description)
+	}
+
+	//復号したTODOを写したテンプレートの項目も暗号化して保存する
+	if res := h.Do(t, http.MethodPost, "/templates", `{"name":"encrypted","todo_ids":[1]}`); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to create template, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+	rows, err := raw.Query(`SELECT description FROM template_items ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	n := 0
+	for ; rows.Next(); n++ {
+		if err := rows.Scan(&description); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(description, "enc:v2:k1:") {
+			t.Errorf("template item is not encrypted at rest, got = %q", description)
+		}
+	}
+	if n != 2 {
+		t.Errorf("unexpected number of template items, got = %d", n)
+	}
+	var template model.ReadTemplateByIDResponse
+	h.Do(t, http.MethodGet, "/templates/2", "").Decode(t, &template)
+	if len(template.Template.Items) != 1 || template.Template.Items[0].Description != "top secret" {
+		t.Errorf("unexpected template, got = %+v", template.Template)
+	}
+}
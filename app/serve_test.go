@@ -0,0 +1,148 @@
+package app_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/app"
+	"github.com/TechBowl-japan/go-stations/app/apptest"
+)
+
+func TestServe_TLS(t *testing.T) {
+	certFile, keyFile, pool := selfSignedCert(t)
+	port, redirectPort := freePort(t), freePort(t)
+	h := apptest.New(t, "-port", port, "-tls-cert", certFile, "-tls-key", keyFile, "-http-redirect-port", redirectPort)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- app.Serve(ctx, h.Config, h) }()
+
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}, ForceAttemptHTTP2: true},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	get := func(url string) *http.Response {
+		t.Helper()
+		//サーバーが起動するまで待つ
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			res, err := client.Get(url)
+			if err == nil {
+				res.Body.Close()
+				return res
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("failed to request", url, "err =", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	res := get("https://127.0.0.1" + port + "/healthz")
+	if res.StatusCode != http.StatusOK || res.ProtoMajor != 2 {
+		t.Errorf("unexpected HTTPS response, status = %d, proto = %s", res.StatusCode, res.Proto)
+	}
+
+	res = get("http://127.0.0.1" + redirectPort + "/todos?size=1")
+	if want := "https://127.0.0.1" + port + "/todos?size=1"; res.StatusCode != http.StatusPermanentRedirect || res.Header.Get("Location") != want {
+		t.Errorf("unexpected redirect, status = %d, location = %s, want = %s", res.StatusCode, res.Header.Get("Location"), want)
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Error("failed to shut down, err =", err)
+	}
+}
+
+// selfSignedCert writes a certificate for 127.0.0.1 and its key, and returns
+// their paths and the pool trusting it.
+func selfSignedCert(t *testing.T) (string, string, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "go-stations test"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return certFile, keyFile, pool
+}
+
+// freePort returns an unused port on 127.0.0.1, e.g. ":54321".
+func freePort(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	return ":" + port
+}
+
+func TestServer_Run_ServeFails(t *testing.T) {
+	//使用中のポートでは起動に失敗する
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	h := apptest.New(t, "-port", "127.0.0.1:"+port, "-maintenance-interval", "1h")
+
+	//ジョブを動かしたまま戻らずに止まることがないよう、キャンセルしないコンテキストで呼ぶ
+	done := make(chan error, 1)
+	go func() { done <- h.Run(context.Background(), h.Config) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Run must return the error of Serve")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after Serve failed")
+	}
+}
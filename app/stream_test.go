@@ -0,0 +1,163 @@
+package app_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/app"
+	"github.com/TechBowl-japan/go-stations/app/apptest"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+func TestStreamTODOs(t *testing.T) {
+	t.Parallel()
+
+	for name, args := range map[string][]string{
+		"memory": nil,
+		"sql":    {"-store", "sql", "-db-path", filepath.Join(t.TempDir(), "todo.db")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := apptest.New(t, args...)
+			for i := 1; i <= 7; i++ {
+				body := fmt.Sprintf(`{"subject":"todo %d","done":%t}`, i, i%2 == 0)
+				if res := h.Do(t, http.MethodPost, "/todos", body); res.StatusCode != http.StatusOK {
+					t.Fatalf("failed to create TODO, status = %d, body = %s", res.StatusCode, res.Body)
+				}
+			}
+
+			for _, tt := range []struct {
+				query string
+				want  []int64
+			}{
+				//GET /todosと違い、sizeを省略すると全件を返す
+				{"", []int64{7, 6, 5, 4, 3, 2, 1}},
+				{"?size=2", []int64{7, 6}},
+				{"?status=done", []int64{6, 4, 2}},
+				{"?prev_id=3", []int64{2, 1}},
+				{"?q=todo%201", []int64{1}},
+				{"?sort=subject&size=3", []int64{1, 2, 3}},
+				{"?status=open&project_id=0", []int64{7, 5, 3, 1}},
+			} {
+				//Acceptヘッダーに関わらずNDJSONで返す
+				res := h.Do(t, http.MethodGet, "/todos/stream"+tt.query, "", "Accept", "application/xml")
+				if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "application/x-ndjson" {
+					t.Fatalf("%s: unexpected response, status = %d, content type = %q, body = %s",
+						tt.query, res.StatusCode, res.Header.Get("Content-Type"), res.Body)
+				}
+				var got []int64
+				for _, line := range bytes.Split(bytes.TrimSuffix(res.Body, []byte("\n")), []byte("\n")) {
+					if len(line) == 0 {
+						continue
+					}
+					var todo model.TODO
+					if err := json.Unmarshal(line, &todo); err != nil {
+						t.Fatalf("%s: invalid line %q, err = %v", tt.query, line, err)
+					}
+					got = append(got, todo.ID)
+				}
+				if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Errorf("%s: unexpected TODOs, got = %v, want = %v", tt.query, got, tt.want)
+				}
+			}
+
+			//該当するTODOがない場合は空の本文を返す
+			if res := h.Do(t, http.MethodGet, "/todos/stream?q=none", ""); res.StatusCode != http.StatusOK || len(res.Body) != 0 {
+				t.Errorf("unexpected empty stream, status = %d, body = %s", res.StatusCode, res.Body)
+			}
+
+			//最初の1件を書く前のエラーはJSONで返す
+			for query, status := range map[string]int{
+				"?size=-1":         http.StatusBadRequest,
+				"?status=unknown":  http.StatusBadRequest,
+				"?sort=unknown":    http.StatusBadRequest,
+				"?project_id=9999": http.StatusNotFound,
+			} {
+				res := h.Do(t, http.MethodGet, "/todos/stream"+query, "")
+				var e model.ErrorResponse
+				res.Decode(t, &e)
+				if res.StatusCode != status || e.Error.Message == "" {
+					t.Errorf("%s: unexpected error, status = %d, body = %s", query, res.StatusCode, res.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamTODOs_Timeouts(t *testing.T) {
+	//全体ではrequest-timeoutとwrite-timeoutを超えても、最後まで書き出す
+	port := freePort(t)
+	h := apptest.New(t, "-port", "127.0.0.1"+port, "-request-timeout", "50ms", "-write-timeout", "100ms")
+	const n = 5000
+	ctx := repository.WithWorkspace(context.Background(), model.DefaultWorkspace)
+	//ソケットのバッファに収まらない大きさにし、クライアントが読む速さで書かせる
+	description := strings.Repeat("x", 2000)
+	for i := 1; i <= n; i++ {
+		if _, err := h.Repository.TODOs().Create(ctx, &model.TODO{Subject: fmt.Sprint("todo ", i), Description: description}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	served := make(chan error, 1)
+	go func() { served <- app.Serve(ctx, h.Config, h) }()
+	defer func() {
+		cancel()
+		if err := <-served; err != nil {
+			t.Error("failed to shut down, err =", err)
+		}
+	}()
+
+	req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1"+port+"/todos/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//圧縮すると小さくなりすぎるため、そのまま受け取る
+	req.Header.Set("Accept-Encoding", "identity")
+	var res *http.Response
+	//サーバーが起動するまで待つ
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if res, err = http.DefaultClient.Do(req); err == nil || time.Now().After(deadline) {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatal("failed to request the stream, err =", err)
+	}
+	defer res.Body.Close()
+
+	start := time.Now()
+	got := 0
+	sc := bufio.NewScanner(res.Body)
+	for sc.Scan() {
+		var todo model.TODO
+		if err := json.Unmarshal(sc.Bytes(), &todo); err != nil || todo.ID == 0 {
+			t.Fatalf("unexpected line %d: %.200s", got+1, sc.Bytes())
+		}
+		got++
+		//ゆっくり読み、どちらのタイムアウトよりも長くかける
+		if got%250 == 0 {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal("stream cut off, err =", err)
+	}
+	if got != n {
+		t.Errorf("unexpected number of TODOs, got = %d, want = %d", got, n)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("stream finished too fast to test the timeouts, elapsed = %s", elapsed)
+	}
+}
@@ -0,0 +1,44 @@
+package app_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/app/apptest"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+)
+
+func TestAPIVersions(t *testing.T) {
+	t.Parallel()
+
+	h := apptest.New(t)
+	if res := h.Do(t, http.MethodPost, "/v1/todos", `{"subject":"buy milk"}`); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to create TODO, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+	if res := h.Do(t, http.MethodPost, "/v2/todos/1/comments", `{"body":"2 bottles"}`); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to create comment, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+
+	//バージョンのないパスとv1、v2は同じデータを同じ形で返す
+	want := h.Do(t, http.MethodGet, "/todos/1?comments=true", "")
+	if want.StatusCode != http.StatusOK {
+		t.Fatalf("failed to read TODO, status = %d, body = %s", want.StatusCode, want.Body)
+	}
+	if got := want.Header.Get(router.APIVersionHeader); got != "" {
+		t.Errorf("unexpected %s of unversioned path, got = %q", router.APIVersionHeader, got)
+	}
+	for _, version := range []string{"v1", "v2"} {
+		res := h.Do(t, http.MethodGet, "/"+version+"/todos/1?comments=true", "")
+		if res.StatusCode != http.StatusOK || !bytes.Equal(res.Body, want.Body) {
+			t.Errorf("%s: unexpected response, status = %d\ngot  = %s\nwant = %s", version, res.StatusCode, res.Body, want.Body)
+		}
+		if got := res.Header.Get(router.APIVersionHeader); got != version {
+			t.Errorf("%s: unexpected %s, got = %q", version, router.APIVersionHeader, got)
+		}
+	}
+
+	if res := h.Do(t, http.MethodGet, "/v3/todos", ""); res.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected status of unknown version, got = %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,61 @@
+package app_test
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/app/apptest"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestWorkspace_Anonymous(t *testing.T) {
+	t.Parallel()
+
+	h := apptest.New(t, "-admin-token", "secret")
+	admin := []string{"Authorization", "Bearer secret"}
+	var ws model.CreateWorkspaceResponse
+	h.Do(t, http.MethodPost, "/admin/workspaces", `{"name":"team"}`, admin...).Decode(t, &ws)
+	res := h.Do(t, http.MethodPost, "/admin/apikeys", `{"name":"member","role":"member","scopes":["read","write"]}`, admin...)
+	var created model.CreateAPIKeyResponse
+	res.Decode(t, &created)
+	if res.StatusCode != http.StatusOK || created.Key == "" {
+		t.Fatalf("failed to create API key, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+	wsID := strconv.FormatInt(ws.Workspace.ID, 10)
+	if res := h.Do(t, http.MethodPut, "/admin/workspaces/"+wsID+"/members/"+strconv.FormatInt(created.APIKey.ID, 10), "", admin...); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to add member, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+	member := []string{"X-API-Key", created.Key, "X-Workspace", wsID}
+	if res := h.Do(t, http.MethodPost, "/todos", `{"subject":"team only"}`, member...); res.StatusCode != http.StatusOK {
+		t.Fatalf("failed to create TODO, status = %d, body = %s", res.StatusCode, res.Body)
+	}
+
+	//APIキーのないリクエストは、既定以外のワークスペースを読み書きできない
+	anonymous := []string{"X-Workspace", wsID}
+	for _, step := range []struct{ method, path, body string }{
+		{http.MethodGet, "/todos", ""},
+		{http.MethodGet, "/todos/1", ""},
+		{http.MethodPut, "/todos", `{"id":1,"subject":"taken over"}`},
+		{http.MethodDelete, "/todos", `{"ids":[1]}`},
+		{http.MethodGet, "/projects", ""},
+		{http.MethodPost, "/batch", `{"requests":[{"method":"GET","path":"/todos/1"}]}`},
+	} {
+		res := h.Do(t, step.method, step.path, step.body, anonymous...)
+		var e model.ErrorResponse
+		res.Decode(t, &e)
+		if res.StatusCode != http.StatusForbidden || e.Error.Code != "forbidden" {
+			t.Errorf("%s %s: unexpected response, status = %d, body = %s", step.method, step.path, res.StatusCode, res.Body)
+		}
+	}
+
+	var read model.ReadTODOByIDResponse
+	h.Do(t, http.MethodGet, "/todos/1", "", member...).Decode(t, &read)
+	if read.TODO.Subject != "team only" {
+		t.Errorf("TODO was changed anonymously, got = %+v", read.TODO)
+	}
+	//既定のワークスペースは、これまでどおりAPIキーなしで使える
+	if res := h.Do(t, http.MethodGet, "/todos", ""); res.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status of the default workspace, got = %d, body = %s", res.StatusCode, res.Body)
+	}
+}
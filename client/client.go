@@ -0,0 +1,201 @@
+// Package client is a Go client of the go-stations API.
+//
+//	c, err := client.New("http://localhost:8080", client.WithAPIKey(key))
+//	todo, err := c.CreateTODO(ctx, &model.CreateTODORequest{Subject: "buy milk"})
+//
+// Failed requests return *Error, which can be matched against ErrNotFound and
+// the other sentinel errors with errors.Is. Idempotent requests (all but POST)
+// are retried on network errors, 429 and 5xx responses other than 500.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default values of the Options.
+const (
+	DefaultMaxRetries = 2
+	DefaultBackoff    = 100 * time.Millisecond
+	// maxBackoff caps the delay between retries, including Retry-After.
+	maxBackoff = 10 * time.Second
+)
+
+// A Client calls the go-stations API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	apiKey     string
+	workspace  int64
+	maxRetries int
+	backoff    time.Duration
+}
+
+// An Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the *http.Client used for the requests (http.DefaultClient by default).
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithAPIKey sends key in the X-API-Key header.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithWorkspace sends id in the X-Workspace header.
+func WithWorkspace(id int64) Option {
+	return func(c *Client) { c.workspace = id }
+}
+
+// WithRetries sets the number of retries of idempotent requests and the base
+// delay between them, doubled on each retry. 0 retries disables retrying.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) { c.maxRetries, c.backoff = maxRetries, backoff }
+}
+
+// New returns a Client of the API served at baseURL, e.g. "http://localhost:8080".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("client: base URL must be http or https, got %q", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		maxRetries: DefaultMaxRetries,
+		backoff:    DefaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// do sends the request and decodes the JSON response into out unless out is nil.
+// in is encoded as the JSON body unless nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("client: failed to encode request: %w", err)
+		}
+	}
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	//POSTは冪等ではないため再試行しない
+	retries := c.maxRetries
+	if method == http.MethodPost {
+		retries = 0
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, u.String(), body)
+		if err == nil && !retryable(resp.StatusCode) {
+			defer resp.Body.Close()
+			return decodeResponse(resp, out)
+		}
+		if attempt >= retries || ctx.Err() != nil {
+			if err != nil {
+				return fmt.Errorf("client: %s %s: %w", method, path, err)
+			}
+			defer resp.Body.Close()
+			return decodeResponse(resp, out)
+		}
+
+		delay := c.backoff << attempt
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		if resp != nil {
+			if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = d
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if delay > maxBackoff {
+			delay = maxBackoff
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.workspace != 0 {
+		req.Header.Set("X-Workspace", strconv.FormatInt(c.workspace, 10))
+	}
+	return c.httpClient.Do(req)
+}
+
+// retryable reports whether a response with the status may succeed if retried.
+// 500は同じリクエストでは再び失敗する可能性が高いため再試行しない
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header given in seconds.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+func decodeResponse(resp *http.Response, out interface{}) error {
+	if resp.StatusCode >= 400 {
+		return newError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("client: failed to decode response: %w", err)
+	}
+	return nil
+}
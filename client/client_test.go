@@ -0,0 +1,89 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/client"
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/handler/router"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository/memory"
+)
+
+func TestClient_TODO(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(middleware.RequestID(router.NewRouterWithRepository(memory.New())))
+	t.Cleanup(srv.Close)
+	c, err := client.New(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for _, subject := range []string{"a", "b", "c", "d", "e"} {
+		if _, err := c.CreateTODO(ctx, &model.CreateTODORequest{Subject: subject}); err != nil {
+			t.Fatal("failed to create TODO, err =", err)
+		}
+	}
+
+	done := true
+	updated, err := c.UpdateTODO(ctx, &model.UpdateTODORequest{ID: 2, Subject: "B", Done: &done})
+	if err != nil || updated.Subject != "B" || !updated.Done {
+		t.Fatalf("unexpected update, got = %+v, err = %v", updated, err)
+	}
+
+	var ids []int64
+	it := c.ListTODOs(ctx, &client.ListOptions{PageSize: 2})
+	for it.Next() {
+		ids = append(ids, it.TODO().ID)
+	}
+	if err := it.Err(); err != nil || len(ids) != 5 || ids[0] != 5 || ids[4] != 1 {
+		t.Errorf("unexpected list, got = %v, err = %v", ids, err)
+	}
+
+	if err := c.DeleteTODOs(ctx, 1, 2); err != nil {
+		t.Fatal("failed to delete TODOs, err =", err)
+	}
+	_, err = c.GetTODO(ctx, 1)
+	var apiErr *client.Error
+	if !errors.Is(err, client.ErrNotFound) || !errors.As(err, &apiErr) || apiErr.Code != "not_found" || apiErr.RequestID == "" {
+		t.Errorf("unexpected error, got = %v", err)
+	}
+	if _, err := c.CreateTODO(ctx, &model.CreateTODORequest{}); !errors.Is(err, client.ErrBadRequest) {
+		t.Errorf("unexpected error, got = %v", err)
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"todo":{"id":1,"subject":"a"}}`))
+	}))
+	t.Cleanup(srv.Close)
+	c, err := client.New(srv.URL, client.WithRetries(2, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//POSTは再試行しない
+	if _, err := c.CreateTODO(context.Background(), &model.CreateTODORequest{Subject: "a"}); err == nil || calls.Load() != 1 {
+		t.Errorf("POST must not be retried, calls = %d, err = %v", calls.Load(), err)
+	}
+	todo, err := c.GetTODO(context.Background(), 1)
+	if err != nil || todo.Subject != "a" || calls.Load() != 3 {
+		t.Errorf("GET must be retried, calls = %d, err = %v", calls.Load(), err)
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// Sentinel errors matched by *Error with errors.Is.
+var (
+	ErrBadRequest      = &Error{StatusCode: http.StatusBadRequest}
+	ErrUnauthorized    = &Error{StatusCode: http.StatusUnauthorized}
+	ErrForbidden       = &Error{StatusCode: http.StatusForbidden}
+	ErrNotFound        = &Error{StatusCode: http.StatusNotFound}
+	ErrConflict        = &Error{StatusCode: http.StatusConflict}
+	ErrTooLarge        = &Error{StatusCode: http.StatusRequestEntityTooLarge}
+	ErrLocked          = &Error{StatusCode: http.StatusLocked}
+	ErrTooManyRequests = &Error{StatusCode: http.StatusTooManyRequests}
+)
+
+// An Error is returned for error responses of the API.
+type Error struct {
+	StatusCode int
+	// Code, Message and RequestID are read from the error envelope of the response.
+	Code      string
+	Message   string
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("client: %d %s", e.StatusCode, e.Message)
+	if e.RequestID != "" {
+		msg += " (request_id " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// Is reports whether target is a sentinel error of the same status code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == "" && t.StatusCode == e.StatusCode
+}
+
+func newError(resp *http.Response) error {
+	e := &Error{StatusCode: resp.StatusCode, RequestID: resp.Header.Get("X-Request-ID")}
+	var body model.ErrorResponse
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if json.Unmarshal(data, &body) == nil && body.Error.Code != "" {
+		e.Code, e.Message = body.Error.Code, body.Error.Message
+		if body.Error.RequestID != "" {
+			e.RequestID = body.Error.RequestID
+		}
+	} else {
+		//エラーの形式で返されなかった場合はステータスの説明をメッセージにする
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
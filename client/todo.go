@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// DefaultPageSize is the number of TODOs fetched per request by ListTODOs.
+const DefaultPageSize = 50
+
+// CreateTODO creates a TODO.
+func (c *Client) CreateTODO(ctx context.Context, req *model.CreateTODORequest) (*model.TODO, error) {
+	var res model.CreateTODOResponse
+	if err := c.do(ctx, http.MethodPost, "/todos", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res.TODO, nil
+}
+
+// GetTODO reads the TODO of id.
+func (c *Client) GetTODO(ctx context.Context, id int64) (*model.TODO, error) {
+	var res model.ReadTODOByIDResponse
+	if err := c.do(ctx, http.MethodGet, "/todos/"+strconv.FormatInt(id, 10), nil, nil, &res); err != nil {
+		return nil, err
+	}
+	return &res.TODO, nil
+}
+
+// UpdateTODO updates the TODO of req.ID. Done and Priority are kept if nil.
+func (c *Client) UpdateTODO(ctx context.Context, req *model.UpdateTODORequest) (*model.TODO, error) {
+	var res model.UpdateTODOResponse
+	if err := c.do(ctx, http.MethodPut, "/todos", nil, req, &res); err != nil {
+		return nil, err
+	}
+	return &res.TODO, nil
+}
+
+// DeleteTODOs deletes the TODOs of ids. Deleting more than one TODO at once
+// requires an admin API key.
+func (c *Client) DeleteTODOs(ctx context.Context, ids ...int64) error {
+	return c.do(ctx, http.MethodDelete, "/todos", nil, &model.DeleteTODORequest{IDs: ids}, nil)
+}
+
+// AssignTODO sets the assignee of the TODO of id to the API key of
+// assigneeID, or removes the assignee if assigneeID is 0.
+func (c *Client) AssignTODO(ctx context.Context, id, assigneeID int64) (*model.TODO, error) {
+	var res model.AssignTODOResponse
+	if err := c.do(ctx, http.MethodPut, "/todos/"+strconv.FormatInt(id, 10)+"/assignee", nil, &model.AssignTODORequest{AssigneeID: assigneeID}, &res); err != nil {
+		return nil, err
+	}
+	return &res.TODO, nil
+}
+
+// ListOptions are the conditions of ListTODOs. Zero values do not filter.
+type ListOptions struct {
+	// Query filters by a string contained in the subject or the description.
+	Query string
+	// Status is "open" or "done".
+	Status    string
+	Priority  string
+	ProjectID int64
+	// Assignee is "me", "none" or the ID of an API key.
+	Assignee string
+	// Archived lists the archived TODOs instead of the others.
+	Archived bool
+	// PageSize is the number of TODOs fetched per request (DefaultPageSize if 0).
+	PageSize int64
+}
+
+// ListTODOs returns an iterator over the TODOs matching opts, newest first.
+// Pages are fetched lazily as the iterator advances.
+//
+//	it := c.ListTODOs(ctx, nil)
+//	for it.Next() {
+//		todo := it.TODO()
+//	}
+//	if err := it.Err(); err != nil {
+//		// handle err
+//	}
+func (c *Client) ListTODOs(ctx context.Context, opts *ListOptions) *TODOIterator {
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+	size := opts.PageSize
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	query := url.Values{"size": {strconv.FormatInt(size, 10)}}
+	for name, v := range map[string]string{"q": opts.Query, "status": opts.Status, "priority": opts.Priority, "assignee": opts.Assignee} {
+		if v != "" {
+			query.Set(name, v)
+		}
+	}
+	if opts.ProjectID != 0 {
+		query.Set("project_id", strconv.FormatInt(opts.ProjectID, 10))
+	}
+	if opts.Archived {
+		query.Set("archived", "true")
+	}
+	return &TODOIterator{c: c, ctx: ctx, query: query, size: size}
+}
+
+// A TODOIterator iterates over the TODOs of ListTODOs.
+type TODOIterator struct {
+	c     *Client
+	ctx   context.Context
+	query url.Values
+	size  int64
+
+	page []model.TODO
+	cur  *model.TODO
+	last bool //最後のページを読み込んだかどうか
+	err  error
+}
+
+// Next advances to the next TODO, fetching the next page if needed.
+// It returns false at the end or on an error; see Err.
+func (it *TODOIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if len(it.page) == 0 {
+		if it.last {
+			return false
+		}
+		if it.cur != nil {
+			it.query.Set("prev_id", strconv.FormatInt(it.cur.ID, 10))
+		}
+		var res model.ReadTODOResponse
+		if err := it.c.do(it.ctx, http.MethodGet, "/todos", it.query, nil, &res); err != nil {
+			it.err = err
+			return false
+		}
+		it.page = res.TODOs
+		it.last = int64(len(res.TODOs)) < it.size
+		if len(it.page) == 0 {
+			return false
+		}
+	}
+	it.cur, it.page = &it.page[0], it.page[1:]
+	return true
+}
+
+// TODO returns the current TODO.
+func (it *TODOIterator) TODO() *model.TODO {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *TODOIterator) Err() error {
+	return it.err
+}
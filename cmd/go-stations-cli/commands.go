@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/seed"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// exportPageSize is the number of rows read at once by the export command.
+const exportPageSize = 100
+
+// A cli runs the data commands on the service layer.
+// CLIは管理者として実行されるため、APIキーによる制限は受けない
+type cli struct {
+	repo     repository.Repository
+	todos    *service.TODOService
+	projects *service.ProjectService
+	comments *service.CommentService
+	apiKeys  *service.APIKeyService
+	out      io.Writer
+}
+
+func newCLI(repo repository.Repository, out io.Writer) *cli {
+	return &cli{
+		repo:     repo,
+		todos:    service.NewTODOServiceWithRepository(repo),
+		projects: service.NewProjectServiceWithRepository(repo),
+		comments: service.NewCommentServiceWithRepository(repo),
+		apiKeys:  service.NewAPIKeyServiceWithRepository(repo),
+		out:      out,
+	}
+}
+
+// An exportData is the document written by the export command.
+type exportData struct {
+	Projects []*model.Project `json:"projects"`
+	TODOs    []*model.TODO    `json:"todos"`
+	Comments []*model.Comment `json:"comments"`
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	switch {
+	case len(args) >= 2 && args[0] == "todo" && args[1] == "list":
+		return c.todoList(ctx, args[2:])
+	case len(args) >= 2 && args[0] == "todo" && args[1] == "add":
+		return c.todoAdd(ctx, args[2:])
+	case len(args) >= 2 && args[0] == "todo" && args[1] == "done":
+		return c.todoDone(ctx, args[2:])
+	case len(args) >= 1 && args[0] == "export":
+		return c.export(ctx, args[1:])
+	case len(args) >= 2 && args[0] == "user" && args[1] == "create":
+		return c.userCreate(ctx, args[2:])
+	case len(args) >= 1 && args[0] == "seed":
+		return c.seed(ctx, args[1:])
+	}
+	return fmt.Errorf("unknown command %q", strings.Join(args, " "))
+}
+
+// newFlagSet returns a FlagSet of the command with the -workspace flag if ws is not nil.
+func newFlagSet(name string, ws *int64) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	if ws != nil {
+		fs.Int64Var(ws, "workspace", model.DefaultWorkspace, "ID of the workspace")
+	}
+	return fs
+}
+
+func (c *cli) todoList(ctx context.Context, args []string) error {
+	var (
+		ws     int64
+		q      model.TODOQuery
+		asJSON bool
+	)
+	fs := newFlagSet("todo list", &ws)
+	fs.StringVar(&q.Status, "status", "", `"open" or "done" (all if empty)`)
+	fs.BoolVar(&q.Archived, "archived", false, "list the archived TODOs instead")
+	fs.Int64Var(&q.Size, "size", 20, "maximum number of TODOs")
+	fs.BoolVar(&asJSON, "json", false, "print the TODOs as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	todos, err := c.todos.ListTODO(repository.WithWorkspace(ctx, ws), &q)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return c.writeJSON(todos)
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDONE\tPRIORITY\tSUBJECT")
+	for _, todo := range todos {
+		done := ""
+		if todo.Done {
+			done = "x"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", todo.ID, done, todo.Priority, todo.Subject)
+	}
+	return tw.Flush()
+}
+
+func (c *cli) todoAdd(ctx context.Context, args []string) error {
+	var (
+		ws   int64
+		todo model.TODO
+	)
+	fs := newFlagSet("todo add", &ws)
+	fs.StringVar(&todo.Description, "description", "", "description of the TODO")
+	fs.StringVar(&todo.Priority, "priority", "", "low, medium or high")
+	fs.Int64Var(&todo.ProjectID, "project", 0, "ID of the project the TODO belongs to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	todo.Subject = strings.Join(fs.Args(), " ")
+	if todo.Subject == "" {
+		return errors.New("todo add: subject is required")
+	}
+
+	created, err := c.todos.InsertTODO(repository.WithWorkspace(ctx, ws), &todo)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Created TODO %d\n", created.ID)
+	return nil
+}
+
+func (c *cli) todoDone(ctx context.Context, args []string) error {
+	var ws int64
+	fs := newFlagSet("todo done", &ws)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ids, err := parseIDs(fs.Args())
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return errors.New("todo done: no IDs given")
+	}
+
+	ctx = repository.WithWorkspace(ctx, ws)
+	done := true
+	for _, id := range ids {
+		if _, err := c.todos.PatchTODO(ctx, id, &model.TODOPatch{Done: &done}); err != nil {
+			return fmt.Errorf("todo done: %d: %w", id, err)
+		}
+		fmt.Fprintf(c.out, "Completed TODO %d\n", id)
+	}
+	return nil
+}
+
+func (c *cli) export(ctx context.Context, args []string) error {
+	var (
+		ws   int64
+		path string
+	)
+	fs := newFlagSet("export", &ws)
+	fs.StringVar(&path, "o", "", "file to write to (standard output if empty)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx = repository.WithWorkspace(ctx, ws)
+
+	var data exportData
+	var err error
+	data.Projects, err = readAll(func(prevID int64) ([]*model.Project, error) {
+		return c.projects.ReadProjects(ctx, prevID, exportPageSize)
+	}, func(p *model.Project) int64 { return p.ID })
+	if err != nil {
+		return err
+	}
+	//アーカイブ済みのTODOも含めて書き出す
+	for _, archived := range []bool{false, true} {
+		todos, err := readAll(func(prevID int64) ([]*model.TODO, error) {
+			return c.todos.ListTODO(ctx, &model.TODOQuery{PrevID: prevID, Size: exportPageSize, Archived: archived})
+		}, func(t *model.TODO) int64 { return t.ID })
+		if err != nil {
+			return err
+		}
+		data.TODOs = append(data.TODOs, todos...)
+	}
+	data.Comments = []*model.Comment{}
+	for _, todo := range data.TODOs {
+		comments, err := readAll(func(prevID int64) ([]*model.Comment, error) {
+			return c.comments.ReadComments(ctx, todo.ID, prevID, exportPageSize)
+		}, func(c *model.Comment) int64 { return c.ID })
+		if err != nil {
+			return err
+		}
+		data.Comments = append(data.Comments, comments...)
+	}
+
+	if path == "" {
+		return c.writeJSON(&data)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(&data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (c *cli) userCreate(ctx context.Context, args []string) error {
+	var name, role, scopes string
+	fs := newFlagSet("user create", nil)
+	fs.StringVar(&name, "name", "", "name of the user (required)")
+	fs.StringVar(&role, "role", model.RoleMember, "admin, member or readonly")
+	fs.StringVar(&scopes, "scopes", model.ScopeRead+","+model.ScopeWrite, "comma-separated scopes of the API key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if name == "" {
+		return errors.New("user create: -name is required")
+	}
+
+	key, secret, err := c.apiKeys.CreateAPIKey(ctx, name, role, strings.Split(scopes, ","))
+	if err != nil {
+		return err
+	}
+	//キーはここでしか表示されないため、控えるように促す
+	fmt.Fprintf(c.out, "Created user %q (API key %d, role %s)\n", key.Name, key.ID, key.Role)
+	fmt.Fprintf(c.out, "API key: %s\n", secret)
+	fmt.Fprintln(c.out, "Store the key now, it cannot be shown again.")
+	return nil
+}
+
+func (c *cli) seed(ctx context.Context, args []string) error {
+	var ws int64
+	fs := newFlagSet("seed", &ws)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	res, err := seed.Load(repository.WithWorkspace(ctx, ws), c.repo)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "Created %d projects, %d TODOs and %d comments\n", res.Projects, res.TODOs, res.Comments)
+	return nil
+}
+
+func (c *cli) writeJSON(v interface{}) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// readAll reads every page of a list ordered by ID descending.
+func readAll[T any](read func(prevID int64) ([]T, error), id func(T) int64) ([]T, error) {
+	all := []T{}
+	var prevID int64
+	for {
+		page, err := read(prevID)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < exportPageSize {
+			return all, nil
+		}
+		prevID = id(page[len(page)-1])
+	}
+}
+
+func parseIDs(args []string) ([]int64, error) {
+	ids := make([]int64, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid ID %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
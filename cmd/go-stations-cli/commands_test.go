@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/repository/memory"
+)
+
+func TestCLI(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	c := newCLI(memory.New(), &out)
+	ctx := context.Background()
+	run := func(args ...string) string {
+		t.Helper()
+		out.Reset()
+		if err := c.run(ctx, args); err != nil {
+			t.Fatalf("%v failed, err = %v", args, err)
+		}
+		return out.String()
+	}
+
+	if got := run("todo", "add", "-priority", "high", "buy", "milk"); got != "Created TODO 1\n" {
+		t.Errorf("unexpected output of todo add, got = %q", got)
+	}
+	run("todo", "add", "-workspace", "2", "other workspace")
+	run("todo", "done", "1")
+	if got := run("todo", "list", "-status", "done"); !strings.Contains(got, "1   x     high      buy milk") {
+		t.Errorf("unexpected output of todo list, got = %q", got)
+	}
+
+	var data exportData
+	if err := json.Unmarshal([]byte(run("export")), &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.TODOs) != 1 || data.TODOs[0].Subject != "buy milk" || !data.TODOs[0].Done {
+		t.Errorf("unexpected export, got = %+v", data)
+	}
+
+	if got := run("user", "create", "-name", "ci", "-role", "readonly"); !strings.Contains(got, "API key: gs_") {
+		t.Errorf("unexpected output of user create, got = %q", got)
+	}
+
+	if got := run("seed", "-workspace", "3"); got != "Created 3 projects, 17 TODOs and 5 comments\n" {
+		t.Errorf("unexpected output of seed, got = %q", got)
+	}
+
+	for _, args := range [][]string{
+		{"todo", "add"},
+		{"todo", "done", "x"},
+		{"todo", "done", "99"},
+		{"user", "create"},
+		{"todo", "remove"},
+	} {
+		if err := c.run(ctx, args); err == nil {
+			t.Errorf("%v must fail", args)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Command go-stations-cli administers a go-stations server and its data.
+//
+// It takes the same flags, environment variables and configuration file as
+// the server, followed by a command:
+//
+//	serve                                   run the HTTP server
+//	migrate up|down [n]|status              manage the schema migrations
+//	todo list [-status s] [-archived] [-size n] [-json]
+//	todo add [-description d] [-priority p] [-project id] <subject>
+//	todo done <id>...                       mark the TODOs as done
+//	export [-o file]                        write all projects, TODOs and comments as JSON
+//	user create -name n [-role r] [-scopes read,write]
+//	                                        issue an API key and print it
+//	seed                                    load the fixture data for demos
+//	reencrypt                               encrypt the TODO descriptions with the
+//	                                        current key of -encryption-keys
+//
+// The todo, export and seed commands accept -workspace to select the workspace.
+// Users are represented by API keys, so "user create" issues an API key.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/TechBowl-japan/go-stations/app"
+	"github.com/TechBowl-japan/go-stations/config"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "go-stations-cli:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	// 設定はサーバーと同じく、デフォルト値 < 設定ファイル < 環境変数 < フラグ の順に優先される
+	cfg, args, err := config.Load(args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	if err := app.Init(cfg); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("no command given (serve, migrate, todo, export, user, seed or reencrypt)")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	//マイグレーションはリポジトリを開く前に実行する
+	if args[0] == "migrate" {
+		return app.Migrate(cfg, args[1:], out)
+	}
+
+	if args[0] == "serve" {
+		return serve(ctx, cfg)
+	}
+	if args[0] == "reencrypt" {
+		return app.Reencrypt(ctx, cfg, out)
+	}
+
+	repo, closeRepo, err := app.OpenRepository(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := closeRepo(); err != nil {
+			slog.Error("Failed to close storage", "err", err)
+		}
+	}()
+
+	return newCLI(repo, out).run(ctx, args)
+}
+
+// serve runs the API server with its maintenance jobs until ctx is done.
+func serve(ctx context.Context, cfg *config.Config) error {
+	srv, err := app.NewServer(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := srv.Close(); err != nil {
+			slog.Error("Failed to close storage", "err", err)
+		}
+	}()
+
+	jobsDone := make(chan struct{})
+	go func() {
+		defer close(jobsDone)
+		srv.Jobs.Run(ctx)
+	}()
+	defer func() { <-jobsDone }()
+	return app.Serve(ctx, cfg, srv)
+}
@@ -0,0 +1,317 @@
+// Package config loads the server configuration from, in increasing order of
+// precedence, built-in defaults, an optional config file, environment
+// variables and command-line flags.
+//
+// Every setting has a flag name (e.g. "db-dsn"), which is also its key in the
+// config file, and an environment variable (e.g. DB_DSN). The config file is
+// given by -config or CONFIG_FILE; see ParseFile for the formats.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// A Config holds the settings of the server.
+type Config struct {
+	// Port is the address to listen on, e.g. ":8080".
+	Port string
+	// TLSCert and TLSKey are the PEM files of the certificate and its key.
+	// If set, the server serves HTTPS and HTTP/2 on Port.
+	TLSCert string
+	TLSKey  string
+	// HTTPRedirectPort is the address on which plain HTTP requests are
+	// redirected to HTTPS on Port (empty disables it). It requires TLS.
+	HTTPRedirectPort string
+	// Store is the storage backend, "sql", "memory" or "eventlog".
+	Store string
+	// EventLogPath is the event log file of the eventlog store.
+	EventLogPath string
+	// DBDriver is one of sqlite3, postgres and mysql.
+	DBDriver string
+	// DBPath is the SQLite file used if DBDSN is empty.
+	DBPath string
+	DBDSN  string
+	// DBMaxOpenConns, DBMaxIdleConns, DBConnMaxLifetime and DBConnMaxIdleTime
+	// configure the connection pool (0 keeps the database/sql defaults).
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+	// DBPingInterval is the interval of the database health checks (0 disables them).
+	DBPingInterval time.Duration
+	// CacheSize is the number of TODO list pages to cache (0 disables the cache).
+	CacheSize int
+	LogLevel  slog.Level
+	// LogFormat is the log output format, "text" or "json".
+	LogFormat string
+	// TimeFormat is the layout of the times of TODOs in JSON and query
+	// parameters, e.g. "2006-01-02 15:04:05" (RFC 3339 if empty).
+	TimeFormat string
+	// TimeZone is the IANA time zone the times of TODOs are written in, e.g.
+	// "Asia/Tokyo" (the zone of each time as stored if empty).
+	TimeZone string
+
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	// RequestTimeout is the deadline of each request (0 disables it).
+	// It should be shorter than WriteTimeout so that the 504 reaches the client.
+	RequestTimeout time.Duration
+	// MaxBodySize is the maximum request body size in bytes (0 disables the limit).
+	MaxBodySize int64
+	// UndoWindow is how long updates and deletes of TODOs can be undone.
+	UndoWindow time.Duration
+	// MaintenanceInterval is the period of the background maintenance jobs (0 disables the periodic runs).
+	MaintenanceInterval time.Duration
+	// ArchiveRetention is how long archived TODOs are kept before they are
+	// purged (0 keeps them forever).
+	ArchiveRetention time.Duration
+
+	// EncryptionKeys are the keys TODO descriptions are encrypted at rest
+	// with, written as "id:base64-secret", current key first. Descriptions
+	// are stored in plain text if empty.
+	EncryptionKeys []string
+
+	// CORSOrigins are the origins allowed to call the API from browsers.
+	// "*" allows any origin. No CORS headers are sent if empty.
+	CORSOrigins []string
+	// AdminToken is accepted as "Authorization: Bearer <AdminToken>" by /admin/*.
+	AdminToken    string
+	RequireAPIKey bool
+	// SlackSigningSecret enables POST /integrations/slack for the Slack app
+	// with this signing secret.
+	SlackSigningSecret string
+	// MCPScope enables POST /mcp, the MCP server for AI assistants: "read"
+	// offers the tools reading TODOs and "write" all of them. It is disabled
+	// if empty.
+	MCPScope string
+	// Profiling enables the profiling endpoints /debug/pprof/ and
+	// /debug/vars for administrators.
+	Profiling bool
+	// Dev enables the development endpoints such as POST /debug/seed.
+	Dev bool
+	// JSONAPI makes JSON:API the response format of clients whose Accept
+	// header does not prefer another format.
+	JSONAPI bool
+}
+
+// DSN returns DBDSN, or DBPath if DBDSN is empty.
+func (c *Config) DSN() string {
+	if c.DBDSN != "" {
+		return c.DBDSN
+	}
+	return c.DBPath
+}
+
+// A setting binds a flag to its environment variable.
+type setting struct {
+	name, env string
+}
+
+// Load returns the Config built from the defaults, the config file, the
+// environment (looked up with getenv) and args, and the arguments left after
+// the flags (e.g. the "migrate" subcommand).
+func Load(args []string, getenv func(string) string) (*Config, []string, error) {
+	c := &Config{}
+	fs := flag.NewFlagSet("go-stations", flag.ContinueOnError)
+	settings := c.define(fs)
+	configFile := fs.String("config", "", "path of the config file (.yaml, .yml or .toml)")
+
+	//コマンドラインで指定されたフラグは、設定ファイルや環境変数で上書きしない
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	fromArgs := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { fromArgs[f.Name] = true })
+
+	path := *configFile
+	if path == "" {
+		path = getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		values, err := ParseFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		for key, value := range values {
+			if key == "config" || fs.Lookup(key) == nil {
+				return nil, nil, fmt.Errorf("config: %s: unknown key %q", path, key)
+			}
+			if fromArgs[key] {
+				continue
+			}
+			if err := fs.Set(key, value); err != nil {
+				return nil, nil, fmt.Errorf("config: %s: %s: %w", path, key, err)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if value := getenv(s.env); value != "" && !fromArgs[s.name] {
+			if err := fs.Set(s.name, value); err != nil {
+				return nil, nil, fmt.Errorf("config: %s: %w", s.env, err)
+			}
+		}
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, nil, err
+	}
+	return c, fs.Args(), nil
+}
+
+// define defines the flags of c with their defaults on fs.
+func (c *Config) define(fs *flag.FlagSet) []setting {
+	fs.StringVar(&c.Port, "port", ":8080", `address to listen on, e.g. ":8080" or "8080"`)
+	fs.StringVar(&c.TLSCert, "tls-cert", "", "certificate file (PEM) to serve HTTPS and HTTP/2 with; requires -tls-key")
+	fs.StringVar(&c.TLSKey, "tls-key", "", "private key file (PEM) of -tls-cert")
+	fs.StringVar(&c.HTTPRedirectPort, "http-redirect-port", "", `address to redirect plain HTTP to HTTPS on, e.g. ":80" (requires -tls-cert)`)
+	fs.StringVar(&c.Store, "store", "sql", `storage backend: "sql", "memory" or "eventlog"`)
+	fs.StringVar(&c.EventLogPath, "event-log-path", ".eventlog/events.jsonl", "event log file (JSON Lines) of -store eventlog")
+	fs.StringVar(&c.DBDriver, "db-driver", "sqlite3", "database driver: sqlite3, postgres or mysql")
+	fs.StringVar(&c.DBPath, "db-path", ".sqlite3/todo.db", "SQLite file used if -db-dsn is empty")
+	fs.StringVar(&c.DBDSN, "db-dsn", "", "data source name of the database")
+	fs.IntVar(&c.DBMaxOpenConns, "db-max-open-conns", 0, "maximum number of open database connections (0 for unlimited)")
+	fs.IntVar(&c.DBMaxIdleConns, "db-max-idle-conns", 2, "maximum number of idle database connections")
+	fs.DurationVar(&c.DBConnMaxLifetime, "db-conn-max-lifetime", 30*time.Minute, "maximum lifetime of a database connection (0 for unlimited)")
+	fs.DurationVar(&c.DBConnMaxIdleTime, "db-conn-max-idle-time", 5*time.Minute, "maximum idle time of a database connection (0 for unlimited)")
+	fs.DurationVar(&c.DBPingInterval, "db-ping-interval", 30*time.Second, "interval of the database health checks (0 disables them)")
+	fs.IntVar(&c.CacheSize, "cache-size", 0, "number of TODO list pages to cache in memory (0 disables the cache)")
+	fs.TextVar(&c.LogLevel, "log-level", slog.LevelInfo, "minimum log level: debug, info, warn or error")
+	fs.StringVar(&c.LogFormat, "log-format", "text", `log output format: "text" or "json"`)
+	fs.StringVar(&c.TimeFormat, "time-format", "", `layout of the times of TODOs in JSON in Go's notation, e.g. "2006-01-02 15:04:05" (RFC 3339 if empty)`)
+	fs.StringVar(&c.TimeZone, "time-zone", "", `time zone the times of TODOs are written in, e.g. "Asia/Tokyo" (as stored if empty)`)
+	fs.DurationVar(&c.ReadTimeout, "read-timeout", 10*time.Second, "maximum duration for reading a request")
+	fs.DurationVar(&c.WriteTimeout, "write-timeout", 30*time.Second, "maximum duration for writing a response")
+	fs.DurationVar(&c.IdleTimeout, "idle-timeout", 2*time.Minute, "maximum duration to keep idle connections")
+	fs.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", 10*time.Second, "maximum duration to wait for requests on shutdown")
+	fs.DurationVar(&c.RequestTimeout, "request-timeout", 20*time.Second, "deadline of each request, answered with 504 on expiry (0 disables it)")
+	fs.Int64Var(&c.MaxBodySize, "max-body-size", 1<<20, "maximum request body size in bytes, answered with 413 if exceeded (0 disables it)")
+	fs.DurationVar(&c.UndoWindow, "undo-window", 5*time.Minute, "how long updates and deletes of TODOs can be undone with POST /todos/undo")
+	fs.DurationVar(&c.MaintenanceInterval, "maintenance-interval", time.Hour, "period of the background maintenance jobs (0 disables the periodic runs)")
+	fs.DurationVar(&c.ArchiveRetention, "archive-retention", 0, "purge TODOs archived and not updated for longer than this (0 keeps them forever)")
+	fs.Var((*listValue)(&c.EncryptionKeys), "encryption-keys", `comma-separated "id:base64-secret" AES keys encrypting TODO descriptions, current key first`)
+	fs.Var((*listValue)(&c.CORSOrigins), "cors-origins", `comma-separated origins allowed by CORS ("*" for any)`)
+	fs.StringVar(&c.AdminToken, "admin-token", "", "bearer token accepted by /admin/*")
+	fs.BoolVar(&c.RequireAPIKey, "require-api-key", false, "reject requests without an X-API-Key header")
+	fs.StringVar(&c.SlackSigningSecret, "slack-signing-secret", "", "signing secret of the Slack app whose /todo command is sent to POST /integrations/slack")
+	fs.StringVar(&c.MCPScope, "mcp-scope", "", `enable the MCP server at POST /mcp with the tools of the scope: "read" or "write"`)
+	fs.BoolVar(&c.Profiling, "profiling", false, "enable /debug/pprof/ and /debug/vars for administrators")
+	fs.BoolVar(&c.Dev, "dev", false, "enable the development endpoints such as POST /debug/seed")
+	fs.BoolVar(&c.JSONAPI, "jsonapi", false, "respond in JSON:API (application/vnd.api+json) unless the Accept header prefers another format")
+
+	//環境変数名は、既存の名前(ADMIN_API_TOKEN)を除いてフラグ名から決める
+	var settings []setting
+	fs.VisitAll(func(f *flag.Flag) {
+		env := strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		if f.Name == "admin-token" {
+			env = "ADMIN_API_TOKEN"
+		}
+		settings = append(settings, setting{name: f.Name, env: env})
+	})
+	return settings
+}
+
+func (c *Config) validate() error {
+	if c.Store != "sql" && c.Store != "memory" && c.Store != "eventlog" {
+		return fmt.Errorf("config: unknown store %q", c.Store)
+	}
+	if c.Store == "eventlog" && c.EventLogPath == "" {
+		return fmt.Errorf("config: event-log-path is required by the eventlog store")
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		return fmt.Errorf("config: unknown log-format %q", c.LogFormat)
+	}
+	if c.MCPScope != "" && c.MCPScope != "read" && c.MCPScope != "write" {
+		return fmt.Errorf("config: unknown mcp-scope %q", c.MCPScope)
+	}
+	if c.TimeFormat != "" {
+		//要素を含まないレイアウト(例: "date")では、全ての日時が同じ文字列になってしまう
+		ref := time.Date(2024, 5, 4, 12, 34, 56, 0, time.UTC)
+		if v := ref.Format(c.TimeFormat); v == c.TimeFormat {
+			return fmt.Errorf("config: time-format %q has no elements of a time", c.TimeFormat)
+		} else if _, err := time.Parse(c.TimeFormat, v); err != nil {
+			return fmt.Errorf("config: time-format %q cannot be parsed back: %w", c.TimeFormat, err)
+		}
+	}
+	if c.TimeZone != "" {
+		if _, err := time.LoadLocation(c.TimeZone); err != nil {
+			return fmt.Errorf("config: unknown time-zone %q", c.TimeZone)
+		}
+	}
+	if c.CacheSize < 0 {
+		return fmt.Errorf("config: cache-size must not be negative")
+	}
+	if c.DBMaxOpenConns < 0 || c.DBMaxIdleConns < 0 {
+		return fmt.Errorf("config: db-max-open-conns and db-max-idle-conns must not be negative")
+	}
+	if c.MaxBodySize < 0 {
+		return fmt.Errorf("config: max-body-size must not be negative")
+	}
+	for name, d := range map[string]time.Duration{
+		"read-timeout":          c.ReadTimeout,
+		"write-timeout":         c.WriteTimeout,
+		"idle-timeout":          c.IdleTimeout,
+		"shutdown-timeout":      c.ShutdownTimeout,
+		"request-timeout":       c.RequestTimeout,
+		"db-conn-max-lifetime":  c.DBConnMaxLifetime,
+		"db-conn-max-idle-time": c.DBConnMaxIdleTime,
+		"db-ping-interval":      c.DBPingInterval,
+		"undo-window":           c.UndoWindow,
+		"maintenance-interval":  c.MaintenanceInterval,
+		"archive-retention":     c.ArchiveRetention,
+	} {
+		if d < 0 {
+			return fmt.Errorf("config: %s must not be negative", name)
+		}
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return fmt.Errorf("config: tls-cert and tls-key must be set together")
+	}
+	if c.HTTPRedirectPort != "" && !c.TLS() {
+		return fmt.Errorf("config: http-redirect-port requires tls-cert and tls-key")
+	}
+	//"8080"のようにポート番号だけが指定された場合は":8080"として扱う
+	c.Port = normalizePort(c.Port)
+	if c.HTTPRedirectPort != "" {
+		c.HTTPRedirectPort = normalizePort(c.HTTPRedirectPort)
+	}
+	return nil
+}
+
+// TLS reports whether the server serves HTTPS.
+func (c *Config) TLS() bool {
+	return c.TLSCert != ""
+}
+
+func normalizePort(port string) string {
+	if !strings.Contains(port, ":") {
+		return ":" + port
+	}
+	return port
+}
+
+// A listValue is a flag.Value of comma-separated strings.
+type listValue []string
+
+func (l *listValue) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *listValue) Set(s string) error {
+	*l = nil
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package config_test
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/config"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	yaml := filepath.Join(dir, "config.yaml")
+	writeFile(t, yaml, `# server settings
+port: 9000
+db-driver: "postgres"
+db-dsn: 'postgres://localhost/todo#1'  # quoted # is kept
+log-level: debug
+cors-origins: [https://a.example, "https://b.example"]
+read-timeout: 5s
+`)
+	toml := filepath.Join(dir, "config.toml")
+	writeFile(t, toml, `store = "memory"
+cache-size = 3
+request-timeout = "3s"
+max-body-size = 1024
+`)
+
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	cases := map[string]struct {
+		Args    []string
+		Env     map[string]string
+		Check   func(t *testing.T, c *config.Config)
+		Rest    []string
+		WantErr bool
+	}{
+		"Defaults": {
+			Check: func(t *testing.T, c *config.Config) {
+				if c.Port != ":8080" || c.Store != "sql" || c.DSN() != ".sqlite3/todo.db" || c.LogLevel != slog.LevelInfo || c.CORSOrigins != nil {
+					t.Errorf("unexpected defaults, got = %+v", c)
+				}
+			},
+		},
+		"File": {
+			Args: []string{"-config", yaml},
+			Check: func(t *testing.T, c *config.Config) {
+				if c.Port != ":9000" || c.DBDriver != "postgres" || c.DSN() != "postgres://localhost/todo#1" ||
+					c.LogLevel != slog.LevelDebug || c.ReadTimeout != 5*time.Second ||
+					!reflect.DeepEqual(c.CORSOrigins, []string{"https://a.example", "https://b.example"}) {
+					t.Errorf("unexpected config from file, got = %+v", c)
+				}
+			},
+		},
+		"TOML from env": {
+			Env: map[string]string{"CONFIG_FILE": toml},
+			Check: func(t *testing.T, c *config.Config) {
+				if c.Store != "memory" || c.CacheSize != 3 || c.RequestTimeout != 3*time.Second || c.MaxBodySize != 1024 {
+					t.Errorf("unexpected config from TOML, got = %+v", c)
+				}
+			},
+		},
+		"Env overrides file, flags override env": {
+			Args: []string{"-config=" + yaml, "-db-driver", "mysql", "migrate", "down"},
+			Env:  map[string]string{"PORT": ":7000", "DB_DRIVER": "sqlite3", "ADMIN_API_TOKEN": "secret"},
+			Rest: []string{"migrate", "down"},
+			Check: func(t *testing.T, c *config.Config) {
+				if c.Port != ":7000" || c.DBDriver != "mysql" || c.AdminToken != "secret" || c.LogLevel != slog.LevelDebug {
+					t.Errorf("unexpected precedence, got = %+v", c)
+				}
+			},
+		},
+		"Unknown key":   {Args: []string{"-config", writeFile(t, filepath.Join(dir, "bad.yaml"), "prot: 80\n")}, WantErr: true},
+		"Nested value":  {Args: []string{"-config", writeFile(t, filepath.Join(dir, "nested.yaml"), "db:\n  dsn: x\n")}, WantErr: true},
+		"Unknown store": {Env: map[string]string{"STORE": "redis"}, WantErr: true},
+		"Invalid env":   {Env: map[string]string{"CACHE_SIZE": "many"}, WantErr: true},
+		"Negative size": {Args: []string{"-max-body-size", "-1"}, WantErr: true},
+		"Missing file":  {Args: []string{"-config", filepath.Join(dir, "missing.yaml")}, WantErr: true},
+		"TLS": {
+			Args: []string{"-port", "8443", "-tls-cert", "cert.pem", "-tls-key", "key.pem", "-http-redirect-port", "8080"},
+			Check: func(t *testing.T, c *config.Config) {
+				if !c.TLS() || c.Port != ":8443" || c.HTTPRedirectPort != ":8080" {
+					t.Errorf("unexpected TLS config, got = %+v", c)
+				}
+			},
+		},
+		"TLS key missing":      {Args: []string{"-tls-cert", "cert.pem"}, WantErr: true},
+		"Redirect without TLS": {Args: []string{"-http-redirect-port", ":80"}, WantErr: true},
+		"Unknown MCP scope":    {Args: []string{"-mcp-scope", "admin"}, WantErr: true},
+		"Time format": {
+			Env: map[string]string{"TIME_FORMAT": "2006-01-02 15:04:05", "TIME_ZONE": "Asia/Tokyo"},
+			Check: func(t *testing.T, c *config.Config) {
+				if c.TimeFormat != "2006-01-02 15:04:05" || c.TimeZone != "Asia/Tokyo" {
+					t.Errorf("unexpected time format, got = %+v", c)
+				}
+			},
+		},
+		"Time format without elements": {Args: []string{"-time-format", "date"}, WantErr: true},
+		"Unknown time zone":            {Args: []string{"-time-zone", "Mars/Olympus"}, WantErr: true},
+		"Event log": {
+			Env: map[string]string{"STORE": "eventlog", "EVENT_LOG_PATH": "events.jsonl"},
+			Check: func(t *testing.T, c *config.Config) {
+				if c.Store != "eventlog" || c.EventLogPath != "events.jsonl" {
+					t.Errorf("unexpected event log config, got = %+v", c)
+				}
+			},
+		},
+		"Event log without path": {Args: []string{"-store", "eventlog", "-event-log-path", ""}, WantErr: true},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, rest, err := config.Load(c.Args, env(c.Env))
+			if c.WantErr {
+				if err == nil {
+					t.Errorf("must fail, got = %+v", cfg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("failed to load config, err =", err)
+			}
+			if len(rest) != 0 || len(c.Rest) != 0 {
+				if !reflect.DeepEqual(rest, c.Rest) {
+					t.Errorf("unexpected rest args, got = %v", rest)
+				}
+			}
+			c.Check(t, cfg)
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) string {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ParseFile reads the flat key/value pairs of a config file. The format is
+// chosen by the extension: YAML (.yaml, .yml) with "key: value" lines, or
+// TOML (.toml) with "key = value" lines. Only the subset needed for flat
+// settings is supported: comments (#), quoted or bare scalars, and inline
+// lists ([a, b]), which are joined with commas. Nested tables and multi-line
+// values are rejected.
+func ParseFile(path string) (map[string]string, error) {
+	var sep string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		sep = ":"
+	case ".toml":
+		sep = "="
+	default:
+		return nil, fmt.Errorf("config: %s: unsupported file type, want .yaml, .yml or .toml", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(s.Text()))
+		if line == "" || line == "---" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, sep)
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t[") {
+			return nil, fmt.Errorf("config: %s:%d: want %q", path, n, "key"+sep+" value")
+		}
+		value, err := parseValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("config: %s:%d: %w", path, n, err)
+		}
+		values[unquote(key)] = value
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// stripComment removes a # comment which is not inside quotes.
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseValue parses a scalar or an inline list into the string form accepted by the flags.
+func parseValue(s string) (string, error) {
+	if strings.HasPrefix(s, "[") {
+		if !strings.HasSuffix(s, "]") {
+			return "", fmt.Errorf("unterminated list %q", s)
+		}
+		items := strings.Split(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"), ",")
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, unquote(item))
+			}
+		}
+		return strings.Join(values, ","), nil
+	}
+	if s == "" || s == "|" || s == ">" || strings.HasPrefix(s, "{") {
+		return "", fmt.Errorf("nested or multi-line values are not supported")
+	}
+	return unquote(s), nil
+}
+
+// unquote removes the double or single quotes around s, if any.
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
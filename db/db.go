@@ -1,25 +1,67 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	_ "embed"
+	"fmt"
 
+	"github.com/TechBowl-japan/go-stations/db/dialect"
+	"github.com/TechBowl-japan/go-stations/db/migrations"
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-//go:embed schema.sql
-var schema string
-
 // NewDB returns go-sqlite3 driver based *sql.DB.
 func NewDB(path string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", path)
+	return Open(dialect.DriverSQLite, path)
+}
+
+// Open returns *sql.DB of the driver ("sqlite3", "postgres" or "mysql") and
+// applies the pending schema migrations.
+func Open(driver, dsn string) (*sql.DB, error) {
+	db, err := Connect(driver, dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err := db.Exec(schema); err != nil {
+	if err := Migrate(context.Background(), db); err != nil {
 		return nil, err
 	}
 
 	return db, nil
 }
+
+// Connect returns *sql.DB of the driver without applying migrations.
+func Connect(driver, dsn string) (*sql.DB, error) {
+	switch driver {
+	case dialect.DriverSQLite:
+		dsn = sqliteDSN(dsn)
+	case dialect.DriverPostgres:
+	case dialect.DriverMySQL:
+		// created_at/updated_atをtime.Timeとして読み込み、複数ステートメントのマイグレーションを実行できるようにする
+		// また、RowsAffectedが変更行数ではなく一致した行数を返すようにする
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ParseTime = true
+		cfg.MultiStatements = true
+		cfg.ClientFoundRows = true
+		dsn = cfg.FormatDSN()
+	default:
+		return nil, fmt.Errorf("db: unsupported driver %q", driver)
+	}
+
+	return sql.Open(driver, dsn)
+}
+
+// Migrate applies the pending schema migrations to db.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	m, err := migrations.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	_, err = m.Up(ctx)
+	return err
+}
@@ -3,6 +3,7 @@ package db_test
 import (
 	"errors"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/TechBowl-japan/go-stations/db"
@@ -35,7 +36,10 @@ func TestNewDB(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			_, err := db.NewDB(c.path)
+			d, err := db.NewDB(c.path)
+			if err == nil {
+				t.Cleanup(func() { d.Close() })
+			}
 			if err != nil {
 				if errors.Is(c.err, err) {
 					t.Errorf("unexpected value, given = %s, expected = %s\n", err, c.err)
@@ -44,3 +48,29 @@ func TestNewDB(t *testing.T) {
 		})
 	}
 }
+
+func TestConnect_SQLite(t *testing.T) {
+	t.Parallel()
+
+	d, err := db.Connect("sqlite3", filepath.Join(t.TempDir(), "connect_test.db"))
+	if err != nil {
+		t.Fatal("failed to connect, err =", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	db.Options{MaxOpenConns: 4, MaxIdleConns: 4}.Apply(d)
+
+	var mode string
+	var timeout int
+	if err := d.QueryRow(`PRAGMA journal_mode`).Scan(&mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.QueryRow(`PRAGMA busy_timeout`).Scan(&timeout); err != nil {
+		t.Fatal(err)
+	}
+	if mode != "wal" || timeout != 5000 {
+		t.Errorf("unexpected pragmas, journal_mode = %s, busy_timeout = %d", mode, timeout)
+	}
+	if got := d.Stats().MaxOpenConnections; got != 4 {
+		t.Errorf("unexpected max open connections, got = %d", got)
+	}
+}
@@ -0,0 +1,177 @@
+// Package dialect abstracts the differences between the SQL databases
+// supported by go-stations (SQLite, PostgreSQL and MySQL).
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Driver names accepted by sql.Open.
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// A Queryer is implemented by both *sql.DB and *sql.Tx.
+type Queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// A Dialect expresses the SQL differences of a database.
+type Dialect interface {
+	// Driver returns the driver name used with sql.Open.
+	Driver() string
+	// Rebind rewrites ? placeholders into the database's placeholder syntax.
+	Rebind(query string) string
+	// Now returns the SQL expression for the current UTC time.
+	Now() string
+	// Time converts t into an argument comparable with the timestamp columns.
+	Time(t time.Time) interface{}
+	// Insert executes the INSERT query and returns the ID of the inserted row,
+	// using RETURNING or LastInsertId depending on the database.
+	Insert(ctx context.Context, q Queryer, query string, args ...interface{}) (int64, error)
+	// Date returns the SQL expression formatting the timestamp expr as "YYYY-MM-DD".
+	Date(expr string) string
+	// Busy reports whether err is a transient lock error, after which the
+	// statement (or the whole transaction) may succeed if retried.
+	Busy(err error) bool
+}
+
+// For returns the Dialect of the driver name. ok is false if the driver is not supported.
+func For(driver string) (d Dialect, ok bool) {
+	switch driver {
+	case DriverSQLite:
+		return SQLite, true
+	case DriverPostgres:
+		return Postgres, true
+	case DriverMySQL:
+		return MySQL, true
+	}
+	return nil, false
+}
+
+var (
+	// SQLite is the Dialect of github.com/mattn/go-sqlite3.
+	SQLite Dialect = sqliteDialect{}
+	// Postgres is the Dialect of github.com/lib/pq.
+	Postgres Dialect = postgresDialect{}
+	// MySQL is the Dialect of github.com/go-sql-driver/mysql.
+	MySQL Dialect = mysqlDialect{}
+)
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Driver() string             { return DriverSQLite }
+func (sqliteDialect) Rebind(query string) string { return query }
+func (sqliteDialect) Now() string                { return `DATETIME('now')` }
+func (sqliteDialect) Date(expr string) string    { return `DATE(` + expr + `)` }
+
+// Time formats t like DATETIME('now') since SQLite compares timestamps as text.
+func (sqliteDialect) Time(t time.Time) interface{} {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+func (sqliteDialect) Insert(ctx context.Context, q Queryer, query string, args ...interface{}) (int64, error) {
+	return lastInsertID(ctx, q, query, args...)
+}
+
+// Busy reports SQLITE_BUSY and SQLITE_LOCKED, returned when another connection
+// holds the lock longer than busy_timeout or when a transaction cannot upgrade
+// its read lock.
+func (sqliteDialect) Busy(err error) bool {
+	var e sqlite3.Error
+	return errors.As(err, &e) && (e.Code == sqlite3.ErrBusy || e.Code == sqlite3.ErrLocked)
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Driver() string { return DriverPostgres }
+func (postgresDialect) Now() string    { return `CURRENT_TIMESTAMP` }
+func (postgresDialect) Date(expr string) string {
+	return `TO_CHAR(` + expr + `, 'YYYY-MM-DD')`
+}
+
+func (postgresDialect) Time(t time.Time) interface{} { return t }
+func (postgresDialect) Busy(err error) bool          { return false }
+
+// Rebind rewrites ? into $1, $2, ... skipping quoted literals.
+func (postgresDialect) Rebind(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	var quote rune
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			//文字列リテラルの中の?は置き換えない
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// Insert appends RETURNING id since lib/pq does not support LastInsertId.
+func (d postgresDialect) Insert(ctx context.Context, q Queryer, query string, args ...interface{}) (int64, error) {
+	var id int64
+	if err := q.QueryRowContext(ctx, d.Rebind(query)+` RETURNING id`, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Driver() string             { return DriverMySQL }
+func (mysqlDialect) Rebind(query string) string { return query }
+func (mysqlDialect) Now() string                { return `UTC_TIMESTAMP()` }
+func (mysqlDialect) Date(expr string) string {
+	return `DATE_FORMAT(` + expr + `, '%Y-%m-%d')`
+}
+
+// Time returns t in UTC since DATETIME columns have no time zone.
+func (mysqlDialect) Time(t time.Time) interface{} { return t.UTC() }
+func (mysqlDialect) Busy(err error) bool          { return false }
+func (mysqlDialect) Insert(ctx context.Context, q Queryer, query string, args ...interface{}) (int64, error) {
+	return lastInsertID(ctx, q, query, args...)
+}
+
+func lastInsertID(ctx context.Context, q Queryer, query string, args ...interface{}) (int64, error) {
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// Detect returns the Dialect of the driver behind db. SQLite is returned for unknown drivers.
+func Detect(db *sql.DB) Dialect {
+	switch db.Driver().(type) {
+	case *pq.Driver:
+		return Postgres
+	case *mysql.MySQLDriver:
+		return MySQL
+	}
+	return SQLite
+}
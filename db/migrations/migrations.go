@@ -0,0 +1,286 @@
+// Package migrations applies the versioned schema migrations embedded in
+// the sql directory and tracks them in the schema_migrations table.
+//
+// Migration files are named NNNN_name.up.sql / NNNN_name.down.sql and are
+// text/template files rendered per database, so one file serves SQLite,
+// PostgreSQL and MySQL.
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/db/dialect"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// A Migration expresses one versioned schema change.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// templateData holds the database specific fragments available to migration files.
+type templateData struct {
+	Driver       string
+	PK           string
+	Timestamp    string
+	Now          string
+	TableOptions string
+}
+
+var templates = map[string]templateData{
+	dialect.DriverSQLite: {
+		Driver:    dialect.DriverSQLite,
+		PK:        "INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT",
+		Timestamp: "DATETIME",
+		Now:       "(DATETIME('now'))",
+	},
+	dialect.DriverPostgres: {
+		Driver:    dialect.DriverPostgres,
+		PK:        "BIGSERIAL NOT NULL PRIMARY KEY",
+		Timestamp: "TIMESTAMPTZ",
+		Now:       "CURRENT_TIMESTAMP",
+	},
+	dialect.DriverMySQL: {
+		Driver:       dialect.DriverMySQL,
+		PK:           "BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY",
+		Timestamp:    "DATETIME",
+		Now:          "(UTC_TIMESTAMP())",
+		TableOptions: " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+	},
+}
+
+// Load returns the migrations rendered for the driver, sorted by version.
+func Load(driver string) ([]Migration, error) {
+	data, ok := templates[driver]
+	if !ok {
+		return nil, fmt.Errorf("migrations: unsupported driver %q", driver)
+	}
+
+	entries, err := files.ReadDir("sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		//NNNN_name.up.sql または NNNN_name.down.sql を解析する
+		name := e.Name()
+		base := strings.TrimSuffix(name, ".sql")
+		direction := path.Ext(base)
+		base = strings.TrimSuffix(base, direction)
+		sep := strings.IndexByte(base, '_')
+		if sep < 0 || (direction != ".up" && direction != ".down") {
+			return nil, fmt.Errorf("migrations: invalid file name %q", name)
+		}
+		version, err := strconv.ParseInt(base[:sep], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrations: invalid version in %q: %w", name, err)
+		}
+
+		body, err := render(name, data)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: base[sep+1:]}
+			byVersion[version] = m
+		}
+		if direction == ".up" {
+			m.Up = body
+		} else {
+			m.Down = body
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migrations: version %d has no up migration", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func render(name string, data templateData) (string, error) {
+	b, err := files.ReadFile("sql/" + name)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(name).Parse(string(b))
+	if err != nil {
+		return "", fmt.Errorf("migrations: failed to parse %q: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("migrations: failed to render %q: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// A Migrator applies migrations to a database.
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect.Dialect
+	migrations []Migration
+}
+
+// NewMigrator returns a Migrator for db with the embedded migrations.
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	d := dialect.Detect(db)
+	migrations, err := Load(d.Driver())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		dialect:    d,
+		migrations: migrations,
+	}, nil
+}
+
+// Migrations returns all known migrations sorted by version.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Applied returns the versions recorded in schema_migrations.
+func (m *Migrator) Applied(ctx context.Context) (map[int64]time.Time, error) {
+	const read = `SELECT version, applied_at FROM schema_migrations`
+
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.QueryContext(ctx, read)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// Version returns the latest applied version, or 0 if nothing is applied.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Up applies all pending migrations and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	const insert = `INSERT INTO schema_migrations(version, applied_at) VALUES(?, ?)`
+
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		err := m.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, m.dialect.Rebind(insert), mig.Version, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("migrations: failed to apply %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down rolls back the latest steps applied migrations and returns the rolled back ones.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	const remove = `DELETE FROM schema_migrations WHERE version = ?`
+
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	//新しいバージョンから順に戻す
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		err := m.inTx(ctx, func(tx *sql.Tx) error {
+			if mig.Down != "" {
+				if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+					return err
+				}
+			}
+			_, err := tx.ExecContext(ctx, m.dialect.Rebind(remove), mig.Version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("migrations: failed to roll back %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	const create = `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, applied_at %s NOT NULL)`
+
+	_, err := m.db.ExecContext(ctx, fmt.Sprintf(create, templates[m.dialect.Driver()].Timestamp))
+	return err
+}
+
+func (m *Migrator) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,80 @@
+package migrations_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/db/migrations"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	for _, driver := range []string{"sqlite3", "postgres", "mysql"} {
+		driver := driver
+		t.Run(driver, func(t *testing.T) {
+			t.Parallel()
+
+			migs, err := migrations.Load(driver)
+			if err != nil {
+				t.Fatal("failed to load migrations, err =", err)
+			}
+			for i, m := range migs {
+				if i > 0 && m.Version <= migs[i-1].Version {
+					t.Errorf("migrations are not sorted, got %d after %d", m.Version, migs[i-1].Version)
+				}
+				if m.Down == "" {
+					t.Errorf("migration %04d_%s has no down migration", m.Version, m.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	t.Parallel()
+
+	d, err := db.Connect("sqlite3", filepath.Join(t.TempDir(), "migrations_test.db"))
+	if err != nil {
+		t.Fatal("failed to connect, err =", err)
+	}
+	defer d.Close()
+
+	m, err := migrations.NewMigrator(d)
+	if err != nil {
+		t.Fatal("failed to create migrator, err =", err)
+	}
+	ctx := context.Background()
+	all := len(m.Migrations())
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal("failed to migrate up, err =", err)
+	}
+	if len(applied) != all {
+		t.Errorf("unexpected applied count, got = %d, want = %d", len(applied), all)
+	}
+
+	// 2回目は何も適用されない
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Errorf("unexpected second up, applied = %d, err = %v", len(applied), err)
+	}
+
+	rolledBack, err := m.Down(ctx, all)
+	if err != nil {
+		t.Fatal("failed to migrate down, err =", err)
+	}
+	if len(rolledBack) != all {
+		t.Errorf("unexpected rolled back count, got = %d, want = %d", len(rolledBack), all)
+	}
+	if v, err := m.Version(ctx); err != nil || v != 0 {
+		t.Errorf("unexpected version after down, got = %d, err = %v", v, err)
+	}
+
+	// 全て戻した後に再度適用できる
+	if _, err := m.Up(ctx); err != nil {
+		t.Error("failed to migrate up again, err =", err)
+	}
+}
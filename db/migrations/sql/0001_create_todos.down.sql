@@ -0,0 +1,2 @@
+{{if eq .Driver "sqlite3"}}DROP TRIGGER IF EXISTS trigger_todos_updated_at;{{end}}
+DROP TABLE IF EXISTS todos;
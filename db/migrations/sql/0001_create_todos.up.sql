@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS todos (
+  id          {{.PK}},
+  subject     TEXT        NOT NULL,
+  description TEXT        NOT NULL{{if ne .Driver "mysql"}} DEFAULT ''{{end}},
+  created_at  {{.Timestamp}} NOT NULL DEFAULT {{.Now}},
+  updated_at  {{.Timestamp}} NOT NULL DEFAULT {{.Now}},
+  CHECK(subject <> '')
+){{.TableOptions}};
+{{if eq .Driver "sqlite3"}}
+CREATE TRIGGER IF NOT EXISTS trigger_todos_updated_at AFTER UPDATE ON todos
+BEGIN
+  UPDATE todos SET updated_at = DATETIME('now') WHERE id == NEW.id;
+END;
+{{end}}
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS comments (
+  id          {{.PK}},
+  todo_id     BIGINT      NOT NULL{{if ne .Driver "mysql"}} REFERENCES todos(id) ON DELETE CASCADE{{end}},
+  body        TEXT        NOT NULL,
+  created_at  {{.Timestamp}} NOT NULL DEFAULT {{.Now}},
+  CHECK(body <> ''){{if eq .Driver "mysql"}},
+  FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE{{end}}
+){{.TableOptions}};
+
+CREATE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_comments_todo_id ON comments(todo_id);
+{{if eq .Driver "sqlite3"}}
+CREATE TRIGGER IF NOT EXISTS trigger_todos_delete_comments AFTER DELETE ON todos
+BEGIN
+  DELETE FROM comments WHERE todo_id == OLD.id;
+END;
+{{end}}
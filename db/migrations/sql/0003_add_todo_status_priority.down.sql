@@ -0,0 +1,3 @@
+DROP INDEX {{if ne .Driver "mysql"}}IF EXISTS index_todos_done{{else}}index_todos_done ON todos{{end}};
+ALTER TABLE todos DROP COLUMN priority;
+ALTER TABLE todos DROP COLUMN done;
@@ -0,0 +1,4 @@
+ALTER TABLE todos ADD COLUMN done BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE todos ADD COLUMN priority VARCHAR(16) NOT NULL DEFAULT '';
+
+CREATE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_todos_done ON todos(done);
@@ -0,0 +1,2 @@
+DROP INDEX {{if ne .Driver "mysql"}}IF EXISTS index_todos_archived{{else}}index_todos_archived ON todos{{end}};
+ALTER TABLE todos DROP COLUMN archived;
@@ -0,0 +1,3 @@
+ALTER TABLE todos ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_todos_archived ON todos(archived);
@@ -0,0 +1,2 @@
+DROP INDEX {{if ne .Driver "mysql"}}IF EXISTS index_todos_sort_order{{else}}index_todos_sort_order ON todos{{end}};
+ALTER TABLE todos DROP COLUMN sort_order;
@@ -0,0 +1,5 @@
+ALTER TABLE todos ADD COLUMN sort_order BIGINT NOT NULL DEFAULT 0;
+-- 既存のTODOは新しい順に並ぶように、間隔を空けて並べる
+UPDATE todos SET sort_order = -id * 1024;
+
+CREATE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_todos_sort_order ON todos(sort_order);
@@ -0,0 +1,6 @@
+{{if eq .Driver "sqlite3"}}DROP TRIGGER IF EXISTS trigger_projects_delete_todos;
+DROP TRIGGER IF EXISTS trigger_projects_updated_at;{{end}}
+{{if eq .Driver "mysql"}}ALTER TABLE todos DROP FOREIGN KEY fk_todos_project_id;{{end}}
+DROP INDEX {{if ne .Driver "mysql"}}IF EXISTS index_todos_project_id{{else}}index_todos_project_id ON todos{{end}};
+ALTER TABLE todos DROP COLUMN project_id;
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS projects (
+  id          {{.PK}},
+  name        TEXT        NOT NULL,
+  description TEXT        NOT NULL{{if ne .Driver "mysql"}} DEFAULT ''{{end}},
+  created_at  {{.Timestamp}} NOT NULL DEFAULT {{.Now}},
+  updated_at  {{.Timestamp}} NOT NULL DEFAULT {{.Now}},
+  CHECK(name <> '')
+){{.TableOptions}};
+
+ALTER TABLE todos ADD COLUMN project_id BIGINT{{if eq .Driver "postgres"}} REFERENCES projects(id) ON DELETE SET NULL{{end}};
+{{if eq .Driver "mysql"}}
+ALTER TABLE todos ADD CONSTRAINT fk_todos_project_id FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE SET NULL;
+{{end}}
+CREATE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_todos_project_id ON todos(project_id);
+{{if eq .Driver "sqlite3"}}
+CREATE TRIGGER IF NOT EXISTS trigger_projects_updated_at AFTER UPDATE ON projects
+BEGIN
+  UPDATE projects SET updated_at = DATETIME('now') WHERE id == NEW.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS trigger_projects_delete_todos AFTER DELETE ON projects
+BEGIN
+  UPDATE todos SET project_id = NULL WHERE project_id == OLD.id;
+END;
+{{end}}
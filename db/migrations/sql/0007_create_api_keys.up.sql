@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  id           {{.PK}},
+  name         VARCHAR(255) NOT NULL,
+  prefix       VARCHAR(16)  NOT NULL,
+  key_hash     CHAR(64)     NOT NULL,
+  scopes       VARCHAR(255) NOT NULL,
+  created_at   {{.Timestamp}} NOT NULL DEFAULT {{.Now}},
+  last_used_at {{.Timestamp}} NULL,
+  revoked_at   {{.Timestamp}} NULL,
+  CHECK(name <> '')
+){{.TableOptions}};
+
+CREATE UNIQUE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_api_keys_key_hash ON api_keys(key_hash);
@@ -0,0 +1,8 @@
+DROP INDEX {{if ne .Driver "mysql"}}IF EXISTS index_projects_workspace_id{{else}}index_projects_workspace_id ON projects{{end}};
+DROP INDEX {{if ne .Driver "mysql"}}IF EXISTS index_comments_workspace_id{{else}}index_comments_workspace_id ON comments{{end}};
+DROP INDEX {{if ne .Driver "mysql"}}IF EXISTS index_todos_workspace_id{{else}}index_todos_workspace_id ON todos{{end}};
+ALTER TABLE projects DROP COLUMN workspace_id;
+ALTER TABLE comments DROP COLUMN workspace_id;
+ALTER TABLE todos DROP COLUMN workspace_id;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS workspaces (
+  id         {{.PK}},
+  name       VARCHAR(255) NOT NULL,
+  created_at {{.Timestamp}} NOT NULL DEFAULT {{.Now}},
+  CHECK(name <> '')
+){{.TableOptions}};
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+  workspace_id BIGINT NOT NULL,
+  api_key_id   BIGINT NOT NULL,
+  created_at   {{.Timestamp}} NOT NULL DEFAULT {{.Now}},
+  PRIMARY KEY (workspace_id, api_key_id)
+){{.TableOptions}};
+
+-- 0は既定のワークスペースで、workspacesテーブルには行を持たない
+ALTER TABLE todos ADD COLUMN workspace_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN workspace_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN workspace_id BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_todos_workspace_id ON todos(workspace_id);
+CREATE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_comments_workspace_id ON comments(workspace_id);
+CREATE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_projects_workspace_id ON projects(workspace_id);
@@ -0,0 +1,2 @@
+DROP INDEX {{if ne .Driver "mysql"}}IF EXISTS index_undo_log_workspace_id_api_key_id{{else}}index_undo_log_workspace_id_api_key_id ON undo_log{{end}};
+DROP TABLE IF EXISTS undo_log;
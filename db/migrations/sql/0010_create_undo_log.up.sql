@@ -0,0 +1,12 @@
+-- 削除・更新の直前のTODOの状態をJSONで保存し、POST /todos/undoで元に戻せるようにする
+-- api_key_idはAPIキーなしのリクエストの場合は0
+CREATE TABLE IF NOT EXISTS undo_log (
+  id           {{.PK}},
+  workspace_id BIGINT      NOT NULL DEFAULT 0,
+  api_key_id   BIGINT      NOT NULL DEFAULT 0,
+  action       VARCHAR(16) NOT NULL,
+  snapshot     TEXT        NOT NULL,
+  created_at   {{.Timestamp}} NOT NULL DEFAULT {{.Now}}
+){{.TableOptions}};
+
+CREATE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_undo_log_workspace_id_api_key_id ON undo_log(workspace_id, api_key_id);
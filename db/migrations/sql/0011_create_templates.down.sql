@@ -0,0 +1,3 @@
+{{if eq .Driver "sqlite3"}}DROP TRIGGER IF EXISTS trigger_templates_delete_items;{{end}}
+DROP TABLE IF EXISTS template_items;
+DROP TABLE IF EXISTS templates;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS templates (
+  id           {{.PK}},
+  name         TEXT        NOT NULL,
+  description  TEXT        NOT NULL{{if ne .Driver "mysql"}} DEFAULT ''{{end}},
+  workspace_id BIGINT      NOT NULL DEFAULT 0,
+  created_at   {{.Timestamp}} NOT NULL DEFAULT {{.Now}},
+  CHECK(name <> '')
+){{.TableOptions}};
+
+CREATE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_templates_workspace_id ON templates(workspace_id);
+
+-- テンプレートから作成するTODOの内容。idの順に並べる
+CREATE TABLE IF NOT EXISTS template_items (
+  id          {{.PK}},
+  template_id BIGINT      NOT NULL{{if ne .Driver "mysql"}} REFERENCES templates(id) ON DELETE CASCADE{{end}},
+  subject     TEXT        NOT NULL,
+  description TEXT        NOT NULL,
+  priority    VARCHAR(16) NOT NULL DEFAULT '',
+  CHECK(subject <> ''){{if eq .Driver "mysql"}},
+  FOREIGN KEY (template_id) REFERENCES templates(id) ON DELETE CASCADE{{end}}
+){{.TableOptions}};
+
+CREATE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_template_items_template_id ON template_items(template_id);
+{{if eq .Driver "sqlite3"}}
+CREATE TRIGGER IF NOT EXISTS trigger_templates_delete_items AFTER DELETE ON templates
+BEGIN
+  DELETE FROM template_items WHERE template_id == OLD.id;
+END;
+{{end}}
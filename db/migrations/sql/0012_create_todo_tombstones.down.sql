@@ -0,0 +1,2 @@
+DROP INDEX {{if ne .Driver "mysql"}}IF EXISTS index_todo_tombstones_workspace_id_deleted_at{{else}}index_todo_tombstones_workspace_id_deleted_at ON todo_tombstones{{end}};
+DROP TABLE IF EXISTS todo_tombstones;
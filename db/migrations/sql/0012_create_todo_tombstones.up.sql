@@ -0,0 +1,10 @@
+-- 削除されたTODOの記録。GET /todos/changesで削除をクライアントに伝えるために残す
+-- 削除を取り消した場合はrestored_atを設定し、以降は削除として扱わない
+CREATE TABLE IF NOT EXISTS todo_tombstones (
+  todo_id      BIGINT      NOT NULL PRIMARY KEY,
+  workspace_id BIGINT      NOT NULL DEFAULT 0,
+  deleted_at   {{.Timestamp}} NOT NULL DEFAULT {{.Now}},
+  restored_at  {{.Timestamp}} NULL
+){{.TableOptions}};
+
+CREATE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_todo_tombstones_workspace_id_deleted_at ON todo_tombstones(workspace_id, deleted_at);
@@ -0,0 +1,8 @@
+-- POST /todos/{id}/claimで取得したTODOのロック。TODOごとに1件で、期限切れのロックも置き換えるまで残る
+-- api_key_idはAPIキーなしのリクエストの場合は0
+CREATE TABLE IF NOT EXISTS todo_locks (
+  todo_id      BIGINT      NOT NULL PRIMARY KEY,
+  workspace_id BIGINT      NOT NULL DEFAULT 0,
+  api_key_id   BIGINT      NOT NULL DEFAULT 0,
+  expires_at   {{.Timestamp}} NOT NULL
+){{.TableOptions}};
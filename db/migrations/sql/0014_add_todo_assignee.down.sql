@@ -0,0 +1,2 @@
+DROP INDEX {{if ne .Driver "mysql"}}IF EXISTS index_todos_assignee_id{{else}}index_todos_assignee_id ON todos{{end}};
+ALTER TABLE todos DROP COLUMN assignee_id;
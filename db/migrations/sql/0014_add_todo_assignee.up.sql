@@ -0,0 +1,4 @@
+-- 担当者のAPIキーのID。担当者がいない場合はNULL
+ALTER TABLE todos ADD COLUMN assignee_id BIGINT NULL;
+
+CREATE INDEX {{if ne .Driver "mysql"}}IF NOT EXISTS {{end}}index_todos_assignee_id ON todos(assignee_id);
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaxIdleConns is the default of database/sql, used if MaxIdleConns is 0.
+const defaultMaxIdleConns = 2
+
+// sqliteBusyTimeout is how long SQLite waits for a lock before failing with SQLITE_BUSY.
+const sqliteBusyTimeout = 5 * time.Second
+
+// Options are the connection pool settings of a *sql.DB.
+// Zero values keep the defaults of database/sql.
+type Options struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// PingInterval is the interval of the health checks made by Monitor.
+	PingInterval time.Duration
+}
+
+// Apply sets the pool settings of o to db.
+func (o Options) Apply(db *sql.DB) {
+	db.SetMaxOpenConns(o.MaxOpenConns)
+	if o.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(o.MaxIdleConns)
+	} else {
+		db.SetMaxIdleConns(defaultMaxIdleConns)
+	}
+	db.SetConnMaxLifetime(o.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(o.ConnMaxIdleTime)
+}
+
+// Monitor pings db every o.PingInterval until ctx is done.
+// When a ping fails, the idle connections are dropped so that the following
+// queries (and pings) reconnect instead of reusing broken connections.
+// It returns immediately if o.PingInterval is not positive.
+func Monitor(ctx context.Context, db *sql.DB, o Options) {
+	if o.PingInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(o.PingInterval)
+	defer ticker.Stop()
+
+	healthy := true
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, o.PingInterval)
+		err := db.PingContext(pingCtx)
+		cancel()
+		switch {
+		case err != nil && ctx.Err() == nil:
+			slog.Warn("Database ping failed, reconnecting", "err", err)
+			healthy = false
+			//アイドル中の接続を捨てて、次回から新しい接続を使わせる
+			db.SetMaxIdleConns(0)
+			o.Apply(db)
+		case err == nil && !healthy:
+			slog.Info("Database connection recovered")
+			healthy = true
+		}
+	}
+}
+
+// sqliteDSN adds the WAL journal mode and the busy timeout to the SQLite dsn
+// unless they are already set. They are applied to every connection of the pool.
+// 読み込みと書き込みを並行でき、ロック中はすぐに失敗せずに待つようにする
+func sqliteDSN(dsn string) string {
+	params := []string{}
+	if !strings.Contains(dsn, "_journal") && !strings.Contains(dsn, ":memory:") && !strings.Contains(dsn, "mode=memory") {
+		params = append(params, "_journal_mode=WAL")
+	}
+	if !strings.Contains(dsn, "_timeout=") {
+		params = append(params, "_busy_timeout="+strconv.FormatInt(sqliteBusyTimeout.Milliseconds(), 10))
+	}
+	if len(params) == 0 {
+		return dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + strings.Join(params, "&")
+}
@@ -1,9 +1,11 @@
 module github.com/TechBowl-japan/go-stations
 
-go 1.16
+go 1.22
 
 require (
-	github.com/google/go-cmp v0.5.9 // indirect
+	github.com/go-sql-driver/mysql v1.7.1
+	github.com/google/go-cmp v0.5.9
 	github.com/jstemmer/go-junit-report v0.9.1
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-sqlite3 v1.14.24
 )
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// An APIKeyHandler implements handling REST endpoints for API keys.
+// APIKeyHandlerは、/admin/apikeys 以下の管理者向けAPIエンドポイントの処理を実装します。
+type APIKeyHandler struct {
+	svc *service.APIKeyService
+}
+
+// NewAPIKeyHandler returns APIKeyHandler based http.Handler.
+// NewAPIKeyHandlerは新しいAPIKeyHandlerを返します。
+func NewAPIKeyHandler(svc *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP handles HTTP requests for /admin/apikeys.
+func (h *APIKeyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.handleCreate(w, r)
+	case http.MethodGet:
+		h.handleRead(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+// handleCreate handles the POST request to mint a new API key.
+// handleCreateは、新しいAPIキーを発行する。キー自体はこのレスポンスでのみ返す。
+func (h *APIKeyHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Error decoding CreateAPIKeyRequest", "err", err)
+		writeDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	key, secret, err := h.svc.CreateAPIKey(r.Context(), req.Name, req.Role, req.Scopes)
+	if err != nil {
+		if verr, ok := err.(*model.ErrValidation); ok {
+			writeError(w, http.StatusBadRequest, verr.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error creating API key", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+	writeJSON(w, http.StatusOK, &model.CreateAPIKeyResponse{
+		APIKey: *key,
+		Key:    secret,
+	})
+}
+
+// handleRead handles the GET request to list API keys without the keys themselves.
+func (h *APIKeyHandler) handleRead(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.svc.ReadAPIKeys(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading API keys", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to read API keys")
+		return
+	}
+
+	converted := make([]model.APIKey, len(keys))
+	for i, key := range keys {
+		converted[i] = *key
+	}
+	writeJSON(w, http.StatusOK, &model.ReadAPIKeyResponse{
+		APIKeys: converted,
+	})
+}
+
+// ServeRevoke handles POST /admin/apikeys/{id}/revoke. Revoked keys are rejected
+// from then on but stay in the list.
+// 失効済みのキーを再度失効させても、最初の失効日時が保たれる。
+func (h *APIKeyHandler) ServeRevoke(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusNotFound, "APIKey not found")
+		return
+	}
+
+	key, err := h.svc.RevokeAPIKey(r.Context(), id)
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "APIKey not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error revoking API key", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+	writeJSON(w, http.StatusOK, &model.RevokeAPIKeyResponse{
+		APIKey: *key,
+	})
+}
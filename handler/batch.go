@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// maxBatchSize is the largest number of requests in a batch.
+const maxBatchSize = 100
+
+// batchMethods are the methods allowed in a batch.
+var batchMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// batchHeaders are the headers of the batch request passed on to each request,
+// so that they are authenticated and scoped like the batch itself.
+var batchHeaders = []string{"Authorization", middleware.APIKeyHeader, middleware.WorkspaceHeader}
+
+// batchPath matches the paths of the batch endpoint of every API version.
+var batchPath = regexp.MustCompile(`^(/v[0-9]+)?/batch$`)
+
+// batchContextKey marks the context of the requests run by a batch.
+type batchContextKey struct{}
+
+// errBatchFailed rolls back an atomic batch.
+var errBatchFailed = errors.New("batch request failed")
+
+// A BatchHandler implements the POST /batch endpoint running several requests in one round trip.
+// BatchHandlerは、複数のリクエストを順に実行し、それぞれの結果をまとめて返します。
+type BatchHandler struct {
+	routes http.Handler
+	repo   repository.Repository
+	//トランザクション内のリポジトリを使うルーティングを作成する
+	newRoutes func(repository.Repository) http.Handler
+}
+
+// NewBatchHandler returns BatchHandler based http.Handler. routes serves the
+// requests of a batch, and newRoutes builds the same routes on the transaction
+// of an atomic batch.
+// NewBatchHandlerは新しいBatchHandlerを返します。
+func NewBatchHandler(routes http.Handler, repo repository.Repository, newRoutes func(repository.Repository) http.Handler) *BatchHandler {
+	return &BatchHandler{
+		routes:    routes,
+		repo:      repo,
+		newRoutes: newRoutes,
+	}
+}
+
+// ServeHTTP handles the POST /batch request.
+// 個々のリクエストの失敗はバッチ全体の失敗にはせず、結果のstatusで返す。
+func (h *BatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	//パスに関わらず、バッチの中からバッチを実行させない
+	if r.Context().Value(batchContextKey{}) != nil {
+		writeError(w, http.StatusBadRequest, "Batch requests must not be nested")
+		return
+	}
+
+	var req model.BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Error decoding BatchRequest", "err", err)
+		writeDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if len(req.Requests) == 0 {
+		writeError(w, http.StatusBadRequest, "Requests are required")
+		return
+	}
+	if len(req.Requests) > maxBatchSize {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("A batch must not exceed %d requests", maxBatchSize))
+		return
+	}
+	for i, item := range req.Requests {
+		if err := validateBatchItem(item); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("requests[%d]: %s", i, err))
+			return
+		}
+	}
+	r = r.WithContext(context.WithValue(r.Context(), batchContextKey{}, true))
+
+	res := &model.BatchResponse{}
+	if !req.Atomic {
+		res.Results = run(r, w.Header(), h.routes, req.Requests, false)
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+
+	err := h.repo.WithTx(r.Context(), func(tx repository.Repository) error {
+		//ビジーで再実行される場合に備えて、結果は毎回作り直す
+		res.Results = run(r, w.Header(), h.newRoutes(tx), req.Requests, true)
+		if last := res.Results[len(res.Results)-1]; last.Status >= http.StatusBadRequest {
+			return errBatchFailed
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchFailed) {
+		res.RolledBack = true
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error running batch", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to run batch")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// run serves the requests in order with routes. If atomic, it stops at the first failure.
+func run(r *http.Request, header http.Header, routes http.Handler, items []model.BatchRequestItem, atomic bool) []model.BatchResult {
+	results := make([]model.BatchResult, 0, len(items))
+	for _, item := range items {
+		result := serveBatchItem(r.Context(), r.Header, header, routes, item)
+		results = append(results, result)
+		if atomic && result.Status >= http.StatusBadRequest {
+			break
+		}
+	}
+	return results
+}
+
+// serveBatchItem serves item with the headers of batchHeaders from header,
+// and returns its status and body. resHeader is the header of the batch
+// response, whose request ID and language are used in error responses.
+func serveBatchItem(ctx context.Context, header, resHeader http.Header, routes http.Handler, item model.BatchRequestItem) model.BatchResult {
+	req, err := http.NewRequestWithContext(ctx, item.Method, item.Path, bytes.NewReader(item.Body))
+	if err != nil {
+		return model.BatchResult{Status: http.StatusBadRequest}
+	}
+	for _, name := range batchHeaders {
+		if v := header.Get(name); v != "" {
+			req.Header.Set(name, v)
+		}
+	}
+	if len(item.Body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rec := &batchWriter{header: http.Header{}}
+	rec.header.Set(middleware.RequestIDHeader, resHeader.Get(middleware.RequestIDHeader))
+	rec.header.Set("Content-Language", resHeader.Get("Content-Language"))
+	routes.ServeHTTP(rec, req)
+
+	result := model.BatchResult{Status: rec.status}
+	if result.Status == 0 {
+		result.Status = http.StatusOK
+	}
+	//レスポンスはJSONのみのため、JSONとして解釈できない本文は返さない
+	if b := bytes.TrimSpace(rec.body.Bytes()); json.Valid(b) {
+		result.Body = b
+	}
+	return result
+}
+
+// validateBatchItem returns an error describing why item cannot be served.
+func validateBatchItem(item model.BatchRequestItem) error {
+	if !batchMethods[item.Method] {
+		return fmt.Errorf("unsupported method %q", item.Method)
+	}
+	u, err := url.Parse(item.Path)
+	if err != nil || !strings.HasPrefix(item.Path, "/") || u.Host != "" {
+		return fmt.Errorf("path must be an absolute path, e.g. /todos")
+	}
+	//バッチの入れ子は許可しない(どのバージョンのパスでも同じ)
+	if batchPath.MatchString(path.Clean(u.Path)) {
+		return fmt.Errorf("batch requests must not be nested")
+	}
+	return nil
+}
+
+// A batchWriter records the response to a request of a batch.
+type batchWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *batchWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
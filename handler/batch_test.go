@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestBatchHandler_Nested(t *testing.T) {
+	//バッチのパスとして検証されない経路でも、バッチの中からバッチは実行されない
+	mux := http.NewServeMux()
+	h := NewBatchHandler(mux, nil, nil)
+	mux.Handle("POST /batch", h)
+	mux.Handle("POST /alias", h)
+
+	body := `{"requests":[{"method":"POST","path":"/alias","body":{"requests":[{"method":"GET","path":"/alias"}]}}]}`
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body)))
+
+	var res model.BatchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal("failed to decode response, err =", err)
+	}
+	if rec.Code != http.StatusOK || len(res.Results) != 1 || res.Results[0].Status != http.StatusBadRequest {
+		t.Errorf("unexpected response, status = %d, body = %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// A CommentHandler implements handling REST endpoints for comments on TODOs.
+// CommentHandlerは、/todos/{id}/comments 以下のREST APIエンドポイントの処理を実装します。
+type CommentHandler struct {
+	svc *service.CommentService
+}
+
+// NewCommentHandler returns CommentHandler based http.Handler.
+// NewCommentHandlerは新しいCommentHandlerを返します。
+func NewCommentHandler(svc *service.CommentService) *CommentHandler {
+	return &CommentHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP handles HTTP requests for the comment API.
+// パスからTODOのIDとコメントのIDを取り出し、HTTPメソッドに基づいて適切なハンドラを呼び出します。
+// /todos/{id}/comments と /todos/{id}/comments/{comment_id} に登録されることを想定しています。
+func (h *CommentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	todoID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusNotFound, "TODO not found")
+		return
+	}
+	var commentID int64
+	if r.PathValue("comment_id") != "" {
+		if commentID, ok = pathID(r, "comment_id"); !ok {
+			writeError(w, http.StatusNotFound, "Comment not found")
+			return
+		}
+	}
+
+	switch {
+	case commentID == 0 && r.Method == http.MethodPost:
+		h.handleCreate(w, r, todoID)
+	case commentID == 0 && r.Method == http.MethodGet:
+		h.handleRead(w, r, todoID)
+	case commentID != 0 && r.Method == http.MethodDelete:
+		h.handleDelete(w, r, todoID, commentID)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+// handleCreate handles the POST request to create a new comment.
+// handleCreateは、新しいコメントを作成するためのPOSTリクエストを処理する。
+func (h *CommentHandler) handleCreate(w http.ResponseWriter, r *http.Request, todoID int64) {
+	var req model.CreateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Error decoding CreateCommentRequest", "err", err)
+		writeDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	//必須フィールドであるBodyが空でないかをチェックする
+	if req.Body == "" {
+		writeError(w, http.StatusBadRequest, "Body is required")
+		return
+	}
+
+	res, err := h.Create(r.Context(), todoID, &req)
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "TODO not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error creating comment", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create comment")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Create handles the endpoint that creates the comment.
+// CommentServiceのCreateCommentメソッドを呼び出し、新しいコメントを作成する
+func (h *CommentHandler) Create(ctx context.Context, todoID int64, req *model.CreateCommentRequest) (*model.CreateCommentResponse, error) {
+	comment, err := h.svc.CreateComment(ctx, todoID, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CreateCommentResponse{
+		Comment: *comment,
+	}, nil
+}
+
+// handleRead handles the GET request to list comments with pagination.
+// handleReadは、prev_idとsizeによるページネーション付きでコメント一覧を返す。
+func (h *CommentHandler) handleRead(w http.ResponseWriter, r *http.Request, todoID int64) {
+	req := &model.ReadCommentRequest{
+		TODOID: todoID,
+		Size:   5, //"size"が指定されていない場合のデフォルト値
+	}
+	query := r.URL.Query()
+
+	if prevIDStr := query.Get("prev_id"); prevIDStr != "" {
+		var err error
+		req.PrevID, err = strconv.ParseInt(prevIDStr, 10, 64)
+		if err != nil {
+			slog.WarnContext(r.Context(), "Error parsing prev_id", "err", err)
+			writeError(w, http.StatusBadRequest, "Invalid prev_id")
+			return
+		}
+	}
+	if sizeStr := query.Get("size"); sizeStr != "" {
+		var err error
+		req.Size, err = strconv.ParseInt(sizeStr, 10, 64)
+		if err != nil {
+			slog.WarnContext(r.Context(), "Error parsing size", "err", err)
+			writeError(w, http.StatusBadRequest, "Invalid size")
+			return
+		}
+	}
+
+	res, err := h.Read(r.Context(), req)
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "TODO not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error reading comments", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to read comments")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Read handles the endpoint that reads the comments.
+func (h *CommentHandler) Read(ctx context.Context, req *model.ReadCommentRequest) (*model.ReadCommentResponse, error) {
+	comments, err := h.svc.ReadComments(ctx, req.TODOID, req.PrevID, req.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	//[]*model.Comment型のスライスを[]model.Comment型のスライスに変換
+	converted := make([]model.Comment, len(comments))
+	for i, comment := range comments {
+		converted[i] = *comment
+	}
+	return &model.ReadCommentResponse{
+		Comments: converted,
+	}, nil
+}
+
+// handleDelete handles the DELETE request to delete a comment.
+// handleDeleteは、コメントを削除するためのDELETEリクエストを処理する。
+func (h *CommentHandler) handleDelete(w http.ResponseWriter, r *http.Request, todoID, commentID int64) {
+	res, err := h.Delete(r.Context(), &model.DeleteCommentRequest{TODOID: todoID, ID: commentID})
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "Comment not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error deleting comment", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to delete comment")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Delete handles the endpoint that deletes the comment.
+func (h *CommentHandler) Delete(ctx context.Context, req *model.DeleteCommentRequest) (*model.DeleteCommentResponse, error) {
+	if err := h.svc.DeleteComment(ctx, req.TODOID, req.ID); err != nil {
+		return nil, err
+	}
+	return &model.DeleteCommentResponse{}, nil
+}
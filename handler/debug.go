@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// publishOnce publishes the runtime stats to expvar once, as expvar panics
+// on publishing a name twice (e.g. for the routers of atomic batches).
+var publishOnce sync.Once
+
+// started is the time the process started serving, for the uptime.
+var started = time.Now()
+
+// NewDebugHandler returns the handler of the profiling endpoints under
+// /debug: the profiles of net/http/pprof at /debug/pprof/ (including an
+// execution trace at /debug/pprof/trace?seconds=N), and the variables of
+// expvar at /debug/vars with the number of goroutines and a heap summary
+// added to memstats.
+// NewDebugHandlerは、負荷をかけた状態で遅延の原因を調べるためのエンドポイントを返します。
+func NewDebugHandler() http.Handler {
+	publishOnce.Do(publishRuntimeStats)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//APIの形式として決めたContent-Typeを消し、プロファイルなどの形式で返させる
+		w.Header().Del("Content-Type")
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func publishRuntimeStats() {
+	expvar.Publish("goroutines", expvar.Func(func() interface{} {
+		return runtime.NumGoroutine()
+	}))
+	expvar.Publish("heap", expvar.Func(func() interface{} {
+		//ReadMemStatsは世界を止めるため、/debug/varsを読んだときだけ呼ぶ
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return map[string]uint64{
+			"alloc_bytes":    m.HeapAlloc,
+			"inuse_bytes":    m.HeapInuse,
+			"sys_bytes":      m.HeapSys,
+			"objects":        m.HeapObjects,
+			"num_gc":         uint64(m.NumGC),
+			"pause_total_ns": m.PauseTotalNs,
+		}
+	}))
+	expvar.Publish("uptime_seconds", expvar.Func(func() interface{} {
+		return int64(time.Since(started).Seconds())
+	}))
+}
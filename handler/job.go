@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/jobs"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// A JobHandler implements handling the admin endpoints of the background jobs.
+// JobHandlerは、/admin/jobs 以下の保守ジョブのエンドポイントの処理を実装します。
+type JobHandler struct {
+	runner *jobs.Runner
+}
+
+// NewJobHandler returns JobHandler based http.Handler.
+// NewJobHandlerは新しいJobHandlerを返します。
+func NewJobHandler(runner *jobs.Runner) *JobHandler {
+	return &JobHandler{
+		runner: runner,
+	}
+}
+
+// ServeRead handles the GET /admin/jobs request.
+// ServeReadは、全てのジョブの実行状況を返す。
+func (h *JobHandler) ServeRead(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, &model.ReadJobsResponse{
+		Jobs: h.runner.Statuses(),
+	})
+}
+
+// ServeRun handles the POST /admin/jobs/{name}/run request.
+// ServeRunは、パスで指定されたジョブをすぐに実行し、終わるのを待ってから状態を返す。
+func (h *JobHandler) ServeRun(w http.ResponseWriter, r *http.Request) {
+	status, err := h.runner.Trigger(r.Context(), r.PathValue("name"))
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "Job not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error running job", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to run job")
+		return
+	}
+	writeJSON(w, http.StatusOK, &model.RunJobResponse{
+		Job: *status,
+	})
+}
@@ -0,0 +1,197 @@
+// Package mcp serves the TODO service as tools over the Model Context
+// Protocol (https://modelcontextprotocol.io), so that AI assistants can
+// create, search and complete TODOs.
+//
+// The server speaks JSON-RPC 2.0 over the Streamable HTTP transport without
+// sessions or server-sent events: every request is a POST answered with a
+// single JSON response. It implements initialize, ping, tools/list and
+// tools/call.
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/handler/render"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// ProtocolVersion is the latest MCP version the server implements.
+const ProtocolVersion = "2025-03-26"
+
+// protocolVersions are the versions the server can speak, newest first.
+var protocolVersions = []string{ProtocolVersion, "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type (
+	request struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id,omitempty"`
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params,omitempty"`
+	}
+
+	response struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  interface{}     `json:"result,omitempty"`
+		Error   *rpcError       `json:"error,omitempty"`
+	}
+
+	rpcError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+)
+
+// A Server is the http.Handler of the MCP endpoint.
+type Server struct {
+	svc   *service.TODOService
+	scope string
+}
+
+// NewServer returns the Server calling svc. scope is model.ScopeRead to
+// enable only the tools reading TODOs, or model.ScopeWrite to enable all.
+// The API key of the request, if any, further limits the tools to those it
+// has the permissions of.
+func NewServer(svc *service.TODOService, scope string) *Server {
+	return &Server{svc: svc, scope: scope}
+}
+
+// ServeHTTP answers a JSON-RPC message. Notifications and responses sent by
+// the client are accepted with 202 and no body.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	//Acceptヘッダーに関わらず、JSON-RPCのためJSONで返す
+	w.Header().Set("Content-Type", render.JSON)
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			render.Write(w, http.StatusRequestEntityTooLarge, errorResponse(nil, codeInvalidRequest, "Request body is too large"))
+			return
+		}
+		render.Write(w, http.StatusOK, errorResponse(nil, codeParseError, "Parse error"))
+		return
+	}
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != "2.0" {
+		//バッチ(配列)は2025-03-26以降では使わないため受け付けない
+		render.Write(w, http.StatusOK, errorResponse(nil, codeInvalidRequest, "Invalid request"))
+		return
+	}
+	if len(req.ID) == 0 {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	result, rerr := s.call(r, &req)
+	if rerr != nil {
+		render.Write(w, http.StatusOK, &response{JSONRPC: "2.0", ID: req.ID, Error: rerr})
+		return
+	}
+	render.Write(w, http.StatusOK, &response{JSONRPC: "2.0", ID: req.ID, Result: result})
+}
+
+// call runs the method of req.
+func (s *Server) call(r *http.Request, req *request) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "Invalid params"}
+		}
+		//クライアントの版に対応していなければ、サーバーの最新の版を返してクライアントに選ばせる
+		version := ProtocolVersion
+		for _, v := range protocolVersions {
+			if v == params.ProtocolVersion {
+				version = v
+			}
+		}
+		return map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "go-stations", "version": "1.0.0"},
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		list := []*tool{}
+		for _, t := range tools {
+			if s.enabled(r, t) {
+				list = append(list, t)
+			}
+		}
+		return map[string]interface{}{"tools": list}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "Invalid params"}
+		}
+		t := findTool(params.Name)
+		if t == nil || !s.enabled(r, t) {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "Unknown tool: " + params.Name}
+		}
+		if len(params.Arguments) == 0 {
+			params.Arguments = json.RawMessage("{}")
+		}
+		v, err := t.run(r.Context(), s.svc, params.Arguments)
+		if err != nil {
+			return toolError(r, err), nil
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return toolError(r, err), nil
+		}
+		return &toolResult{Content: []content{{Type: "text", Text: string(b)}}}, nil
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "Method not found: " + req.Method}
+	}
+}
+
+// enabled reports whether t is within the scope of s and the permissions of
+// the API key of r.
+func (s *Server) enabled(r *http.Request, t *tool) bool {
+	if t.permission == model.PermissionWrite && s.scope != model.ScopeWrite {
+		return false
+	}
+	key, ok := service.APIKeyFromContext(r.Context())
+	return !ok || key.Can(t.permission)
+}
+
+// toolError returns the result of a tool which failed with err. Errors of
+// the arguments are told to the model so that it can correct them; others
+// are logged.
+func toolError(r *http.Request, err error) *toolResult {
+	message := "Internal error"
+	switch e := err.(type) {
+	case *model.ErrValidation, *model.ErrNotFound, *model.ErrLocked, *argumentError:
+		message = e.Error()
+	default:
+		slog.ErrorContext(r.Context(), "Error running MCP tool", "err", err)
+	}
+	return &toolResult{Content: []content{{Type: "text", Text: message}}, IsError: true}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
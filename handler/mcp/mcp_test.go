@@ -0,0 +1,121 @@
+package mcp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/mcp"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository/memory"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+type rpcResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		Tools           []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	} `json:"result"`
+	Error *struct {
+		Code int `json:"code"`
+	} `json:"error"`
+}
+
+func call(t *testing.T, h http.Handler, ctx context.Context, body string) (*httptest.ResponseRecorder, *rpcResponse) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)).WithContext(ctx)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	var res rpcResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatalf("failed to decode response %s, err = %v", w.Body, err)
+		}
+	}
+	return w, &res
+}
+
+func toolNames(res *rpcResponse) string {
+	var names []string
+	for _, tool := range res.Result.Tools {
+		names = append(names, tool.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	h := mcp.NewServer(service.NewTODOServiceWithRepository(memory.New()), model.ScopeWrite)
+
+	_, res := call(t, h, ctx, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{}}}`)
+	if res.Result.ProtocolVersion != "2024-11-05" {
+		t.Errorf("unexpected protocol version, got = %q", res.Result.ProtocolVersion)
+	}
+	if w, _ := call(t, h, ctx, `{"jsonrpc":"2.0","method":"notifications/initialized"}`); w.Code != http.StatusAccepted {
+		t.Errorf("unexpected status of notification, got = %d", w.Code)
+	}
+
+	_, res = call(t, h, ctx, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"create_todo","arguments":{"subject":"buy milk"}}}`)
+	if res.Result.IsError || len(res.Result.Content) != 1 || !strings.Contains(res.Result.Content[0].Text, `"subject":"buy milk"`) {
+		t.Errorf("unexpected result of create_todo, got = %+v", res.Result)
+	}
+	call(t, h, ctx, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"complete_todo","arguments":{"id":1}}}`)
+	_, res = call(t, h, ctx, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"search_todos","arguments":{"query":"milk","status":"done"}}}`)
+	if res.Result.IsError || !strings.Contains(res.Result.Content[0].Text, `"done":true`) {
+		t.Errorf("unexpected result of search_todos, got = %+v", res.Result)
+	}
+
+	//引数の誤りはツールのエラーとしてモデルに返す
+	_, res = call(t, h, ctx, `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"read_todo","arguments":{"id":99}}}`)
+	if !res.Result.IsError || res.Result.Content[0].Text != "TODO not found" {
+		t.Errorf("unexpected result of missing TODO, got = %+v", res.Result)
+	}
+	for body, code := range map[string]int{
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"delete_todo"}}`: -32602,
+		`{"jsonrpc":"2.0","id":7,"method":"resources/list"}`:                             -32601,
+		`{"jsonrpc":"2.0","id":8`:                                                        -32700,
+		`[{"jsonrpc":"2.0","id":9,"method":"ping"}]`:                                     -32600,
+	} {
+		if _, res := call(t, h, ctx, body); res.Error == nil || res.Error.Code != code {
+			t.Errorf("unexpected error of %s, got = %+v", body, res.Error)
+		}
+	}
+}
+
+func TestServer_Scope(t *testing.T) {
+	svc := service.NewTODOServiceWithRepository(memory.New())
+	list := `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`
+	readonly := service.WithAPIKey(context.Background(), &model.APIKey{Role: model.RoleReadonly, Scopes: []string{model.ScopeRead}})
+
+	for name, tt := range map[string]struct {
+		scope string
+		ctx   context.Context
+		want  string
+	}{
+		"write":         {model.ScopeWrite, context.Background(), "search_todos,read_todo,create_todo,complete_todo"},
+		"read":          {model.ScopeRead, context.Background(), "search_todos,read_todo"},
+		"read-only key": {model.ScopeWrite, readonly, "search_todos,read_todo"},
+	} {
+		h := mcp.NewServer(svc, tt.scope)
+		if _, res := call(t, h, tt.ctx, list); toolNames(res) != tt.want {
+			t.Errorf("%s: unexpected tools, got = %s", name, toolNames(res))
+		}
+	}
+
+	//一覧に出ないツールは呼び出せない
+	h := mcp.NewServer(svc, model.ScopeRead)
+	_, res := call(t, h, context.Background(), `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"create_todo","arguments":{"subject":"a"}}}`)
+	if res.Error == nil || res.Error.Code != -32602 {
+		t.Errorf("unexpected result of disabled tool, got = %+v", res)
+	}
+}
@@ -0,0 +1,176 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+const (
+	// defaultSearchSize and maxSearchSize bound the TODOs returned by search_todos.
+	defaultSearchSize = 20
+	maxSearchSize     = 100
+)
+
+type (
+	// A tool is an operation of the TODO service offered to the model.
+	tool struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		InputSchema json.RawMessage `json:"inputSchema"`
+		//ツールを使うのに必要な権限(readまたはwrite)
+		permission model.Permission
+		run        func(ctx context.Context, svc *service.TODOService, args json.RawMessage) (interface{}, error)
+	}
+
+	toolResult struct {
+		Content []content `json:"content"`
+		IsError bool      `json:"isError,omitempty"`
+	}
+
+	content struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+
+	// An argumentError is returned for arguments not matching the input schema.
+	argumentError struct {
+		message string
+	}
+)
+
+func (e *argumentError) Error() string {
+	return e.message
+}
+
+// tools are all the tools, in the order of tools/list.
+var tools = []*tool{
+	{
+		Name:        "search_todos",
+		Description: "Search TODOs whose subject or description contains a text, newest first.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{` +
+			`"query":{"type":"string","description":"Text to search for; all TODOs if empty"},` +
+			`"status":{"type":"string","enum":["open","done"],"description":"Only TODOs of this status"},` +
+			`"size":{"type":"integer","minimum":1,"maximum":100,"description":"Maximum number of TODOs (default 20)"}}}`),
+		permission: model.PermissionRead,
+		run: func(ctx context.Context, svc *service.TODOService, args json.RawMessage) (interface{}, error) {
+			var a struct {
+				Query  string `json:"query"`
+				Status string `json:"status"`
+				Size   int64  `json:"size"`
+			}
+			if err := decode(args, &a); err != nil {
+				return nil, err
+			}
+			if a.Size <= 0 {
+				a.Size = defaultSearchSize
+			}
+			if a.Size > maxSearchSize {
+				a.Size = maxSearchSize
+			}
+			todos, err := svc.ListTODO(ctx, &model.TODOQuery{Query: a.Query, Status: a.Status, Size: a.Size})
+			if err != nil {
+				return nil, err
+			}
+			res := &model.ReadTODOResponse{TODOs: make([]model.TODO, len(todos))}
+			for i, todo := range todos {
+				res.TODOs[i] = *todo
+			}
+			return res, nil
+		},
+	},
+	{
+		Name:        "read_todo",
+		Description: "Read a TODO by its ID.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"id":{"type":"integer"}},"required":["id"]}`),
+		permission:  model.PermissionRead,
+		run: func(ctx context.Context, svc *service.TODOService, args json.RawMessage) (interface{}, error) {
+			id, err := decodeID(args)
+			if err != nil {
+				return nil, err
+			}
+			todo, err := svc.ReadTODOByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return &model.ReadTODOByIDResponse{TODO: *todo}, nil
+		},
+	},
+	{
+		Name:        "create_todo",
+		Description: "Create a TODO.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{` +
+			`"subject":{"type":"string"},"description":{"type":"string"}},"required":["subject"]}`),
+		permission: model.PermissionWrite,
+		run: func(ctx context.Context, svc *service.TODOService, args json.RawMessage) (interface{}, error) {
+			var a struct {
+				Subject     string `json:"subject"`
+				Description string `json:"description"`
+			}
+			if err := decode(args, &a); err != nil {
+				return nil, err
+			}
+			if a.Subject == "" {
+				return nil, &argumentError{message: "subject is required"}
+			}
+			todo, err := svc.CreateTODO(ctx, a.Subject, a.Description)
+			if err != nil {
+				return nil, err
+			}
+			return &model.CreateTODOResponse{TODO: *todo}, nil
+		},
+	},
+	{
+		Name:        "complete_todo",
+		Description: "Mark a TODO as done.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{"id":{"type":"integer"}},"required":["id"]}`),
+		permission:  model.PermissionWrite,
+		run: func(ctx context.Context, svc *service.TODOService, args json.RawMessage) (interface{}, error) {
+			id, err := decodeID(args)
+			if err != nil {
+				return nil, err
+			}
+			done := true
+			todo, err := svc.PatchTODO(ctx, id, &model.TODOPatch{Done: &done})
+			if err != nil {
+				return nil, err
+			}
+			return &model.UpdateTODOResponse{TODO: *todo}, nil
+		},
+	},
+}
+
+func findTool(name string) *tool {
+	for _, t := range tools {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// decode decodes the arguments of a tool into v, rejecting unknown ones.
+func decode(args json.RawMessage, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(args))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return &argumentError{message: "invalid arguments: " + err.Error()}
+	}
+	return nil
+}
+
+func decodeID(args json.RawMessage) (int64, error) {
+	var a struct {
+		ID int64 `json:"id"`
+	}
+	if err := decode(args, &a); err != nil {
+		return 0, err
+	}
+	if a.ID <= 0 {
+		return 0, &argumentError{message: "id is required"}
+	}
+	return a.ID, nil
+}
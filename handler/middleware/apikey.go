@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// APIKeyHeader is the request header carrying the API key.
+const APIKeyHeader = "X-API-Key"
+
+// APIKey returns a middleware authenticating the X-API-Key header with svc.
+// GET and HEAD requests need model.PermissionRead and all other methods
+// model.PermissionWrite. Requests without the header are rejected only if
+// required is true. The APIKey is available with service.APIKeyFromContext.
+func APIKey(svc *service.APIKeyService, required bool) func(http.Handler) http.Handler {
+	return apiKey(svc, required, func(r *http.Request) model.Permission {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			return model.PermissionRead
+		}
+		return model.PermissionWrite
+	})
+}
+
+// APIKeyFor is APIKey needing perm for every method, for endpoints which
+// check the permissions of what they do themselves, e.g. the MCP tools
+// reading TODOs over POST.
+func APIKeyFor(svc *service.APIKeyService, required bool, perm model.Permission) func(http.Handler) http.Handler {
+	return apiKey(svc, required, func(*http.Request) model.Permission { return perm })
+}
+
+func apiKey(svc *service.APIKeyService, required bool, permission func(r *http.Request) model.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get(APIKeyHeader)
+			if header == "" {
+				if required {
+					writeError(w, http.StatusUnauthorized, "API key is required")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := svc.Authenticate(r.Context(), header)
+			if err != nil {
+				if err == service.ErrInvalidAPIKey {
+					writeError(w, http.StatusUnauthorized, "Invalid API key")
+					return
+				}
+				slog.ErrorContext(r.Context(), "Error authenticating API key", "err", err)
+				writeError(w, http.StatusInternalServerError, "Failed to authenticate API key")
+				return
+			}
+
+			if perm := permission(r); !key.Can(perm) {
+				writeError(w, http.StatusForbidden, "API key lacks the "+string(perm)+" permission")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(service.WithAPIKey(r.Context(), key)))
+		})
+	}
+}
+
+// Admin returns a middleware accepting only requests authenticated as an
+// administrator: either with the header "Authorization: Bearer <token>" or
+// with an API key of the admin role authenticated by APIKey beforehand.
+// An empty token disables the bearer token.
+func Admin(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key, ok := service.APIKeyFromContext(r.Context()); ok {
+				if !key.Can(model.PermissionAdmin) {
+					writeError(w, http.StatusForbidden, "Admin role is required")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			//タイミング攻撃を避けるため、定数時間で比較する
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "" || !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				writeError(w, http.StatusUnauthorized, "Admin token or API key is required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository/memory"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestAPIKey(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	svc := service.NewAPIKeyServiceWithRepository(memory.New())
+	mustCreateKey := func(name, role string, scopes ...string) (*model.APIKey, string) {
+		key, secret, err := svc.CreateAPIKey(ctx, name, role, scopes)
+		if err != nil {
+			t.Fatal("failed to create API key, err =", err)
+		}
+		return key, secret
+	}
+	_, adminKey := mustCreateKey("admin", model.RoleAdmin, model.ScopeRead, model.ScopeWrite)
+	_, memberKey := mustCreateKey("member", model.RoleMember, model.ScopeRead, model.ScopeWrite)
+	_, readKey := mustCreateKey("reader", model.RoleMember, model.ScopeRead)
+	_, readonlyKey := mustCreateKey("readonly", model.RoleReadonly, model.ScopeRead, model.ScopeWrite)
+	revoked, revokedKey := mustCreateKey("revoked", model.RoleAdmin, model.ScopeRead, model.ScopeWrite)
+	if _, err := svc.RevokeAPIKey(ctx, revoked.ID); err != nil {
+		t.Fatal("failed to revoke API key, err =", err)
+	}
+
+	cases := map[string]struct {
+		Method      string
+		Key         string
+		BearerToken string
+		Required    bool
+		Admin       bool
+		Want        int
+	}{
+		"No key":                {Method: http.MethodGet, Want: http.StatusOK},
+		"No key required":       {Method: http.MethodGet, Required: true, Want: http.StatusUnauthorized},
+		"Read scope":            {Method: http.MethodGet, Key: readKey, Required: true, Want: http.StatusOK},
+		"Missing scope":         {Method: http.MethodPost, Key: readKey, Want: http.StatusForbidden},
+		"Member writes":         {Method: http.MethodPost, Key: memberKey, Want: http.StatusOK},
+		"Readonly role":         {Method: http.MethodPut, Key: readonlyKey, Want: http.StatusForbidden},
+		"Unknown key":           {Method: http.MethodGet, Key: "gs_00000000_unknown", Want: http.StatusUnauthorized},
+		"Revoked key":           {Method: http.MethodGet, Key: revokedKey, Want: http.StatusUnauthorized},
+		"Malformed header":      {Method: http.MethodGet, Key: "secret", Want: http.StatusUnauthorized},
+		"Admin by key":          {Method: http.MethodPost, Key: adminKey, Admin: true, Want: http.StatusOK},
+		"Admin by token":        {Method: http.MethodGet, BearerToken: "token", Admin: true, Want: http.StatusOK},
+		"Admin by wrong token":  {Method: http.MethodGet, BearerToken: "wrong", Admin: true, Want: http.StatusUnauthorized},
+		"Admin without auth":    {Method: http.MethodGet, Admin: true, Want: http.StatusUnauthorized},
+		"Admin by member key":   {Method: http.MethodGet, Key: memberKey, Admin: true, Want: http.StatusForbidden},
+		"Admin key wins header": {Method: http.MethodGet, Key: memberKey, BearerToken: "token", Admin: true, Want: http.StatusForbidden},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, ok := service.APIKeyFromContext(r.Context()); ok != (c.Key != "") {
+					t.Errorf("unexpected API key in context, got = %v", ok)
+				}
+			})
+			if c.Admin {
+				h = middleware.Admin("token")(h)
+			}
+			h = middleware.APIKey(svc, c.Required)(h)
+
+			req := httptest.NewRequest(c.Method, "/todos", nil)
+			if c.Key != "" {
+				req.Header.Set(middleware.APIKeyHeader, c.Key)
+			}
+			if c.BearerToken != "" {
+				req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != c.Want {
+				t.Errorf("unexpected status, got = %d, want = %d", rec.Code, c.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// BodyLimit returns a middleware limiting request bodies to n bytes.
+// Requests declaring a larger Content-Length are answered with 413 right away.
+// Otherwise the body is wrapped with http.MaxBytesReader, so reading past the
+// limit fails with *http.MaxBytesError, which the handlers answer with 413.
+// A non-positive n disables it.
+func BodyLimit(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if n <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must not exceed %d bytes", n))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+)
+
+func TestBodyLimit(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		Limit      int64
+		Body       string
+		Chunked    bool
+		WantStatus int
+	}{
+		"Within limit":  {Limit: 8, Body: "12345678", WantStatus: http.StatusOK},
+		"Too large":     {Limit: 8, Body: "123456789", WantStatus: http.StatusRequestEntityTooLarge},
+		"Chunked large": {Limit: 8, Body: "123456789", Chunked: true, WantStatus: http.StatusRequestEntityTooLarge},
+		"Disabled":      {Body: "123456789", WantStatus: http.StatusOK},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := middleware.BodyLimit(c.Limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				//ハンドラと同様に、読み込みエラーが*http.MaxBytesErrorなら413を返す
+				if _, err := io.ReadAll(r.Body); err != nil {
+					if _, ok := err.(*http.MaxBytesError); ok {
+						w.WriteHeader(http.StatusRequestEntityTooLarge)
+						return
+					}
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(c.Body))
+			if c.Chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != c.WantStatus {
+				t.Errorf("unexpected status, got = %d", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package middleware_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+)
+
+func TestCompress(t *testing.T) {
+	t.Parallel()
+
+	large := `{"todos":"` + strings.Repeat("a", 2048) + `"}`
+	cases := map[string]struct {
+		AcceptEncoding string
+		Body           string
+		Flush          bool
+		WantGzip       bool
+	}{
+		"Large JSON":          {AcceptEncoding: "gzip", Body: large, WantGzip: true},
+		"Small JSON":          {AcceptEncoding: "gzip", Body: `{"todos":[]}`},
+		"Gzip not accepted":   {AcceptEncoding: "br", Body: large},
+		"Gzip refused":        {AcceptEncoding: "gzip;q=0, *", Body: large},
+		"Wildcard":            {AcceptEncoding: "*", Body: large, WantGzip: true},
+		"Flushed small chunk": {AcceptEncoding: "deflate, gzip;q=0.5", Body: `{"id":1}`, Flush: true, WantGzip: true},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := middleware.Compress(middleware.DefaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				io.WriteString(w, c.Body)
+				if c.Flush {
+					w.(http.Flusher).Flush()
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			req.Header.Set("Accept-Encoding", c.AcceptEncoding)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusCreated {
+				t.Errorf("unexpected status, got = %d", rec.Code)
+			}
+			if rec.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("Vary header is missing, got = %q", rec.Header().Get("Vary"))
+			}
+
+			body := rec.Body.String()
+			gotGzip := rec.Header().Get("Content-Encoding") == "gzip"
+			if gotGzip != c.WantGzip {
+				t.Fatalf("unexpected Content-Encoding, got = %q, want gzip = %v", rec.Header().Get("Content-Encoding"), c.WantGzip)
+			}
+			if gotGzip {
+				zr, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal("failed to read gzip, err =", err)
+				}
+				b, err := io.ReadAll(zr)
+				if err != nil {
+					t.Fatal("failed to read gzip, err =", err)
+				}
+				body = string(b)
+			}
+			if body != c.Body {
+				t.Errorf("unexpected body, got = %q", body)
+			}
+		})
+	}
+}
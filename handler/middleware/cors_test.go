@@ -0,0 +1,56 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+)
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		Origins    []string
+		Method     string
+		Origin     string
+		Preflight  bool
+		WantOrigin string
+		WantStatus int
+	}{
+		"Disabled":       {Method: http.MethodGet, Origin: "https://a.example", WantStatus: http.StatusOK},
+		"Allowed":        {Origins: []string{"https://a.example"}, Method: http.MethodGet, Origin: "https://a.example", WantOrigin: "https://a.example", WantStatus: http.StatusOK},
+		"Not allowed":    {Origins: []string{"https://a.example"}, Method: http.MethodGet, Origin: "https://b.example", WantStatus: http.StatusOK},
+		"Wildcard":       {Origins: []string{"*"}, Method: http.MethodGet, Origin: "https://b.example", WantOrigin: "https://b.example", WantStatus: http.StatusOK},
+		"Preflight":      {Origins: []string{"*"}, Method: http.MethodOptions, Origin: "https://a.example", Preflight: true, WantOrigin: "https://a.example", WantStatus: http.StatusNoContent},
+		"Plain OPTIONS":  {Origins: []string{"*"}, Method: http.MethodOptions, Origin: "https://a.example", WantOrigin: "https://a.example", WantStatus: http.StatusOK},
+		"Same origin":    {Origins: []string{"*"}, Method: http.MethodGet, WantStatus: http.StatusOK},
+		"Preflight deny": {Origins: []string{"https://a.example"}, Method: http.MethodOptions, Origin: "https://b.example", Preflight: true, WantStatus: http.StatusOK},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := middleware.CORS(c.Origins)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest(c.Method, "/todos", nil)
+			if c.Origin != "" {
+				req.Header.Set("Origin", c.Origin)
+			}
+			if c.Preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != c.WantStatus {
+				t.Errorf("unexpected status, got = %d", rec.Code)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != c.WantOrigin {
+				t.Errorf("unexpected Access-Control-Allow-Origin, got = %q", got)
+			}
+		})
+	}
+}
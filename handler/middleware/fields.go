@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/handler/render"
+)
+
+// Fields returns a middleware for sparse responses: with ?fields=id,subject,
+// the resources in the response only have those fields (and "id").
+// See render.WithFields.
+func Fields(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var fields []string
+		for _, v := range r.URL.Query()["fields"] {
+			for _, f := range strings.Split(v, ",") {
+				if f = strings.TrimSpace(f); f != "" {
+					fields = append(fields, f)
+				}
+			}
+		}
+		if len(fields) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(render.WithFields(w, fields), r)
+	})
+}
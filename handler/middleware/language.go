@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/i18n"
+)
+
+// Language is a middleware choosing the language of the messages of the
+// response from the Accept-Language header among i18n.Languages. The chosen
+// language is set as the Content-Language of the response before the next
+// handler runs, and render.Write translates error messages into it.
+func Language(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", i18n.Match(r.Header.Get("Accept-Language")))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/handler/render"
+)
+
+// Negotiate returns a middleware choosing the response format from the Accept
+// header among the formats registered in package render, defaultType (e.g.
+// render.JSON) if the client has no preference. The chosen media type is set
+// as the Content-Type of the response before the next handler runs, and
+// render.Write encodes with it.
+func Negotiate(defaultType string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept")
+			//ハンドラが独自の形式で返す場合は、ハンドラ側で上書きする
+			w.Header().Set("Content-Type", render.Negotiate(r.Header.Get("Accept"), defaultType))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/handler/render"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	h := middleware.Negotiate(render.JSON)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		render.Write(w, http.StatusOK, &model.HealthzResponse{Message: "OK"})
+	}))
+
+	cases := map[string]struct {
+		Accept      string
+		ContentType string
+		Body        string
+	}{
+		"Default": {ContentType: "application/json", Body: `{"message":"OK"}` + "\n"},
+		"XML":     {Accept: "application/xml", ContentType: "application/xml", Body: "<response><message>OK</message></response>"},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if c.Accept != "" {
+				req.Header.Set("Accept", c.Accept)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Type"); got != c.ContentType {
+				t.Errorf("unexpected Content-Type, got = %s, want = %s", got, c.ContentType)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("unexpected Vary, got = %s", got)
+			}
+			if !strings.HasSuffix(rec.Body.String(), c.Body) {
+				t.Errorf("unexpected body, got = %s, want suffix = %s", rec.Body.String(), c.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		Header string
+		Keep   bool
+	}{
+		"Generated": {},
+		"Kept":      {Header: "abc-123", Keep: true},
+		"Too long":  {Header: strings.Repeat("a", 129)},
+		"Invalid":   {Header: "a b"},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			logger := logging.New(&buf, "text", slog.LevelInfo)
+			var got string
+			h := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = logging.RequestID(r.Context())
+				logger.InfoContext(r.Context(), "handled")
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			if c.Header != "" {
+				req.Header.Set(middleware.RequestIDHeader, c.Header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(middleware.RequestIDHeader)
+			if id == "" || id != got {
+				t.Fatalf("unexpected request ID, header = %q, context = %q", id, got)
+			}
+			if (id == c.Header) != c.Keep {
+				t.Errorf("unexpected request ID, got = %q", id)
+			}
+			if !strings.Contains(buf.String(), "request_id="+id) {
+				t.Errorf("request ID is not logged, got = %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestRequestID_ErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	h := middleware.RequestID(middleware.Admin("secret")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	req := httptest.NewRequest(http.MethodGet, "/admin/apikeys", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var resp model.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusUnauthorized || resp.Error.RequestID != "req-1" {
+		t.Errorf("unexpected response, status = %d, body = %+v", rec.Code, resp)
+	}
+}
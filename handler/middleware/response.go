@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/handler/render"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// writeError writes the same error envelope as the handlers.
+func writeError(w http.ResponseWriter, status int, message string) {
+	code := strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+	if status == http.StatusInternalServerError {
+		code = "internal_error"
+	}
+	render.Write(w, status, &model.ErrorResponse{
+		Error: model.ErrorBody{Code: code, Message: message, RequestID: w.Header().Get(RequestIDHeader)},
+	})
+}
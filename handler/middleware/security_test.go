@@ -0,0 +1,88 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	t.Parallel()
+
+	for _, hsts := range []bool{false, true} {
+		h := middleware.SecurityHeaders(hsts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/todos", nil))
+
+		for name, want := range map[string]string{
+			"X-Content-Type-Options":  "nosniff",
+			"X-Frame-Options":         "DENY",
+			"Content-Security-Policy": "default-src 'none'; frame-ancestors 'none'",
+		} {
+			if got := rec.Header().Get(name); got != want {
+				t.Errorf("unexpected %s, got = %q, want = %q", name, got, want)
+			}
+		}
+		if got := rec.Header().Get("Strict-Transport-Security") != ""; got != hsts {
+			t.Errorf("unexpected Strict-Transport-Security with hsts = %v, got = %q", hsts, rec.Header().Get("Strict-Transport-Security"))
+		}
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		Method     string
+		Headers    []string
+		Cookies    []*http.Cookie
+		WantStatus int
+		WantCookie bool
+	}{
+		"No cookies":          {Method: http.MethodPost, WantStatus: http.StatusOK},
+		"API key":             {Method: http.MethodPost, Headers: []string{middleware.APIKeyHeader, "key"}, Cookies: []*http.Cookie{{Name: "session", Value: "s"}}, WantStatus: http.StatusOK},
+		"Bearer token":        {Method: http.MethodDelete, Headers: []string{"Authorization", "Bearer t"}, Cookies: []*http.Cookie{{Name: "session", Value: "s"}}, WantStatus: http.StatusOK},
+		"Issue on GET":        {Method: http.MethodGet, Cookies: []*http.Cookie{{Name: "session", Value: "s"}}, WantStatus: http.StatusOK, WantCookie: true},
+		"Missing cookie":      {Method: http.MethodPost, Headers: []string{middleware.CSRFHeader, "t"}, Cookies: []*http.Cookie{{Name: "session", Value: "s"}}, WantStatus: http.StatusForbidden, WantCookie: true},
+		"Missing header":      {Method: http.MethodPut, Cookies: []*http.Cookie{{Name: middleware.CSRFCookie, Value: "t"}}, WantStatus: http.StatusForbidden},
+		"Mismatch":            {Method: http.MethodPost, Headers: []string{middleware.CSRFHeader, "u"}, Cookies: []*http.Cookie{{Name: middleware.CSRFCookie, Value: "t"}}, WantStatus: http.StatusForbidden},
+		"Match":               {Method: http.MethodPost, Headers: []string{middleware.CSRFHeader, "t"}, Cookies: []*http.Cookie{{Name: middleware.CSRFCookie, Value: "t"}}, WantStatus: http.StatusOK},
+		"Safe without header": {Method: http.MethodHead, Cookies: []*http.Cookie{{Name: middleware.CSRFCookie, Value: "t"}}, WantStatus: http.StatusOK},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h := middleware.CSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest(c.Method, "/todos", nil)
+			for i := 0; i+1 < len(c.Headers); i += 2 {
+				req.Header.Set(c.Headers[i], c.Headers[i+1])
+			}
+			for _, cookie := range c.Cookies {
+				req.AddCookie(cookie)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != c.WantStatus {
+				t.Errorf("unexpected status, got = %d, want = %d", rec.Code, c.WantStatus)
+			}
+			var issued *http.Cookie
+			for _, cookie := range rec.Result().Cookies() {
+				if cookie.Name == middleware.CSRFCookie {
+					issued = cookie
+				}
+			}
+			if (issued != nil) != c.WantCookie {
+				t.Fatalf("unexpected CSRF cookie, got = %v", issued)
+			}
+			if issued != nil && (len(issued.Value) < 32 || issued.HttpOnly || issued.SameSite != http.SameSiteStrictMode) {
+				t.Errorf("unexpected CSRF cookie attributes, got = %+v", issued)
+			}
+		})
+	}
+}
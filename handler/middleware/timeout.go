@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Timeout returns a middleware giving every request a deadline of d.
+// The deadline is set on the request context, so DB queries made with it are
+// canceled on expiry. If the handler then fails with a 5xx status, or writes
+// nothing, the response is replaced with 504. A non-positive d disables it.
+// Requests for which skip returns true, e.g. long-lived streams, get no
+// deadline; skip may be nil.
+func Timeout(d time.Duration, skip func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip != nil && skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{ResponseWriter: w, ctx: ctx}
+			next.ServeHTTP(tw, r.WithContext(ctx))
+			if !tw.wroteHeader && tw.expired() {
+				tw.timeout()
+			}
+		})
+	}
+}
+
+// A timeoutWriter replaces failed responses with 504 once the deadline has passed.
+type timeoutWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) expired() bool {
+	return errors.Is(tw.ctx.Err(), context.DeadlineExceeded)
+}
+
+func (tw *timeoutWriter) timeout() {
+	tw.wroteHeader = true
+	tw.timedOut = true
+	writeError(tw.ResponseWriter, http.StatusGatewayTimeout, "Request timed out")
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	if tw.wroteHeader {
+		return
+	}
+	//期限切れで失敗した場合は、ハンドラのエラーの代わりに504を返す
+	if status >= http.StatusInternalServerError && tw.expired() {
+		tw.timeout()
+		return
+	}
+	tw.wroteHeader = true
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	if !tw.wroteHeader {
+		tw.WriteHeader(http.StatusOK)
+	}
+	if tw.timedOut {
+		//504を返したので、ハンドラが書き込む本文は捨てる
+		return len(p), nil
+	}
+	return tw.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher for streaming handlers.
+func (tw *timeoutWriter) Flush() {
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying ResponseWriter.
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
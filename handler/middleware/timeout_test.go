@@ -0,0 +1,88 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		Timeout    time.Duration
+		Skip       func(*http.Request) bool
+		Handler    http.HandlerFunc
+		WantStatus int
+	}{
+		"In time": {
+			Timeout:    time.Second,
+			Handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) },
+			WantStatus: http.StatusCreated,
+		},
+		"Failed after deadline": {
+			Timeout: time.Millisecond,
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				http.Error(w, "canceled", http.StatusInternalServerError)
+			},
+			WantStatus: http.StatusGatewayTimeout,
+		},
+		"Nothing written": {
+			Timeout:    time.Millisecond,
+			Handler:    func(w http.ResponseWriter, r *http.Request) { <-r.Context().Done() },
+			WantStatus: http.StatusGatewayTimeout,
+		},
+		"Succeeded after deadline": {
+			Timeout: time.Millisecond,
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				w.Write([]byte("{}"))
+			},
+			WantStatus: http.StatusOK,
+		},
+		"Disabled": {
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				if _, ok := r.Context().Deadline(); ok {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			},
+			WantStatus: http.StatusOK,
+		},
+		"Skipped": {
+			Timeout: time.Millisecond,
+			Skip:    func(r *http.Request) bool { return r.URL.Path == "/todos" },
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				if _, ok := r.Context().Deadline(); ok {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			},
+			WantStatus: http.StatusOK,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			middleware.Timeout(c.Timeout, c.Skip)(c.Handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/todos", nil))
+
+			if rec.Code != c.WantStatus {
+				t.Fatalf("unexpected status, got = %d", rec.Code)
+			}
+			if c.WantStatus == http.StatusGatewayTimeout {
+				var resp model.ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Error.Code != "gateway_timeout" {
+					t.Errorf("unexpected body, got = %q", rec.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// WorkspaceHeader is the request header selecting the workspace.
+const WorkspaceHeader = "X-Workspace"
+
+// Workspace returns a middleware scoping the request to the workspace of the
+// X-Workspace header, or the default workspace without the header. It must
+// run after APIKey so the membership of the key can be checked.
+func Workspace(svc *service.WorkspaceService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := int64(model.DefaultWorkspace)
+			if header := r.Header.Get(WorkspaceHeader); header != "" {
+				var err error
+				id, err = strconv.ParseInt(header, 10, 64)
+				if err != nil || id <= 0 {
+					writeError(w, http.StatusBadRequest, "Invalid "+WorkspaceHeader)
+					return
+				}
+			}
+
+			ctx, err := svc.Enter(r.Context(), id)
+			if err != nil {
+				switch err.(type) {
+				case *model.ErrNotFound:
+					writeError(w, http.StatusNotFound, "Workspace not found")
+				case *model.ErrForbidden:
+					if _, ok := service.APIKeyFromContext(r.Context()); !ok {
+						writeError(w, http.StatusForbidden, "API key is required to access the workspace")
+						return
+					}
+					writeError(w, http.StatusForbidden, "API key is not a member of the workspace")
+				default:
+					slog.ErrorContext(r.Context(), "Error entering workspace", "err", err)
+					writeError(w, http.StatusInternalServerError, "Failed to enter workspace")
+				}
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxPerPage is the largest per_page accepted by numbered pages.
+const maxPerPage = 100
+
+// setPageLinks sets the RFC 5988 Link header pointing to the first, last,
+// previous and next pages of a numbered page.
+// URLはリクエストのパスとクエリを元に、pageのみを置き換えて生成する。
+func setPageLinks(w http.ResponseWriter, u *url.URL, page, perPage, total int64) {
+	last := (total + perPage - 1) / perPage
+	if last < 1 {
+		last = 1
+	}
+
+	link := func(p int64, rel string) string {
+		query := u.Query()
+		query.Set("page", strconv.FormatInt(p, 10))
+		query.Set("per_page", strconv.FormatInt(perPage, 10))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, u.Path, query.Encode(), rel)
+	}
+	links := []string{link(1, "first")}
+	if page > 1 {
+		links = append(links, link(min(page-1, last), "prev"))
+	}
+	if page < last {
+		links = append(links, link(page+1, "next"))
+	}
+	links = append(links, link(last, "last"))
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
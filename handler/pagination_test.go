@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSetPageLinks(t *testing.T) {
+	u, _ := url.Parse("/todos?status=open&page=2&per_page=2")
+	tests := map[string]struct {
+		page, total int64
+		want        string
+	}{
+		"middle": {2, 5, `</todos?page=1&per_page=2&status=open>; rel="first", ` +
+			`</todos?page=1&per_page=2&status=open>; rel="prev", ` +
+			`</todos?page=3&per_page=2&status=open>; rel="next", ` +
+			`</todos?page=3&per_page=2&status=open>; rel="last"`},
+		"first": {1, 3, `</todos?page=1&per_page=2&status=open>; rel="first", ` +
+			`</todos?page=2&per_page=2&status=open>; rel="next", ` +
+			`</todos?page=2&per_page=2&status=open>; rel="last"`},
+		"empty": {1, 0, `</todos?page=1&per_page=2&status=open>; rel="first", ` +
+			`</todos?page=1&per_page=2&status=open>; rel="last"`},
+		"beyond last": {5, 3, `</todos?page=1&per_page=2&status=open>; rel="first", ` +
+			`</todos?page=2&per_page=2&status=open>; rel="prev", ` +
+			`</todos?page=2&per_page=2&status=open>; rel="last"`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			setPageLinks(w, u, tt.page, 2, tt.total)
+			if got := w.Header().Get("Link"); got != tt.want {
+				t.Errorf("unexpected Link header\ngot  = %s\nwant = %s", got, tt.want)
+			}
+		})
+	}
+}
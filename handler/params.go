@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// pathID parses the path parameter name (e.g. {id}) as a positive ID.
+func pathID(r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// parseTime parses a query parameter given as RFC 3339, in the time format
+// of the TODOs (see model.SetTimeFormat), or as a date (2006-01-02).
+// Dates are interpreted as the beginning of the day in the time zone of
+// model.TimeLocation.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, model.TimeLocation()); err == nil {
+		return t, nil
+	}
+	return model.ParseTime(s)
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// A ProjectHandler implements handling REST endpoints for projects.
+// ProjectHandlerは、/projects 以下のREST APIエンドポイントの処理を実装します。
+type ProjectHandler struct {
+	svc *service.ProjectService
+}
+
+// NewProjectHandler returns ProjectHandler based http.Handler.
+// NewProjectHandlerは新しいProjectHandlerを返します。
+func NewProjectHandler(svc *service.ProjectService) *ProjectHandler {
+	return &ProjectHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP handles HTTP requests for the project API.
+// /projects と /projects/{id} に登録されることを想定しています。
+func (h *ProjectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var id int64
+	if r.PathValue("id") != "" {
+		var ok bool
+		if id, ok = pathID(r, "id"); !ok {
+			writeError(w, http.StatusNotFound, "Project not found")
+			return
+		}
+	}
+
+	switch {
+	case id == 0 && r.Method == http.MethodPost:
+		h.handleCreate(w, r)
+	case id == 0 && r.Method == http.MethodGet:
+		h.handleRead(w, r)
+	case id != 0 && r.Method == http.MethodGet:
+		h.handleReadByID(w, r, id)
+	case id != 0 && r.Method == http.MethodPut:
+		h.handleUpdate(w, r, id)
+	case id != 0 && r.Method == http.MethodDelete:
+		h.handleDelete(w, r, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+// handleCreate handles the POST request to create a new project.
+// handleCreateは、新しいプロジェクトを作成するためのPOSTリクエストを処理する。
+func (h *ProjectHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Error decoding CreateProjectRequest", "err", err)
+		writeDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	//必須フィールドであるNameが空でないかをチェックする
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	res, err := h.Create(r.Context(), &req)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error creating project", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create project")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Create handles the endpoint that creates the project.
+func (h *ProjectHandler) Create(ctx context.Context, req *model.CreateProjectRequest) (*model.CreateProjectResponse, error) {
+	project, err := h.svc.CreateProject(ctx, req.Name, req.Description)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CreateProjectResponse{
+		Project: *project,
+	}, nil
+}
+
+// handleRead handles the GET request to list projects with pagination.
+// handleReadは、prev_idとsizeによるページネーション付きでプロジェクト一覧を返す。
+func (h *ProjectHandler) handleRead(w http.ResponseWriter, r *http.Request) {
+	req := &model.ReadProjectRequest{
+		Size: 5, //"size"が指定されていない場合のデフォルト値
+	}
+	query := r.URL.Query()
+
+	if prevIDStr := query.Get("prev_id"); prevIDStr != "" {
+		var err error
+		req.PrevID, err = strconv.ParseInt(prevIDStr, 10, 64)
+		if err != nil {
+			slog.WarnContext(r.Context(), "Error parsing prev_id", "err", err)
+			writeError(w, http.StatusBadRequest, "Invalid prev_id")
+			return
+		}
+	}
+	if sizeStr := query.Get("size"); sizeStr != "" {
+		var err error
+		req.Size, err = strconv.ParseInt(sizeStr, 10, 64)
+		if err != nil {
+			slog.WarnContext(r.Context(), "Error parsing size", "err", err)
+			writeError(w, http.StatusBadRequest, "Invalid size")
+			return
+		}
+	}
+
+	res, err := h.Read(r.Context(), req)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading projects", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to read projects")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Read handles the endpoint that reads the projects.
+func (h *ProjectHandler) Read(ctx context.Context, req *model.ReadProjectRequest) (*model.ReadProjectResponse, error) {
+	projects, err := h.svc.ReadProjects(ctx, req.PrevID, req.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	//[]*model.Project型のスライスを[]model.Project型のスライスに変換
+	converted := make([]model.Project, len(projects))
+	for i, project := range projects {
+		converted[i] = *project
+	}
+	return &model.ReadProjectResponse{
+		Projects: converted,
+	}, nil
+}
+
+// handleReadByID handles the GET request to read a project with its counters.
+func (h *ProjectHandler) handleReadByID(w http.ResponseWriter, r *http.Request, id int64) {
+	project, err := h.svc.ReadProject(r.Context(), id)
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "Project not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error reading project", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to read project")
+		return
+	}
+	writeJSON(w, http.StatusOK, &model.ReadProjectByIDResponse{
+		Project: *project,
+	})
+}
+
+// handleUpdate handles the PUT request to update a project.
+// handleUpdateは、プロジェクトの名前と説明を変更するためのPUTリクエストを処理する。
+func (h *ProjectHandler) handleUpdate(w http.ResponseWriter, r *http.Request, id int64) {
+	var req model.UpdateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Error decoding UpdateProjectRequest", "err", err)
+		writeDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	res, err := h.Update(r.Context(), id, &req)
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "Project not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error updating project", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to update project")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Update handles the endpoint that updates the project.
+func (h *ProjectHandler) Update(ctx context.Context, id int64, req *model.UpdateProjectRequest) (*model.UpdateProjectResponse, error) {
+	project, err := h.svc.UpdateProject(ctx, id, req.Name, req.Description)
+	if err != nil {
+		return nil, err
+	}
+	return &model.UpdateProjectResponse{
+		Project: *project,
+	}, nil
+}
+
+// handleDelete handles the DELETE request to delete a project.
+// handleDeleteは、プロジェクトを削除する。属していたTODOは削除されない。
+func (h *ProjectHandler) handleDelete(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := h.svc.DeleteProject(r.Context(), id); err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "Project not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error deleting project", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to delete project")
+		return
+	}
+	writeJSON(w, http.StatusOK, &model.DeleteProjectResponse{})
+}
@@ -0,0 +1,161 @@
+package render
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// WithFields returns w making Write keep only the fields (and "id") of the
+// resources in successful responses, e.g. the TODOs of {"todos":[...]}.
+// A resource is a struct with an "id" member, or a slice of them, directly in
+// the response. Responses without resources are written as they are.
+func WithFields(w http.ResponseWriter, fields []string) http.ResponseWriter {
+	return &fieldsWriter{ResponseWriter: w, fields: fields}
+}
+
+type fieldsWriter struct {
+	http.ResponseWriter
+	fields []string
+}
+
+// Flush implements http.Flusher for streaming handlers.
+func (fw *fieldsWriter) Flush() {
+	if f, ok := fw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying ResponseWriter.
+func (fw *fieldsWriter) Unwrap() http.ResponseWriter {
+	return fw.ResponseWriter
+}
+
+// fieldsOf returns the fields given to WithFields anywhere in the chain of
+// ResponseWriters of w, or nil.
+func fieldsOf(w http.ResponseWriter) []string {
+	for {
+		switch t := w.(type) {
+		case *fieldsWriter:
+			return t.fields
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = t.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// An UnknownFieldError is returned for a field the resources do not have.
+type UnknownFieldError struct {
+	Field string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("Unknown field %q in fields", e.Field)
+}
+
+// selectFields returns the tree of v with the resources reduced to fields.
+func selectFields(v interface{}, fields []string) (interface{}, error) {
+	resources := resourceFields(reflect.TypeOf(v))
+	if len(resources) == 0 {
+		return v, nil
+	}
+	keep := map[string]bool{"id": true}
+	for _, f := range fields {
+		known := false
+		for _, names := range resources {
+			known = known || names[f]
+		}
+		if !known {
+			return nil, &UnknownFieldError{Field: f}
+		}
+		keep[f] = true
+	}
+
+	tree, err := toTree(v)
+	if err != nil {
+		return nil, err
+	}
+	body, _ := tree.(object)
+	for i, f := range body {
+		if resources[f.key] == nil {
+			continue
+		}
+		switch value := f.value.(type) {
+		case object:
+			body[i].value = pick(value, keep)
+		case []interface{}:
+			for j, e := range value {
+				if o, ok := e.(object); ok {
+					value[j] = pick(o, keep)
+				}
+			}
+		}
+	}
+	return body, nil
+}
+
+func pick(o object, keep map[string]bool) object {
+	picked := object{}
+	for _, f := range o {
+		if keep[f.key] {
+			picked = append(picked, f)
+		}
+	}
+	return picked
+}
+
+// resourceFields returns the JSON names of the fields of each resource member
+// of the response type t by the member's JSON name.
+func resourceFields(t reflect.Type) map[string]map[string]bool {
+	resources := map[string]map[string]bool{}
+	for name, ft := range jsonFields(t) {
+		for ft.Kind() == reflect.Pointer || ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+		}
+		if names := jsonFields(ft); names["id"] != nil {
+			resources[name] = make(map[string]bool, len(names))
+			for n := range names {
+				resources[name][n] = true
+			}
+		}
+	}
+	return resources
+}
+
+// jsonFields returns the types of the fields of the struct t (or a pointer to
+// it) by their JSON names, or nil if t is not a struct.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	if t == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		//埋め込まれた構造体の項目は、JSONと同じく外側の項目として扱う
+		if f.Anonymous && name == "" {
+			for n, ft := range jsonFields(f.Type) {
+				fields[n] = ft
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
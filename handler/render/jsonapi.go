@@ -0,0 +1,193 @@
+package render
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// JSONAPI is the media type of JSON:API (https://jsonapi.org/format/1.1/).
+const JSONAPI = "application/vnd.api+json"
+
+// encodeJSONAPI writes v as a JSON:API document:
+//
+//   - An error envelope becomes "errors" with one error object.
+//   - The first member holding an object with an "id", or an array, is the
+//     primary data. Its key names the resource type, e.g. "todo" and "todos"
+//     are both "todos". Members ending in "_id" become relationships, e.g.
+//     project_id is the "project" of type "projects", and the other members
+//     are the attributes.
+//   - The other members, e.g. total_count, go to "meta", as does the whole
+//     response if it has no primary data.
+//   - The Link header set for numbered pages, if w is the http.ResponseWriter,
+//     becomes "links".
+func encodeJSONAPI(w io.Writer, v interface{}) error {
+	tree, err := toTree(v)
+	if err != nil {
+		return err
+	}
+	body, _ := tree.(object)
+
+	doc := object{}
+	if e, ok := body.get("error"); ok && len(body) == 1 {
+		doc = append(doc, field{"errors", []interface{}{jsonAPIError(e)}})
+		return json.NewEncoder(w).Encode(doc)
+	}
+
+	var (
+		meta  object
+		found bool
+	)
+	for _, f := range body {
+		if data, ok := jsonAPIData(f); ok && !found {
+			doc = append(doc, field{"data", data})
+			found = true
+			continue
+		}
+		meta = append(meta, f)
+	}
+	if len(meta) > 0 || !found {
+		if meta == nil {
+			meta = object{}
+		}
+		doc = append(doc, field{"meta", meta})
+	}
+	if rw, ok := w.(http.ResponseWriter); ok {
+		if links := parseLinks(rw.Header().Get("Link")); len(links) > 0 {
+			doc = append(doc, field{"links", links})
+		}
+	}
+	return json.NewEncoder(w).Encode(doc)
+}
+
+// jsonAPIData returns the resource object or the array of resource objects of f,
+// and false if f is not a resource.
+func jsonAPIData(f field) (interface{}, bool) {
+	typ := jsonAPIType(f.key)
+	switch v := f.value.(type) {
+	case object:
+		if _, ok := v.get("id"); !ok {
+			return nil, false
+		}
+		return jsonAPIResource(typ, v), true
+	case []interface{}:
+		resources := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			o, ok := e.(object)
+			if !ok {
+				return nil, false
+			}
+			if _, ok := o.get("id"); !ok {
+				return nil, false
+			}
+			resources = append(resources, jsonAPIResource(typ, o))
+		}
+		return resources, true
+	}
+	return nil, false
+}
+
+// jsonAPIResource returns the resource object of o.
+func jsonAPIResource(typ string, o object) object {
+	id, _ := o.get("id")
+	attributes, relationships := object{}, object{}
+	for _, f := range o {
+		if f.key == "id" {
+			continue
+		}
+		//数値の"_id"で終わる項目は、他のリソースへの参照として扱う
+		if name, ok := strings.CutSuffix(f.key, "_id"); ok {
+			if n, ok := f.value.(json.Number); ok {
+				relationships = append(relationships, field{name, object{
+					{"data", object{{"type", jsonAPIType(name)}, {"id", string(n)}}},
+				}})
+				continue
+			}
+		}
+		attributes = append(attributes, f)
+	}
+
+	resource := object{{"type", typ}, {"id", jsonAPIID(id)}, {"attributes", attributes}}
+	if len(relationships) > 0 {
+		resource = append(resource, field{"relationships", relationships})
+	}
+	return resource
+}
+
+// jsonAPIError returns the error object of an ErrorBody, e.g.
+// {"code":"not_found","message":"TODO not found","request_id":"x"}.
+// The request ID is kept in meta under the same name.
+func jsonAPIError(e interface{}) object {
+	body, _ := e.(object)
+	out := object{}
+	code, _ := body.get("code")
+	if s, ok := code.(string); ok {
+		if status := statusOfCode(s); status != 0 {
+			out = append(out, field{"status", strconv.Itoa(status)}, field{"title", http.StatusText(status)})
+		}
+		out = append(out, field{"code", s})
+	}
+	if message, ok := body.get("message"); ok {
+		out = append(out, field{"detail", message})
+	}
+	if id, ok := body.get("request_id"); ok {
+		out = append(out, field{"meta", object{{"request_id", id}}})
+	}
+	return out
+}
+
+// jsonAPIType returns the resource type of a member or relationship name,
+// e.g. "todos" for "todo" and "api_keys" for "api_key".
+func jsonAPIType(key string) string {
+	if strings.HasSuffix(key, "s") {
+		return key
+	}
+	return key + "s"
+}
+
+// jsonAPIID returns id as a string, as JSON:API requires.
+func jsonAPIID(id interface{}) string {
+	switch id := id.(type) {
+	case json.Number:
+		return string(id)
+	case string:
+		return id
+	}
+	return ""
+}
+
+// statusOfCode returns the status of an error code of the error envelope,
+// e.g. 404 for "not_found", and 0 if there is none.
+func statusOfCode(code string) int {
+	if code == "internal_error" {
+		return http.StatusInternalServerError
+	}
+	for status := 400; status < 600; status++ {
+		text := http.StatusText(status)
+		if text != "" && strings.ToLower(strings.ReplaceAll(text, " ", "_")) == code {
+			return status
+		}
+	}
+	return 0
+}
+
+// parseLinks returns the links of a Link header by their rel, e.g.
+// {"next": "/todos?page=2"} for `</todos?page=2>; rel="next"`.
+func parseLinks(header string) object {
+	links := object{}
+	for _, part := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, p := range strings.Split(params, ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if k == "rel" {
+				links = append(links, field{strings.Trim(v, `"`), strings.Trim(target, "<>")})
+			}
+		}
+	}
+	return links
+}
@@ -0,0 +1,118 @@
+package render
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// encodeMsgpack writes v in MessagePack (https://msgpack.org/). Times are
+// strings in RFC 3339 as in JSON, not the timestamp extension type.
+func encodeMsgpack(w io.Writer, v interface{}) error {
+	tree, err := toTree(v)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	if err := writeMsgpack(bw, tree); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func writeMsgpack(w *bufio.Writer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		return w.WriteByte(0xc0)
+	case bool:
+		if v {
+			return w.WriteByte(0xc3)
+		}
+		return w.WriteByte(0xc2)
+	case json.Number:
+		return writeMsgpackNumber(w, v)
+	case string:
+		writeMsgpackHeader(w, len(v), 0xa0, 31, 0xd9, 0xda, 0xdb)
+		_, err := w.WriteString(v)
+		return err
+	case []interface{}:
+		writeMsgpackHeader(w, len(v), 0x90, 15, 0, 0xdc, 0xdd)
+		for _, e := range v {
+			if err := writeMsgpack(w, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case object:
+		writeMsgpackHeader(w, len(v), 0x80, 15, 0, 0xde, 0xdf)
+		for _, f := range v {
+			if err := writeMsgpack(w, f.key); err != nil {
+				return err
+			}
+			if err := writeMsgpack(w, f.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("render: cannot encode %T in MessagePack", v)
+}
+
+// writeMsgpackHeader writes the format and length of a string, array or map:
+// fix|n if n <= fixMax, otherwise the 8 (if any), 16 or 32 bit form.
+func writeMsgpackHeader(w *bufio.Writer, n int, fix byte, fixMax int, f8, f16, f32 byte) {
+	var b []byte
+	switch {
+	case n <= fixMax:
+		b = []byte{fix | byte(n)}
+	case f8 != 0 && n <= math.MaxUint8:
+		b = []byte{f8, byte(n)}
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16([]byte{f16}, uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32([]byte{f32}, uint32(n))
+	}
+	w.Write(b)
+}
+
+// writeMsgpackNumber writes n in the smallest integer format, or as float 64
+// if it is not an integer.
+func writeMsgpackNumber(w *bufio.Writer, n json.Number) error {
+	var b []byte
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		switch {
+		case i >= 0 && i <= 0x7f:
+			b = []byte{byte(i)}
+		case i < 0 && i >= -32:
+			b = []byte{byte(int8(i))}
+		case i >= 0 && i <= math.MaxUint8:
+			b = []byte{0xcc, byte(i)}
+		case i >= 0 && i <= math.MaxUint16:
+			b = binary.BigEndian.AppendUint16([]byte{0xcd}, uint16(i))
+		case i >= 0 && i <= math.MaxUint32:
+			b = binary.BigEndian.AppendUint32([]byte{0xce}, uint32(i))
+		case i >= 0:
+			b = binary.BigEndian.AppendUint64([]byte{0xcf}, uint64(i))
+		case i >= math.MinInt8:
+			b = []byte{0xd0, byte(int8(i))}
+		case i >= math.MinInt16:
+			b = binary.BigEndian.AppendUint16([]byte{0xd1}, uint16(int16(i)))
+		case i >= math.MinInt32:
+			b = binary.BigEndian.AppendUint32([]byte{0xd2}, uint32(int32(i)))
+		default:
+			b = binary.BigEndian.AppendUint64([]byte{0xd3}, uint64(i))
+		}
+	} else {
+		f, err := n.Float64()
+		if err != nil {
+			return err
+		}
+		b = binary.BigEndian.AppendUint64([]byte{0xcb}, math.Float64bits(f))
+	}
+	_, err := w.Write(b)
+	return err
+}
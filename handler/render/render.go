@@ -0,0 +1,197 @@
+// Package render writes API responses in the format negotiated from the
+// Accept header. JSON is always available; other formats are registered with
+// Register (MessagePack and XML are built in).
+//
+// The negotiated media type travels in the Content-Type response header:
+// middleware.Negotiate sets it before the handler runs, and Write encodes
+// with the Encoder registered for it.
+package render
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/TechBowl-japan/go-stations/i18n"
+	"github.com/TechBowl-japan/go-stations/logging"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// JSON is the media type used when the client accepts nothing else.
+const JSON = "application/json"
+
+// NDJSON is the media type of newline-delimited JSON, one value per line,
+// written by the streaming endpoints.
+const NDJSON = "application/x-ndjson"
+
+// requestIDHeader is middleware.RequestIDHeader, which cannot be imported
+// because the middleware uses this package.
+const requestIDHeader = "X-Request-ID"
+
+// An Encoder writes v, a value which can be marshaled to JSON, to w.
+type Encoder interface {
+	Encode(w io.Writer, v interface{}) error
+}
+
+// EncoderFunc adapts a function to Encoder.
+type EncoderFunc func(w io.Writer, v interface{}) error
+
+// Encode calls f(w, v).
+func (f EncoderFunc) Encode(w io.Writer, v interface{}) error {
+	return f(w, v)
+}
+
+var (
+	mu       sync.RWMutex
+	types    []string //ネゴシエーションで同じ品質の場合に優先する順
+	encoders = map[string]Encoder{}
+)
+
+func init() {
+	Register(JSON, EncoderFunc(func(w io.Writer, v interface{}) error {
+		return json.NewEncoder(w).Encode(v)
+	}))
+	Register("application/msgpack", EncoderFunc(encodeMsgpack))
+	Register("application/xml", EncoderFunc(encodeXML))
+	Register(JSONAPI, EncoderFunc(encodeJSONAPI))
+}
+
+// Register makes enc available for mediaType, e.g. "application/msgpack".
+// Registering a media type again replaces its Encoder.
+func Register(mediaType string, enc Encoder) {
+	mu.Lock()
+	defer mu.Unlock()
+	mediaType = strings.ToLower(mediaType)
+	if _, ok := encoders[mediaType]; !ok {
+		types = append(types, mediaType)
+	}
+	encoders[mediaType] = enc
+}
+
+// lookup returns the Encoder of the media type of a Content-Type header.
+func lookup(contentType string) (Encoder, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mu.RLock()
+	defer mu.RUnlock()
+	enc, ok := encoders[strings.ToLower(strings.TrimSpace(mediaType))]
+	return enc, ok
+}
+
+// Negotiate returns the registered media type the Accept header prefers.
+// It returns defaultType if the header is empty, accepts none of them or
+// accepts defaultType as much as any other (e.g. "*/*"), so that clients which
+// do not care get the server's default format.
+func Negotiate(accept, defaultType string) string {
+	if strings.TrimSpace(accept) == "" {
+		return defaultType
+	}
+	ranges := parseAccept(accept)
+
+	mu.RLock()
+	defer mu.RUnlock()
+	best, bestQ := defaultType, quality(ranges, defaultType)
+	for _, t := range types {
+		if q := quality(ranges, t); q > bestQ {
+			best, bestQ = t, q
+		}
+	}
+	if bestQ <= 0 {
+		return defaultType
+	}
+	return best
+}
+
+// Write writes v with the status code in the format of the Content-Type
+// header of w, falling back to JSON if no Encoder is registered for it.
+// The message of an error response is translated into the language of the
+// Content-Language header of w, set by middleware.Language.
+// If w comes from WithFields, a successful response is reduced to the fields,
+// and 400 is written instead if a field is unknown.
+func Write(w http.ResponseWriter, status int, v interface{}) {
+	enc, ok := lookup(w.Header().Get("Content-Type"))
+	if !ok {
+		enc, _ = lookup(JSON)
+		w.Header().Set("Content-Type", JSON)
+	}
+	if fields := fieldsOf(w); fields != nil && status < http.StatusMultipleChoices {
+		selected, err := selectFields(v, fields)
+		if err != nil {
+			status = http.StatusBadRequest
+			selected = &model.ErrorResponse{
+				Error: model.ErrorBody{Code: "bad_request", Message: err.Error(), RequestID: w.Header().Get(requestIDHeader)},
+			}
+		}
+		v = selected
+	}
+	if res, ok := v.(*model.ErrorResponse); ok {
+		v = translate(w.Header().Get("Content-Language"), res)
+	}
+	w.WriteHeader(status)
+	if err := enc.Encode(w, v); err != nil {
+		//ヘッダーは送信済みのため、ログに記録するだけにする
+		slog.Error("Error encoding response", "err", err, logging.RequestIDKey, w.Header().Get(requestIDHeader))
+	}
+}
+
+// translate returns res with the message in lang.
+func translate(lang string, res *model.ErrorResponse) *model.ErrorResponse {
+	if lang == "" || lang == i18n.Source {
+		return res
+	}
+	translated := *res
+	translated.Error.Message = i18n.Translate(lang, res.Error.Message)
+	return &translated
+}
+
+// A mediaRange is an element of the Accept header, e.g. "application/*;q=0.5".
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !ok {
+			continue
+		}
+		r := mediaRange{typ: typ, subtype: subtype, q: 1}
+		for _, p := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "q") {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					r.q = f
+				}
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// quality returns the q of the most specific range matching mediaType (0 if none).
+func quality(ranges []mediaRange, mediaType string) float64 {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
@@ -0,0 +1,203 @@
+package render_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/render"
+)
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		Accept  string
+		Default string
+		Want    string
+	}{
+		"Empty":             {Want: "application/json"},
+		"Any":               {Accept: "*/*", Want: "application/json"},
+		"MessagePack":       {Accept: "application/msgpack", Want: "application/msgpack"},
+		"XML by q":          {Accept: "application/json;q=0.5, application/xml", Want: "application/xml"},
+		"Specific q":        {Accept: "application/*, application/json;q=0", Want: "application/msgpack"},
+		"Unsupported":       {Accept: "text/html", Want: "application/json"},
+		"Default JSONAPI":   {Accept: "*/*", Default: render.JSONAPI, Want: render.JSONAPI},
+		"JSON over JSONAPI": {Accept: "application/json", Default: render.JSONAPI, Want: "application/json"},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			def := c.Default
+			if def == "" {
+				def = render.JSON
+			}
+			if got := render.Negotiate(c.Accept, def); got != c.Want {
+				t.Errorf("unexpected media type, got = %s, want = %s", got, c.Want)
+			}
+		})
+	}
+}
+
+type item struct {
+	ID      int64   `json:"id"`
+	Subject string  `json:"subject"`
+	Done    bool    `json:"done"`
+	Score   float64 `json:"score"`
+	Note    *string `json:"note"`
+	Omitted string  `json:"omitted,omitempty"`
+}
+
+type response struct {
+	Items  []item           `json:"items"`
+	Counts map[string]int64 `json:"counts"`
+}
+
+// JSONは従来どおりHTMLの文字をエスケープする
+const wantJSON = `{"items":[{"id":1,"subject":"a\u003cb","done":false,"score":1.5,"note":null},{"id":-300,"subject":"","done":true,"score":0,"note":null}],"counts":{"1":70000}}` + "\n"
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	v := &response{
+		Items:  []item{{ID: 1, Subject: "a<b", Score: 1.5}, {ID: -300, Done: true}},
+		Counts: map[string]int64{"1": 70000},
+	}
+
+	cases := map[string]struct {
+		ContentType string
+		Want        string
+	}{
+		"JSON":     {ContentType: "application/json", Want: wantJSON},
+		"Fallback": {ContentType: "text/csv", Want: wantJSON},
+		"XML": {
+			ContentType: "application/xml",
+			Want: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<response><items>` +
+				`<item><id>1</id><subject>a&lt;b</subject><done>false</done><score>1.5</score><note></note></item>` +
+				`<item><id>-300</id><subject></subject><done>true</done><score>0</score><note></note></item>` +
+				`</items><counts><entry key="1">70000</entry></counts></response>`,
+		},
+		"MessagePack": {
+			ContentType: "application/msgpack",
+			Want: hex.EncodeToString(bytes.Join([][]byte{
+				{0x82, 0xa5}, []byte("items"), {0x92},
+				{0x85, 0xa2}, []byte("id"), {0x01}, {0xa7}, []byte("subject"), {0xa3}, []byte("a<b"),
+				{0xa4}, []byte("done"), {0xc2}, {0xa5}, []byte("score"), {0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0},
+				{0xa4}, []byte("note"), {0xc0},
+				{0x85, 0xa2}, []byte("id"), {0xd1, 0xfe, 0xd4}, {0xa7}, []byte("subject"), {0xa0},
+				{0xa4}, []byte("done"), {0xc3}, {0xa5}, []byte("score"), {0x00},
+				{0xa4}, []byte("note"), {0xc0},
+				{0xa6}, []byte("counts"), {0x81, 0xa1, '1', 0xce, 0, 0x01, 0x11, 0x70},
+			}, nil)),
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			rec.Header().Set("Content-Type", c.ContentType)
+			render.Write(rec, http.StatusCreated, v)
+
+			got := rec.Body.String()
+			if c.ContentType == "application/msgpack" {
+				got = hex.EncodeToString(rec.Body.Bytes())
+			}
+			if rec.Code != http.StatusCreated || got != c.Want {
+				t.Errorf("unexpected response, status = %d\ngot:  %s\nwant: %s", rec.Code, got, c.Want)
+			}
+			if want := strings.Replace(c.ContentType, "text/csv", "application/json", 1); rec.Header().Get("Content-Type") != want {
+				t.Errorf("unexpected Content-Type, got = %s, want = %s", rec.Header().Get("Content-Type"), want)
+			}
+		})
+	}
+}
+
+func TestWrite_JSONAPI(t *testing.T) {
+	t.Parallel()
+
+	type todo struct {
+		ID        int64  `json:"id"`
+		Subject   string `json:"subject"`
+		ProjectID int64  `json:"project_id,omitempty"`
+	}
+
+	cases := map[string]struct {
+		Value interface{}
+		Want  string
+	}{
+		"Resource": {
+			Value: &struct {
+				TODO todo `json:"todo"`
+			}{TODO: todo{ID: 1, Subject: "a", ProjectID: 2}},
+			Want: `{"data":{"type":"todos","id":"1","attributes":{"subject":"a"},"relationships":{"project":{"data":{"type":"projects","id":"2"}}}}}`,
+		},
+		"Meta only": {
+			Value: &struct {
+				Action string `json:"action"`
+			}{Action: "update"},
+			Want: `{"meta":{"action":"update"}}`,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			rec.Header().Set("Content-Type", render.JSONAPI)
+			render.Write(rec, http.StatusOK, c.Value)
+			if got := strings.TrimSpace(rec.Body.String()); got != c.Want {
+				t.Errorf("unexpected document\ngot:  %s\nwant: %s", got, c.Want)
+			}
+		})
+	}
+}
+
+func TestWrite_Fields(t *testing.T) {
+	t.Parallel()
+
+	v := &response{
+		Items:  []item{{ID: 1, Subject: "a", Score: 1.5}},
+		Counts: map[string]int64{"x": 1},
+	}
+
+	cases := map[string]struct {
+		Fields []string
+		Status int
+		Want   string
+	}{
+		"Selected": {
+			Fields: []string{"score", "subject"},
+			Status: http.StatusOK,
+			Want:   `{"items":[{"id":1,"subject":"a","score":1.5}],"counts":{"x":1}}`,
+		},
+		"Unknown": {
+			Fields: []string{"subject", "owner"},
+			Status: http.StatusBadRequest,
+			Want:   `{"error":{"code":"bad_request","message":"Unknown field \"owner\" in fields"}}`,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			render.Write(render.WithFields(rec, c.Fields), http.StatusOK, v)
+			if got := strings.TrimSpace(rec.Body.String()); rec.Code != c.Status || got != c.Want {
+				t.Errorf("unexpected response, status = %d\ngot:  %s\nwant: %s", rec.Code, got, c.Want)
+			}
+		})
+	}
+}
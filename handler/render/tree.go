@@ -0,0 +1,106 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// A field is a member of a JSON object.
+type field struct {
+	key   string
+	value interface{}
+}
+
+// An object is a JSON object. Objects are decoded into it rather than maps
+// to keep the order of the struct fields.
+type object []field
+
+// get returns the value of key.
+func (o object) get(key string) (interface{}, bool) {
+	for _, f := range o {
+		if f.key == key {
+			return f.value, true
+		}
+	}
+	return nil, false
+}
+
+// MarshalJSON writes the members of o in order.
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// toTree returns the JSON value of v as nil, bool, json.Number, string,
+// []interface{} or object. Going through JSON makes the other formats
+// honor the json tags, omitempty and MarshalJSON of the models.
+func toTree(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return decodeValue(dec)
+}
+
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		fields := object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, field{key: key.(string), value: value})
+		}
+		_, err := dec.Token() // }
+		return fields, err
+	case json.Delim('['):
+		values := []interface{}{}
+		for dec.More() {
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		_, err := dec.Token() // ]
+		return values, err
+	case nil:
+		return nil, nil
+	}
+	switch tok := tok.(type) {
+	case bool, json.Number, string:
+		return tok, nil
+	}
+	return nil, fmt.Errorf("render: unexpected JSON token %v", tok)
+}
@@ -0,0 +1,97 @@
+package render
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// xmlRoot is the name of the root element of XML responses.
+const xmlRoot = "response"
+
+// encodeXML writes v as XML. Object members become elements named after
+// their JSON keys, array elements become <item> elements and null becomes
+// an empty element, e.g. {"todos":[{"id":1}]} is
+// <response><todos><item><id>1</id></item></todos></response>.
+// Keys which are not valid XML names (e.g. numbers) are written as
+// <entry key="...">.
+func encodeXML(w io.Writer, v interface{}) error {
+	tree, err := toTree(v)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if err := writeXML(enc, xmlElement(xmlRoot), tree); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func writeXML(enc *xml.Encoder, start xml.StartElement, v interface{}) error {
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+	case bool:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	case json.Number:
+		if err := enc.EncodeToken(xml.CharData(v)); err != nil {
+			return err
+		}
+	case string:
+		if err := enc.EncodeToken(xml.CharData(v)); err != nil {
+			return err
+		}
+	case []interface{}:
+		for _, e := range v {
+			if err := writeXML(enc, xmlElement("item"), e); err != nil {
+				return err
+			}
+		}
+	case object:
+		for _, f := range v {
+			if err := writeXML(enc, xmlElement(f.key), f.value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("render: cannot encode %T in XML", v)
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// xmlElement returns the start element of name, or <entry key="name"> if
+// name is not a valid XML name.
+func xmlElement(name string) xml.StartElement {
+	if validXMLName(name) {
+		return xml.StartElement{Name: xml.Name{Local: name}}
+	}
+	return xml.StartElement{
+		Name: xml.Name{Local: "entry"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}},
+	}
+}
+
+// validXMLName reports whether name is a valid XML name without a namespace prefix.
+// JSONのキーはASCIIのsnake_caseのため、ASCIIの範囲だけを許可する
+func validXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		case i > 0 && (c == '-' || c == '.' || c >= '0' && c <= '9'):
+		default:
+			return false
+		}
+	}
+	return true
+}
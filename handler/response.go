@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/handler/render"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// writeJSON writes v as a response with the status code. The format is the
+// one negotiated by middleware.Negotiate, JSON if the request did not go through it.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	render.Write(w, status, v)
+}
+
+// writeError writes the error envelope with the status code.
+// codeはステータスから生成する(例: 404 -> "not_found")。
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, &model.ErrorResponse{
+		Error: model.ErrorBody{
+			Code:      errorCode(status),
+			Message:   message,
+			RequestID: w.Header().Get(middleware.RequestIDHeader),
+		},
+	})
+}
+
+// writeDecodeError writes the error for a request body that could not be decoded:
+// 413 if the body exceeded the limit set by middleware.BodyLimit, 400 otherwise.
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must not exceed %d bytes", tooLarge.Limit))
+		return
+	}
+	writeError(w, http.StatusBadRequest, "Invalid JSON")
+}
+
+// errorCode returns the snake_case status text, e.g. "method_not_allowed".
+func errorCode(status int) string {
+	if status == http.StatusInternalServerError {
+		return "internal_error"
+	}
+	return strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
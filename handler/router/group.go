@@ -0,0 +1,77 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// A Middleware wraps a handler, e.g. to authenticate the requests to it.
+type Middleware func(http.Handler) http.Handler
+
+// A Group registers routes under a path prefix, wrapping their handlers with
+// the middleware of the group. Groups nest: a child group inherits the prefix
+// and the middleware of its parent, so subsystems can be mounted with their
+// own middleware stacks on the same mux.
+type Group struct {
+	mux        *http.ServeMux
+	prefix     string
+	middleware []Middleware
+}
+
+// NewGroup returns the root Group of mux, without a prefix or middleware.
+func NewGroup(mux *http.ServeMux) *Group {
+	return &Group{mux: mux}
+}
+
+// Group returns a child group under prefix, e.g. "/v1", whose routes are
+// wrapped by the middleware of g and then by middleware.
+func (g *Group) Group(prefix string, middleware ...Middleware) *Group {
+	return &Group{
+		mux:        g.mux,
+		prefix:     g.prefix + strings.TrimSuffix(prefix, "/"),
+		middleware: append(g.middleware[:len(g.middleware):len(g.middleware)], middleware...),
+	}
+}
+
+// Use appends middleware to the stack of g. It applies to the routes
+// registered afterwards, including those of the child groups made afterwards.
+func (g *Group) Use(middleware ...Middleware) {
+	g.middleware = append(g.middleware[:len(g.middleware):len(g.middleware)], middleware...)
+}
+
+// Handle registers h for pattern under the prefix of g. pattern is that of
+// http.ServeMux, optionally starting with a method, e.g. "GET /todos/{id}".
+// The first middleware of the stack runs first.
+func (g *Group) Handle(pattern string, h http.Handler) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		method, path = "", pattern
+	}
+	for i := len(g.middleware) - 1; i >= 0; i-- {
+		h = g.middleware[i](h)
+	}
+	if method != "" {
+		method += " "
+	}
+	g.mux.Handle(method+g.prefix+path, h)
+}
+
+// Get registers h for GET requests to path.
+func (g *Group) Get(path string, h http.HandlerFunc) {
+	g.Handle(http.MethodGet+" "+path, h)
+}
+
+// Post registers h for POST requests to path.
+func (g *Group) Post(path string, h http.HandlerFunc) {
+	g.Handle(http.MethodPost+" "+path, h)
+}
+
+// Put registers h for PUT requests to path.
+func (g *Group) Put(path string, h http.HandlerFunc) {
+	g.Handle(http.MethodPut+" "+path, h)
+}
+
+// Delete registers h for DELETE requests to path.
+func (g *Group) Delete(path string, h http.HandlerFunc) {
+	g.Handle(http.MethodDelete+" "+path, h)
+}
@@ -0,0 +1,65 @@
+package router_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/router"
+)
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	trace := func(name string) router.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.PathValue("id")))
+	}
+
+	mux := http.NewServeMux()
+	root := router.NewGroup(mux)
+	api := root.Group("/api/", trace("api"))
+	v1 := api.Group("/v1", trace("v1"))
+	v1.Get("/todos/{id}", ok)
+	//Useは後から作るグループと、後から登録するルートだけに適用される
+	api.Use(trace("late"))
+	api.Group("/v2").Get("/todos/{id}", ok)
+	root.Post("/hooks", ok)
+
+	testcases := map[string]struct {
+		method, path string
+		wantStatus   int
+		wantBody     string
+		wantCalls    string
+	}{
+		"Nested group":        {http.MethodGet, "/api/v1/todos/3", http.StatusOK, "3", "api,v1"},
+		"Middleware by Use":   {http.MethodGet, "/api/v2/todos/4", http.StatusOK, "4", "api,late"},
+		"Root group":          {http.MethodPost, "/hooks", http.StatusOK, "", ""},
+		"Method not allowed":  {http.MethodPost, "/api/v1/todos/3", http.StatusMethodNotAllowed, "", ""},
+		"Prefix not repeated": {http.MethodGet, "/todos/3", http.StatusNotFound, "", ""},
+	}
+	for name, tc := range testcases {
+		calls = nil
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		if rec.Code != tc.wantStatus {
+			t.Errorf("%s: unexpected status, got = %d, want = %d", name, rec.Code, tc.wantStatus)
+			continue
+		}
+		if tc.wantStatus == http.StatusOK && rec.Body.String() != tc.wantBody {
+			t.Errorf("%s: unexpected body, got = %q, want = %q", name, rec.Body.String(), tc.wantBody)
+		}
+		if got := strings.Join(calls, ","); got != tc.wantCalls {
+			t.Errorf("%s: unexpected middleware calls, got = %q, want = %q", name, got, tc.wantCalls)
+		}
+	}
+}
@@ -3,17 +3,208 @@ package router
 import (
 	"database/sql"
 	"net/http"
+	"regexp"
+	"time"
 
 	"github.com/TechBowl-japan/go-stations/handler"
+	"github.com/TechBowl-japan/go-stations/handler/mcp"
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/handler/ui"
+	"github.com/TechBowl-japan/go-stations/jobs"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/repository/sqlrepo"
 	"github.com/TechBowl-japan/go-stations/service"
 )
 
+// Options configures the authentication of the routes.
+type Options struct {
+	// AdminToken lets "Authorization: Bearer <AdminToken>" access the /admin
+	// endpoints, e.g. to mint the first admin API key. If empty, only API keys
+	// of the admin role can access them.
+	AdminToken string
+	// RequireAPIKey rejects requests to the resource endpoints without an X-API-Key header.
+	// Requests with an invalid key are rejected even if false.
+	RequireAPIKey bool
+	// Dev enables the development endpoints, e.g. POST /debug/seed.
+	Dev bool
+	// Profiling enables /debug/pprof/ and /debug/vars, authenticated like
+	// the /admin endpoints.
+	Profiling bool
+	// UndoWindow is how long POST /todos/undo can revert updates and deletes.
+	// If 0, service.DefaultUndoWindow is used.
+	UndoWindow time.Duration
+	// AssignHooks are notified when PUT /todos/{id}/assignee changes the
+	// assignee of a TODO.
+	AssignHooks []service.AssignHook
+	// SlackSigningSecret is the signing secret of the Slack app sending the
+	// /todo slash command to POST /integrations/slack. The endpoint is not
+	// registered if empty.
+	SlackSigningSecret string
+	// MCPScope is the scope of the tools of the MCP server at POST /mcp,
+	// model.ScopeRead or model.ScopeWrite. The endpoint is not registered if empty.
+	MCPScope string
+	// Jobs are the background jobs listed and run by /admin/jobs.
+	// The endpoints are not registered if nil.
+	Jobs *jobs.Runner
+}
+
 func NewRouter(todoDB *sql.DB) *http.ServeMux {
+	return NewRouterWithRepository(sqlrepo.New(todoDB))
+}
+
+// NewRouterWithRepository registers the routes backed by repo with the default Options.
+func NewRouterWithRepository(repo repository.Repository) *http.ServeMux {
+	return NewRouterWithOptions(repo, Options{})
+}
+
+// NewRouterWithOptions registers the routes backed by repo.
+func NewRouterWithOptions(repo repository.Repository, opts Options) *http.ServeMux {
 	// register routes
 	mux := http.NewServeMux()
 	//healthzエンドポイント追加
 	mux.Handle("/healthz", handler.NewHealthzHandler())
-	// TODOエンドポイント追加
-	mux.Handle("/todos", handler.NewTODOHandler(service.NewTODOService(todoDB)))
+	//ブラウザからTODOを操作する画面(APIの呼び出しは画面に入力したAPIキーで認証する)
+	mux.Handle("GET /ui/", ui.Handler("/ui/"))
+
+	// リソースのエンドポイントはX-API-Keyで認証し、X-Workspaceのワークスペースに限定する
+	apiKeyService := service.NewAPIKeyServiceWithRepository(repo)
+	workspaceService := service.NewWorkspaceServiceWithRepository(repo)
+	todoService := service.NewTODOServiceWithOptions(repo, service.TODOOptions{UndoWindow: opts.UndoWindow, AssignHooks: opts.AssignHooks})
+	root := NewGroup(mux)
+	api := root.Group("", middleware.APIKey(apiKeyService, opts.RequireAPIKey), middleware.Workspace(workspaceService))
+
+	// バッチエンドポイント
+	// atomicの場合は、トランザクション内のリポジトリで同じルーティングを作成して実行する
+	// ジョブはトランザクションに参加できないため、その中では登録しない(プロファイルも同様)
+	txOpts := opts
+	txOpts.Jobs = nil
+	txOpts.Profiling = false
+	batchHandler := handler.NewBatchHandler(mux, repo, func(tx repository.Repository) http.Handler {
+		return NewRouterWithOptions(tx, txOpts)
+	})
+
+	// バージョンのないパスはv1として残し、v1とv2は振る舞いの変わらないハンドラーを共有する
+	resources := newResources(repo, todoService, batchHandler)
+	resources.register(api)
+	for _, version := range []string{"v1", "v2"} {
+		resources.register(api.Group("/"+version, apiVersion(version)))
+	}
+
+	// Slackのスラッシュコマンドは、APIキーではなくSlackの署名で認証する
+	// ワークスペースを指定できないため、デフォルトのワークスペースのTODOを操作する
+	if opts.SlackSigningSecret != "" {
+		integrations := root.Group("/integrations")
+		integrations.Handle("POST /slack", handler.NewSlackHandler(todoService, opts.SlackSigningSecret))
+	}
+
+	// MCPサーバーはPOSTでツールを呼び出すため、APIキーには読み取りの権限だけを求め、
+	// 書き込みの権限はツールごとに確かめる
+	if opts.MCPScope != "" {
+		mcpGroup := root.Group("", middleware.APIKeyFor(apiKeyService, opts.RequireAPIKey, model.PermissionRead), middleware.Workspace(workspaceService))
+		mcpGroup.Handle("POST /mcp", mcp.NewServer(todoService, opts.MCPScope))
+	}
+
+	// 開発用のエンドポイントは、明示的に有効にした場合のみ登録する
+	if opts.Dev {
+		api.Handle("POST /debug/seed", handler.NewSeedHandler(repo))
+	}
+
+	// 管理者向けエンドポイント追加
+	// 管理者トークンだけで最初のキーを発行できるよう、APIキーは必須にしない
+	adminOnly := root.Group("", middleware.APIKey(apiKeyService, false), middleware.Admin(opts.AdminToken))
+	admin := adminOnly.Group("/admin")
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	admin.Handle("/apikeys", apiKeyHandler)
+	admin.Post("/apikeys/{id}/revoke", apiKeyHandler.ServeRevoke)
+	workspaceHandler := handler.NewWorkspaceHandler(workspaceService)
+	admin.Handle("/workspaces", workspaceHandler)
+	admin.Handle("/workspaces/{id}/members/{api_key_id}", http.HandlerFunc(workspaceHandler.ServeMember))
+	if opts.Jobs != nil {
+		jobHandler := handler.NewJobHandler(opts.Jobs)
+		admin.Get("/jobs", jobHandler.ServeRead)
+		admin.Post("/jobs/{name}/run", jobHandler.ServeRun)
+	}
+	// プロファイルは内部の情報を含むため、管理者だけに公開する
+	if opts.Profiling {
+		debugHandler := handler.NewDebugHandler()
+		adminOnly.Handle("/debug/pprof/", debugHandler)
+		adminOnly.Handle("GET /debug/vars", debugHandler)
+	}
 	return mux
 }
+
+// resources holds the handlers of the resource endpoints, which are shared
+// by the API versions.
+type resources struct {
+	todo     *handler.TODOHandler
+	comment  *handler.CommentHandler
+	project  *handler.ProjectHandler
+	template *handler.TemplateHandler
+	batch    *handler.BatchHandler
+}
+
+func newResources(repo repository.Repository, todoService *service.TODOService, batch *handler.BatchHandler) *resources {
+	return &resources{
+		todo:     handler.NewTODOHandler(todoService),
+		comment:  handler.NewCommentHandler(service.NewCommentServiceWithRepository(repo)),
+		project:  handler.NewProjectHandler(service.NewProjectServiceWithRepository(repo)),
+		template: handler.NewTemplateHandler(service.NewTemplateServiceWithRepository(repo)),
+		batch:    batch,
+	}
+}
+
+// streamPath matches the paths of the TODO stream of every API version.
+var streamPath = regexp.MustCompile(`^(/v[0-9]+)?/todos/stream$`)
+
+// IsStream reports whether r is for GET /todos/stream, whose response may
+// take longer than any fixed request timeout. The handler extends the write
+// deadline per TODO instead.
+func IsStream(r *http.Request) bool {
+	return r.Method == http.MethodGet && streamPath.MatchString(r.URL.Path)
+}
+
+// register registers the resource endpoints in g.
+func (res *resources) register(g *Group) {
+	// TODOエンドポイント
+	g.Handle("/todos", res.todo)
+	g.Get("/todos/{id}", res.todo.ServeReadByID)
+	g.Get("/todos/stats", res.todo.ServeStats)
+	g.Get("/todos/changes", res.todo.ServeChanges)
+	g.Get("/todos/stream", res.todo.ServeStream)
+	g.Put("/todos/reorder", res.todo.ServeReorder)
+	g.Post("/todos/undo", res.todo.ServeUndo)
+	g.Post("/todos/{id}/clone", res.todo.ServeClone)
+	g.Post("/todos/{id}/archive", res.todo.ServeArchive)
+	g.Post("/todos/{id}/unarchive", res.todo.ServeUnarchive)
+	g.Post("/todos/{id}/claim", res.todo.ServeClaim)
+	g.Post("/todos/{id}/release", res.todo.ServeRelease)
+	g.Put("/todos/{id}/assignee", res.todo.ServeAssign)
+	// コメントエンドポイント
+	g.Handle("/todos/{id}/comments", res.comment)
+	g.Handle("/todos/{id}/comments/{comment_id}", res.comment)
+	// プロジェクトエンドポイント
+	g.Handle("/projects", res.project)
+	g.Handle("/projects/{id}", res.project)
+	g.Get("/projects/{id}/todos", res.todo.ServeProjectTODOs)
+	// テンプレートエンドポイント
+	g.Handle("/templates", res.template)
+	g.Handle("/templates/{id}", res.template)
+	g.Post("/templates/{id}/instantiate", res.template.ServeInstantiate)
+	// バッチエンドポイント
+	g.Handle("POST /batch", res.batch)
+}
+
+// APIVersionHeader is the response header telling the API version of the
+// versioned paths, e.g. "v1" for /v1/todos.
+const APIVersionHeader = "API-Version"
+
+// apiVersion returns a middleware setting the APIVersionHeader to version.
+func apiVersion(version string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(APIVersionHeader, version)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
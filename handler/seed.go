@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/seed"
+)
+
+// A SeedHandler implements the POST /debug/seed endpoint loading fixture data.
+// SeedHandlerは、デモやフロントエンドの開発用にサンプルデータを作成します。
+type SeedHandler struct {
+	repo repository.Repository
+}
+
+// NewSeedHandler returns SeedHandler based http.Handler.
+// NewSeedHandlerは新しいSeedHandlerを返します。
+func NewSeedHandler(repo repository.Repository) *SeedHandler {
+	return &SeedHandler{repo: repo}
+}
+
+// ServeHTTP creates the fixtures in the workspace of the request.
+func (h *SeedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	res, err := seed.Load(r.Context(), h.repo)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error loading fixtures", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to load fixtures")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
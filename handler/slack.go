@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/handler/render"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+const (
+	// SlackSignatureHeader and SlackTimestampHeader are the headers Slack
+	// signs its requests with.
+	SlackSignatureHeader = "X-Slack-Signature"
+	SlackTimestampHeader = "X-Slack-Request-Timestamp"
+
+	// slackMaxSkew is how old a signed request may be, to reject replays.
+	slackMaxSkew = 5 * time.Minute
+	// slackListSize is the number of TODOs listed by "/todo list".
+	slackListSize = 10
+)
+
+// A SlackHandler implements handling the Slack slash command endpoint.
+// SlackHandlerは、Slackのスラッシュコマンド(/todo add|list|done)の処理を実装します。
+type SlackHandler struct {
+	svc    *service.TODOService
+	secret []byte
+}
+
+// NewSlackHandler returns SlackHandler based http.Handler, accepting the
+// requests signed with signingSecret, the signing secret of the Slack app.
+// NewSlackHandlerは新しいSlackHandlerを返します。
+func NewSlackHandler(svc *service.TODOService, signingSecret string) *SlackHandler {
+	return &SlackHandler{
+		svc:    svc,
+		secret: []byte(signingSecret),
+	}
+}
+
+// ServeHTTP handles the POST /integrations/slack request.
+// ServeHTTPは、署名を検証してからコマンドのテキストに応じてTODOを操作し、Block Kitで応答する。
+// Slackは200以外の応答を表示しないため、コマンドのエラーも200で返す。
+func (h *SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Error reading Slack request", "err", err)
+		writeDecodeError(w, err)
+		return
+	}
+	if !h.verify(r.Header, body, time.Now()) {
+		writeError(w, http.StatusUnauthorized, "Invalid Slack signature")
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid form")
+		return
+	}
+
+	//Acceptヘッダーに関わらず、Slackが解釈できるJSONで返す
+	w.Header().Set("Content-Type", render.JSON)
+	writeJSON(w, http.StatusOK, h.run(r, form.Get("text")))
+}
+
+// verify reports whether header has the signature of Slack for body, made
+// within slackMaxSkew of now.
+// 署名は "v0:<timestamp>:<body>" のHMAC-SHA256
+func (h *SlackHandler) verify(header http.Header, body []byte, now time.Time) bool {
+	ts := header.Get(SlackTimestampHeader)
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return false
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > slackMaxSkew || d < -slackMaxSkew {
+		return false
+	}
+	mac := hmac.New(sha256.New, h.secret)
+	fmt.Fprintf(mac, "v0:%s:", ts)
+	mac.Write(body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(header.Get(SlackSignatureHeader)), []byte(want))
+}
+
+// run runs the command written in text and returns the message to reply.
+func (h *SlackHandler) run(r *http.Request, text string) *model.SlackMessage {
+	ctx := r.Context()
+	command, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	arg = strings.TrimSpace(arg)
+	switch command {
+	case "add":
+		if arg == "" {
+			return slackReply("Usage: `/todo add <subject>`")
+		}
+		todo, err := h.svc.CreateTODO(ctx, arg, "")
+		if err != nil {
+			return h.slackError(r, err, "Failed to create TODO")
+		}
+		msg := slackReply(fmt.Sprintf("Added #%d %s", todo.ID, slackEscape(todo.Subject)))
+		msg.ResponseType = model.SlackInChannel
+		return msg
+	case "list":
+		todos, err := h.svc.ListTODO(ctx, &model.TODOQuery{Status: model.TODOStatusOpen, Size: slackListSize})
+		if err != nil {
+			return h.slackError(r, err, "Failed to read TODOs")
+		}
+		return slackTODOs(todos)
+	case "done":
+		id, err := strconv.ParseInt(strings.TrimPrefix(arg, "#"), 10, 64)
+		if err != nil || id <= 0 {
+			return slackReply("Usage: `/todo done <id>`")
+		}
+		done := true
+		todo, err := h.svc.PatchTODO(ctx, id, &model.TODOPatch{Done: &done})
+		if err != nil {
+			return h.slackError(r, err, "Failed to update TODO")
+		}
+		msg := slackReply(fmt.Sprintf("Completed #%d %s", todo.ID, slackEscape(todo.Subject)))
+		msg.ResponseType = model.SlackInChannel
+		return msg
+	default:
+		return slackReply("Usage: `/todo add <subject>`, `/todo list` or `/todo done <id>`")
+	}
+}
+
+// slackError returns the reply to err of the service: its message if the
+// command was wrong, message otherwise.
+func (h *SlackHandler) slackError(r *http.Request, err error, message string) *model.SlackMessage {
+	switch err := err.(type) {
+	case *model.ErrNotFound:
+		return slackReply(err.Error())
+	case *model.ErrValidation:
+		return slackReply(err.Error())
+	case *model.ErrLocked:
+		return slackReply(err.Error())
+	}
+	slog.ErrorContext(r.Context(), "Error running Slack command", "err", err)
+	return slackReply(message)
+}
+
+// slackReply returns an ephemeral message of text.
+func slackReply(text string) *model.SlackMessage {
+	return &model.SlackMessage{
+		ResponseType: model.SlackEphemeral,
+		Text:         text,
+		Blocks: []model.SlackBlock{
+			{Type: "section", Text: &model.SlackText{Type: "mrkdwn", Text: text}},
+		},
+	}
+}
+
+// slackTODOs returns the message listing todos, a section for each.
+func slackTODOs(todos []*model.TODO) *model.SlackMessage {
+	if len(todos) == 0 {
+		return slackReply("No open TODOs")
+	}
+	msg := &model.SlackMessage{
+		ResponseType: model.SlackEphemeral,
+		Text:         fmt.Sprintf("%d open TODOs", len(todos)),
+	}
+	for _, todo := range todos {
+		text := fmt.Sprintf("*#%d* %s", todo.ID, slackEscape(todo.Subject))
+		if todo.Description != "" {
+			text += "\n" + slackEscape(todo.Description)
+		}
+		msg.Blocks = append(msg.Blocks, model.SlackBlock{
+			Type: "section", Text: &model.SlackText{Type: "mrkdwn", Text: text},
+		})
+	}
+	msg.Blocks = append(msg.Blocks, model.SlackBlock{
+		Type:     "context",
+		Elements: []model.SlackText{{Type: "mrkdwn", Text: "Complete one with `/todo done <id>`"}},
+	})
+	return msg
+}
+
+// slackEscape escapes the characters of s which are control characters in
+// Slack's mrkdwn.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
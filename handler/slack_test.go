@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository/memory"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func slackRequest(secret, text string, at time.Time) *http.Request {
+	body := url.Values{"command": {"/todo"}, "text": {text}}.Encode()
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+
+	r := httptest.NewRequest(http.MethodPost, "/integrations/slack", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set(SlackTimestampHeader, ts)
+	r.Header.Set(SlackSignatureHeader, "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+func TestSlackHandler(t *testing.T) {
+	h := NewSlackHandler(service.NewTODOServiceWithRepository(memory.New()), "secret")
+	run := func(text string) *model.SlackMessage {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, slackRequest("secret", text, time.Now()))
+		if w.Code != http.StatusOK {
+			t.Fatalf("unexpected status of %q, got = %d, body = %s", text, w.Code, w.Body)
+		}
+		var msg model.SlackMessage
+		if err := json.Unmarshal(w.Body.Bytes(), &msg); err != nil {
+			t.Fatal("failed to decode message, err =", err)
+		}
+		return &msg
+	}
+
+	if msg := run("add buy <milk>"); msg.ResponseType != model.SlackInChannel || msg.Text != "Added #1 buy &lt;milk&gt;" {
+		t.Errorf("unexpected reply to add, got = %+v", msg)
+	}
+	run("add call mom")
+	if msg := run("done 1"); msg.Text != "Completed #1 buy &lt;milk&gt;" {
+		t.Errorf("unexpected reply to done, got = %+v", msg)
+	}
+	//完了したTODOは一覧に含めない
+	msg := run("list")
+	if len(msg.Blocks) != 2 || msg.Blocks[0].Text.Text != "*#2* call mom" || msg.Blocks[1].Type != "context" {
+		t.Errorf("unexpected reply to list, got = %+v", msg)
+	}
+	for text, want := range map[string]string{
+		"done 99": "TODO not found",
+		"done x":  "Usage: `/todo done <id>`",
+		"add":     "Usage: `/todo add <subject>`",
+	} {
+		if msg := run(text); msg.ResponseType != model.SlackEphemeral || msg.Text != want {
+			t.Errorf("unexpected reply to %q, got = %+v", text, msg)
+		}
+	}
+}
+
+func TestSlackHandler_Signature(t *testing.T) {
+	h := NewSlackHandler(service.NewTODOServiceWithRepository(memory.New()), "secret")
+	for name, r := range map[string]*http.Request{
+		"wrong secret": slackRequest("other", "list", time.Now()),
+		"too old":      slackRequest("secret", "list", time.Now().Add(-10*time.Minute)),
+		"unsigned":     httptest.NewRequest(http.MethodPost, "/integrations/slack", strings.NewReader("text=list")),
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: unexpected status, got = %d", name, w.Code)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// A TemplateHandler implements handling REST endpoints for TODO templates.
+// TemplateHandlerは、/templates 以下のREST APIエンドポイントの処理を実装します。
+type TemplateHandler struct {
+	svc *service.TemplateService
+}
+
+// NewTemplateHandler returns TemplateHandler based http.Handler.
+// NewTemplateHandlerは新しいTemplateHandlerを返します。
+func NewTemplateHandler(svc *service.TemplateService) *TemplateHandler {
+	return &TemplateHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP handles HTTP requests for the template API.
+// /templates と /templates/{id} に登録されることを想定しています。
+func (h *TemplateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var id int64
+	if r.PathValue("id") != "" {
+		var ok bool
+		if id, ok = pathID(r, "id"); !ok {
+			writeError(w, http.StatusNotFound, "Template not found")
+			return
+		}
+	}
+
+	switch {
+	case id == 0 && r.Method == http.MethodPost:
+		h.handleCreate(w, r)
+	case id == 0 && r.Method == http.MethodGet:
+		h.handleRead(w, r)
+	case id != 0 && r.Method == http.MethodGet:
+		h.handleReadByID(w, r, id)
+	case id != 0 && r.Method == http.MethodDelete:
+		h.handleDelete(w, r, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+// handleCreate handles the POST request to create a new template.
+// handleCreateは、項目を指定するか既存のTODOを元に、新しいテンプレートを作成する。
+func (h *TemplateHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Error decoding CreateTemplateRequest", "err", err)
+		writeDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	//必須フィールドであるNameが空でないかをチェックする
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	res, err := h.Create(r.Context(), &req)
+	if err != nil {
+		if _, ok := err.(*model.ErrValidation); ok {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		//元にするTODOが存在しない場合、404NotFoundを返す
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error creating template", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create template")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Create handles the endpoint that creates the template.
+func (h *TemplateHandler) Create(ctx context.Context, req *model.CreateTemplateRequest) (*model.CreateTemplateResponse, error) {
+	template, err := h.svc.CreateTemplate(ctx, req.Name, req.Description, req.Items, req.TODOIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CreateTemplateResponse{
+		Template: *template,
+	}, nil
+}
+
+// handleRead handles the GET request to list all templates.
+func (h *TemplateHandler) handleRead(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.svc.ReadTemplates(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading templates", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to read templates")
+		return
+	}
+
+	//[]*model.Template型のスライスを[]model.Template型のスライスに変換
+	converted := make([]model.Template, len(templates))
+	for i, template := range templates {
+		converted[i] = *template
+	}
+	writeJSON(w, http.StatusOK, &model.ReadTemplateResponse{
+		Templates: converted,
+	})
+}
+
+// handleReadByID handles the GET request to read a template with its items.
+func (h *TemplateHandler) handleReadByID(w http.ResponseWriter, r *http.Request, id int64) {
+	template, err := h.svc.ReadTemplate(r.Context(), id)
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "Template not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error reading template", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to read template")
+		return
+	}
+	writeJSON(w, http.StatusOK, &model.ReadTemplateByIDResponse{
+		Template: *template,
+	})
+}
+
+// handleDelete handles the DELETE request to delete a template.
+// handleDeleteは、テンプレートを削除する。作成済みのTODOは削除されない。
+func (h *TemplateHandler) handleDelete(w http.ResponseWriter, r *http.Request, id int64) {
+	if err := h.svc.DeleteTemplate(r.Context(), id); err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "Template not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error deleting template", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to delete template")
+		return
+	}
+	writeJSON(w, http.StatusOK, &model.DeleteTemplateResponse{})
+}
+
+// ServeInstantiate handles the POST /templates/{id}/instantiate request.
+// ServeInstantiateは、テンプレートの全ての項目からTODOを作成する。リクエストボディは省略できる。
+func (h *TemplateHandler) ServeInstantiate(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusNotFound, "Template not found")
+		return
+	}
+	var req model.InstantiateTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		slog.WarnContext(r.Context(), "Error decoding InstantiateTemplateRequest", "err", err)
+		writeDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	res, err := h.Instantiate(r.Context(), id, &req)
+	if err != nil {
+		//テンプレートまたはプロジェクトが存在しない場合、404NotFoundを返す
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error instantiating template", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to instantiate template")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Instantiate handles the endpoint that creates the TODOs of the template.
+func (h *TemplateHandler) Instantiate(ctx context.Context, id int64, req *model.InstantiateTemplateRequest) (*model.InstantiateTemplateResponse, error) {
+	todos, err := h.svc.InstantiateTemplate(ctx, id, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	converted := make([]model.TODO, len(todos))
+	for i, todo := range todos {
+		converted[i] = *todo
+	}
+	return &model.InstantiateTemplateResponse{
+		TODOs: converted,
+	}, nil
+}
@@ -3,10 +3,16 @@ package handler
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
+	"github.com/TechBowl-japan/go-stations/handler/middleware"
+	"github.com/TechBowl-japan/go-stations/handler/render"
 	"github.com/TechBowl-japan/go-stations/model"
 	"github.com/TechBowl-japan/go-stations/service"
 )
@@ -14,12 +20,13 @@ import (
 // A TODOHandler implements handling REST endpoints.
 // TODOHandlerは、TODOに関するREST APIエンドポイントを処理を実装します。
 type TODOHandler struct {
-	svc *service.TODOService //TODOServiceを使用してデータ操作を行う
+	svc service.TODOServicer //TODOServiceを使用してデータ操作を行う
 }
 
-// NewTODOHandler returns TODOHandler based http.Handler.
+// NewTODOHandler returns TODOHandler based http.Handler. svc is usually a
+// *service.TODOService; tests may pass a servicetest.TODOService instead.
 // NewTODOHandlerは新しいTODOHandlerを返します。
-func NewTODOHandler(svc *service.TODOService) *TODOHandler {
+func NewTODOHandler(svc service.TODOServicer) *TODOHandler {
 	return &TODOHandler{
 		svc: svc, //TODOServiceを注入
 	}
@@ -39,7 +46,7 @@ func (h *TODOHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.handleDelete(w, r) //TODO削除の処理を呼び出す
 	default:
 		//他のメソッドは許可されていないため、エラーレスポンスを返す
-		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
 	}
 }
 
@@ -50,33 +57,38 @@ func (h *TODOHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
 	//リクエストボディを解析し、CreateTODORequest構造体にデコードする。
 	var req model.CreateTODORequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		//JSONのデコードに失敗した場合、400BadRequestを返す
-		log.Printf("Error decoding CreateTODORequest: %v", err)
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		//JSONのデコードに失敗した場合、400BadRequest(ボディが大きすぎる場合は413)を返す
+		slog.WarnContext(r.Context(), "Error decoding CreateTODORequest", "err", err)
+		writeDecodeError(w, err)
 		return
 	}
 	defer r.Body.Close() //リクエストボディをクローズする
 	//必須フィールドであるSubjectが空でないかをチェックする
 	if req.Subject == "" {
 		//Subjectが空の場合、400BadRequestを返す
-		http.Error(w, "Subject is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Subject is required")
 		return
 	}
 	//Contextを取得し、Createメソッドを呼び出してTODOを作成する
 	ctx := r.Context()
 	res, err := h.Create(ctx, &req)
 	if err != nil {
+		//優先度などの値が不正な場合、400BadRequestを返す
+		if _, ok := err.(*model.ErrValidation); ok {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		//指定されたプロジェクトが存在しない場合、404NotFoundを返す
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
 		//TODOの作成時にエラーが発生した場合、500Internal Server Errorを返す
-		http.Error(w, "Failed to create TODO", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, "Failed to create TODO")
 		return
 	}
 	//レスポンスヘッダを設定し、成功ステータス(200 OK)を返す
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(res); err != nil {
-		//レスポンスヘッダのエンコードに失敗した場合、500 Internal Server Errorを返す
-		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
-	}
+	writeJSON(w, http.StatusOK, res)
 
 }
 
@@ -84,7 +96,13 @@ func (h *TODOHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
 // TODOServiceのCreateTODOメソッドを呼び出し、新しいTODOを作成する
 func (h *TODOHandler) Create(ctx context.Context, req *model.CreateTODORequest) (*model.CreateTODOResponse, error) {
 	//TODOServiceを使用して新しいTODOを作成する
-	todo, err := h.svc.CreateTODO(ctx, req.Subject, req.Description)
+	todo, err := h.svc.InsertTODO(ctx, &model.TODO{
+		Subject:     req.Subject,
+		Description: req.Description,
+		Done:        req.Done,
+		Priority:    req.Priority,
+		ProjectID:   req.ProjectID,
+	})
 	if err != nil {
 		//作成中にエラーが発生した場合、そのエラー呼び出し元に返す
 		return nil, err
@@ -96,8 +114,24 @@ func (h *TODOHandler) Create(ctx context.Context, req *model.CreateTODORequest)
 }
 
 func (h *TODOHandler) handleRead(w http.ResponseWriter, r *http.Request) {
+	h.serveRead(w, r, 0)
+}
+
+// ServeProjectTODOs handles the GET /projects/{id}/todos request.
+// ServeProjectTODOsは、パスで指定されたプロジェクトのTODO一覧を返す。クエリパラメータはGET /todosと同じ。
+func (h *TODOHandler) ServeProjectTODOs(w http.ResponseWriter, r *http.Request) {
+	projectID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+	h.serveRead(w, r, projectID)
+}
+
+// serveRead lists TODOs, only of the project if projectID is not 0.
+func (h *TODOHandler) serveRead(w http.ResponseWriter, r *http.Request, projectID int64) {
 	//ReadTODORequest構造体のインスタンスを作成
-	req := &model.ReadTODORequest{}
+	req := &model.ReadTODORequest{ProjectID: projectID}
 	//クエリパラメータを取得
 	query := r.URL.Query()
 
@@ -108,8 +142,8 @@ func (h *TODOHandler) handleRead(w http.ResponseWriter, r *http.Request) {
 		req.PrevID, err = strconv.ParseInt(prevIDStr, 10, 64)
 		if err != nil {
 			//エラーが発生した場合、400BadRequestを返す
-			log.Printf("Error parsing prev_id: %v", err)
-			http.Error(w, "Invalid prev_id", http.StatusBadRequest)
+			slog.WarnContext(r.Context(), "Error parsing prev_id", "err", err)
+			writeError(w, http.StatusBadRequest, "Invalid prev_id")
 			return
 		}
 	}
@@ -121,8 +155,8 @@ func (h *TODOHandler) handleRead(w http.ResponseWriter, r *http.Request) {
 		req.Size, err = strconv.ParseInt(sizeStr, 10, 64)
 		if err != nil {
 			//エラーが発生した場合、400BadRequestを返す
-			log.Printf("Error parsing size: %v", err)
-			http.Error(w, "Invalid size", http.StatusBadRequest)
+			slog.WarnContext(r.Context(), "Error parsing size", "err", err)
+			writeError(w, http.StatusBadRequest, "Invalid size")
 			return
 		}
 	} else {
@@ -130,31 +164,109 @@ func (h *TODOHandler) handleRead(w http.ResponseWriter, r *http.Request) {
 		req.Size = 5
 	}
 
-	// TODOの取得処理を呼び出す
+	//"page"または"per_page"が指定された場合はページ番号方式にする
+	if query.Has("page") || query.Has("per_page") {
+		req.Page, req.PerPage = 1, req.Size
+		for name, v := range map[string]*int64{"page": &req.Page, "per_page": &req.PerPage} {
+			if s := query.Get(name); s != "" {
+				n, err := strconv.ParseInt(s, 10, 64)
+				if err != nil || n <= 0 {
+					writeError(w, http.StatusBadRequest, "Invalid "+name)
+					return
+				}
+				*v = n
+			}
+		}
+		if req.PerPage > maxPerPage {
+			writeError(w, http.StatusBadRequest, "per_page must be at most "+strconv.Itoa(maxPerPage))
+			return
+		}
+	}
+
+	if !parseTODOFilters(w, query, req) {
+		return
+	}
+
+	// TODO一覧が前回から変わっていなければ304 Not Modifiedを返す
 	ctx := r.Context()
+	version, err := h.svc.TODOVersion(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading TODO version", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to read TODOs")
+		return
+	}
+	etag := weakETag(version.Count, version.CommentCount, version.LastModified.Unix(), r.URL.RawQuery)
+	//assignee=meの結果はAPIキーごとに異なるため、キーもETagに含める
+	if req.Assignee == service.AssigneeMe {
+		if key, ok := service.APIKeyFromContext(ctx); ok {
+			etag = weakETag(version.Count, version.CommentCount, version.LastModified.Unix(), r.URL.RawQuery, key.ID)
+		}
+	}
+	if checkNotModified(w, r, etag, version.LastModified) {
+		return
+	}
+
+	// TODOの取得処理を呼び出す
 	res, err := h.Read(ctx, req)
 	if err != nil {
+		//絞り込み条件が不正な場合、400BadRequestを返す
+		if _, ok := err.(*model.ErrValidation); ok {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		//指定されたプロジェクトが存在しない場合、404NotFoundを返す
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
 		//エラーが発生した場合、500Internal Server Errorを返す
-		log.Printf("Error reading TODOs: %v", err)
-		http.Error(w, "Failed to read TODOs", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error reading TODOs", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to read TODOs")
 		return
 	}
 
-	//レスポンスヘッダを設定して成功ステータス(200 OK)を返す
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	//レスポンスをJSONとしてエンコード
-	if err := json.NewEncoder(w).Encode(res); err != nil {
-		//エンコード中にエラーが発生した場合、500 Internal Server Errorを返す
-		log.Printf("Error reading response: %v", err)
-		http.Error(w, "Failed to read response", http.StatusInternalServerError)
+	//ページ番号方式の場合は、前後のページへのLinkヘッダを付ける
+	if res.TotalCount != nil {
+		setPageLinks(w, r.URL, req.Page, req.PerPage, *res.TotalCount)
 	}
+
+	//レスポンスヘッダを設定して成功ステータス(200 OK)を返す
+	writeJSON(w, http.StatusOK, res)
 }
 
 // Read handles the endpoint that reads the TODOs.
 func (h *TODOHandler) Read(ctx context.Context, req *model.ReadTODORequest) (*model.ReadTODOResponse, error) {
+	sort, err := service.ParseTODOSort(req.Sort)
+	if err != nil {
+		return nil, err
+	}
+	assignee, err := service.ParseAssignee(ctx, req.Assignee)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &model.TODOQuery{
+		PrevID:        req.PrevID,
+		Size:          req.Size,
+		Query:         req.Query,
+		Status:        req.Status,
+		Priority:      req.Priority,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		UpdatedAfter:  req.UpdatedAfter,
+		UpdatedBefore: req.UpdatedBefore,
+		Archived:      req.Archived,
+		ProjectID:     req.ProjectID,
+		AssigneeID:    assignee,
+		Sort:          sort,
+	}
+	if req.Page > 0 {
+		q.Size = req.PerPage
+		q.Offset = (req.Page - 1) * req.PerPage
+	}
+
 	// TODOを取得するためにサービス層を呼び出す。
-	todos, err := h.svc.ReadTODO(ctx, req.PrevID, req.Size)
+	todos, err := h.svc.ListTODO(ctx, q)
 	if err != nil {
 		//エラーが発生した場合は呼び出し元に返す
 		return nil, err
@@ -169,20 +281,569 @@ func (h *TODOHandler) Read(ctx context.Context, req *model.ReadTODORequest) (*mo
 		}
 	}
 
-	//変換されたTODOを含むレスポンスを返す
-	return &model.ReadTODOResponse{
+	res := &model.ReadTODOResponse{
 		TODOs: convertedTodos,
+	}
+	//ページ番号方式の場合は総数も返す
+	if req.Page > 0 {
+		total, err := h.svc.CountTODO(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		res.TotalCount = &total
+	}
+
+	//変換されたTODOを含むレスポンスを返す
+	return res, nil
+}
+
+// ServeStream handles the GET /todos/stream request.
+// ServeStreamは、GET /todosと同じ条件に合うTODOを、読み込んだ順に1行1件のJSON(NDJSON)で返す。
+// 全件を読み込んでから返すのではないため、大量のTODOでもメモリを使わない。
+// sizeを省略した場合は全件を返す。クライアントが切断した場合は読み込みを中止する。
+// 書き込みの期限は1件ごとにサーバーのWriteTimeoutだけ延ばすため、全体の時間は制限しない。
+// 途中で読み込みに失敗した場合は、最後の行にエラーのJSONを書いて終える。
+func (h *TODOHandler) ServeStream(w http.ResponseWriter, r *http.Request) {
+	req := &model.ReadTODORequest{}
+	query := r.URL.Query()
+	for name, v := range map[string]*int64{"prev_id": &req.PrevID, "size": &req.Size} {
+		if s := query.Get(name); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || n < 0 {
+				writeError(w, http.StatusBadRequest, "Invalid "+name)
+				return
+			}
+			*v = n
+		}
+	}
+	if !parseTODOFilters(w, query, req) {
+		return
+	}
+	ctx := r.Context()
+	sort, err := service.ParseTODOSort(req.Sort)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	assignee, err := service.ParseAssignee(ctx, req.Assignee)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	q := &model.TODOQuery{
+		PrevID:        req.PrevID,
+		Size:          req.Size,
+		Query:         req.Query,
+		Status:        req.Status,
+		Priority:      req.Priority,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		UpdatedAfter:  req.UpdatedAfter,
+		UpdatedBefore: req.UpdatedBefore,
+		Archived:      req.Archived,
+		ProjectID:     req.ProjectID,
+		AssigneeID:    assignee,
+		Sort:          sort,
+	}
+
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	writeTimeout := serverWriteTimeout(r)
+	started := false
+	err = h.svc.StreamTODO(ctx, q, func(todo *model.TODO) error {
+		//最初の1件を書く前にヘッダーを送る(それまでのエラーは通常のエラー応答にできる)
+		if !started {
+			//Acceptヘッダーに関わらず、NDJSONで返す
+			w.Header().Set("Content-Type", render.NDJSON)
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		//1件ずつ書き込みの期限を延ばし、書き出しの途中でWriteTimeoutに達しないようにする
+		if writeTimeout > 0 {
+			if err := rc.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+		if err := enc.Encode(todo); err != nil {
+			return err
+		}
+		//1件ごとにクライアントへ送る
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	})
+	if err == nil {
+		if !started {
+			//該当するTODOがない場合は空の本文を返す
+			w.Header().Set("Content-Type", render.NDJSON)
+			w.WriteHeader(http.StatusOK)
+		}
+		return
+	}
+	if started {
+		//ヘッダー送信後はステータスを変えられないため、最後の行にエラーを書いて打ち切る
+		//クライアントが切断した場合は書き込めないため、ログに残すだけにする
+		if ctx.Err() != nil {
+			slog.InfoContext(ctx, "TODO stream aborted", "err", err)
+			return
+		}
+		slog.ErrorContext(ctx, "Error streaming TODOs", "err", err)
+		enc.Encode(&model.ErrorResponse{
+			Error: model.ErrorBody{
+				Code:      errorCode(http.StatusInternalServerError),
+				Message:   "Failed to read TODOs",
+				RequestID: w.Header().Get(middleware.RequestIDHeader),
+			},
+		})
+		return
+	}
+	switch err.(type) {
+	case *model.ErrValidation:
+		writeError(w, http.StatusBadRequest, err.Error())
+	case *model.ErrNotFound:
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		slog.ErrorContext(ctx, "Error streaming TODOs", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to read TODOs")
+	}
+}
+
+// serverWriteTimeout returns the WriteTimeout of the http.Server handling r,
+// or 0 if it has none or r was not received by an http.Server.
+func serverWriteTimeout(r *http.Request) time.Duration {
+	srv, ok := r.Context().Value(http.ServerContextKey).(*http.Server)
+	if !ok {
+		return 0
+	}
+	return srv.WriteTimeout
+}
+
+// parseTODOFilters parses the query parameters filtering and sorting TODOs
+// into req, shared by GET /todos and GET /todos/stream. It writes 400 and
+// returns false if one is malformed.
+func parseTODOFilters(w http.ResponseWriter, query url.Values, req *model.ReadTODORequest) bool {
+	//"q"パラメータが指定された場合は検索を行う
+	req.Query = query.Get("q")
+
+	//"project_id"パラメータを解析(パスで指定された場合はそちらを優先する)
+	if projectIDStr := query.Get("project_id"); projectIDStr != "" && req.ProjectID == 0 {
+		var err error
+		if req.ProjectID, err = strconv.ParseInt(projectIDStr, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid project_id")
+			return false
+		}
+	}
+
+	//"archived"パラメータを解析
+	if archivedStr := query.Get("archived"); archivedStr != "" {
+		var err error
+		if req.Archived, err = strconv.ParseBool(archivedStr); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid archived")
+			return false
+		}
+	}
+
+	//絞り込み条件と並び順を取得(値の検証はサービス層で行う)
+	req.Status = query.Get("status")
+	req.Priority = query.Get("priority")
+	req.Sort = query.Get("sort")
+	req.Assignee = query.Get("assignee")
+	for name, t := range map[string]*time.Time{
+		"created_after":  &req.CreatedAfter,
+		"created_before": &req.CreatedBefore,
+		"updated_after":  &req.UpdatedAfter,
+		"updated_before": &req.UpdatedBefore,
+	} {
+		if s := query.Get(name); s != "" {
+			var err error
+			if *t, err = parseTime(s); err != nil {
+				writeError(w, http.StatusBadRequest, "Invalid "+name)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ServeReadByID handles the GET /todos/{id} request.
+// ServeReadByIDは、パスで指定されたIDのTODOを1件返す。見つからない場合は404を返す。
+func (h *TODOHandler) ServeReadByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusNotFound, "TODO not found")
+		return
+	}
+
+	res, err := h.ReadByID(r.Context(), id)
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "TODO not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error reading TODO", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to read TODO")
+		return
+	}
+
+	//TODOが前回から変わっていなければ304 Not Modifiedを返す
+	todo := res.TODO
+	if checkNotModified(w, r, weakETag(todo.ID, todo.UpdatedAt.Unix(), todo.CommentCount), todo.UpdatedAt) {
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// ReadByID handles the endpoint that reads the TODO of id.
+func (h *TODOHandler) ReadByID(ctx context.Context, id int64) (*model.ReadTODOByIDResponse, error) {
+	todo, err := h.svc.ReadTODOByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ReadTODOByIDResponse{
+		TODO: *todo,
+	}, nil
+}
+
+// ServeStats handles the GET /todos/stats request.
+// ServeStatsは、TODOの件数を状態・優先度・プロジェクト・作成日ごとに集計して返す。
+func (h *TODOHandler) ServeStats(w http.ResponseWriter, r *http.Request) {
+	res, err := h.Stats(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading TODO stats", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to read TODO stats")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Stats handles the endpoint that reads the TODO stats.
+func (h *TODOHandler) Stats(ctx context.Context) (*model.ReadTODOStatsResponse, error) {
+	stats, err := h.svc.TODOStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ReadTODOStatsResponse{Stats: *stats}, nil
+}
+
+// ServeChanges handles the GET /todos/changes request.
+// ServeChangesは、sinceで指定した時刻またはトークン以降に作成・更新・削除されたTODOのIDを返す。
+// sinceを省略した場合は、全てのTODOを作成として返す。
+func (h *TODOHandler) ServeChanges(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if s := r.URL.Query().Get("since"); s != "" {
+		var err error
+		if since, err = service.ParseChangesCursor(s); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	res, err := h.Changes(r.Context(), since)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading TODO changes", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to read TODO changes")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Changes handles the endpoint that reads the TODO changes.
+func (h *TODOHandler) Changes(ctx context.Context, since time.Time) (*model.ReadTODOChangesResponse, error) {
+	changes, err := h.svc.TODOChanges(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ReadTODOChangesResponse{Changes: *changes}, nil
+}
+
+// ServeUndo handles the POST /todos/undo request.
+// ServeUndoは、呼び出し元が直前に行ったTODOの更新・削除を元に戻す。元に戻せる操作がない場合は404を返す。
+func (h *TODOHandler) ServeUndo(w http.ResponseWriter, r *http.Request) {
+	res, err := h.Undo(r.Context())
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "Nothing to undo")
+			return
+		}
+		if _, ok := err.(*model.ErrLocked); ok {
+			writeError(w, http.StatusLocked, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error undoing TODO operation", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to undo")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Undo handles the endpoint that reverts the latest update or delete of TODOs.
+func (h *TODOHandler) Undo(ctx context.Context) (*model.UndoTODOResponse, error) {
+	action, todos, err := h.svc.UndoTODO(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &model.UndoTODOResponse{Action: action, TODOs: make([]model.TODO, len(todos))}
+	for i, todo := range todos {
+		res.TODOs[i] = *todo
+	}
+	return res, nil
+}
+
+// ServeClone handles the POST /todos/{id}/clone request.
+// ServeCloneは、パスで指定されたIDのTODOを複製する。comments=trueの場合はコメントも複製する。
+func (h *TODOHandler) ServeClone(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusNotFound, "TODO not found")
+		return
+	}
+	var withComments bool
+	if s := r.URL.Query().Get("comments"); s != "" {
+		var err error
+		if withComments, err = strconv.ParseBool(s); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid comments")
+			return
+		}
+	}
+
+	res, err := h.Clone(r.Context(), id, withComments)
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "TODO not found")
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error cloning TODO", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to clone TODO")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Clone handles the endpoint that copies the TODO of id.
+func (h *TODOHandler) Clone(ctx context.Context, id int64, withComments bool) (*model.CloneTODOResponse, error) {
+	todo, err := h.svc.CloneTODO(ctx, id, withComments)
+	if err != nil {
+		return nil, err
+	}
+	return &model.CloneTODOResponse{
+		TODO: *todo,
 	}, nil
 }
 
+// ServeClaim handles the POST /todos/{id}/claim request.
+// ServeClaimは、パスで指定されたIDのTODOをロックし、他のクライアントが更新・削除できないようにする。
+// ロックの期間は本文のttl_secondsで指定でき、省略した場合は15分。他のクライアントがロックしている場合は423を返す。
+func (h *TODOHandler) ServeClaim(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusNotFound, "TODO not found")
+		return
+	}
+	//本文は省略できる
+	var req model.ClaimTODORequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		slog.WarnContext(r.Context(), "Error decoding ClaimTODORequest", "err", err)
+		writeDecodeError(w, err)
+		return
+	}
+
+	res, err := h.Claim(r.Context(), id, &req)
+	if err != nil {
+		writeLockError(w, r, err, "Failed to claim TODO")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Claim handles the endpoint that locks the TODO of id.
+func (h *TODOHandler) Claim(ctx context.Context, id int64, req *model.ClaimTODORequest) (*model.ClaimTODOResponse, error) {
+	lock, err := h.svc.ClaimTODO(ctx, id, time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ClaimTODOResponse{Lock: *lock}, nil
+}
+
+// ServeRelease handles the POST /todos/{id}/release request.
+// ServeReleaseは、自分が取得したTODOのロックを解除する。ロックされていない場合も成功する。
+func (h *TODOHandler) ServeRelease(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusNotFound, "TODO not found")
+		return
+	}
+	if err := h.svc.ReleaseTODO(r.Context(), id); err != nil {
+		writeLockError(w, r, err, "Failed to release TODO")
+		return
+	}
+	writeJSON(w, http.StatusOK, &model.ReleaseTODOResponse{})
+}
+
+// writeLockError writes the error of claiming or releasing a TODO.
+func writeLockError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch err.(type) {
+	case *model.ErrNotFound:
+		writeError(w, http.StatusNotFound, "TODO not found")
+	case *model.ErrValidation:
+		writeError(w, http.StatusBadRequest, err.Error())
+	case *model.ErrLocked:
+		writeError(w, http.StatusLocked, err.Error())
+	default:
+		slog.ErrorContext(r.Context(), "Error locking TODO", "err", err)
+		writeError(w, http.StatusInternalServerError, message)
+	}
+}
+
+// ServeAssign handles the PUT /todos/{id}/assignee request.
+// ServeAssignは、パスで指定されたIDのTODOの担当者を変更する。assignee_idが0の場合は担当者を外す。
+func (h *TODOHandler) ServeAssign(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusNotFound, "TODO not found")
+		return
+	}
+	var req model.AssignTODORequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Error decoding AssignTODORequest", "err", err)
+		writeDecodeError(w, err)
+		return
+	}
+
+	res, err := h.Assign(r.Context(), id, &req)
+	if err != nil {
+		switch err.(type) {
+		case *model.ErrNotFound:
+			writeError(w, http.StatusNotFound, "TODO not found")
+		case *model.ErrValidation:
+			writeError(w, http.StatusBadRequest, err.Error())
+		case *model.ErrLocked:
+			writeError(w, http.StatusLocked, err.Error())
+		default:
+			slog.ErrorContext(r.Context(), "Error assigning TODO", "err", err)
+			writeError(w, http.StatusInternalServerError, "Failed to assign TODO")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Assign handles the endpoint that changes the assignee of the TODO of id.
+func (h *TODOHandler) Assign(ctx context.Context, id int64, req *model.AssignTODORequest) (*model.AssignTODOResponse, error) {
+	todo, err := h.svc.AssignTODO(ctx, id, req.AssigneeID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.AssignTODOResponse{TODO: *todo}, nil
+}
+
+// ServeArchive handles the POST /todos/{id}/archive request.
+// ServeArchiveは、パスで指定されたIDのTODOをアーカイブする。
+func (h *TODOHandler) ServeArchive(w http.ResponseWriter, r *http.Request) {
+	h.serveArchive(w, r, true)
+}
+
+// ServeUnarchive handles the POST /todos/{id}/unarchive request.
+// ServeUnarchiveは、パスで指定されたIDのTODOのアーカイブを解除する。
+func (h *TODOHandler) ServeUnarchive(w http.ResponseWriter, r *http.Request) {
+	h.serveArchive(w, r, false)
+}
+
+func (h *TODOHandler) serveArchive(w http.ResponseWriter, r *http.Request, archived bool) {
+	id, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusNotFound, "TODO not found")
+		return
+	}
+
+	res, err := h.Archive(r.Context(), id, archived)
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "TODO not found")
+			return
+		}
+		if _, ok := err.(*model.ErrLocked); ok {
+			writeError(w, http.StatusLocked, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error archiving TODO", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to archive TODO")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Archive handles the endpoints that archive and unarchive the TODO of id.
+func (h *TODOHandler) Archive(ctx context.Context, id int64, archived bool) (*model.ArchiveTODOResponse, error) {
+	todo, err := h.svc.ArchiveTODO(ctx, id, archived)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ArchiveTODOResponse{
+		TODO: *todo,
+	}, nil
+}
+
+// ServeReorder handles the PUT /todos/reorder request.
+// ServeReorderは、TODOの手動の並び順(sort=position)を変更する。
+func (h *TODOHandler) ServeReorder(w http.ResponseWriter, r *http.Request) {
+	var req model.ReorderTODORequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Error decoding ReorderTODORequest", "err", err)
+		writeDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	//idsか、idとbefore/afterのどちらか一方のみを受け付ける
+	byIDs := len(req.IDs) > 0 && req.ID == 0 && req.Before == 0 && req.After == 0
+	move := len(req.IDs) == 0 && req.ID != 0 && (req.Before != 0) != (req.After != 0)
+	if !byIDs && !move {
+		writeError(w, http.StatusBadRequest, "Either ids, or id with before or after is required")
+		return
+	}
+
+	res, err := h.Reorder(r.Context(), &req)
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, "TODO not found")
+			return
+		}
+		if _, ok := err.(*model.ErrValidation); ok {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error reordering TODOs", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to reorder TODOs")
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// Reorder handles the endpoint that reorders the TODOs.
+func (h *TODOHandler) Reorder(ctx context.Context, req *model.ReorderTODORequest) (*model.ReorderTODOResponse, error) {
+	var err error
+	switch {
+	case len(req.IDs) > 0:
+		err = h.svc.ReorderTODO(ctx, req.IDs)
+	case req.After != 0:
+		err = h.svc.MoveTODO(ctx, req.ID, req.After, true)
+	default:
+		err = h.svc.MoveTODO(ctx, req.ID, req.Before, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model.ReorderTODOResponse{}, nil
+}
+
 // handleUpdate handles the PUT request to update an existing TODO.
 // handleUpdateは、既存のTODOを変更するためのPUTリクエストを処理する。
 func (h *TODOHandler) handleUpdate(w http.ResponseWriter, r *http.Request) {
 	//リクエストボディを解析し、UpdateTODORequest構造体にデコードする。
 	var req model.UpdateTODORequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding UpdateTODORequest: %v", err)
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		slog.WarnContext(r.Context(), "Error decoding UpdateTODORequest", "err", err)
+		writeDecodeError(w, err)
 		return
 	}
 
@@ -191,7 +852,7 @@ func (h *TODOHandler) handleUpdate(w http.ResponseWriter, r *http.Request) {
 	//必須フィールドが正しいかをチェックをする。
 	if req.ID == 0 || req.Subject == "" {
 		//IDが0かSubjectが空の場合、400BadRequestを返す
-		http.Error(w, "Invalid ID or Subject", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "Invalid ID or Subject")
 		return
 	}
 
@@ -199,32 +860,42 @@ func (h *TODOHandler) handleUpdate(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	res, err := h.Update(ctx, &req)
 	if err != nil {
-		//TODOが見つからなかった場合
+		//TODOまたは指定されたプロジェクトが見つからなかった場合
 		if _, ok := err.(*model.ErrNotFound); ok {
-			http.Error(w, "TODO not found", http.StatusNotFound)
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if _, ok := err.(*model.ErrValidation); ok {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		//他のクライアントがTODOをロックしている場合、423Lockedを返す
+		if _, ok := err.(*model.ErrLocked); ok {
+			writeError(w, http.StatusLocked, err.Error())
 			return
 		}
 
 		//その他のエラーが発生した場合、500Internal Server Errorを返す
-		log.Printf("Error updating TODO: %v", err)
-		http.Error(w, "Failed to update TODO", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error updating TODO", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to update TODO")
 		return
 	}
 
 	//レスポンスヘッダを設定し、成功ステータス(200 OK)を返す
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(res); err != nil {
-		//レスポンスヘッダのエンコードに失敗した場合、500Internal Server Errorを返す
-		http.Error(w, "Faild to encode JSON", http.StatusInternalServerError)
-	}
+	writeJSON(w, http.StatusOK, res)
 }
 
 // Update handles the endpoint that updates the TODO.
 // UpdateはTODOの更新を行うエンドポイントを処理します。
 func (h *TODOHandler) Update(ctx context.Context, req *model.UpdateTODORequest) (*model.UpdateTODOResponse, error) {
 	//TODOServiceのUpdateTODOメソッドを呼び出してTODOを更新する
-	todo, err := h.svc.UpdateTODO(ctx, req.ID, req.Subject, req.Description)
+	todo, err := h.svc.PatchTODO(ctx, req.ID, &model.TODOPatch{
+		Subject:     &req.Subject,
+		Description: &req.Description,
+		Done:        req.Done,
+		Priority:    req.Priority,
+		ProjectID:   req.ProjectID,
+	})
 	if err != nil {
 		//更新中にエラーが発生した場合、そのエラーを呼び出し元に返す。
 		return nil, err
@@ -240,8 +911,8 @@ func (h *TODOHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
 	//リクエストボディを解析し、DeleteTODORequest構造体にデコードする。
 	var req model.DeleteTODORequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding DeleteTODORequest : %v", err)
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		slog.WarnContext(r.Context(), "Error decoding DeleteTODORequest", "err", err)
+		writeDecodeError(w, err)
 		return
 	}
 
@@ -249,7 +920,7 @@ func (h *TODOHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
 
 	//IDsが空かどうかを確認
 	if len(req.IDs) == 0 {
-		http.Error(w, "IDs are required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "IDs are required")
 		return
 	}
 
@@ -258,22 +929,25 @@ func (h *TODOHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
 	res, err := h.Delete(ctx, &req) //正しく2つの戻り値を処理
 	if err != nil {
 		if _, ok := err.(*model.ErrNotFound); ok {
-			http.Error(w, "TODO not found", http.StatusNotFound)
+			writeError(w, http.StatusNotFound, "TODO not found")
+			return
+		}
+		if _, ok := err.(*model.ErrForbidden); ok {
+			writeError(w, http.StatusForbidden, "Deleting multiple TODOs requires the admin role")
+			return
+		}
+		if _, ok := err.(*model.ErrLocked); ok {
+			writeError(w, http.StatusLocked, err.Error())
 			return
 		}
 
-		log.Printf("Error deleting TODORequest: %v", err)
-		http.Error(w, "Failed to delete TODO", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error deleting TODORequest", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to delete TODO")
 		return
 	}
 
 	//レスポンスヘッダを設定し、成功ステータス（200 OK）を返す
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(res); err != nil { //resをエンコード
-		log.Printf("Error encoding response: %v", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-	}
+	writeJSON(w, http.StatusOK, res)
 
 }
 
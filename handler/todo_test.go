@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service/servicetest"
+)
+
+func TestTODOHandlerRead(t *testing.T) {
+	var got *model.TODOQuery
+	svc := &servicetest.TODOService{
+		TODOVersionFunc: func(context.Context) (*model.TODOVersion, error) {
+			return &model.TODOVersion{LastModified: time.Unix(1714780800, 0)}, nil
+		},
+		ListTODOFunc: func(_ context.Context, q *model.TODOQuery) ([]*model.TODO, error) {
+			got = q
+			return []*model.TODO{{ID: 2, Subject: "b"}}, nil
+		},
+	}
+	w := httptest.NewRecorder()
+	NewTODOHandler(svc).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/todos?q=milk&status=open&size=3&sort=-subject", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status, got = %d, body = %s", w.Code, w.Body)
+	}
+	want := &model.TODOQuery{Query: "milk", Status: "open", Size: 3, Sort: []model.TODOSort{{Field: "subject", Desc: true}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected query, got = %+v, want = %+v", got, want)
+	}
+}
+
+func TestTODOHandlerErrors(t *testing.T) {
+	failure := errors.New("connection reset")
+	tests := map[string]struct {
+		svc    *servicetest.TODOService
+		serve  func(h *TODOHandler) http.HandlerFunc
+		id     string
+		status int
+	}{
+		"not found": {
+			svc: &servicetest.TODOService{ReadTODOByIDFunc: func(context.Context, int64) (*model.TODO, error) {
+				return nil, &model.ErrNotFound{Resource: "TODO"}
+			}},
+			serve:  func(h *TODOHandler) http.HandlerFunc { return h.ServeReadByID },
+			id:     "1",
+			status: http.StatusNotFound,
+		},
+		"read failure": {
+			svc: &servicetest.TODOService{ReadTODOByIDFunc: func(context.Context, int64) (*model.TODO, error) {
+				return nil, failure
+			}},
+			serve:  func(h *TODOHandler) http.HandlerFunc { return h.ServeReadByID },
+			id:     "1",
+			status: http.StatusInternalServerError,
+		},
+		"version failure": {
+			svc:    &servicetest.TODOService{},
+			serve:  func(h *TODOHandler) http.HandlerFunc { return h.ServeHTTP },
+			status: http.StatusInternalServerError,
+		},
+		"stats failure": {
+			svc: &servicetest.TODOService{TODOStatsFunc: func(context.Context) (*model.TODOStats, error) {
+				return nil, failure
+			}},
+			serve:  func(h *TODOHandler) http.HandlerFunc { return h.ServeStats },
+			status: http.StatusInternalServerError,
+		},
+		"stream failure": {
+			svc: &servicetest.TODOService{StreamTODOFunc: func(context.Context, *model.TODOQuery, func(*model.TODO) error) error {
+				return failure
+			}},
+			serve:  func(h *TODOHandler) http.HandlerFunc { return h.ServeStream },
+			status: http.StatusInternalServerError,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.SetPathValue("id", tt.id)
+			w := httptest.NewRecorder()
+			tt.serve(NewTODOHandler(tt.svc))(w, r)
+			if w.Code != tt.status {
+				t.Errorf("unexpected status, got = %d, want = %d, body = %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestTODOHandlerStreamAborted(t *testing.T) {
+	first := `{"id":1,"subject":"a","description":"","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}` + "\n"
+	tests := map[string]struct {
+		canceled bool
+		want     string
+	}{
+		//送信済みの行に続けて、最後の行にエラーを書く
+		"failed": {want: first + `{"error":{"code":"internal_error","message":"Failed to read TODOs"}}` + "\n"},
+		//クライアントが切断した場合は何も書かない
+		"disconnected": {canceled: true, want: first},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			svc := &servicetest.TODOService{
+				StreamTODOFunc: func(_ context.Context, _ *model.TODOQuery, fn func(*model.TODO) error) error {
+					if err := fn(&model.TODO{ID: 1, Subject: "a"}); err != nil {
+						return err
+					}
+					if tt.canceled {
+						cancel()
+					}
+					return errors.New("connection reset")
+				},
+			}
+			w := httptest.NewRecorder()
+			NewTODOHandler(svc).ServeStream(w, httptest.NewRequest(http.MethodGet, "/todos/stream", nil).WithContext(ctx))
+
+			if w.Code != http.StatusOK || !w.Flushed || w.Body.String() != tt.want {
+				t.Errorf("unexpected response, status = %d, flushed = %t, body = %s", w.Code, w.Flushed, w.Body)
+			}
+		})
+	}
+}
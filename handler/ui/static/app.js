@@ -0,0 +1,164 @@
+// TODO一覧の画面。同じオリジンのAPIを呼び出して一覧・作成・編集・完了を行う
+'use strict';
+
+const pageSize = 20;
+const settings = {
+  apiKey: localStorage.getItem('apiKey') || '',
+  workspace: localStorage.getItem('workspace') || '',
+};
+
+const list = document.getElementById('todos');
+const more = document.getElementById('more');
+const errorBox = document.getElementById('error');
+const template = document.getElementById('todo');
+let lastID = 0;
+
+// api calls the API and returns the decoded body, throwing the message of
+// the error envelope on failure.
+async function api(method, path, body) {
+  const headers = { Accept: 'application/json' };
+  if (body !== undefined) {
+    headers['Content-Type'] = 'application/json';
+  }
+  if (settings.apiKey) {
+    headers['X-API-Key'] = settings.apiKey;
+  }
+  if (settings.workspace) {
+    headers['X-Workspace'] = settings.workspace;
+  }
+  //Cookieを送るブラウザのセッションではCSRFトークンが必要になる
+  const csrf = document.cookie.split('; ').find((c) => c.startsWith('csrf_token='));
+  if (csrf) {
+    headers['X-CSRF-Token'] = csrf.slice('csrf_token='.length);
+  }
+
+  const res = await fetch(path, {
+    method,
+    headers,
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+  const data = await res.json().catch(() => ({}));
+  if (!res.ok) {
+    throw new Error((data.error && data.error.message) || res.statusText);
+  }
+  return data;
+}
+
+function showError(err) {
+  errorBox.textContent = err ? err.message : '';
+  errorBox.hidden = !err;
+}
+
+function render(todo) {
+  const li = template.content.firstElementChild.cloneNode(true);
+  const view = li.querySelector('.view');
+  const edit = li.querySelector('.edit');
+  const startEdit = li.querySelector('.start-edit');
+  const done = li.querySelector('.done');
+
+  const fill = (t) => {
+    todo = t;
+    li.classList.toggle('done', !!t.done);
+    done.checked = !!t.done;
+    li.querySelector('.subject').textContent = t.subject;
+    li.querySelector('.description').textContent = t.description;
+  };
+  fill(todo);
+
+  const update = async (patch) => {
+    try {
+      const res = await api('PUT', '/todos', {
+        id: todo.id,
+        subject: todo.subject,
+        description: todo.description,
+        done: !!todo.done,
+        ...patch,
+      });
+      fill(res.todo);
+      showError(null);
+      return true;
+    } catch (err) {
+      showError(err);
+      return false;
+    }
+  };
+
+  done.addEventListener('change', async () => {
+    if (!(await update({ done: done.checked }))) {
+      done.checked = !!todo.done;
+    }
+  });
+
+  const toggleEdit = (editing) => {
+    view.hidden = editing;
+    startEdit.hidden = editing;
+    edit.hidden = !editing;
+    if (editing) {
+      edit.subject.value = todo.subject;
+      edit.description.value = todo.description;
+      edit.subject.focus();
+    }
+  };
+  startEdit.addEventListener('click', () => toggleEdit(true));
+  li.querySelector('.cancel').addEventListener('click', () => toggleEdit(false));
+  edit.addEventListener('submit', async (e) => {
+    e.preventDefault();
+    if (await update({ subject: edit.subject.value, description: edit.description.value })) {
+      toggleEdit(false);
+    }
+  });
+  return li;
+}
+
+async function load(reset) {
+  if (reset) {
+    list.replaceChildren();
+    lastID = 0;
+  }
+  const query = new URLSearchParams({ size: pageSize });
+  if (lastID) {
+    query.set('prev_id', lastID);
+  }
+  try {
+    const { todos } = await api('GET', '/todos?' + query);
+    todos.forEach((todo) => list.append(render(todo)));
+    if (todos.length > 0) {
+      lastID = todos[todos.length - 1].id;
+    }
+    more.hidden = todos.length < pageSize;
+    showError(null);
+  } catch (err) {
+    showError(err);
+  }
+}
+
+document.getElementById('create').addEventListener('submit', async (e) => {
+  e.preventDefault();
+  const form = e.target;
+  try {
+    const { todo } = await api('POST', '/todos', {
+      subject: form.subject.value,
+      description: form.description.value,
+    });
+    list.prepend(render(todo));
+    form.reset();
+    showError(null);
+  } catch (err) {
+    showError(err);
+  }
+});
+
+const settingsForm = document.getElementById('settings');
+settingsForm.apiKey.value = settings.apiKey;
+settingsForm.workspace.value = settings.workspace;
+settingsForm.addEventListener('submit', (e) => {
+  e.preventDefault();
+  settings.apiKey = settingsForm.apiKey.value.trim();
+  settings.workspace = settingsForm.workspace.value.trim();
+  localStorage.setItem('apiKey', settings.apiKey);
+  localStorage.setItem('workspace', settings.workspace);
+  load(true);
+});
+
+more.addEventListener('click', () => load(false));
+load(true);
@@ -0,0 +1,34 @@
+// Package ui serves the embedded web UI listing, creating, editing and
+// completing TODOs through the API, for demos and operators without a
+// frontend of their own.
+package ui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// csp allows the UI to load its own scripts and styles and call the API of
+// the same origin only.
+const csp = "default-src 'self'; frame-ancestors 'none'; form-action 'self'; base-uri 'none'"
+
+// Handler returns the handler serving the UI under prefix, e.g. "/ui/".
+// The UI calls the API with the API key and workspace entered in it, so
+// the files themselves need no authentication.
+func Handler(prefix string) http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	fileServer := http.StripPrefix(prefix, http.FileServer(http.FS(files)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//APIの形式として決めたContent-Typeを消し、ファイルの拡張子から決めさせる
+		w.Header().Del("Content-Type")
+		w.Header().Set("Content-Security-Policy", csp)
+		fileServer.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,69 @@
+package ui_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/handler/ui"
+)
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /ui/", ui.Handler("/ui/"))
+	//ミドルウェアが設定したAPIの形式はファイルの形式で上書きされる
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Security-Policy", "default-src 'none'")
+		mux.ServeHTTP(w, r)
+	})
+
+	cases := map[string]struct {
+		Path       string
+		WantStatus int
+		WantType   string
+		WantBody   string
+		//WantStatusが0の場合は、リダイレクト先
+		WantLocation string
+	}{
+		"Index":      {Path: "/ui/", WantStatus: http.StatusOK, WantType: "text/html", WantBody: `<script src="app.js"`},
+		"Script":     {Path: "/ui/app.js", WantStatus: http.StatusOK, WantType: "text/javascript", WantBody: "async function api("},
+		"Stylesheet": {Path: "/ui/style.css", WantStatus: http.StatusOK, WantType: "text/css"},
+		"Redirect":   {Path: "/ui", WantLocation: "/ui/"},
+		"Not found":  {Path: "/ui/missing.js", WantStatus: http.StatusNotFound},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.Path, nil))
+			//リダイレクトのステータスはGoのバージョンによって異なる
+			if c.WantStatus == 0 {
+				if rec.Code/100 != 3 || rec.Header().Get("Location") != c.WantLocation {
+					t.Errorf("unexpected redirect, status = %d, Location = %q", rec.Code, rec.Header().Get("Location"))
+				}
+				return
+			}
+			if rec.Code != c.WantStatus {
+				t.Fatalf("unexpected status, got = %d, want = %d", rec.Code, c.WantStatus)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, c.WantType) {
+				t.Errorf("unexpected Content-Type, got = %q, want = %q", got, c.WantType)
+			}
+			if got := rec.Header().Get("Content-Security-Policy"); !strings.Contains(got, "default-src 'self'") {
+				t.Errorf("unexpected Content-Security-Policy, got = %q", got)
+			}
+			if !strings.Contains(rec.Body.String(), c.WantBody) {
+				t.Errorf("unexpected body, got = %q", rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// A WorkspaceHandler implements handling REST endpoints for workspaces.
+// WorkspaceHandlerは、/admin/workspaces 以下の管理者向けAPIエンドポイントの処理を実装します。
+type WorkspaceHandler struct {
+	svc *service.WorkspaceService
+}
+
+// NewWorkspaceHandler returns WorkspaceHandler based http.Handler.
+// NewWorkspaceHandlerは新しいWorkspaceHandlerを返します。
+func NewWorkspaceHandler(svc *service.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		svc: svc,
+	}
+}
+
+// ServeHTTP handles HTTP requests for /admin/workspaces.
+func (h *WorkspaceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.handleCreate(w, r)
+	case http.MethodGet:
+		h.handleRead(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+// handleCreate handles the POST request to create a new workspace.
+func (h *WorkspaceHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateWorkspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Error decoding CreateWorkspaceRequest", "err", err)
+		writeDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	ws, err := h.svc.CreateWorkspace(r.Context(), req.Name)
+	if err != nil {
+		if verr, ok := err.(*model.ErrValidation); ok {
+			writeError(w, http.StatusBadRequest, verr.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error creating workspace", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create workspace")
+		return
+	}
+	writeJSON(w, http.StatusOK, &model.CreateWorkspaceResponse{
+		Workspace: *ws,
+	})
+}
+
+// handleRead handles the GET request to list workspaces.
+func (h *WorkspaceHandler) handleRead(w http.ResponseWriter, r *http.Request) {
+	workspaces, err := h.svc.ReadWorkspaces(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading workspaces", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to read workspaces")
+		return
+	}
+
+	converted := make([]model.Workspace, len(workspaces))
+	for i, ws := range workspaces {
+		converted[i] = *ws
+	}
+	writeJSON(w, http.StatusOK, &model.ReadWorkspaceResponse{
+		Workspaces: converted,
+	})
+}
+
+// ServeMember handles PUT and DELETE /admin/workspaces/{id}/members/{api_key_id},
+// which add the API key to and remove it from the members of the workspace.
+// PUTは冪等で、既にメンバーの場合もエラーにしない。
+func (h *WorkspaceHandler) ServeMember(w http.ResponseWriter, r *http.Request) {
+	workspaceID, ok := pathID(r, "id")
+	if !ok {
+		writeError(w, http.StatusNotFound, "Workspace not found")
+		return
+	}
+	apiKeyID, ok := pathID(r, "api_key_id")
+	if !ok {
+		writeError(w, http.StatusNotFound, "APIKey not found")
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodPut:
+		err = h.svc.AddMember(r.Context(), workspaceID, apiKeyID)
+	case http.MethodDelete:
+		err = h.svc.RemoveMember(r.Context(), workspaceID, apiKeyID)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	if err != nil {
+		if nf, ok := err.(*model.ErrNotFound); ok {
+			writeError(w, http.StatusNotFound, nf.Error())
+			return
+		}
+		slog.ErrorContext(r.Context(), "Error updating workspace members", "err", err)
+		writeError(w, http.StatusInternalServerError, "Failed to update workspace members")
+		return
+	}
+	writeJSON(w, http.StatusOK, &model.WorkspaceMemberResponse{})
+}
@@ -0,0 +1,169 @@
+// Package i18n translates the messages of the API, which are written in
+// English in the code, into the language the client prefers.
+//
+// The catalogs are the JSON files in catalogs/, one per language, mapping an
+// English message to its translation, e.g. catalogs/ja.json. A message with
+// "%s" matches any text in its place, and the translation puts the matched
+// text where its own "%s" (or "%[n]s" to change the order) is, translated
+// too if the catalog has it:
+//
+//	"%s not found": "%sが見つかりません"
+//
+// Messages the catalog lacks are left in English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Source is the language the messages are written in.
+const Source = "en"
+
+//go:embed catalogs/*.json
+var files embed.FS
+
+// catalogs maps a lowercase language tag, e.g. "ja", to its catalog.
+var catalogs = map[string]*catalog{}
+
+type catalog struct {
+	exact    map[string]string
+	patterns []pattern
+}
+
+type pattern struct {
+	re          *regexp.Regexp
+	translation string
+	literal     int //プレースホルダ以外の文字数。多いほど優先する
+}
+
+func init() {
+	entries, err := files.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+	for _, e := range entries {
+		b, err := files.ReadFile(path.Join("catalogs", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(b, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: catalog %s: %v", e.Name(), err))
+		}
+		catalogs[strings.ToLower(strings.TrimSuffix(e.Name(), ".json"))] = newCatalog(messages)
+	}
+}
+
+func newCatalog(messages map[string]string) *catalog {
+	c := &catalog{exact: map[string]string{}}
+	for msg, translation := range messages {
+		if !strings.Contains(msg, "%s") {
+			c.exact[msg] = translation
+			continue
+		}
+		parts := strings.Split(msg, "%s")
+		for i, p := range parts {
+			parts[i] = regexp.QuoteMeta(p)
+		}
+		c.patterns = append(c.patterns, pattern{
+			re:          regexp.MustCompile("^" + strings.Join(parts, "(.+?)") + "$"),
+			translation: translation,
+			literal:     len(msg) - 2*strings.Count(msg, "%s"),
+		})
+	}
+	sort.Slice(c.patterns, func(i, j int) bool {
+		return c.patterns[i].literal > c.patterns[j].literal
+	})
+	return c
+}
+
+// Languages returns the languages messages can be written in, Source first.
+func Languages() []string {
+	langs := []string{Source}
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs[1:])
+	return langs
+}
+
+// Match returns the language the Accept-Language header prefers among
+// Languages. The languages are tried in the order of their quality; a
+// language with a region, e.g. "ja-JP", falls back to the language without it
+// ("ja"). It returns Source if none of them is available.
+func Match(acceptLanguage string) string {
+	type tag struct {
+		name string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		params := strings.Split(part, ";")
+		t := tag{name: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		for _, p := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "q") {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					t.q = f
+				}
+			}
+		}
+		if t.name != "" && t.q > 0 {
+			tags = append(tags, t)
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		for name := t.name; name != ""; {
+			if name == "*" || name == Source {
+				return Source
+			}
+			if _, ok := catalogs[name]; ok {
+				return name
+			}
+			//地域などの後ろのサブタグを外して探し直す(例: zh-hant-tw -> zh-hant -> zh)
+			i := strings.LastIndex(name, "-")
+			if i < 0 {
+				break
+			}
+			name = name[:i]
+		}
+	}
+	return Source
+}
+
+// Translate returns message in lang, a language returned by Match, or
+// message itself if the catalog of lang does not have it.
+func Translate(lang, message string) string {
+	c, ok := catalogs[strings.ToLower(lang)]
+	if !ok {
+		return message
+	}
+	return c.translate(message)
+}
+
+func (c *catalog) translate(message string) string {
+	if t, ok := c.exact[message]; ok {
+		return t
+	}
+	for _, p := range c.patterns {
+		m := p.re.FindStringSubmatch(message)
+		if m == nil {
+			continue
+		}
+		args := make([]interface{}, len(m)-1)
+		for i, s := range m[1:] {
+			args[i] = c.translate(s)
+		}
+		return fmt.Sprintf(p.translation, args...)
+	}
+	return message
+}
@@ -0,0 +1,61 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                          "en",
+		"ja":                        "ja",
+		"ja-JP":                     "ja",
+		"JA-jp;q=0.5":               "ja",
+		"fr, ja;q=0.8, en;q=0.9":    "en",
+		"fr, ja;q=0.8":              "ja",
+		"ja;q=0, fr":                "en",
+		"*":                         "en",
+		"en-US,en;q=0.9,ja;q=0.8":   "en",
+		"de-CH, de;q=0.9, ja;q=0.1": "ja",
+	} {
+		if got := Match(header); got != want {
+			t.Errorf("Match(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	for _, tt := range []struct {
+		lang, message, want string
+	}{
+		{"ja", "TODO not found", "TODOが見つかりません"},
+		{"ja", "Undo entry not found", "取り消し履歴が見つかりません"},
+		{"ja", "invalid status: must be open or done", "statusが不正です: openかdoneにしてください"},
+		{"ja", "invalid subject: a reason without translation", "subjectが不正です: a reason without translation"},
+		{"ja", "Request body must not exceed 256 bytes", "リクエストボディは256バイト以下にしてください"},
+		{"ja", "A message without translation", "A message without translation"},
+		{"en", "TODO not found", "TODO not found"},
+		{"fr", "TODO not found", "TODO not found"},
+	} {
+		if got := Translate(tt.lang, tt.message); got != tt.want {
+			t.Errorf("Translate(%q, %q) = %q, want %q", tt.lang, tt.message, got, tt.want)
+		}
+	}
+}
+
+// TestCatalogs checks that every translation takes the texts its message matches.
+func TestCatalogs(t *testing.T) {
+	verb := regexp.MustCompile(`%(\[\d+\])?s`)
+	for lang, c := range catalogs {
+		for _, p := range c.patterns {
+			if want, got := p.re.NumSubexp(), len(verb.FindAllString(p.translation, -1)); got != want {
+				t.Errorf("%s: %q has %d placeholders, want %d", lang, p.translation, got, want)
+			}
+		}
+		for msg, translation := range c.exact {
+			if verb.MatchString(translation) {
+				t.Errorf("%s: translation of %q has a placeholder", lang, msg)
+			}
+		}
+	}
+}
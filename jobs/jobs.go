@@ -0,0 +1,140 @@
+// Package jobs runs maintenance jobs periodically in the background, e.g.
+// expiring the undo log, and on demand from the admin API.
+package jobs
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// metrics are published at /debug/vars under "jobs", with the runs, failures
+// and last_duration_ms of each job.
+var metrics = expvar.NewMap("jobs")
+
+// A Job is a maintenance task.
+type Job struct {
+	Name string
+	// Interval is the period of the runs. If 0, the job only runs on demand.
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// A Runner runs Jobs. A job never runs concurrently with itself.
+type Runner struct {
+	jobs []*job
+}
+
+type job struct {
+	Job
+	running sync.Mutex //同じジョブを同時に実行しないためのロック
+	metrics *expvar.Map
+
+	mu     sync.Mutex
+	status model.JobStatus
+}
+
+// NewRunner returns a Runner of jobs. Job names must be unique.
+func NewRunner(jobs ...Job) *Runner {
+	r := &Runner{}
+	for _, j := range jobs {
+		m := new(expvar.Map).Init()
+		metrics.Set(j.Name, m)
+		status := model.JobStatus{Name: j.Name}
+		if j.Interval > 0 {
+			status.Interval = j.Interval.String()
+		}
+		r.jobs = append(r.jobs, &job{Job: j, metrics: m, status: status})
+	}
+	return r
+}
+
+// Run runs each job every its interval until ctx is done, and returns
+// after the running jobs have returned.
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range r.jobs {
+		if j.Interval <= 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			ticker := time.NewTicker(j.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					j.run(ctx)
+				}
+			}
+		}(j)
+	}
+	wg.Wait()
+}
+
+// Trigger runs the job of name now and returns its status after the run.
+// It waits if the job is already running. It returns *model.ErrNotFound if
+// there is no such job.
+func (r *Runner) Trigger(ctx context.Context, name string) (*model.JobStatus, error) {
+	for _, j := range r.jobs {
+		if j.Name == name {
+			j.run(ctx)
+			status := j.snapshot()
+			return &status, nil
+		}
+	}
+	return nil, &model.ErrNotFound{Resource: "Job"}
+}
+
+// Statuses returns the status of every job in the order given to NewRunner.
+func (r *Runner) Statuses() []model.JobStatus {
+	statuses := make([]model.JobStatus, len(r.jobs))
+	for i, j := range r.jobs {
+		statuses[i] = j.snapshot()
+	}
+	return statuses
+}
+
+func (j *job) run(ctx context.Context) {
+	j.running.Lock()
+	defer j.running.Unlock()
+
+	start := time.Now()
+	err := j.Run(ctx)
+	end := time.Now()
+
+	j.metrics.Add("runs", 1)
+	j.metrics.Set("last_duration_ms", intVar(end.Sub(start).Milliseconds()))
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status.Runs++
+	j.status.LastRunAt = &end
+	j.status.LastError = ""
+	if err != nil {
+		j.metrics.Add("failures", 1)
+		j.status.Failures++
+		j.status.LastError = err.Error()
+		slog.ErrorContext(ctx, "Job failed", "job", j.Name, "err", err)
+		return
+	}
+	slog.DebugContext(ctx, "Job finished", "job", j.Name, "duration", end.Sub(start))
+}
+
+func (j *job) snapshot() model.JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+func intVar(n int64) *expvar.Int {
+	v := new(expvar.Int)
+	v.Set(n)
+	return v
+}
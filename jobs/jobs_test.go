@@ -0,0 +1,83 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/jobs"
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestRunner_Trigger(t *testing.T) {
+	ctx := context.Background()
+	fail := true
+	runner := jobs.NewRunner(
+		jobs.Job{Name: "ok", Interval: time.Hour, Run: func(context.Context) error { return nil }},
+		jobs.Job{Name: "flaky", Run: func(context.Context) error {
+			if fail {
+				return errors.New("boom")
+			}
+			return nil
+		}},
+	)
+
+	status, err := runner.Trigger(ctx, "flaky")
+	if err != nil {
+		t.Fatal("failed to trigger job, err =", err)
+	}
+	if status.Runs != 1 || status.Failures != 1 || status.LastError != "boom" || status.LastRunAt == nil {
+		t.Errorf("unexpected status after failure, got = %+v", status)
+	}
+
+	//成功した場合は直前のエラーが消える
+	fail = false
+	status, err = runner.Trigger(ctx, "flaky")
+	if err != nil {
+		t.Fatal("failed to trigger job, err =", err)
+	}
+	if status.Runs != 2 || status.Failures != 1 || status.LastError != "" {
+		t.Errorf("unexpected status after success, got = %+v", status)
+	}
+
+	if _, err := runner.Trigger(ctx, "unknown"); err == nil {
+		t.Error("expected an error for an unknown job")
+	} else if _, ok := err.(*model.ErrNotFound); !ok {
+		t.Errorf("unexpected error type, got = %T", err)
+	}
+
+	statuses := runner.Statuses()
+	if len(statuses) != 2 || statuses[0].Name != "ok" || statuses[0].Interval != "1h0m0s" || statuses[0].Runs != 0 || statuses[1].Runs != 2 {
+		t.Errorf("unexpected statuses, got = %+v", statuses)
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan struct{}, 1)
+	runner := jobs.NewRunner(jobs.Job{Name: "tick", Interval: time.Millisecond, Run: func(context.Context) error {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+		return nil
+	}})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runner.Run(ctx)
+	}()
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not run periodically")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the context was done")
+	}
+}
@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// ExpireUndo returns the job deleting the undo log entries which can no
+// longer be undone, in every workspace.
+func ExpireUndo(repo repository.Repository, opts service.TODOOptions, interval time.Duration) Job {
+	svc := service.NewTODOServiceWithOptions(repo, opts)
+	return Job{
+		Name:     "expire_undo",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			return eachWorkspace(ctx, repo, svc.ExpireUndo)
+		},
+	}
+}
+
+// PurgeArchived returns the job deleting the TODOs archived and not updated
+// for longer than retention, in every workspace.
+func PurgeArchived(repo repository.Repository, retention, interval time.Duration) Job {
+	svc := service.NewTODOServiceWithRepository(repo)
+	return Job{
+		Name:     "purge_archived",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			before := time.Now().Add(-retention)
+			return eachWorkspace(ctx, repo, func(ctx context.Context) error {
+				n, err := svc.PurgeArchivedTODO(ctx, before)
+				if n > 0 {
+					slog.InfoContext(ctx, "Purged archived TODOs", "workspace_id", repository.WorkspaceID(ctx), "count", n)
+				}
+				return err
+			})
+		},
+	}
+}
+
+// VacuumSQLite returns the job rebuilding the SQLite database of db to
+// reclaim the space of deleted rows.
+func VacuumSQLite(db *sql.DB, interval time.Duration) Job {
+	return Job{
+		Name:     "vacuum_sqlite",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			_, err := db.ExecContext(ctx, `VACUUM`)
+			return err
+		},
+	}
+}
+
+// eachWorkspace runs fn with the context of the default workspace and then of every Workspace.
+func eachWorkspace(ctx context.Context, repo repository.Repository, fn func(ctx context.Context) error) error {
+	workspaces, err := service.NewWorkspaceServiceWithRepository(repo).ReadWorkspaces(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(repository.WithWorkspace(ctx, 0)); err != nil {
+		return err
+	}
+	for _, ws := range workspaces {
+		if err := fn(repository.WithWorkspace(ctx, ws.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,69 +1,57 @@
 package main
 
 import (
-	"fmt"
-	"log"
-	"net/http"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
 	"os"
-	"time"
+	"os/signal"
+	"syscall"
 
-	// errors パッケージをインポート
-	"github.com/TechBowl-japan/go-stations/db"
-	"github.com/TechBowl-japan/go-stations/handler/router"
+	"github.com/TechBowl-japan/go-stations/app"
+	"github.com/TechBowl-japan/go-stations/config"
 )
 
 func main() {
 	err := realMain()
 	if err != nil {
-		log.Fatalln("main: failed to exit successfully, err =", err)
+		slog.Error("main: failed to exit successfully", "err", err)
+		os.Exit(1)
 	}
 }
 
 func realMain() error {
 	// config values
-	const (
-		defaultPort   = ":8080"
-		defaultDBPath = ".sqlite3/todo.db"
-	)
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
-	}
-
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = defaultDBPath
+	// 設定はデフォルト値 < 設定ファイル < 環境変数 < フラグ の順に優先される
+	cfg, args, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
 	}
-
-	// set time zone
-	var err error
-	time.Local, err = time.LoadLocation("Asia/Tokyo")
 	if err != nil {
-		log.Println("Failed to load time zone:", err)
 		return err
 	}
-
-	// set up sqlite3
-	todoDB, err := db.NewDB(dbPath)
-	if err != nil {
-		log.Println("Failed to initialize database:", dbPath, err)
+	// set up logger and time zone
+	if err := app.Init(cfg); err != nil {
 		return err
 	}
-	defer todoDB.Close()
 
-	// NOTE: 新しいエンドポイントの登録はrouter.NewRouterの内部で行うようにする
-	mux := router.NewRouter(todoDB)
+	// "migrate" サブコマンドが指定された場合は、マイグレーションのみを実行して終了する
+	if len(args) > 0 && args[0] == "migrate" {
+		return app.Migrate(cfg, args[1:], os.Stdout)
+	}
 
-	// TODO: サーバーをlistenする
-	// log.Printf("Starting server on port%s\n", port)
-	// return http.ListenAndServe(port, mux)
-	log.Printf("Starting server on port %s\n", port)
-	err = http.ListenAndServe(port, mux)
+	// set up storage and routes
+	srv, err := app.NewServer(cfg)
 	if err != nil {
-		log.Printf("Failed to start server on port %s: %v\n", port, err)
-		return fmt.Errorf("server failed to start on %s: %w", port, err)
-
+		return err
 	}
-	return nil
+	defer srv.Close()
+
+	// SIGINT/SIGTERMを受け取ったら、処理中のリクエストを待ってから終了する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 保守ジョブはサーバーと同じコンテキストで動かし、終了時に止める
+	return srv.Run(ctx, cfg)
 }
@@ -0,0 +1,83 @@
+package model
+
+import "time"
+
+// Scopes of API keys. ScopeRead allows GET and HEAD requests, ScopeWrite all others.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+type (
+	// An APIKey expresses a key for machine clients. The key itself is only
+	// returned once when it is created; only its hash is stored.
+	// APIKeyは、機械的なクライアント向けのAPIキーを表現します。キー自体は作成時にのみ返します。
+	APIKey struct {
+		ID int64 `json:"id"`
+		//キーの先頭部分(どのキーかを見分けるための表示用)
+		Prefix     string     `json:"prefix"`
+		Name       string     `json:"name"`
+		Role       string     `json:"role"`
+		Scopes     []string   `json:"scopes"`
+		CreatedAt  time.Time  `json:"created_at"`
+		LastUsedAt *time.Time `json:"last_used_at"`
+		RevokedAt  *time.Time `json:"revoked_at"`
+	}
+
+	// A CreateAPIKeyRequest expresses ...
+	// CreateAPIKeyRequestはAPIキー作成時の管理者からのリクエスト形式
+	// Roleを省略した場合はmemberになる
+	CreateAPIKeyRequest struct {
+		Name   string   `json:"name"`
+		Role   string   `json:"role"`
+		Scopes []string `json:"scopes"`
+	}
+	// A CreateAPIKeyResponse expresses ...
+	// Keyは作成時のみ返すため、利用者が保存する必要がある
+	CreateAPIKeyResponse struct {
+		APIKey APIKey `json:"api_key"`
+		Key    string `json:"key"`
+	}
+
+	// A ReadAPIKeyResponse expresses ...
+	ReadAPIKeyResponse struct {
+		APIKeys []APIKey `json:"api_keys"`
+	}
+
+	// A RevokeAPIKeyResponse expresses ...
+	RevokeAPIKeyResponse struct {
+		APIKey APIKey `json:"api_key"`
+	}
+)
+
+// HasScope reports whether k has the scope.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether k may perform p. The role must grant p, and the
+// scopes further limit the key: reading needs the read scope and every other
+// permission except admin needs the write scope.
+func (k *APIKey) Can(p Permission) bool {
+	if !RoleAllows(k.Role, p) {
+		return false
+	}
+	switch p {
+	case PermissionRead:
+		return k.HasScope(ScopeRead)
+	case PermissionAdmin:
+		return true
+	default:
+		return k.HasScope(ScopeWrite)
+	}
+}
+
+// Revoked reports whether k has been revoked.
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
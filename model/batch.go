@@ -0,0 +1,34 @@
+package model
+
+import "encoding/json"
+
+type (
+	// A BatchRequest expresses ...
+	// BatchRequestは、まとめて実行するリクエストの一覧を表現します。
+	BatchRequest struct {
+		Requests []BatchRequestItem `json:"requests"`
+		//trueの場合は全てのリクエストを1つのトランザクションで実行し、1件でも失敗すると全て取り消す
+		Atomic bool `json:"atomic"`
+	}
+	// A BatchRequestItem expresses one of the requests of a batch, e.g.
+	// {"method":"POST","path":"/todos","body":{"subject":"a"}}.
+	// BatchRequestItemは、バッチに含まれる1件のリクエストを表現します。
+	BatchRequestItem struct {
+		Method string          `json:"method"`
+		Path   string          `json:"path"`
+		Body   json.RawMessage `json:"body,omitempty"`
+	}
+
+	// A BatchResponse expresses ...
+	// BatchResponseは、実行したリクエストごとの結果をリクエストと同じ順に返す
+	BatchResponse struct {
+		Results []BatchResult `json:"results"`
+		//atomicの場合に失敗して全て取り消したかどうか(取り消していない場合は省略)
+		RolledBack bool `json:"rolled_back,omitempty"`
+	}
+	// A BatchResult expresses the response to a BatchRequestItem.
+	BatchResult struct {
+		Status int             `json:"status"`
+		Body   json.RawMessage `json:"body,omitempty"`
+	}
+)
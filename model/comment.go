@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+type (
+	// A Comment expresses a comment attached to a TODO.
+	// Commentは、TODOに付けられたコメントのデータ形式を表現します。
+	Comment struct {
+		ID        int64     `json:"id"`
+		TODOID    int64     `json:"todo_id"`
+		Body      string    `json:"body"`
+		CreatedAt time.Time `json:"created_at"`
+		//属するワークスペースのID(既定のワークスペースの場合は省略)
+		WorkspaceID int64 `json:"workspace_id,omitempty"`
+	}
+
+	// A CreateCommentRequest expresses ...
+	// CreateCommentRequestはコメント作成時の利用者からのリクエスト形式
+	CreateCommentRequest struct {
+		Body string `json:"body"`
+	}
+	// A CreateCommentResponse expresses ...
+	// CreateCommentResponseは保存したコメントをレスポンスとして返す
+	CreateCommentResponse struct {
+		Comment Comment `json:"comment"`
+	}
+
+	// A ReadCommentRequest expresses ...
+	ReadCommentRequest struct {
+		TODOID int64 `json:"todo_id"`
+		PrevID int64 `json:"prev_id"`
+		Size   int64 `json:"size"`
+	}
+	// A ReadCommentResponse expresses ...
+	ReadCommentResponse struct {
+		Comments []Comment `json:"comments"`
+	}
+
+	// A DeleteCommentRequest expresses ...
+	DeleteCommentRequest struct {
+		TODOID int64 `json:"todo_id"`
+		ID     int64 `json:"id"`
+	}
+	// A DeleteCommentResponse expresses ...
+	DeleteCommentResponse struct{}
+)
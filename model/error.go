@@ -1,5 +1,21 @@
 package model
 
+type (
+	// An ErrorResponse expresses the error envelope returned by every endpoint.
+	// ErrorResponseは、全てのエンドポイントが返すエラーレスポンスの形式を表現します。
+	ErrorResponse struct {
+		Error ErrorBody `json:"error"`
+	}
+	// An ErrorBody expresses ...
+	// Codeは機械向けの識別子(例: not_found)、Messageは人向けの説明
+	// RequestIDは問い合わせ時にログと突き合わせるためのリクエストID
+	ErrorBody struct {
+		Code      string `json:"code"`
+		Message   string `json:"message"`
+		RequestID string `json:"request_id,omitempty"`
+	}
+)
+
 type ErrNotFound struct {
 	Resource string `json:"resource"`
 }
@@ -7,3 +23,13 @@ type ErrNotFound struct {
 func (e *ErrNotFound) Error() string {
 	return e.Resource + " not found"
 }
+
+// An ErrValidation is returned when a request has an invalid value.
+type ErrValidation struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func (e *ErrValidation) Error() string {
+	return "invalid " + e.Field + ": " + e.Reason
+}
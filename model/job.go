@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+type (
+	// A JobStatus expresses the state of a background maintenance job.
+	// JobStatusは、バックグラウンドで実行される保守ジョブの状態を表現します。
+	JobStatus struct {
+		Name string `json:"name"`
+		//定期実行の間隔(例: 1h0m0s)。定期実行しない場合は空
+		Interval string `json:"interval,omitempty"`
+		Runs     int64  `json:"runs"`
+		Failures int64  `json:"failures"`
+		//最後に実行を終えた日時と、その実行のエラー(成功した場合は省略)
+		LastRunAt *time.Time `json:"last_run_at"`
+		LastError string     `json:"last_error,omitempty"`
+	}
+
+	// A ReadJobsResponse expresses ...
+	// ReadJobsResponseは、全てのジョブの状態をレスポンスとして返す
+	ReadJobsResponse struct {
+		Jobs []JobStatus `json:"jobs"`
+	}
+
+	// A RunJobResponse expresses ...
+	// RunJobResponseは、実行を終えたジョブの状態をレスポンスとして返す
+	RunJobResponse struct {
+		Job JobStatus `json:"job"`
+	}
+)
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+type (
+	// A TODOLock expresses the claim of a TODO by an API key. Until it
+	// expires or is released, only the holder can update or delete the TODO.
+	// TODOLockは、TODOを編集中であることを示すロックを表現します。
+	TODOLock struct {
+		TODOID int64 `json:"todo_id"`
+		//ロックしているAPIキーのID(APIキーなしのリクエストの場合は0)
+		HolderID  int64     `json:"holder_id"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	// A ClaimTODORequest expresses ...
+	// ClaimTODORequestはロックの期間を指定するリクエスト形式(本文を省略した場合は既定の期間)
+	ClaimTODORequest struct {
+		TTLSeconds int64 `json:"ttl_seconds"`
+	}
+	// A ClaimTODOResponse expresses ...
+	ClaimTODOResponse struct {
+		Lock TODOLock `json:"lock"`
+	}
+
+	// A ReleaseTODOResponse expresses ...
+	ReleaseTODOResponse struct{}
+)
+
+// An ErrLocked is returned when a TODO is claimed by another API key.
+type ErrLocked struct {
+	Lock TODOLock `json:"lock"`
+}
+
+func (e *ErrLocked) Error() string {
+	return fmt.Sprintf("TODO is claimed by another client until %s", FormatTime(e.Lock.ExpiresAt))
+}
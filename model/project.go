@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+type (
+	// A Project expresses a group of TODOs.
+	// Projectは、TODOをまとめるプロジェクト(リスト)のデータ形式を表現します。
+	Project struct {
+		ID          int64     `json:"id"`
+		Name        string    `json:"name"`
+		Description string    `json:"description"`
+		CreatedAt   time.Time `json:"created_at"`
+		UpdatedAt   time.Time `json:"updated_at"`
+		//プロジェクトに属する未完了・完了済みのTODOの件数
+		OpenCount int64 `json:"open_count"`
+		DoneCount int64 `json:"done_count"`
+		//属するワークスペースのID(既定のワークスペースの場合は省略)
+		WorkspaceID int64 `json:"workspace_id,omitempty"`
+	}
+
+	// A CreateProjectRequest expresses ...
+	// CreateProjectRequestはプロジェクト作成時の利用者からのリクエスト形式
+	CreateProjectRequest struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	// A CreateProjectResponse expresses ...
+	CreateProjectResponse struct {
+		Project Project `json:"project"`
+	}
+
+	// A ReadProjectRequest expresses ...
+	ReadProjectRequest struct {
+		PrevID int64 `json:"prev_id"`
+		Size   int64 `json:"size"`
+	}
+	// A ReadProjectResponse expresses ...
+	ReadProjectResponse struct {
+		Projects []Project `json:"projects"`
+	}
+
+	// A ReadProjectByIDResponse expresses ...
+	ReadProjectByIDResponse struct {
+		Project Project `json:"project"`
+	}
+
+	// An UpdateProjectRequest expresses ...
+	UpdateProjectRequest struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	// An UpdateProjectResponse expresses ...
+	UpdateProjectResponse struct {
+		Project Project `json:"project"`
+	}
+
+	// A DeleteProjectResponse expresses ...
+	// プロジェクトを削除しても、属していたTODOは削除されずプロジェクトなしになる
+	DeleteProjectResponse struct{}
+)
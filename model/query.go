@@ -0,0 +1,93 @@
+package model
+
+import "time"
+
+// Values of TODO statuses used by TODOQuery.Status.
+const (
+	TODOStatusOpen = "open"
+	TODOStatusDone = "done"
+)
+
+// Values of TODO.Priority. An empty priority means the priority is not set.
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+)
+
+// TODOSortFields is the allowlist of fields TODO lists can be sorted by.
+var TODOSortFields = map[string]bool{
+	"id":         true,
+	"subject":    true,
+	"priority":   true,
+	"created_at": true,
+	"updated_at": true,
+	"position":   true,
+}
+
+type (
+	// A TODOQuery expresses the conditions, order and page of a TODO list.
+	// TODOQueryは、TODO一覧の絞り込み条件・並び順・ページを表現します。
+	TODOQuery struct {
+		//IDがPrevIDより小さいTODOのみを返す(0の場合は先頭から)
+		PrevID int64
+		//先頭から読み飛ばす件数(PrevIDとは併用しない)
+		Offset int64
+		Size   int64
+		//件名または説明に含まれる文字列
+		Query string
+		//空の値は絞り込まない
+		Status        string
+		Priority      string
+		CreatedAfter  time.Time
+		CreatedBefore time.Time
+		UpdatedAfter  time.Time
+		UpdatedBefore time.Time
+		//falseの場合はアーカイブされていないTODOのみ、trueの場合はアーカイブ済みのTODOのみ
+		Archived bool
+		//0の場合は絞り込まない
+		ProjectID int64
+		//nilの場合は絞り込まない。0を指す場合は担当者のいないTODOのみ
+		AssigneeID *int64
+		//空の場合はIDの降順(新しい順)。positionは手動で並べ替えた順
+		Sort []TODOSort
+	}
+
+	// A TODOSort expresses a sort key of TODOQuery.
+	TODOSort struct {
+		Field string
+		Desc  bool
+	}
+
+	// A TODOPatch expresses a partial update of a TODO.
+	// TODOPatchは、TODOの部分的な更新を表現します。nilのフィールドは変更しません。
+	TODOPatch struct {
+		Subject     *string
+		Description *string
+		Done        *bool
+		Priority    *string
+		Archived    *bool
+		//0の場合はプロジェクトから外す
+		ProjectID *int64
+		//0の場合は担当者を外す
+		AssigneeID *int64
+	}
+)
+
+// DefaultSort reports whether q is ordered by ID descending, the only order prev_id works with.
+func (q *TODOQuery) DefaultSort() bool {
+	return len(q.Sort) == 0 || (len(q.Sort) == 1 && q.Sort[0] == TODOSort{Field: "id", Desc: true})
+}
+
+// PriorityRank returns the order of priority, higher is more important.
+func PriorityRank(priority string) int {
+	switch priority {
+	case PriorityHigh:
+		return 3
+	case PriorityMedium:
+		return 2
+	case PriorityLow:
+		return 1
+	}
+	return 0
+}
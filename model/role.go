@@ -0,0 +1,56 @@
+package model
+
+// Roles of API keys.
+const (
+	// RoleAdmin can do everything, including the /admin endpoints and bulk deletes.
+	RoleAdmin = "admin"
+	// RoleMember can read and write TODOs one by one.
+	RoleMember = "member"
+	// RoleReadonly can only read.
+	RoleReadonly = "readonly"
+)
+
+// A Permission is an action checked against the role and scopes of an APIKey.
+type Permission string
+
+// Permissions checked by the middleware and services.
+const (
+	PermissionRead       Permission = "read"
+	PermissionWrite      Permission = "write"
+	PermissionBulkDelete Permission = "bulk_delete"
+	PermissionAdmin      Permission = "admin"
+	// PermissionWorkspace is granted by the membership of a workspace, not by roles.
+	PermissionWorkspace Permission = "workspace"
+)
+
+// An ErrForbidden is returned when the caller lacks the permission.
+type ErrForbidden struct {
+	Permission Permission `json:"permission"`
+}
+
+func (e *ErrForbidden) Error() string {
+	return "permission " + string(e.Permission) + " is required"
+}
+
+// rolePermissions lists the permissions granted to each role.
+var rolePermissions = map[string][]Permission{
+	RoleAdmin:    {PermissionRead, PermissionWrite, PermissionBulkDelete, PermissionAdmin},
+	RoleMember:   {PermissionRead, PermissionWrite},
+	RoleReadonly: {PermissionRead},
+}
+
+// ValidRole reports whether role is one of the roles above.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RoleAllows reports whether role grants the permission.
+func RoleAllows(role string, p Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
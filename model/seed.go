@@ -0,0 +1,9 @@
+package model
+
+// A SeedResponse expresses the numbers of fixtures created by POST /debug/seed.
+// SeedResponseは、作成したサンプルデータの件数を表します。
+type SeedResponse struct {
+	Projects int64 `json:"projects"`
+	TODOs    int64 `json:"todos"`
+	Comments int64 `json:"comments"`
+}
@@ -0,0 +1,36 @@
+package model
+
+// Values of SlackMessage.ResponseType.
+const (
+	SlackEphemeral = "ephemeral"
+	SlackInChannel = "in_channel"
+)
+
+type (
+	// A SlackMessage expresses the response to a Slack slash command, formatted
+	// with Block Kit (https://api.slack.com/block-kit).
+	// SlackMessageは、Slackのスラッシュコマンドへの応答を表現します。
+	SlackMessage struct {
+		//ephemeralはコマンドを実行した人にだけ、in_channelはチャンネル全体に表示する
+		ResponseType string `json:"response_type"`
+		//通知などブロックを表示できない場合に使われる
+		Text   string       `json:"text"`
+		Blocks []SlackBlock `json:"blocks"`
+	}
+
+	// A SlackBlock expresses a Block Kit block, e.g. a section or a context.
+	SlackBlock struct {
+		Type string `json:"type"`
+		//sectionの本文
+		Text *SlackText `json:"text,omitempty"`
+		//contextの要素
+		Elements []SlackText `json:"elements,omitempty"`
+	}
+
+	// A SlackText expresses a Block Kit text object.
+	SlackText struct {
+		//mrkdwnまたはplain_text
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+)
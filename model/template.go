@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+type (
+	// A Template expresses a named set of TODOs which can be created at once.
+	// Templateは、まとめて作成できるTODOの雛形のデータ形式を表現します。
+	Template struct {
+		ID          int64          `json:"id"`
+		Name        string         `json:"name"`
+		Description string         `json:"description"`
+		Items       []TemplateItem `json:"items"`
+		CreatedAt   time.Time      `json:"created_at"`
+		//属するワークスペースのID(既定のワークスペースの場合は省略)
+		WorkspaceID int64 `json:"workspace_id,omitempty"`
+	}
+
+	// A TemplateItem expresses a TODO created from a Template.
+	// TemplateItemは、テンプレートから作成されるTODOの内容を表現します。
+	TemplateItem struct {
+		Subject     string `json:"subject"`
+		Description string `json:"description"`
+		//優先度(low, medium, highのいずれか。未設定の場合は省略)
+		Priority string `json:"priority,omitempty"`
+	}
+
+	// A CreateTemplateRequest expresses ...
+	// CreateTemplateRequestは、itemsで内容を指定するか、todo_idsで既存のTODOを元にテンプレートを作成する
+	CreateTemplateRequest struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description"`
+		Items       []TemplateItem `json:"items"`
+		TODOIDs     []int64        `json:"todo_ids"`
+	}
+	// A CreateTemplateResponse expresses ...
+	CreateTemplateResponse struct {
+		Template Template `json:"template"`
+	}
+
+	// A ReadTemplateResponse expresses ...
+	ReadTemplateResponse struct {
+		Templates []Template `json:"templates"`
+	}
+
+	// A ReadTemplateByIDResponse expresses ...
+	ReadTemplateByIDResponse struct {
+		Template Template `json:"template"`
+	}
+
+	// A DeleteTemplateResponse expresses ...
+	// テンプレートを削除しても、テンプレートから作成済みのTODOは削除されない
+	DeleteTemplateResponse struct{}
+
+	// An InstantiateTemplateRequest expresses ...
+	// InstantiateTemplateRequestは、テンプレートから作成するTODOの属するプロジェクトを指定する(0の場合はプロジェクトなし)
+	InstantiateTemplateRequest struct {
+		ProjectID int64 `json:"project_id"`
+	}
+	// An InstantiateTemplateResponse expresses ...
+	// InstantiateTemplateResponseは、テンプレートから作成したTODOをテンプレートの順に返す
+	InstantiateTemplateResponse struct {
+		TODOs []TODO `json:"todos"`
+	}
+)
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeLayout is the layout of the times of TODOs in JSON unless
+// SetTimeFormat sets another, the one of time.Time.
+const DefaultTimeLayout = time.RFC3339Nano
+
+// A timeFormat is the layout and time zone set by SetTimeFormat.
+type timeFormat struct {
+	layout string
+	loc    *time.Location
+}
+
+// currentTimeFormat is nil until SetTimeFormat sets a format other than the default.
+var currentTimeFormat atomic.Pointer[timeFormat]
+
+// SetTimeFormat makes the times of TODOs written to and read from JSON use
+// layout, a layout of the time package such as "2006-01-02 15:04:05", in
+// loc. An empty layout is DefaultTimeLayout, and a nil loc keeps the time
+// zone of each time as stored. It is meant to be called once at startup.
+// SetTimeFormatは、既存のクライアントが期待する形式(例: JSTの"2006-01-02 15:04:05")に合わせるために使う。
+func SetTimeFormat(layout string, loc *time.Location) {
+	if layout == "" {
+		layout = DefaultTimeLayout
+	}
+	if layout == DefaultTimeLayout && loc == nil {
+		currentTimeFormat.Store(nil)
+		return
+	}
+	currentTimeFormat.Store(&timeFormat{layout: layout, loc: loc})
+}
+
+// FormatTime formats t in the format set by SetTimeFormat.
+func FormatTime(t time.Time) string {
+	f := currentTimeFormat.Load()
+	if f == nil {
+		return t.Format(DefaultTimeLayout)
+	}
+	if f.loc != nil {
+		t = t.In(f.loc)
+	}
+	return t.Format(f.layout)
+}
+
+// ParseTime parses s in the format set by SetTimeFormat, in its time zone
+// if the layout has none. It also accepts RFC 3339, so that the times of
+// clients sending the default format keep working.
+func ParseTime(s string) (time.Time, error) {
+	f := currentTimeFormat.Load()
+	if f == nil {
+		return time.Parse(DefaultTimeLayout, s)
+	}
+	t, err := time.ParseInLocation(f.layout, s, TimeLocation())
+	if err != nil {
+		if t, rerr := time.Parse(time.RFC3339Nano, s); rerr == nil {
+			return t, nil
+		}
+		return time.Time{}, err
+	}
+	return t, nil
+}
+
+// TimeLocation returns the time zone set by SetTimeFormat, or time.Local.
+// Times given without a zone, e.g. dates in query parameters, are in it.
+func TimeLocation() *time.Location {
+	if f := currentTimeFormat.Load(); f != nil && f.loc != nil {
+		return f.loc
+	}
+	return time.Local
+}
+
+// customTimeFormat reports whether SetTimeFormat set a format other than the default.
+func customTimeFormat() bool {
+	return currentTimeFormat.Load() != nil
+}
@@ -0,0 +1,58 @@
+package model_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+func TestTimeFormat(t *testing.T) {
+	t.Cleanup(func() { model.SetTimeFormat("", nil) })
+
+	created := time.Date(2024, 5, 4, 3, 4, 5, 600, time.UTC)
+	todo := model.TODO{ID: 1, Subject: "a", CreatedAt: created, UpdatedAt: created.Add(time.Hour)}
+
+	//既定ではtime.Timeと同じくRFC 3339で書く
+	b, err := json.Marshal(todo)
+	if err != nil {
+		t.Fatal("failed to marshal TODO, err =", err)
+	}
+	if want := `{"id":1,"subject":"a","description":"","created_at":"2024-05-04T03:04:05.0000006Z","updated_at":"2024-05-04T04:04:05.0000006Z"}`; string(b) != want {
+		t.Errorf("unexpected default JSON, got = %s", b)
+	}
+
+	jst, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("time zone database is not available, err =", err)
+	}
+	model.SetTimeFormat("2006-01-02 15:04:05", jst)
+	b, err = json.Marshal(&todo)
+	if err != nil {
+		t.Fatal("failed to marshal TODO, err =", err)
+	}
+	if !strings.Contains(string(b), `"created_at":"2024-05-04 12:04:05"`) || !strings.Contains(string(b), `"updated_at":"2024-05-04 13:04:05"`) {
+		t.Errorf("unexpected JSON in the custom format, got = %s", b)
+	}
+
+	var got model.TODO
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal("failed to unmarshal TODO, err =", err)
+	}
+	if got.ID != 1 || got.Subject != "a" || !got.CreatedAt.Equal(created.Truncate(time.Second)) {
+		t.Errorf("unexpected TODO, got = %+v", got)
+	}
+
+	//RFC 3339の入力も受け付ける
+	for _, s := range []string{"2024-05-04 12:04:05", "2024-05-04T03:04:05Z"} {
+		parsed, err := model.ParseTime(s)
+		if err != nil || !parsed.Equal(created.Truncate(time.Second)) {
+			t.Errorf("unexpected time of %q, got = %v, err = %v", s, parsed, err)
+		}
+	}
+	if _, err := model.ParseTime("05/04/2024"); err == nil {
+		t.Error("parsing a time of another format succeeded")
+	}
+}
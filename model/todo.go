@@ -1,6 +1,9 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type (
 	// A TODO expresses ...
@@ -11,6 +14,20 @@ type (
 		Description string    `json:"description"`
 		CreatedAt   time.Time `json:"created_at"` //キャメルケースにより、Created_atではなく、CreatedAt
 		UpdatedAt   time.Time `json:"updated_at"`
+		//TODOに付いているコメントの件数(0件の場合は省略)
+		CommentCount int64 `json:"comment_count,omitempty"`
+		//完了済みかどうか(未完了の場合は省略)
+		Done bool `json:"done,omitempty"`
+		//優先度(low, medium, highのいずれか。未設定の場合は省略)
+		Priority string `json:"priority,omitempty"`
+		//アーカイブ済みかどうか(アーカイブされていない場合は省略)
+		Archived bool `json:"archived,omitempty"`
+		//属するプロジェクトのID(プロジェクトに属していない場合は省略)
+		ProjectID int64 `json:"project_id,omitempty"`
+		//属するワークスペースのID(既定のワークスペースの場合は省略)
+		WorkspaceID int64 `json:"workspace_id,omitempty"`
+		//担当者のAPIキーのID(担当者がいない場合は省略)
+		AssigneeID int64 `json:"assignee_id,omitempty"`
 	}
 
 	// A CreateTODORequest expresses ...
@@ -18,6 +35,9 @@ type (
 	CreateTODORequest struct {
 		Subject     string `json:"subject"`
 		Description string `json:"description"`
+		Done        bool   `json:"done"`
+		Priority    string `json:"priority"`
+		ProjectID   int64  `json:"project_id"`
 	}
 	// A CreateTODOResponse expresses ...
 	// CreateTODOResponseは保存したTODOをレスポンスとして返す
@@ -29,10 +49,74 @@ type (
 	ReadTODORequest struct {
 		PrevID int64 `json:"prev_id"`
 		Size   int64 `json:"size"`
+		//件名または説明に含まれる検索文字列(空の場合は絞り込まない)
+		Query string `json:"q"`
+		//open または done で絞り込む(空の場合は絞り込まない)
+		Status   string `json:"status"`
+		Priority string `json:"priority"`
+		//作成日時・更新日時の範囲(afterは指定日時を含み、beforeは含まない)
+		CreatedAfter  time.Time `json:"created_after"`
+		CreatedBefore time.Time `json:"created_before"`
+		UpdatedAfter  time.Time `json:"updated_after"`
+		UpdatedBefore time.Time `json:"updated_before"`
+		//カンマ区切りの並び替え項目(先頭に-を付けると降順。例: -updated_at,subject)
+		Sort string `json:"sort"`
+		//ページ番号(1始まり)。0の場合はprev_idによるカーソル方式になる
+		Page    int64 `json:"page"`
+		PerPage int64 `json:"per_page"`
+		//trueの場合はアーカイブ済みのTODOのみ、falseの場合はアーカイブされていないTODOのみを返す
+		Archived bool `json:"archived"`
+		//指定されたプロジェクトのTODOのみを返す(0の場合は絞り込まない)
+		ProjectID int64 `json:"project_id"`
+		//担当者で絞り込む("me"は自分のAPIキー、"none"は担当者なし、空の場合は絞り込まない)
+		Assignee string `json:"assignee"`
 	}
 	// A ReadTODOResponse expresses ...
 	ReadTODOResponse struct {
 		TODOs []TODO `json:"todos"`
+		//条件に一致するTODOの総数(ページ番号方式の場合のみ)
+		TotalCount *int64 `json:"total_count,omitempty"`
+	}
+
+	// A ReadTODOByIDResponse expresses ...
+	// ReadTODOByIDResponseはIDで指定された1件のTODOをレスポンスとして返す
+	ReadTODOByIDResponse struct {
+		TODO TODO `json:"todo"`
+	}
+
+	// An ArchiveTODOResponse expresses ...
+	// ArchiveTODOResponseは、アーカイブ状態を変更したTODOをレスポンスとして返す
+	ArchiveTODOResponse struct {
+		TODO TODO `json:"todo"`
+	}
+
+	// An AssignTODORequest expresses ...
+	// AssignTODORequestは担当者を変更するリクエスト形式。0の場合は担当者を外す
+	AssignTODORequest struct {
+		AssigneeID int64 `json:"assignee_id"`
+	}
+	// An AssignTODOResponse expresses ...
+	// AssignTODOResponseは、担当者を変更したTODOをレスポンスとして返す
+	AssignTODOResponse struct {
+		TODO TODO `json:"todo"`
+	}
+
+	// A TODOAssignment expresses a change of the assignee of a TODO, passed
+	// to the hooks notified of assignments.
+	// TODOAssignmentは、TODOの担当者の変更を表現します。0は担当者なしを表します。
+	TODOAssignment struct {
+		TODO TODO
+		//変更前と変更後の担当者のAPIキーのID
+		From int64
+		To   int64
+		//変更したAPIキーのID(APIキーなしのリクエストの場合は0)
+		AssignedBy int64
+	}
+
+	// A CloneTODOResponse expresses ...
+	// CloneTODOResponseは、複製して作成したTODOをレスポンスとして返す
+	CloneTODOResponse struct {
+		TODO TODO `json:"todo"`
 	}
 
 	// A UpdateTODORequest expresses ...
@@ -40,12 +124,95 @@ type (
 		ID          int64  `json:"id"`
 		Subject     string `json:"subject"`
 		Description string `json:"description"`
+		//省略された場合は変更しない
+		Done     *bool   `json:"done,omitempty"`
+		Priority *string `json:"priority,omitempty"`
+		//0の場合はプロジェクトから外す
+		ProjectID *int64 `json:"project_id,omitempty"`
 	}
 	// A UpdateTODOResponse expresses ...
 	UpdateTODOResponse struct {
 		TODO TODO `json:"todo"`
 	}
 
+	// A TODOStats expresses aggregate counts of the TODOs of a workspace.
+	// TODOStatsは、ワークスペースのTODOの集計結果を表現します。
+	TODOStats struct {
+		Total int64 `json:"total"`
+		//完了済み・未完了の件数(アーカイブ済みのTODOも含む)
+		Done     int64 `json:"done"`
+		Open     int64 `json:"open"`
+		Archived int64 `json:"archived"`
+		//優先度ごとの件数(未設定の場合は"none")
+		ByPriority map[string]int64 `json:"by_priority"`
+		//プロジェクトごとの件数(プロジェクトに属していないTODOはproject_idが0)
+		ByProject []TODOProjectCount `json:"by_project"`
+		//直近の日ごとの作成件数(UTCの日付、古い順)
+		CreatedPerDay []TODODayCount `json:"created_per_day"`
+	}
+
+	// A TODOProjectCount expresses the number of TODOs of a project.
+	TODOProjectCount struct {
+		ProjectID int64 `json:"project_id"`
+		Count     int64 `json:"count"`
+	}
+
+	// A TODODayCount expresses the number of TODOs created on a date (YYYY-MM-DD).
+	TODODayCount struct {
+		Date  string `json:"date"`
+		Count int64  `json:"count"`
+	}
+
+	// A ReadTODOStatsResponse expresses ...
+	// ReadTODOStatsResponseは、GET /todos/statsのレスポンス形式
+	ReadTODOStatsResponse struct {
+		Stats TODOStats `json:"stats"`
+	}
+
+	// A TODOVersion expresses a cheap summary of all TODOs and their comments.
+	// TODOVersionは、TODO一覧が変更されたかどうかを判定するための情報を表現します。
+	TODOVersion struct {
+		Count        int64
+		CommentCount int64
+		//TODOの更新日時とコメントの作成日時のうち最も新しいもの
+		LastModified time.Time
+	}
+
+	// A TODOChanges expresses the TODOs changed since a cursor.
+	// TODOChangesは、カーソル以降に作成・更新・削除されたTODOを表現します。
+	TODOChanges struct {
+		//削除の取り消しで戻ったTODOは作成として扱う
+		Created []int64         `json:"created"`
+		Updated []int64         `json:"updated"`
+		Deleted []TODOTombstone `json:"deleted"`
+		//次回のsinceに指定するトークン
+		NextSince string `json:"next_since"`
+	}
+
+	// A TODOTombstone expresses a deleted TODO.
+	// TODOTombstoneは、削除されたTODOのIDと削除日時を表現します。
+	TODOTombstone struct {
+		ID        int64     `json:"id"`
+		DeletedAt time.Time `json:"deleted_at"`
+	}
+
+	// A ReadTODOChangesResponse expresses ...
+	// ReadTODOChangesResponseは、GET /todos/changesのレスポンス形式
+	ReadTODOChangesResponse struct {
+		Changes TODOChanges `json:"changes"`
+	}
+
+	// A ReorderTODORequest expresses ...
+	// ReorderTODORequestは、IDsで並び順を指定するか、IDのTODOをBeforeまたはAfterのTODOの直前・直後に移動する
+	ReorderTODORequest struct {
+		IDs    []int64 `json:"ids"`
+		ID     int64   `json:"id"`
+		Before int64   `json:"before"`
+		After  int64   `json:"after"`
+	}
+	// A ReorderTODOResponse expresses ...
+	ReorderTODOResponse struct{}
+
 	// A DeleteTODORequest expresses ...
 	DeleteTODORequest struct {
 		IDs []int64 `json:"ids"`
@@ -53,3 +220,47 @@ type (
 	// A DeleteTODOResponse expresses ...
 	DeleteTODOResponse struct{}
 )
+
+// MarshalJSON writes the TODO with the times in the format set by
+// SetTimeFormat, as time.Time does by default.
+func (t TODO) MarshalJSON() ([]byte, error) {
+	type todo TODO
+	if !customTimeFormat() {
+		return json.Marshal(todo(t))
+	}
+	return json.Marshal(&struct {
+		todo
+		CreatedAt string `json:"created_at"`
+		UpdatedAt string `json:"updated_at"`
+	}{todo(t), FormatTime(t.CreatedAt), FormatTime(t.UpdatedAt)})
+}
+
+// UnmarshalJSON reads a TODO written by MarshalJSON.
+func (t *TODO) UnmarshalJSON(b []byte) error {
+	type todo TODO
+	if !customTimeFormat() {
+		return json.Unmarshal(b, (*todo)(t))
+	}
+	v := struct {
+		*todo
+		CreatedAt string `json:"created_at"`
+		UpdatedAt string `json:"updated_at"`
+	}{todo: (*todo)(t)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		s string
+		t *time.Time
+	}{{v.CreatedAt, &t.CreatedAt}, {v.UpdatedAt, &t.UpdatedAt}} {
+		if f.s == "" {
+			continue
+		}
+		parsed, err := ParseTime(f.s)
+		if err != nil {
+			return err
+		}
+		*f.t = parsed
+	}
+	return nil
+}
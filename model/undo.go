@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// The actions recorded in the undo log.
+const (
+	UndoActionUpdate = "update"
+	UndoActionDelete = "delete"
+)
+
+type (
+	// An UndoEntry expresses the state of TODOs right before a destructive operation.
+	// UndoEntryは、削除・更新の直前のTODOの状態を表現します。
+	UndoEntry struct {
+		ID     int64  `json:"-"`
+		Action string `json:"-"`
+		//操作したAPIキーのID(APIキーなしのリクエストの場合は0)
+		APIKeyID int64   `json:"-"`
+		TODOs    []*TODO `json:"todos"`
+		//TODOのIDごとの手動の並び順(削除の場合のみ)
+		Positions map[int64]int64 `json:"positions,omitempty"`
+		//削除されたTODOに付いていたコメント(削除の場合のみ)
+		Comments  []*Comment `json:"comments,omitempty"`
+		CreatedAt time.Time  `json:"-"`
+	}
+
+	// An UndoTODOResponse expresses ...
+	// UndoTODOResponseは、元に戻した操作と復元後のTODOをレスポンスとして返す
+	UndoTODOResponse struct {
+		Action string `json:"action"`
+		TODOs  []TODO `json:"todos"`
+	}
+)
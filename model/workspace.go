@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// DefaultWorkspace is the ID of the workspace used without the X-Workspace
+// header. It holds all data created before workspaces existed and has no
+// row in the workspaces table.
+const DefaultWorkspace = 0
+
+type (
+	// A Workspace expresses a tenant. TODOs, comments and projects of one
+	// workspace are never visible from another.
+	// Workspaceは、データを分離するテナント(ワークスペース)を表現します。
+	Workspace struct {
+		ID        int64     `json:"id"`
+		Name      string    `json:"name"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// A CreateWorkspaceRequest expresses ...
+	// CreateWorkspaceRequestはワークスペース作成時の管理者からのリクエスト形式
+	CreateWorkspaceRequest struct {
+		Name string `json:"name"`
+	}
+	// A CreateWorkspaceResponse expresses ...
+	CreateWorkspaceResponse struct {
+		Workspace Workspace `json:"workspace"`
+	}
+
+	// A ReadWorkspaceResponse expresses ...
+	ReadWorkspaceResponse struct {
+		Workspaces []Workspace `json:"workspaces"`
+	}
+
+	// A WorkspaceMemberResponse expresses ...
+	// メンバーの追加・削除の結果として返す
+	WorkspaceMemberResponse struct{}
+)
@@ -0,0 +1,399 @@
+// Package cache provides an in-process LRU cache in front of the TODO list
+// queries of a repository.Repository.
+//
+// Every write through the wrapped repository (TODOs or comments, which change
+// comment_count) invalidates the whole cache, so cached pages are never stale
+// with respect to writes made by this process.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"expvar"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// metrics are published at /debug/vars under "todo_read_cache".
+var metrics = expvar.NewMap("todo_read_cache")
+
+// A Repository wraps a repository.Repository with a read cache.
+type Repository struct {
+	inner repository.Repository
+	lru   *lru
+}
+
+var _ repository.Repository = (*Repository)(nil)
+
+// New returns a Repository caching up to size TODO lists read from inner.
+func New(inner repository.Repository, size int) *Repository {
+	return &Repository{
+		inner: inner,
+		lru:   newLRU(size),
+	}
+}
+
+// TODOs returns the caching TODORepository.
+func (r *Repository) TODOs() repository.TODORepository {
+	return &todoRepository{inner: r.inner.TODOs(), lru: r.lru}
+}
+
+// Comments returns the CommentRepository which invalidates the cache on writes.
+func (r *Repository) Comments() repository.CommentRepository {
+	return &commentRepository{CommentRepository: r.inner.Comments(), lru: r.lru}
+}
+
+// Projects returns the ProjectRepository which invalidates the cache on deletes.
+func (r *Repository) Projects() repository.ProjectRepository {
+	return &projectRepository{ProjectRepository: r.inner.Projects(), lru: r.lru}
+}
+
+// APIKeys returns the APIKeyRepository of the inner repository. API keys are never cached.
+func (r *Repository) APIKeys() repository.APIKeyRepository {
+	return r.inner.APIKeys()
+}
+
+// Workspaces returns the WorkspaceRepository of the inner repository.
+func (r *Repository) Workspaces() repository.WorkspaceRepository {
+	return r.inner.Workspaces()
+}
+
+// Undo returns the UndoRepository of the inner repository. Restoring TODOs
+// goes through TODOs and Comments, which invalidate the cache.
+func (r *Repository) Undo() repository.UndoRepository {
+	return r.inner.Undo()
+}
+
+// Templates returns the TemplateRepository of the inner repository. Templates
+// are not cached, and instantiating one goes through TODOs.
+func (r *Repository) Templates() repository.TemplateRepository {
+	return r.inner.Templates()
+}
+
+// Locks returns the LockRepository of the inner repository. Locks are not
+// part of the cached lists.
+func (r *Repository) Locks() repository.LockRepository {
+	return r.inner.Locks()
+}
+
+// WithTx runs fn in a transaction of the inner repository. Reads inside the
+// transaction bypass the cache, and the cache is invalidated after the
+// transaction if fn wrote anything.
+func (r *Repository) WithTx(ctx context.Context, fn func(r repository.Repository) error) error {
+	tx := &txRepository{}
+	defer func() {
+		if tx.dirty {
+			r.lru.invalidate()
+		}
+	}()
+	return r.inner.WithTx(ctx, func(inner repository.Repository) error {
+		tx.inner = inner
+		return fn(tx)
+	})
+}
+
+// A txRepository is the view of a transaction. It never reads from the cache
+// because the transaction may see uncommitted writes.
+type txRepository struct {
+	inner repository.Repository
+	dirty bool
+}
+
+func (r *txRepository) TODOs() repository.TODORepository {
+	return &todoRepository{inner: r.inner.TODOs(), dirty: &r.dirty}
+}
+
+func (r *txRepository) Comments() repository.CommentRepository {
+	return &commentRepository{CommentRepository: r.inner.Comments(), dirty: &r.dirty}
+}
+
+func (r *txRepository) Projects() repository.ProjectRepository {
+	return &projectRepository{ProjectRepository: r.inner.Projects(), dirty: &r.dirty}
+}
+
+func (r *txRepository) APIKeys() repository.APIKeyRepository {
+	return r.inner.APIKeys()
+}
+
+func (r *txRepository) Workspaces() repository.WorkspaceRepository {
+	return r.inner.Workspaces()
+}
+
+func (r *txRepository) Undo() repository.UndoRepository {
+	return r.inner.Undo()
+}
+
+func (r *txRepository) Templates() repository.TemplateRepository {
+	return r.inner.Templates()
+}
+
+func (r *txRepository) Locks() repository.LockRepository {
+	return r.inner.Locks()
+}
+
+func (r *txRepository) WithTx(ctx context.Context, fn func(r repository.Repository) error) error {
+	return fn(r)
+}
+
+// A todoRepository caches List. Either lru (outside a transaction)
+// or dirty (inside a transaction) is set.
+type todoRepository struct {
+	inner repository.TODORepository
+	lru   *lru
+	dirty *bool
+}
+
+func (r *todoRepository) Create(ctx context.Context, todo *model.TODO) (*model.TODO, error) {
+	defer r.invalidate()
+	return r.inner.Create(ctx, todo)
+}
+
+func (r *todoRepository) List(ctx context.Context, q *model.TODOQuery) ([]*model.TODO, error) {
+	return r.cached(listKey(ctx, q), func() ([]*model.TODO, error) {
+		return r.inner.List(ctx, q)
+	})
+}
+
+// listKey returns the cache key of List with q in the workspace of ctx.
+func listKey(ctx context.Context, q *model.TODOQuery) string {
+	//ポインタはアドレスではなく指す値をキーにし、それ以外は%+vで全ての条件をキーにする
+	//ワークスペースごとに結果が異なるため、キーに含める
+	assignee := "nil"
+	if q.AssigneeID != nil {
+		assignee = strconv.FormatInt(*q.AssigneeID, 10)
+	}
+	c := *q
+	c.AssigneeID = nil
+	return fmt.Sprintf("list:%d:assignee=%s:%+v", repository.WorkspaceID(ctx), assignee, c)
+}
+
+// Count is not cached; it is only used by numbered pages.
+func (r *todoRepository) Count(ctx context.Context, q *model.TODOQuery) (int64, error) {
+	return r.inner.Count(ctx, q)
+}
+
+// Find is not cached; single TODOs are cheap to read by primary key.
+func (r *todoRepository) Find(ctx context.Context, id int64) (*model.TODO, error) {
+	return r.inner.Find(ctx, id)
+}
+
+func (r *todoRepository) Update(ctx context.Context, id int64, patch *model.TODOPatch) (*model.TODO, error) {
+	defer r.invalidate()
+	return r.inner.Update(ctx, id, patch)
+}
+
+func (r *todoRepository) Delete(ctx context.Context, ids []int64) error {
+	defer r.invalidate()
+	return r.inner.Delete(ctx, ids)
+}
+
+func (r *todoRepository) Restore(ctx context.Context, todo *model.TODO, position int64) error {
+	defer r.invalidate()
+	return r.inner.Restore(ctx, todo, position)
+}
+
+// Positions and Neighbor are not cached; they are only used to reorder.
+func (r *todoRepository) Positions(ctx context.Context, ids []int64) (map[int64]int64, error) {
+	return r.inner.Positions(ctx, ids)
+}
+
+func (r *todoRepository) Neighbor(ctx context.Context, pos int64, after bool, exclude int64) (int64, bool, error) {
+	return r.inner.Neighbor(ctx, pos, after, exclude)
+}
+
+func (r *todoRepository) SetPositions(ctx context.Context, positions map[int64]int64) error {
+	defer r.invalidate()
+	return r.inner.SetPositions(ctx, positions)
+}
+
+func (r *todoRepository) Renumber(ctx context.Context) error {
+	defer r.invalidate()
+	return r.inner.Renumber(ctx)
+}
+
+// Stats is not cached; dashboards read it much less often than lists.
+func (r *todoRepository) Stats(ctx context.Context, since time.Time) (*model.TODOStats, error) {
+	return r.inner.Stats(ctx, since)
+}
+
+// Stream is not cached; it is meant for datasets too large to keep.
+func (r *todoRepository) Stream(ctx context.Context, q *model.TODOQuery, fn func(*model.TODO) error) error {
+	return r.inner.Stream(ctx, q, fn)
+}
+
+// Changes is not cached since it is how clients sync.
+func (r *todoRepository) Changes(ctx context.Context, since time.Time) (*model.TODOChanges, error) {
+	return r.inner.Changes(ctx, since)
+}
+
+// Version is not cached since it is how clients detect changes.
+func (r *todoRepository) Version(ctx context.Context) (*model.TODOVersion, error) {
+	return r.inner.Version(ctx)
+}
+
+func (r *todoRepository) invalidate() {
+	if r.dirty != nil {
+		*r.dirty = true
+		return
+	}
+	r.lru.invalidate()
+}
+
+// cached returns the TODOs of key from the cache, or reads and stores them.
+func (r *todoRepository) cached(key string, read func() ([]*model.TODO, error)) ([]*model.TODO, error) {
+	if r.lru == nil {
+		return read()
+	}
+	if todos, ok := r.lru.get(key); ok {
+		metrics.Add("hits", 1)
+		return todos, nil
+	}
+	metrics.Add("misses", 1)
+
+	//読み込み中に書き込みがあった場合は、古い結果を保存しないようにする
+	gen := r.lru.generation()
+	todos, err := read()
+	if err != nil {
+		return nil, err
+	}
+	r.lru.add(key, todos, gen)
+	return todos, nil
+}
+
+// A commentRepository invalidates the cache on writes since they change comment_count.
+type commentRepository struct {
+	repository.CommentRepository
+	lru   *lru
+	dirty *bool
+}
+
+func (r *commentRepository) Create(ctx context.Context, todoID int64, body string) (*model.Comment, error) {
+	defer r.invalidate()
+	return r.CommentRepository.Create(ctx, todoID, body)
+}
+
+func (r *commentRepository) Delete(ctx context.Context, todoID, id int64) error {
+	defer r.invalidate()
+	return r.CommentRepository.Delete(ctx, todoID, id)
+}
+
+func (r *commentRepository) Restore(ctx context.Context, comment *model.Comment) error {
+	defer r.invalidate()
+	return r.CommentRepository.Restore(ctx, comment)
+}
+
+func (r *commentRepository) invalidate() {
+	if r.dirty != nil {
+		*r.dirty = true
+		return
+	}
+	r.lru.invalidate()
+}
+
+// A projectRepository invalidates the cache on deletes since they remove
+// the project_id of TODOs.
+type projectRepository struct {
+	repository.ProjectRepository
+	lru   *lru
+	dirty *bool
+}
+
+func (r *projectRepository) Delete(ctx context.Context, id int64) error {
+	defer r.invalidate()
+	return r.ProjectRepository.Delete(ctx, id)
+}
+
+func (r *projectRepository) invalidate() {
+	if r.dirty != nil {
+		*r.dirty = true
+		return
+	}
+	r.lru.invalidate()
+}
+
+// An lru is a fixed size least-recently-used cache of TODO lists.
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	gen   uint64
+	order *list.List // 先頭が最近使われたもの
+	items map[string]*list.Element
+}
+
+type entry struct {
+	key   string
+	todos []*model.TODO
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:  size,
+		order: list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+func (c *lru) get(key string) ([]*model.TODO, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return copyTODOs(e.Value.(*entry).todos), true
+}
+
+// add stores todos unless the cache was invalidated after generation gen.
+func (c *lru) add(key string, todos []*model.TODO, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.gen != gen || c.size <= 0 {
+		return
+	}
+	if e, ok := c.items[key]; ok {
+		e.Value.(*entry).todos = copyTODOs(todos)
+		c.order.MoveToFront(e)
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry{key: key, todos: copyTODOs(todos)})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry).key)
+		metrics.Add("evictions", 1)
+	}
+}
+
+func (c *lru) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+func (c *lru) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.order.Init()
+	c.items = map[string]*list.Element{}
+	metrics.Add("invalidations", 1)
+}
+
+// copyTODOs returns a deep copy so callers cannot modify the cached values.
+func copyTODOs(todos []*model.TODO) []*model.TODO {
+	c := make([]*model.TODO, len(todos))
+	for i, todo := range todos {
+		t := *todo
+		c[i] = &t
+	}
+	return c
+}
@@ -0,0 +1,141 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/repository/cache"
+	"github.com/TechBowl-japan/go-stations/repository/memory"
+	"github.com/TechBowl-japan/go-stations/repository/repotest"
+)
+
+func TestRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.Repository {
+		return cache.New(memory.New(), 8)
+	})
+}
+
+// countingRepository counts the List calls reaching the inner repository.
+type countingRepository struct {
+	*memory.Repository
+	reads int
+}
+
+func (r *countingRepository) TODOs() repository.TODORepository {
+	return countingTODOs{r.Repository.TODOs(), r}
+}
+
+type countingTODOs struct {
+	repository.TODORepository
+	r *countingRepository
+}
+
+func (c countingTODOs) List(ctx context.Context, q *model.TODOQuery) ([]*model.TODO, error) {
+	c.r.reads++
+	return c.TODORepository.List(ctx, q)
+}
+
+func TestRepository_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingRepository{Repository: memory.New()}
+	repo := cache.New(inner, 1)
+
+	todo, err := repo.TODOs().Create(ctx, &model.TODO{Subject: "subject"})
+	if err != nil {
+		t.Fatal("failed to create TODO, err =", err)
+	}
+
+	read := func(prevID int64, wantReads int) []*model.TODO {
+		t.Helper()
+		todos, err := repo.TODOs().List(ctx, &model.TODOQuery{PrevID: prevID, Size: 5})
+		if err != nil {
+			t.Fatal("failed to read TODOs, err =", err)
+		}
+		if inner.reads != wantReads {
+			t.Errorf("unexpected inner reads, got = %d, want = %d", inner.reads, wantReads)
+		}
+		return todos
+	}
+
+	read(0, 1)
+	read(0, 1) // キャッシュから返る
+
+	// 返された値を変更してもキャッシュは変わらない
+	todos := read(0, 1)
+	todos[0].Subject = "modified"
+	if todos := read(0, 1); todos[0].Subject != "subject" {
+		t.Errorf("cached value was modified, got = %q", todos[0].Subject)
+	}
+
+	// サイズ1のため、別のキーを読むと追い出される
+	read(todo.ID, 2)
+	read(0, 3)
+
+	// コメントの追加でcomment_countが変わるため無効化される
+	if _, err := repo.Comments().Create(ctx, todo.ID, "comment"); err != nil {
+		t.Fatal("failed to create comment, err =", err)
+	}
+	if todos := read(0, 4); todos[0].CommentCount != 1 {
+		t.Errorf("stale comment count, got = %d", todos[0].CommentCount)
+	}
+
+	// トランザクション内の更新はコミット後に無効化される
+	subject := "updated"
+	err = repo.WithTx(ctx, func(r repository.Repository) error {
+		_, err := r.TODOs().Update(ctx, todo.ID, &model.TODOPatch{Subject: &subject})
+		return err
+	})
+	if err != nil {
+		t.Fatal("failed to update TODO, err =", err)
+	}
+	if todos := read(0, 5); todos[0].Subject != "updated" {
+		t.Errorf("stale subject, got = %q", todos[0].Subject)
+	}
+}
+
+func TestRepository_AssigneeKey(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingRepository{Repository: memory.New()}
+	repo := cache.New(inner, 8)
+
+	for _, assignee := range []int64{1, 2} {
+		todo, err := repo.TODOs().Create(ctx, &model.TODO{Subject: "subject"})
+		if err != nil {
+			t.Fatal("failed to create TODO, err =", err)
+		}
+		if _, err := repo.TODOs().Update(ctx, todo.ID, &model.TODOPatch{AssigneeID: &assignee}); err != nil {
+			t.Fatal("failed to assign TODO, err =", err)
+		}
+	}
+
+	read := func(assignee int64, wantReads int) []*model.TODO {
+		t.Helper()
+		//同じ条件でも毎回別のポインタで渡す
+		todos, err := repo.TODOs().List(ctx, &model.TODOQuery{AssigneeID: &assignee, Size: 5})
+		if err != nil {
+			t.Fatal("failed to read TODOs, err =", err)
+		}
+		if inner.reads != wantReads {
+			t.Errorf("unexpected inner reads, got = %d, want = %d", inner.reads, wantReads)
+		}
+		return todos
+	}
+
+	// ポインタのアドレスではなく担当者のIDでキャッシュする
+	read(1, 1)
+	read(1, 1)
+	// 担当者が異なれば別のキーになる
+	if todos := read(2, 2); len(todos) != 1 || todos[0].AssigneeID != 2 {
+		t.Errorf("unexpected TODOs of assignee 2, got = %+v", todos)
+	}
+	if todos := read(1, 2); len(todos) != 1 || todos[0].AssigneeID != 1 {
+		t.Errorf("unexpected TODOs of assignee 1, got = %+v", todos)
+	}
+	// 担当者なしの条件とも区別する
+	read(0, 3)
+	if _, err := repo.TODOs().List(ctx, &model.TODOQuery{Size: 5}); err != nil || inner.reads != 4 {
+		t.Errorf("unexpected inner reads without assignee, got = %d, err = %v", inner.reads, err)
+	}
+}
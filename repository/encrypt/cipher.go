@@ -0,0 +1,112 @@
+package encrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// prefix starts every encrypted value, followed by the key ID, ":" and the
+// base64url of the nonce and the sealed value.
+const prefix = "enc:v2:"
+
+// legacyPrefix starts the values encrypted without associated data, which
+// are still decrypted until Reencrypt rewrites them.
+const legacyPrefix = "enc:v1:"
+
+// A Cipher encrypts values with AES-GCM under the keys of a KeyProvider.
+type Cipher struct {
+	keys KeyProvider
+}
+
+// NewCipher returns a Cipher using keys.
+func NewCipher(keys KeyProvider) *Cipher {
+	return &Cipher{keys: keys}
+}
+
+// Encrypt encrypts s with the current key, binding it to the associated data
+// aad so that it cannot be decrypted with other aad, e.g. when copied to
+// another row. The empty string is kept as is.
+func (c *Cipher) Encrypt(ctx context.Context, s string, aad []byte) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	key, err := c.keys.CurrentKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(s)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(s), aad)
+	return prefix + key.ID + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value returned by Encrypt with the same aad and any key
+// of the provider. Values without the prefix, written before encryption was
+// enabled, are returned as they are, and so are those with the prefix which
+// are not encrypted values at all, e.g. a plain description starting with it.
+func (c *Cipher) Decrypt(ctx context.Context, s string, aad []byte) (string, error) {
+	rest, ok := strings.CutPrefix(s, prefix)
+	if !ok {
+		if rest, ok = strings.CutPrefix(s, legacyPrefix); !ok {
+			return s, nil
+		}
+		//旧形式は関連データなしで暗号化されている
+		aad = nil
+	}
+	id, data, ok := strings.Cut(rest, ":")
+	if !ok || id == "" {
+		return s, nil
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil || len(sealed) < nonceSize {
+		return s, nil
+	}
+	key, err := c.keys.Key(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	plain, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], aad)
+	if err != nil {
+		return "", fmt.Errorf("encrypt: failed to decrypt with key %q: %w", id, err)
+	}
+	return string(plain), nil
+}
+
+// Current reports whether s is empty or encrypted with the current key, so
+// it needs no re-encryption.
+func (c *Cipher) Current(ctx context.Context, s string) (bool, error) {
+	if s == "" {
+		return true, nil
+	}
+	key, err := c.keys.CurrentKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(s, prefix+key.ID+":"), nil
+}
+
+// nonceSize is the nonce size of AES-GCM.
+const nonceSize = 12
+
+func newAEAD(key *Key) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: key %q: %w", key.ID, err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,544 @@
+// Package encrypt encrypts the descriptions of TODOs at rest in front of a
+// repository.Repository.
+//
+// Descriptions are encrypted on every write through the wrapped repository,
+// including the copies kept in the undo log and the items of templates, and
+// decrypted on every read. Each value is bound to the workspace and the ID
+// of its TODO or template item, so a value copied to another row cannot be
+// decrypted; new TODOs and templates are therefore written in two steps, the
+// descriptions after the IDs are assigned.
+// Descriptions written before encryption was enabled are read as they are
+// until Reencrypt rewrites them. Searching with TODOQuery.Query matches the
+// decrypted descriptions in process, reading every TODO of the other
+// conditions, so it is slower than on plain text.
+package encrypt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// searchBatch is the number of TODOs read at once while searching.
+const searchBatch = 200
+
+// errStreamDone stops the inner stream once Stream has found enough TODOs.
+var errStreamDone = errors.New("encrypt: stream done")
+
+// A Repository wraps a repository.Repository encrypting TODO descriptions.
+type Repository struct {
+	inner  repository.Repository
+	cipher *Cipher
+}
+
+var _ repository.Repository = (*Repository)(nil)
+
+// New returns a Repository storing the descriptions in inner encrypted with c.
+func New(inner repository.Repository, c *Cipher) *Repository {
+	return &Repository{inner: inner, cipher: c}
+}
+
+// TODOs returns the TODORepository encrypting descriptions.
+func (r *Repository) TODOs() repository.TODORepository {
+	return &todoRepository{TODORepository: r.inner.TODOs(), repo: r.inner, cipher: r.cipher}
+}
+
+// Comments returns the CommentRepository of the inner repository.
+func (r *Repository) Comments() repository.CommentRepository {
+	return r.inner.Comments()
+}
+
+// Projects returns the ProjectRepository of the inner repository.
+func (r *Repository) Projects() repository.ProjectRepository {
+	return r.inner.Projects()
+}
+
+// APIKeys returns the APIKeyRepository of the inner repository.
+func (r *Repository) APIKeys() repository.APIKeyRepository {
+	return r.inner.APIKeys()
+}
+
+// Workspaces returns the WorkspaceRepository of the inner repository.
+func (r *Repository) Workspaces() repository.WorkspaceRepository {
+	return r.inner.Workspaces()
+}
+
+// Undo returns the UndoRepository encrypting the descriptions of the TODOs
+// in the entries.
+func (r *Repository) Undo() repository.UndoRepository {
+	return &undoRepository{UndoRepository: r.inner.Undo(), cipher: r.cipher}
+}
+
+// Templates returns the TemplateRepository encrypting the descriptions of
+// the items, which may be copied from TODOs.
+func (r *Repository) Templates() repository.TemplateRepository {
+	return &templateRepository{TemplateRepository: r.inner.Templates(), repo: r.inner, cipher: r.cipher}
+}
+
+// Locks returns the LockRepository of the inner repository.
+func (r *Repository) Locks() repository.LockRepository {
+	return r.inner.Locks()
+}
+
+// WithTx runs fn in a transaction of the inner repository.
+func (r *Repository) WithTx(ctx context.Context, fn func(r repository.Repository) error) error {
+	return r.inner.WithTx(ctx, func(inner repository.Repository) error {
+		return fn(New(inner, r.cipher))
+	})
+}
+
+// Reencrypt encrypts the descriptions of the TODOs and template items of
+// every workspace which are plain text or encrypted with an old key with the
+// current key, and returns the number of TODOs and templates rewritten. It
+// is safe to run again after a failure. The rewritten TODOs get a new
+// updated_at, so clients syncing with GET /todos/changes fetch them again.
+func (r *Repository) Reencrypt(ctx context.Context) (int64, error) {
+	workspaces, err := r.inner.Workspaces().Read(ctx)
+	if err != nil {
+		return 0, err
+	}
+	ids := []int64{0}
+	for _, ws := range workspaces {
+		ids = append(ids, ws.ID)
+	}
+
+	var n int64
+	for _, id := range ids {
+		ctx := repository.WithWorkspace(ctx, id)
+		todos, err := r.reencryptTODOs(ctx)
+		n += todos
+		if err != nil {
+			return n, err
+		}
+		templates, err := r.reencryptTemplates(ctx)
+		n += templates
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// reencryptTODOs re-encrypts the TODOs of the workspace of ctx.
+func (r *Repository) reencryptTODOs(ctx context.Context) (int64, error) {
+	var n int64
+	todos := r.inner.TODOs()
+	for _, archived := range []bool{false, true} {
+		q := &model.TODOQuery{Archived: archived, Size: searchBatch}
+		for {
+			page, err := todos.List(ctx, q)
+			if err != nil {
+				return n, err
+			}
+			for _, todo := range page {
+				description, ok, err := r.reencrypt(ctx, todo.Description, todoAAD(ctx, todo.ID))
+				if err != nil {
+					return n, err
+				}
+				if !ok {
+					continue
+				}
+				//読み込んだ後に削除されたTODOは読み飛ばす
+				var nf *model.ErrNotFound
+				if _, err := todos.Update(ctx, todo.ID, &model.TODOPatch{Description: &description}); err != nil && !errors.As(err, &nf) {
+					return n, err
+				} else if err == nil {
+					n++
+				}
+			}
+			if len(page) < searchBatch {
+				break
+			}
+			q.PrevID = page[len(page)-1].ID
+		}
+	}
+	return n, nil
+}
+
+// reencryptTemplates re-encrypts the template items of the workspace of ctx.
+func (r *Repository) reencryptTemplates(ctx context.Context) (int64, error) {
+	templates, err := r.inner.Templates().Read(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, template := range templates {
+		rewritten := false
+		for i, item := range template.Items {
+			description, ok, err := r.reencrypt(ctx, item.Description, templateItemAAD(ctx, template.ID, i))
+			if err != nil {
+				return n, err
+			}
+			if ok {
+				template.Items[i].Description = description
+				rewritten = true
+			}
+		}
+		if !rewritten {
+			continue
+		}
+		//項目は削除してから挿入し直すため、トランザクション内で置き換える
+		err := r.inner.WithTx(ctx, func(tx repository.Repository) error {
+			return tx.Templates().UpdateItems(ctx, template.ID, template.Items)
+		})
+		var nf *model.ErrNotFound
+		if err != nil && !errors.As(err, &nf) {
+			return n, err
+		} else if err == nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// reencrypt returns s encrypted with the current key and aad and true, or
+// false if s is already encrypted with them.
+func (r *Repository) reencrypt(ctx context.Context, s string, aad []byte) (string, bool, error) {
+	current, err := r.cipher.Current(ctx, s)
+	if err != nil || current {
+		return "", false, err
+	}
+	plain, err := r.cipher.Decrypt(ctx, s, aad)
+	if err != nil {
+		return "", false, err
+	}
+	encrypted, err := r.cipher.Encrypt(ctx, plain, aad)
+	if err != nil {
+		return "", false, err
+	}
+	return encrypted, true, nil
+}
+
+type todoRepository struct {
+	repository.TODORepository
+	//IDが決まってから説明を書き込むためのトランザクションに使う
+	repo   repository.Repository
+	cipher *Cipher
+}
+
+func (r *todoRepository) Create(ctx context.Context, todo *model.TODO) (*model.TODO, error) {
+	if todo.Description == "" {
+		return r.TODORepository.Create(ctx, todo)
+	}
+	//説明はTODOのIDに結び付けて暗号化するため、IDが決まってから書き込む
+	var created *model.TODO
+	err := r.repo.WithTx(ctx, func(tx repository.Repository) error {
+		t := *todo
+		t.Description = ""
+		inserted, err := tx.TODOs().Create(ctx, &t)
+		if err != nil {
+			return err
+		}
+		description, err := r.cipher.Encrypt(ctx, todo.Description, todoAAD(ctx, inserted.ID))
+		if err != nil {
+			return err
+		}
+		created, err = tx.TODOs().Update(ctx, inserted.ID, &model.TODOPatch{Description: &description})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, decryptTODO(ctx, r.cipher, created)
+}
+
+func (r *todoRepository) List(ctx context.Context, q *model.TODOQuery) ([]*model.TODO, error) {
+	if q.Query != "" {
+		todos, _, err := r.search(ctx, q, false)
+		return todos, err
+	}
+	todos, err := r.TODORepository.List(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	for _, todo := range todos {
+		if err := decryptTODO(ctx, r.cipher, todo); err != nil {
+			return nil, err
+		}
+	}
+	return todos, nil
+}
+
+// Stream decrypts the TODOs before calling fn. With q.Query, it reads the
+// TODOs of the other conditions and matches the decrypted descriptions like
+// search, counting q.Offset and q.Size among the matches.
+func (r *todoRepository) Stream(ctx context.Context, q *model.TODOQuery, fn func(*model.TODO) error) error {
+	if q.Query == "" {
+		return r.TODORepository.Stream(ctx, q, func(todo *model.TODO) error {
+			if err := decryptTODO(ctx, r.cipher, todo); err != nil {
+				return err
+			}
+			return fn(todo)
+		})
+	}
+	inner := *q
+	inner.Query, inner.Offset, inner.Size = "", 0, 0
+	query := strings.ToLower(q.Query)
+	var n int64
+	err := r.TODORepository.Stream(ctx, &inner, func(todo *model.TODO) error {
+		if err := decryptTODO(ctx, r.cipher, todo); err != nil {
+			return err
+		}
+		if !strings.Contains(strings.ToLower(todo.Subject), query) &&
+			!strings.Contains(strings.ToLower(todo.Description), query) {
+			return nil
+		}
+		n++
+		if q.Size > 0 && n <= q.Offset {
+			return nil
+		}
+		if err := fn(todo); err != nil {
+			return err
+		}
+		//件数に達したら読み込みを打ち切る
+		if q.Size > 0 && n-q.Offset >= q.Size {
+			return errStreamDone
+		}
+		return nil
+	})
+	if err == errStreamDone {
+		return nil
+	}
+	return err
+}
+
+func (r *todoRepository) Count(ctx context.Context, q *model.TODOQuery) (int64, error) {
+	if q.Query != "" {
+		_, n, err := r.search(ctx, q, true)
+		return n, err
+	}
+	return r.TODORepository.Count(ctx, q)
+}
+
+func (r *todoRepository) Find(ctx context.Context, id int64) (*model.TODO, error) {
+	todo, err := r.TODORepository.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return todo, decryptTODO(ctx, r.cipher, todo)
+}
+
+func (r *todoRepository) Update(ctx context.Context, id int64, patch *model.TODOPatch) (*model.TODO, error) {
+	if patch.Description != nil {
+		description, err := r.cipher.Encrypt(ctx, *patch.Description, todoAAD(ctx, id))
+		if err != nil {
+			return nil, err
+		}
+		p := *patch
+		p.Description = &description
+		patch = &p
+	}
+	todo, err := r.TODORepository.Update(ctx, id, patch)
+	if err != nil {
+		return nil, err
+	}
+	return todo, decryptTODO(ctx, r.cipher, todo)
+}
+
+func (r *todoRepository) Restore(ctx context.Context, todo *model.TODO, position int64) error {
+	encrypted, err := encryptTODO(ctx, r.cipher, todo)
+	if err != nil {
+		return err
+	}
+	return r.TODORepository.Restore(ctx, encrypted, position)
+}
+
+// search returns the TODOs matching q, or only counts them if count is
+// true, comparing q.Query with the decrypted descriptions.
+func (r *todoRepository) search(ctx context.Context, q *model.TODOQuery, count bool) ([]*model.TODO, int64, error) {
+	matched := []*model.TODO{}
+	if !count && q.Size == 0 {
+		return matched, 0, nil
+	}
+	//検索文字列以外の条件で読み込み、復号してから絞り込む
+	inner := *q
+	inner.Query, inner.Offset, inner.Size = "", 0, searchBatch
+	if count {
+		inner.PrevID = 0
+	}
+	query := strings.ToLower(q.Query)
+	var n int64
+	for {
+		todos, err := r.TODORepository.List(ctx, &inner)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, todo := range todos {
+			if err := decryptTODO(ctx, r.cipher, todo); err != nil {
+				return nil, 0, err
+			}
+			if !strings.Contains(strings.ToLower(todo.Subject), query) &&
+				!strings.Contains(strings.ToLower(todo.Description), query) {
+				continue
+			}
+			n++
+			if count || n <= q.Offset {
+				continue
+			}
+			matched = append(matched, todo)
+			if int64(len(matched)) == q.Size {
+				return matched, n, nil
+			}
+		}
+		if len(todos) < searchBatch {
+			return matched, n, nil
+		}
+		inner.Offset += searchBatch
+	}
+}
+
+type undoRepository struct {
+	repository.UndoRepository
+	cipher *Cipher
+}
+
+func (r *undoRepository) Push(ctx context.Context, entry *model.UndoEntry) error {
+	e := *entry
+	e.TODOs = make([]*model.TODO, len(entry.TODOs))
+	for i, todo := range entry.TODOs {
+		encrypted, err := encryptTODO(ctx, r.cipher, todo)
+		if err != nil {
+			return err
+		}
+		e.TODOs[i] = encrypted
+	}
+	return r.UndoRepository.Push(ctx, &e)
+}
+
+func (r *undoRepository) Pop(ctx context.Context, apiKeyID int64, since time.Time) (*model.UndoEntry, error) {
+	entry, err := r.UndoRepository.Pop(ctx, apiKeyID, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, todo := range entry.TODOs {
+		if err := decryptTODO(ctx, r.cipher, todo); err != nil {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+type templateRepository struct {
+	repository.TemplateRepository
+	//IDが決まってから項目の説明を書き込むためのトランザクションに使う
+	repo   repository.Repository
+	cipher *Cipher
+}
+
+func (r *templateRepository) Create(ctx context.Context, template *model.Template) (*model.Template, error) {
+	//項目の説明はテンプレートのIDに結び付けて暗号化するため、IDが決まってから書き込む
+	t := *template
+	t.Items = make([]model.TemplateItem, len(template.Items))
+	for i, item := range template.Items {
+		item.Description = ""
+		t.Items[i] = item
+	}
+	var created *model.Template
+	err := r.repo.WithTx(ctx, func(tx repository.Repository) error {
+		var err error
+		if created, err = tx.Templates().Create(ctx, &t); err != nil {
+			return err
+		}
+		encrypted, err := encryptItems(ctx, r.cipher, created.ID, template.Items)
+		if err != nil {
+			return err
+		}
+		if err := tx.Templates().UpdateItems(ctx, created.ID, encrypted); err != nil {
+			return err
+		}
+		created.Items = append([]model.TemplateItem{}, template.Items...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (r *templateRepository) Read(ctx context.Context) ([]*model.Template, error) {
+	templates, err := r.TemplateRepository.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, template := range templates {
+		if err := decryptItems(ctx, r.cipher, template.ID, template.Items); err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+func (r *templateRepository) Find(ctx context.Context, id int64) (*model.Template, error) {
+	template, err := r.TemplateRepository.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return template, decryptItems(ctx, r.cipher, template.ID, template.Items)
+}
+
+func (r *templateRepository) UpdateItems(ctx context.Context, id int64, items []model.TemplateItem) error {
+	encrypted, err := encryptItems(ctx, r.cipher, id, items)
+	if err != nil {
+		return err
+	}
+	return r.TemplateRepository.UpdateItems(ctx, id, encrypted)
+}
+
+// encryptItems returns a copy of items of the template of id with the
+// descriptions encrypted.
+func encryptItems(ctx context.Context, c *Cipher, id int64, items []model.TemplateItem) ([]model.TemplateItem, error) {
+	encrypted := make([]model.TemplateItem, len(items))
+	for i, item := range items {
+		var err error
+		if item.Description, err = c.Encrypt(ctx, item.Description, templateItemAAD(ctx, id, i)); err != nil {
+			return nil, err
+		}
+		encrypted[i] = item
+	}
+	return encrypted, nil
+}
+
+// decryptItems decrypts the descriptions of items of the template of id in place.
+func decryptItems(ctx context.Context, c *Cipher, id int64, items []model.TemplateItem) error {
+	for i := range items {
+		var err error
+		if items[i].Description, err = c.Decrypt(ctx, items[i].Description, templateItemAAD(ctx, id, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encryptTODO returns a copy of todo with the description encrypted.
+func encryptTODO(ctx context.Context, c *Cipher, todo *model.TODO) (*model.TODO, error) {
+	t := *todo
+	var err error
+	if t.Description, err = c.Encrypt(ctx, todo.Description, todoAAD(ctx, todo.ID)); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// decryptTODO decrypts the description of todo in place.
+func decryptTODO(ctx context.Context, c *Cipher, todo *model.TODO) error {
+	var err error
+	todo.Description, err = c.Decrypt(ctx, todo.Description, todoAAD(ctx, todo.ID))
+	return err
+}
+
+// todoAAD returns the associated data binding the description of the TODO
+// of id to the TODO in the workspace of ctx.
+func todoAAD(ctx context.Context, id int64) []byte {
+	return []byte(fmt.Sprintf("todo:%d:%d", repository.WorkspaceID(ctx), id))
+}
+
+// templateItemAAD returns the associated data binding the description of
+// the i-th item of the template of id to the item in the workspace of ctx.
+func templateItemAAD(ctx context.Context, id int64, i int) []byte {
+	return []byte(fmt.Sprintf("template:%d:%d:%d", repository.WorkspaceID(ctx), id, i))
+}
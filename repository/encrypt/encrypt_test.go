@@ -0,0 +1,285 @@
+package encrypt_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/repository/encrypt"
+	"github.com/TechBowl-japan/go-stations/repository/memory"
+	"github.com/TechBowl-japan/go-stations/repository/repotest"
+)
+
+func keys(t *testing.T, specs ...string) encrypt.StaticKeys {
+	t.Helper()
+	k, err := encrypt.ParseKeys(specs)
+	if err != nil {
+		t.Fatal("failed to parse keys, err =", err)
+	}
+	return k
+}
+
+func secret(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func TestRepository(t *testing.T) {
+	c := encrypt.NewCipher(keys(t, "k1:"+secret(1)))
+	repotest.Run(t, func(t *testing.T) repository.Repository {
+		return encrypt.New(memory.New(), c)
+	})
+}
+
+func TestRepository_AtRest(t *testing.T) {
+	ctx := context.Background()
+	inner := memory.New()
+	repo := encrypt.New(inner, encrypt.NewCipher(keys(t, "k1:"+secret(1))))
+
+	todo, err := repo.TODOs().Create(ctx, &model.TODO{Subject: "subject", Description: "secret plan"})
+	if err != nil {
+		t.Fatal("failed to create TODO, err =", err)
+	}
+	if todo.Description != "secret plan" {
+		t.Errorf("unexpected description, got = %q", todo.Description)
+	}
+	raw, err := inner.TODOs().Find(ctx, todo.ID)
+	if err != nil {
+		t.Fatal("failed to find TODO, err =", err)
+	}
+	if !strings.HasPrefix(raw.Description, "enc:v2:k1:") || strings.Contains(raw.Description, "secret") {
+		t.Errorf("description is not encrypted at rest, got = %q", raw.Description)
+	}
+
+	//取り消し用の履歴にも平文を残さない
+	if err := repo.Undo().Push(ctx, &model.UndoEntry{Action: model.UndoActionUpdate, TODOs: []*model.TODO{todo}}); err != nil {
+		t.Fatal("failed to push undo entry, err =", err)
+	}
+	if todo.Description != "secret plan" {
+		t.Errorf("Push modified the entry, got = %q", todo.Description)
+	}
+	entry, err := inner.Undo().Pop(ctx, 0, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal("failed to pop undo entry, err =", err)
+	}
+	if strings.Contains(entry.TODOs[0].Description, "secret") {
+		t.Errorf("undo entry is not encrypted at rest, got = %q", entry.TODOs[0].Description)
+	}
+
+	//TODOから写したテンプレートの項目にも平文を残さない
+	template, err := repo.Templates().Create(ctx, &model.Template{Name: "plan", Items: []model.TemplateItem{{Subject: todo.Subject, Description: todo.Description}}})
+	if err != nil {
+		t.Fatal("failed to create template, err =", err)
+	}
+	if template.Items[0].Description != "secret plan" {
+		t.Errorf("unexpected template item, got = %q", template.Items[0].Description)
+	}
+	rawTemplate, err := inner.Templates().Find(ctx, template.ID)
+	if err != nil {
+		t.Fatal("failed to find template, err =", err)
+	}
+	if got := rawTemplate.Items[0].Description; !strings.HasPrefix(got, "enc:v2:k1:") || strings.Contains(got, "secret") {
+		t.Errorf("template item is not encrypted at rest, got = %q", got)
+	}
+	if found, err := repo.Templates().Find(ctx, template.ID); err != nil || found.Items[0].Description != "secret plan" {
+		t.Errorf("unexpected template, got = %+v, err = %v", found, err)
+	}
+
+	//暗号化された説明を別のTODOに写しても復号できない
+	other, err := repo.TODOs().Create(ctx, &model.TODO{Subject: "other", Description: "public"})
+	if err != nil {
+		t.Fatal("failed to create TODO, err =", err)
+	}
+	if _, err := inner.TODOs().Update(ctx, other.ID, &model.TODOPatch{Description: &raw.Description}); err != nil {
+		t.Fatal("failed to copy description, err =", err)
+	}
+	if todo, err := repo.TODOs().Find(ctx, other.ID); err == nil {
+		t.Errorf("copied description was decrypted, got = %q", todo.Description)
+	}
+}
+
+func TestRepository_PlainWithPrefix(t *testing.T) {
+	ctx := context.Background()
+	inner := memory.New()
+	//暗号化を有効にする前に、接頭辞と同じ文字列で始まる説明が書かれていた
+	plain, err := inner.TODOs().Create(ctx, &model.TODO{Subject: "notes", Description: "enc:v1: is the old format"})
+	if err != nil {
+		t.Fatal("failed to create TODO, err =", err)
+	}
+	repo := encrypt.New(inner, encrypt.NewCipher(keys(t, "k1:"+secret(1))))
+	if _, err := repo.TODOs().Create(ctx, &model.TODO{Subject: "secret", Description: "secret plan"}); err != nil {
+		t.Fatal("failed to create TODO, err =", err)
+	}
+
+	todos, err := repo.TODOs().List(ctx, &model.TODOQuery{Size: 5})
+	if err != nil {
+		t.Fatal("failed to list TODOs, err =", err)
+	}
+	if len(todos) != 2 || todos[1].ID != plain.ID || todos[1].Description != "enc:v1: is the old format" || todos[0].Description != "secret plan" {
+		t.Errorf("unexpected TODOs, got = %+v", todos)
+	}
+	if n, err := repo.Reencrypt(ctx); err != nil || n != 1 {
+		t.Errorf("unexpected re-encryption, n = %d, err = %v", n, err)
+	}
+	if todo, err := repo.TODOs().Find(ctx, plain.ID); err != nil || todo.Description != "enc:v1: is the old format" {
+		t.Errorf("unexpected TODO after re-encryption, got = %+v, err = %v", todo, err)
+	}
+}
+
+func TestRepository_Reencrypt(t *testing.T) {
+	ctx := context.Background()
+	inner := memory.New()
+	ws, err := inner.Workspaces().Create(ctx, "team")
+	if err != nil {
+		t.Fatal("failed to create workspace, err =", err)
+	}
+	wsCtx := repository.WithWorkspace(ctx, ws.ID)
+
+	//暗号化を有効にする前のTODOと、古い鍵で暗号化したTODO
+	plain, err := inner.TODOs().Create(wsCtx, &model.TODO{Subject: "plain", Description: "written in plain text"})
+	if err != nil {
+		t.Fatal("failed to create TODO, err =", err)
+	}
+	old := encrypt.New(inner, encrypt.NewCipher(keys(t, "k1:"+secret(1))))
+	archived, err := old.TODOs().Create(ctx, &model.TODO{Subject: "old", Description: "old key"})
+	if err != nil {
+		t.Fatal("failed to create TODO, err =", err)
+	}
+	if _, err := old.TODOs().Update(ctx, archived.ID, &model.TODOPatch{Archived: ptr(true)}); err != nil {
+		t.Fatal("failed to archive TODO, err =", err)
+	}
+	if _, err := old.TODOs().Create(ctx, &model.TODO{Subject: "empty"}); err != nil {
+		t.Fatal("failed to create TODO, err =", err)
+	}
+	template, err := inner.Templates().Create(wsCtx, &model.Template{Name: "plain", Items: []model.TemplateItem{{Subject: "item", Description: "plain item"}, {Subject: "empty"}}})
+	if err != nil {
+		t.Fatal("failed to create template, err =", err)
+	}
+
+	rotated := encrypt.New(inner, encrypt.NewCipher(keys(t, "k2:"+secret(2), "k1:"+secret(1))))
+	n, err := rotated.Reencrypt(ctx)
+	if err != nil || n != 3 {
+		t.Fatalf("unexpected re-encryption, n = %d, err = %v", n, err)
+	}
+	if n, err := rotated.Reencrypt(ctx); err != nil || n != 0 {
+		t.Errorf("unexpected second re-encryption, n = %d, err = %v", n, err)
+	}
+
+	for _, tt := range []struct {
+		ctx  context.Context
+		id   int64
+		want string
+	}{
+		{wsCtx, plain.ID, "written in plain text"},
+		{ctx, archived.ID, "old key"},
+	} {
+		raw, err := inner.TODOs().Find(tt.ctx, tt.id)
+		if err != nil {
+			t.Fatal("failed to find TODO, err =", err)
+		}
+		if !strings.HasPrefix(raw.Description, "enc:v2:k2:") {
+			t.Errorf("TODO %d is not encrypted with the new key, got = %q", tt.id, raw.Description)
+		}
+		//新しい鍵だけで読める
+		todo, err := encrypt.New(inner, encrypt.NewCipher(keys(t, "k2:"+secret(2)))).TODOs().Find(tt.ctx, tt.id)
+		if err != nil || todo.Description != tt.want {
+			t.Errorf("unexpected TODO %d, got = %+v, err = %v", tt.id, todo, err)
+		}
+	}
+
+	raw, err := inner.Templates().Find(wsCtx, template.ID)
+	if err != nil {
+		t.Fatal("failed to find template, err =", err)
+	}
+	if !strings.HasPrefix(raw.Items[0].Description, "enc:v2:k2:") || raw.Items[1].Description != "" {
+		t.Errorf("template items are not encrypted with the new key, got = %+v", raw.Items)
+	}
+	found, err := encrypt.New(inner, encrypt.NewCipher(keys(t, "k2:"+secret(2)))).Templates().Find(wsCtx, template.ID)
+	if err != nil || found.Items[0].Description != "plain item" {
+		t.Errorf("unexpected template, got = %+v, err = %v", found, err)
+	}
+}
+
+func TestCipher(t *testing.T) {
+	ctx := context.Background()
+	c := encrypt.NewCipher(keys(t, "k1:"+secret(1)))
+	aad := []byte("todo:0:1")
+
+	a, err := c.Encrypt(ctx, "value", aad)
+	if err != nil {
+		t.Fatal("failed to encrypt, err =", err)
+	}
+	b, _ := c.Encrypt(ctx, "value", aad)
+	if a == b {
+		t.Error("encrypting twice returned the same value")
+	}
+	if got, err := c.Decrypt(ctx, a, aad); err != nil || got != "value" {
+		t.Errorf("unexpected decrypted value, got = %q, err = %v", got, err)
+	}
+
+	//改ざんされた値や未知の鍵、別の行に写された値は復号できない
+	tampered := a[:len(a)-2] + "AA"
+	if tampered == a {
+		tampered = a[:len(a)-2] + "BB"
+	}
+	for _, s := range []string{tampered, "enc:v2:k9:" + strings.TrimPrefix(a, "enc:v2:k1:")} {
+		if _, err := c.Decrypt(ctx, s, aad); err == nil {
+			t.Errorf("decrypting %q succeeded", s)
+		}
+	}
+	for _, other := range [][]byte{[]byte("todo:0:2"), []byte("todo:1:1"), nil} {
+		if _, err := c.Decrypt(ctx, a, other); err == nil {
+			t.Errorf("decrypting with associated data %q succeeded", other)
+		}
+	}
+
+	//暗号化された値の形式でない値は、接頭辞が同じでも平文として返す
+	for _, s := range []string{"plain", "enc:v2:k1", "enc:v1:", "enc:v1:note: see the wiki", "enc:v2:k1:not base64!"} {
+		if got, err := c.Decrypt(ctx, s, aad); err != nil || got != s {
+			t.Errorf("unexpected plain value, got = %q, err = %v, want = %q", got, err, s)
+		}
+	}
+
+	//関連データなしで暗号化された旧形式の値も読める
+	block, err := aes.NewCipher(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	legacy := "enc:v1:k1:" + base64.RawURLEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte("old value"), nil))
+	if got, err := c.Decrypt(ctx, legacy, aad); err != nil || got != "old value" {
+		t.Errorf("unexpected legacy value, got = %q, err = %v", got, err)
+	}
+	if current, err := c.Current(ctx, legacy); err != nil || current {
+		t.Errorf("legacy value must be re-encrypted, current = %v, err = %v", current, err)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	for _, specs := range [][]string{
+		nil,
+		{"k1"},
+		{":" + secret(1)},
+		{"k1:not base64"},
+		{"k1:" + base64.StdEncoding.EncodeToString([]byte("short"))},
+		{"k1:" + secret(1), "k1:" + secret(2)},
+	} {
+		if _, err := encrypt.ParseKeys(specs); err == nil {
+			t.Errorf("ParseKeys(%q) succeeded", specs)
+		}
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
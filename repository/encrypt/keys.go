@@ -0,0 +1,82 @@
+package encrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// A Key is an AES key with the ID stored next to the values it encrypts.
+type Key struct {
+	ID string
+	// Secret is 16, 24 or 32 bytes for AES-128, AES-192 or AES-256.
+	Secret []byte
+}
+
+// A KeyProvider supplies the keys of a Cipher. StaticKeys reads them from
+// the configuration; a provider backed by a key management service would
+// fetch (and cache) the data keys instead.
+type KeyProvider interface {
+	// CurrentKey returns the key new values are encrypted with.
+	CurrentKey(ctx context.Context) (*Key, error)
+	// Key returns the key of id, which may be an older one kept to decrypt
+	// values written before a rotation.
+	Key(ctx context.Context, id string) (*Key, error)
+}
+
+// StaticKeys is a KeyProvider of a fixed list of keys. The first key is the
+// current one.
+type StaticKeys []*Key
+
+var _ KeyProvider = StaticKeys(nil)
+
+// ParseKeys parses keys written as "id:base64-secret", current key first,
+// e.g. the value of -encryption-keys. A key is rotated by putting a new one
+// first and keeping the old ones until every value is re-encrypted.
+func ParseKeys(specs []string) (StaticKeys, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("encrypt: no keys given")
+	}
+	keys := make(StaticKeys, 0, len(specs))
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		id, secret, ok := strings.Cut(spec, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("encrypt: key must be written as id:base64-secret")
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("encrypt: duplicate key ID %q", id)
+		}
+		seen[id] = true
+		b, err := base64.StdEncoding.DecodeString(secret)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt: secret of key %q is not base64: %w", id, err)
+		}
+		//鍵の長さはAESの作成時に検証される
+		if _, err := aes.NewCipher(b); err != nil {
+			return nil, fmt.Errorf("encrypt: secret of key %q: %w", id, err)
+		}
+		keys = append(keys, &Key{ID: id, Secret: b})
+	}
+	return keys, nil
+}
+
+// CurrentKey returns the first key.
+func (k StaticKeys) CurrentKey(ctx context.Context) (*Key, error) {
+	if len(k) == 0 {
+		return nil, fmt.Errorf("encrypt: no keys")
+	}
+	return k[0], nil
+}
+
+// Key returns the key of id.
+func (k StaticKeys) Key(ctx context.Context, id string) (*Key, error) {
+	for _, key := range k {
+		if key.ID == id {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("encrypt: unknown key %q", id)
+}
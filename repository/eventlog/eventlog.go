@@ -0,0 +1,169 @@
+// Package eventlog implements repository.Repository by event sourcing.
+//
+// Every write is recorded as an Event in an append-only Log, and the current
+// state is the projection of the events in memory (a memory.Repository).
+// Writes are applied to the projection from the very events recorded, the
+// same way Open replays the Log on start, so the replayed state is always
+// the state the writes made. The Log is the full history of the data, which
+// can be read with Events for auditing.
+package eventlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/repository/memory"
+)
+
+// An Event expresses a write to the repository.
+type Event struct {
+	// Seq numbers the events in the Log from 1.
+	Seq int64 `json:"seq"`
+	// Type is one of the event types such as TODOCreated.
+	Type string `json:"type"`
+	// Time is when the write was made, which the projection stamps the
+	// changes with.
+	Time time.Time `json:"time"`
+	// WorkspaceID is the workspace the write was made in.
+	WorkspaceID int64 `json:"workspace_id,omitempty"`
+	// Data holds the arguments of the write, whose form depends on Type.
+	Data json.RawMessage `json:"data"`
+}
+
+// A Repository implements repository.Repository with the events of a Log.
+// It is safe for concurrent use.
+type Repository struct {
+	log  Log
+	proj repository.Repository
+	//トランザクション中のみ設定される、コミット時に追記するイベント
+	pending *[]*Event
+}
+
+var _ repository.Repository = (*Repository)(nil)
+
+// Open returns a Repository with the state replayed from the events of log.
+func Open(log Log) (*Repository, error) {
+	proj := memory.New()
+	ctx := context.Background()
+	err := log.Read(func(e *Event) error {
+		if _, err := apply(ctx, proj, e); err != nil {
+			return fmt.Errorf("eventlog: failed to replay event %d (%s): %w", e.Seq, e.Type, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Repository{log: log, proj: proj}, nil
+}
+
+// Events calls fn with the events of the Log, oldest first.
+func (r *Repository) Events(fn func(*Event) error) error {
+	return r.log.Read(fn)
+}
+
+// TODOs returns the TODORepository.
+func (r *Repository) TODOs() repository.TODORepository {
+	return todoRepository{r.proj.TODOs(), r}
+}
+
+// Comments returns the CommentRepository.
+func (r *Repository) Comments() repository.CommentRepository {
+	return commentRepository{r.proj.Comments(), r}
+}
+
+// Projects returns the ProjectRepository.
+func (r *Repository) Projects() repository.ProjectRepository {
+	return projectRepository{r.proj.Projects(), r}
+}
+
+// APIKeys returns the APIKeyRepository.
+func (r *Repository) APIKeys() repository.APIKeyRepository {
+	return apiKeyRepository{r.proj.APIKeys(), r}
+}
+
+// Workspaces returns the WorkspaceRepository.
+func (r *Repository) Workspaces() repository.WorkspaceRepository {
+	return workspaceRepository{r.proj.Workspaces(), r}
+}
+
+// Undo returns the UndoRepository.
+func (r *Repository) Undo() repository.UndoRepository {
+	return undoRepository{r.proj.Undo(), r}
+}
+
+// Templates returns the TemplateRepository.
+func (r *Repository) Templates() repository.TemplateRepository {
+	return templateRepository{r.proj.Templates(), r}
+}
+
+// Locks returns the LockRepository.
+func (r *Repository) Locks() repository.LockRepository {
+	return lockRepository{r.proj.Locks(), r}
+}
+
+// WithTx runs fn in a transaction of the projection. The events of fn are
+// appended to the Log right before the projection commits, all or none of
+// them, so the Log has exactly the committed writes in the order of commit.
+func (r *Repository) WithTx(ctx context.Context, fn func(r repository.Repository) error) error {
+	//既にトランザクション中の場合は、そのトランザクションに参加する
+	if r.pending != nil {
+		return fn(r)
+	}
+
+	var pending []*Event
+	//Logへの追記後に取り消されないよう、キャンセルはここで確認する
+	return r.proj.WithTx(context.WithoutCancel(ctx), func(proj repository.Repository) error {
+		if err := fn(&Repository{log: r.log, proj: proj, pending: &pending}); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		return r.log.Append(pending)
+	})
+}
+
+// record makes an event of typ with data, applies it to the projection and
+// returns the result of the write. Outside a transaction the write runs in
+// its own one.
+func (r *Repository) record(ctx context.Context, typ string, data interface{}) (interface{}, error) {
+	if r.pending == nil {
+		var res interface{}
+		err := r.WithTx(ctx, func(tx repository.Repository) error {
+			var err error
+			res, err = tx.(*Repository).record(ctx, typ, data)
+			return err
+		})
+		return res, err
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	e := &Event{Type: typ, Time: time.Now().UTC(), WorkspaceID: repository.WorkspaceID(ctx), Data: b}
+	//失敗した書き込みはイベントにしない
+	res, err := apply(ctx, r.proj, e)
+	if err != nil {
+		return nil, err
+	}
+	*r.pending = append(*r.pending, e)
+	return res, nil
+}
+
+// apply applies e to proj and returns the result of the write.
+func apply(ctx context.Context, proj repository.Repository, e *Event) (interface{}, error) {
+	h, ok := handlers[e.Type]
+	if !ok {
+		return nil, fmt.Errorf("eventlog: unknown event type %q", e.Type)
+	}
+	ctx = memory.WithNow(repository.WithWorkspace(ctx, e.WorkspaceID), e.Time)
+	return h(ctx, proj, e.Data)
+}
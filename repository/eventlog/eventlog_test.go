@@ -0,0 +1,131 @@
+package eventlog_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/repository/eventlog"
+	"github.com/TechBowl-japan/go-stations/repository/repotest"
+)
+
+func TestRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.Repository {
+		repo, err := eventlog.Open(eventlog.NewMemoryLog())
+		if err != nil {
+			t.Fatal("failed to open repository, err =", err)
+		}
+		return repo
+	})
+}
+
+func TestRepository_Replay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	log, err := eventlog.OpenFileLog(path)
+	if err != nil {
+		t.Fatal("failed to open log, err =", err)
+	}
+	repo, err := eventlog.Open(log)
+	if err != nil {
+		t.Fatal("failed to open repository, err =", err)
+	}
+
+	for _, subject := range []string{"a", "b", "c"} {
+		if _, err := repo.TODOs().Create(ctx, &model.TODO{Subject: subject, Priority: model.PriorityHigh}); err != nil {
+			t.Fatal("failed to create TODO, err =", err)
+		}
+	}
+	done := true
+	if _, err := repo.TODOs().Update(ctx, 2, &model.TODOPatch{Done: &done}); err != nil {
+		t.Fatal("failed to update TODO, err =", err)
+	}
+	if _, err := repo.Comments().Create(ctx, 1, "comment"); err != nil {
+		t.Fatal("failed to create comment, err =", err)
+	}
+	//トランザクションが失敗した場合は、イベントも記録されない
+	err = repo.WithTx(ctx, func(r repository.Repository) error {
+		if err := r.TODOs().Delete(ctx, []int64{1}); err != nil {
+			return err
+		}
+		return r.TODOs().Delete(ctx, []int64{99})
+	})
+	if err == nil {
+		t.Fatal("deleting a missing TODO succeeded")
+	}
+	if err := repo.TODOs().Delete(ctx, []int64{3}); err != nil {
+		t.Fatal("failed to delete TODO, err =", err)
+	}
+	want := snapshot(t, repo)
+	if err := log.Close(); err != nil {
+		t.Fatal("failed to close log, err =", err)
+	}
+
+	//書き込み途中で止まった行は読み飛ばして切り詰める
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal("failed to open log file, err =", err)
+	}
+	if _, err := f.WriteString(`{"seq":99,"type":"todo.cre`); err != nil {
+		t.Fatal("failed to write log file, err =", err)
+	}
+	f.Close()
+
+	log, err = eventlog.OpenFileLog(path)
+	if err != nil {
+		t.Fatal("failed to reopen log, err =", err)
+	}
+	defer log.Close()
+	replayed, err := eventlog.Open(log)
+	if err != nil {
+		t.Fatal("failed to replay log, err =", err)
+	}
+	if got := snapshot(t, replayed); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed state differs\ngot  = %+v\nwant = %+v", got, want)
+	}
+
+	//再生後の書き込みは続きの番号で追記される
+	if _, err := replayed.TODOs().Create(ctx, &model.TODO{Subject: "d"}); err != nil {
+		t.Fatal("failed to create TODO after replay, err =", err)
+	}
+	var types []string
+	var last int64
+	err = replayed.Events(func(e *eventlog.Event) error {
+		if e.Seq != last+1 {
+			t.Errorf("unexpected seq, got = %d, want = %d", e.Seq, last+1)
+		}
+		last = e.Seq
+		types = append(types, e.Type)
+		return nil
+	})
+	if err != nil {
+		t.Fatal("failed to read events, err =", err)
+	}
+	wantTypes := []string{
+		eventlog.TODOCreated, eventlog.TODOCreated, eventlog.TODOCreated, eventlog.TODOUpdated,
+		eventlog.CommentCreated, eventlog.TODOsDeleted, eventlog.TODOCreated,
+	}
+	if !reflect.DeepEqual(types, wantTypes) {
+		t.Errorf("unexpected events, got = %v, want = %v", types, wantTypes)
+	}
+}
+
+// snapshot returns the TODOs and changes of repo to compare states.
+func snapshot(t *testing.T, repo repository.Repository) []interface{} {
+	t.Helper()
+	ctx := context.Background()
+	todos, err := repo.TODOs().List(ctx, &model.TODOQuery{Size: 10})
+	if err != nil {
+		t.Fatal("failed to list TODOs, err =", err)
+	}
+	changes, err := repo.TODOs().Changes(ctx, time.Time{})
+	if err != nil {
+		t.Fatal("failed to read changes, err =", err)
+	}
+	return []interface{}{todos, changes}
+}
@@ -0,0 +1,379 @@
+package eventlog
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// Types of events, one per write method of the repositories.
+const (
+	TODOCreated            = "todo.created"
+	TODOUpdated            = "todo.updated"
+	TODOsDeleted           = "todo.deleted"
+	TODORestored           = "todo.restored"
+	TODOPositionsSet       = "todo.positions_set"
+	TODOsRenumbered        = "todo.renumbered"
+	CommentCreated         = "comment.created"
+	CommentDeleted         = "comment.deleted"
+	CommentRestored        = "comment.restored"
+	ProjectCreated         = "project.created"
+	ProjectUpdated         = "project.updated"
+	ProjectDeleted         = "project.deleted"
+	TemplateCreated        = "template.created"
+	TemplateItemsUpdated   = "template.items_updated"
+	TemplateDeleted        = "template.deleted"
+	APIKeyCreated          = "api_key.created"
+	APIKeyTouched          = "api_key.touched"
+	APIKeyRevoked          = "api_key.revoked"
+	WorkspaceCreated       = "workspace.created"
+	WorkspaceMemberAdded   = "workspace.member_added"
+	WorkspaceMemberRemoved = "workspace.member_removed"
+	UndoPushed             = "undo.pushed"
+	UndoPopped             = "undo.popped"
+	UndoPruned             = "undo.pruned"
+	LockSet                = "lock.set"
+	LocksDeleted           = "lock.deleted"
+)
+
+// todoData is model.TODO written without its MarshalJSON, which may drop
+// the precision of the times.
+type todoData model.TODO
+
+// The data of the events. IDs are those of the written entities.
+type (
+	idData struct {
+		ID int64 `json:"id"`
+	}
+	idsData struct {
+		IDs []int64 `json:"ids"`
+	}
+	todoUpdatedData struct {
+		ID    int64            `json:"id"`
+		Patch *model.TODOPatch `json:"patch"`
+	}
+	todoRestoredData struct {
+		TODO     *todoData `json:"todo"`
+		Position int64     `json:"position"`
+	}
+	positionsData struct {
+		Positions map[int64]int64 `json:"positions"`
+	}
+	commentCreatedData struct {
+		TODOID int64  `json:"todo_id"`
+		Body   string `json:"body"`
+	}
+	commentDeletedData struct {
+		TODOID int64 `json:"todo_id"`
+		ID     int64 `json:"id"`
+	}
+	projectData struct {
+		ID          int64  `json:"id,omitempty"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	templateItemsData struct {
+		ID    int64                `json:"id"`
+		Items []model.TemplateItem `json:"items"`
+	}
+	apiKeyCreatedData struct {
+		Key  *model.APIKey `json:"key"`
+		Hash string        `json:"hash"`
+	}
+	nameData struct {
+		Name string `json:"name"`
+	}
+	memberData struct {
+		WorkspaceID int64 `json:"workspace_id"`
+		APIKeyID    int64 `json:"api_key_id"`
+	}
+	undoPushedData struct {
+		Action   string           `json:"action"`
+		APIKeyID int64            `json:"api_key_id"`
+		Entry    *model.UndoEntry `json:"entry"`
+	}
+	undoPoppedData struct {
+		APIKeyID int64     `json:"api_key_id"`
+		Since    time.Time `json:"since"`
+	}
+	undoPrunedData struct {
+		Before time.Time `json:"before"`
+	}
+)
+
+// A handler applies the data of an event to the projection.
+type handler func(ctx context.Context, proj repository.Repository, data json.RawMessage) (interface{}, error)
+
+// handlers are the handlers of the event types.
+var handlers = map[string]handler{}
+
+// on registers the handler of the events of typ, whose data is a D.
+func on[D any](typ string, fn func(ctx context.Context, proj repository.Repository, d *D) (interface{}, error)) {
+	handlers[typ] = func(ctx context.Context, proj repository.Repository, data json.RawMessage) (interface{}, error) {
+		d := new(D)
+		if err := json.Unmarshal(data, d); err != nil {
+			return nil, err
+		}
+		return fn(ctx, proj, d)
+	}
+}
+
+// none is the result of the writes which return only an error.
+func none(err error) (interface{}, error) {
+	return nil, err
+}
+
+func init() {
+	on(TODOCreated, func(ctx context.Context, proj repository.Repository, d *todoData) (interface{}, error) {
+		return proj.TODOs().Create(ctx, (*model.TODO)(d))
+	})
+	on(TODOUpdated, func(ctx context.Context, proj repository.Repository, d *todoUpdatedData) (interface{}, error) {
+		return proj.TODOs().Update(ctx, d.ID, d.Patch)
+	})
+	on(TODOsDeleted, func(ctx context.Context, proj repository.Repository, d *idsData) (interface{}, error) {
+		return none(proj.TODOs().Delete(ctx, d.IDs))
+	})
+	on(TODORestored, func(ctx context.Context, proj repository.Repository, d *todoRestoredData) (interface{}, error) {
+		return none(proj.TODOs().Restore(ctx, (*model.TODO)(d.TODO), d.Position))
+	})
+	on(TODOPositionsSet, func(ctx context.Context, proj repository.Repository, d *positionsData) (interface{}, error) {
+		return none(proj.TODOs().SetPositions(ctx, d.Positions))
+	})
+	on(TODOsRenumbered, func(ctx context.Context, proj repository.Repository, _ *struct{}) (interface{}, error) {
+		return none(proj.TODOs().Renumber(ctx))
+	})
+
+	on(CommentCreated, func(ctx context.Context, proj repository.Repository, d *commentCreatedData) (interface{}, error) {
+		return proj.Comments().Create(ctx, d.TODOID, d.Body)
+	})
+	on(CommentDeleted, func(ctx context.Context, proj repository.Repository, d *commentDeletedData) (interface{}, error) {
+		return none(proj.Comments().Delete(ctx, d.TODOID, d.ID))
+	})
+	on(CommentRestored, func(ctx context.Context, proj repository.Repository, d *model.Comment) (interface{}, error) {
+		return none(proj.Comments().Restore(ctx, d))
+	})
+
+	on(ProjectCreated, func(ctx context.Context, proj repository.Repository, d *projectData) (interface{}, error) {
+		return proj.Projects().Create(ctx, d.Name, d.Description)
+	})
+	on(ProjectUpdated, func(ctx context.Context, proj repository.Repository, d *projectData) (interface{}, error) {
+		return proj.Projects().Update(ctx, d.ID, d.Name, d.Description)
+	})
+	on(ProjectDeleted, func(ctx context.Context, proj repository.Repository, d *idData) (interface{}, error) {
+		return none(proj.Projects().Delete(ctx, d.ID))
+	})
+
+	on(TemplateCreated, func(ctx context.Context, proj repository.Repository, d *model.Template) (interface{}, error) {
+		return proj.Templates().Create(ctx, d)
+	})
+	on(TemplateItemsUpdated, func(ctx context.Context, proj repository.Repository, d *templateItemsData) (interface{}, error) {
+		return none(proj.Templates().UpdateItems(ctx, d.ID, d.Items))
+	})
+	on(TemplateDeleted, func(ctx context.Context, proj repository.Repository, d *idData) (interface{}, error) {
+		return none(proj.Templates().Delete(ctx, d.ID))
+	})
+
+	on(APIKeyCreated, func(ctx context.Context, proj repository.Repository, d *apiKeyCreatedData) (interface{}, error) {
+		return proj.APIKeys().Create(ctx, d.Key, d.Hash)
+	})
+	on(APIKeyTouched, func(ctx context.Context, proj repository.Repository, d *idData) (interface{}, error) {
+		return none(proj.APIKeys().Touch(ctx, d.ID))
+	})
+	on(APIKeyRevoked, func(ctx context.Context, proj repository.Repository, d *idData) (interface{}, error) {
+		return proj.APIKeys().Revoke(ctx, d.ID)
+	})
+
+	on(WorkspaceCreated, func(ctx context.Context, proj repository.Repository, d *nameData) (interface{}, error) {
+		return proj.Workspaces().Create(ctx, d.Name)
+	})
+	on(WorkspaceMemberAdded, func(ctx context.Context, proj repository.Repository, d *memberData) (interface{}, error) {
+		return none(proj.Workspaces().AddMember(ctx, d.WorkspaceID, d.APIKeyID))
+	})
+	on(WorkspaceMemberRemoved, func(ctx context.Context, proj repository.Repository, d *memberData) (interface{}, error) {
+		return none(proj.Workspaces().RemoveMember(ctx, d.WorkspaceID, d.APIKeyID))
+	})
+
+	on(UndoPushed, func(ctx context.Context, proj repository.Repository, d *undoPushedData) (interface{}, error) {
+		//ActionとAPIKeyIDはUndoEntryのJSONに含まれないため、別に記録している
+		d.Entry.Action, d.Entry.APIKeyID = d.Action, d.APIKeyID
+		return none(proj.Undo().Push(ctx, d.Entry))
+	})
+	on(UndoPopped, func(ctx context.Context, proj repository.Repository, d *undoPoppedData) (interface{}, error) {
+		return proj.Undo().Pop(ctx, d.APIKeyID, d.Since)
+	})
+	on(UndoPruned, func(ctx context.Context, proj repository.Repository, d *undoPrunedData) (interface{}, error) {
+		return none(proj.Undo().Prune(ctx, d.Before))
+	})
+
+	on(LockSet, func(ctx context.Context, proj repository.Repository, d *model.TODOLock) (interface{}, error) {
+		return none(proj.Locks().Set(ctx, d))
+	})
+	on(LocksDeleted, func(ctx context.Context, proj repository.Repository, d *idsData) (interface{}, error) {
+		return none(proj.Locks().Delete(ctx, d.IDs))
+	})
+}
+
+// result converts the result of Repository.record into a T.
+func result[T any](res interface{}, err error) (T, error) {
+	v, _ := res.(T)
+	return v, err
+}
+
+// The repositories read the projection and record the writes as events.
+type (
+	todoRepository struct {
+		repository.TODORepository
+		r *Repository
+	}
+	commentRepository struct {
+		repository.CommentRepository
+		r *Repository
+	}
+	projectRepository struct {
+		repository.ProjectRepository
+		r *Repository
+	}
+	templateRepository struct {
+		repository.TemplateRepository
+		r *Repository
+	}
+	apiKeyRepository struct {
+		repository.APIKeyRepository
+		r *Repository
+	}
+	workspaceRepository struct {
+		repository.WorkspaceRepository
+		r *Repository
+	}
+	undoRepository struct {
+		repository.UndoRepository
+		r *Repository
+	}
+	lockRepository struct {
+		repository.LockRepository
+		r *Repository
+	}
+)
+
+func (t todoRepository) Create(ctx context.Context, todo *model.TODO) (*model.TODO, error) {
+	return result[*model.TODO](t.r.record(ctx, TODOCreated, (*todoData)(todo)))
+}
+
+func (t todoRepository) Update(ctx context.Context, id int64, patch *model.TODOPatch) (*model.TODO, error) {
+	return result[*model.TODO](t.r.record(ctx, TODOUpdated, &todoUpdatedData{ID: id, Patch: patch}))
+}
+
+func (t todoRepository) Delete(ctx context.Context, ids []int64) error {
+	_, err := t.r.record(ctx, TODOsDeleted, &idsData{IDs: ids})
+	return err
+}
+
+func (t todoRepository) Restore(ctx context.Context, todo *model.TODO, position int64) error {
+	_, err := t.r.record(ctx, TODORestored, &todoRestoredData{TODO: (*todoData)(todo), Position: position})
+	return err
+}
+
+func (t todoRepository) SetPositions(ctx context.Context, positions map[int64]int64) error {
+	_, err := t.r.record(ctx, TODOPositionsSet, &positionsData{Positions: positions})
+	return err
+}
+
+func (t todoRepository) Renumber(ctx context.Context) error {
+	_, err := t.r.record(ctx, TODOsRenumbered, struct{}{})
+	return err
+}
+
+func (c commentRepository) Create(ctx context.Context, todoID int64, body string) (*model.Comment, error) {
+	return result[*model.Comment](c.r.record(ctx, CommentCreated, &commentCreatedData{TODOID: todoID, Body: body}))
+}
+
+func (c commentRepository) Delete(ctx context.Context, todoID, id int64) error {
+	_, err := c.r.record(ctx, CommentDeleted, &commentDeletedData{TODOID: todoID, ID: id})
+	return err
+}
+
+func (c commentRepository) Restore(ctx context.Context, comment *model.Comment) error {
+	_, err := c.r.record(ctx, CommentRestored, comment)
+	return err
+}
+
+func (p projectRepository) Create(ctx context.Context, name, description string) (*model.Project, error) {
+	return result[*model.Project](p.r.record(ctx, ProjectCreated, &projectData{Name: name, Description: description}))
+}
+
+func (p projectRepository) Update(ctx context.Context, id int64, name, description string) (*model.Project, error) {
+	return result[*model.Project](p.r.record(ctx, ProjectUpdated, &projectData{ID: id, Name: name, Description: description}))
+}
+
+func (p projectRepository) Delete(ctx context.Context, id int64) error {
+	_, err := p.r.record(ctx, ProjectDeleted, &idData{ID: id})
+	return err
+}
+
+func (t templateRepository) Create(ctx context.Context, template *model.Template) (*model.Template, error) {
+	return result[*model.Template](t.r.record(ctx, TemplateCreated, template))
+}
+
+func (t templateRepository) UpdateItems(ctx context.Context, id int64, items []model.TemplateItem) error {
+	_, err := t.r.record(ctx, TemplateItemsUpdated, &templateItemsData{ID: id, Items: items})
+	return err
+}
+
+func (t templateRepository) Delete(ctx context.Context, id int64) error {
+	_, err := t.r.record(ctx, TemplateDeleted, &idData{ID: id})
+	return err
+}
+
+func (a apiKeyRepository) Create(ctx context.Context, key *model.APIKey, hash string) (*model.APIKey, error) {
+	return result[*model.APIKey](a.r.record(ctx, APIKeyCreated, &apiKeyCreatedData{Key: key, Hash: hash}))
+}
+
+func (a apiKeyRepository) Touch(ctx context.Context, id int64) error {
+	_, err := a.r.record(ctx, APIKeyTouched, &idData{ID: id})
+	return err
+}
+
+func (a apiKeyRepository) Revoke(ctx context.Context, id int64) (*model.APIKey, error) {
+	return result[*model.APIKey](a.r.record(ctx, APIKeyRevoked, &idData{ID: id}))
+}
+
+func (w workspaceRepository) Create(ctx context.Context, name string) (*model.Workspace, error) {
+	return result[*model.Workspace](w.r.record(ctx, WorkspaceCreated, &nameData{Name: name}))
+}
+
+func (w workspaceRepository) AddMember(ctx context.Context, workspaceID, apiKeyID int64) error {
+	_, err := w.r.record(ctx, WorkspaceMemberAdded, &memberData{WorkspaceID: workspaceID, APIKeyID: apiKeyID})
+	return err
+}
+
+func (w workspaceRepository) RemoveMember(ctx context.Context, workspaceID, apiKeyID int64) error {
+	_, err := w.r.record(ctx, WorkspaceMemberRemoved, &memberData{WorkspaceID: workspaceID, APIKeyID: apiKeyID})
+	return err
+}
+
+func (u undoRepository) Push(ctx context.Context, entry *model.UndoEntry) error {
+	_, err := u.r.record(ctx, UndoPushed, &undoPushedData{Action: entry.Action, APIKeyID: entry.APIKeyID, Entry: entry})
+	return err
+}
+
+func (u undoRepository) Pop(ctx context.Context, apiKeyID int64, since time.Time) (*model.UndoEntry, error) {
+	return result[*model.UndoEntry](u.r.record(ctx, UndoPopped, &undoPoppedData{APIKeyID: apiKeyID, Since: since}))
+}
+
+func (u undoRepository) Prune(ctx context.Context, before time.Time) error {
+	_, err := u.r.record(ctx, UndoPruned, &undoPrunedData{Before: before})
+	return err
+}
+
+func (l lockRepository) Set(ctx context.Context, lock *model.TODOLock) error {
+	_, err := l.r.record(ctx, LockSet, lock)
+	return err
+}
+
+func (l lockRepository) Delete(ctx context.Context, ids []int64) error {
+	_, err := l.r.record(ctx, LocksDeleted, &idsData{IDs: ids})
+	return err
+}
@@ -0,0 +1,179 @@
+package eventlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+)
+
+// A Log stores events in the order they are appended. Events are never
+// changed or removed once appended.
+type Log interface {
+	// Append stores events after the existing ones, all or none of them,
+	// and sets their Seq.
+	Append(events []*Event) error
+	// Read calls fn with every event, oldest first, and stops with the error of fn.
+	Read(fn func(*Event) error) error
+}
+
+// A MemoryLog is a Log in process memory, lost when the process exits.
+// It is safe for concurrent use.
+type MemoryLog struct {
+	mu     sync.RWMutex
+	events []*Event
+}
+
+var _ Log = (*MemoryLog)(nil)
+
+// NewMemoryLog returns an empty MemoryLog.
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{}
+}
+
+// Append stores copies of events.
+func (l *MemoryLog) Append(events []*Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, e := range events {
+		e.Seq = int64(len(l.events)) + 1
+		c := *e
+		l.events = append(l.events, &c)
+	}
+	return nil
+}
+
+// Read calls fn with copies of the events.
+func (l *MemoryLog) Read(fn func(*Event) error) error {
+	l.mu.RLock()
+	events := l.events[:len(l.events):len(l.events)]
+	l.mu.RUnlock()
+
+	for _, e := range events {
+		c := *e
+		if err := fn(&c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A FileLog is a Log in a file of JSON Lines, one event per line, which
+// can be read with tools such as jq. It is safe for concurrent use within
+// one process; the file must not be written by other processes.
+type FileLog struct {
+	mu   sync.Mutex
+	f    *os.File
+	last int64 //最後のイベントのSeq
+	end  int64 //最後の行の直後の位置
+}
+
+var _ Log = (*FileLog)(nil)
+
+// OpenFileLog opens the log file of path, creating it if needed.
+// A line left incomplete by a crash during Append is truncated.
+func OpenFileLog(path string) (*FileLog, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("eventlog: failed to open %s: %w", path, err)
+	}
+	l := &FileLog{f: f}
+	//末尾まで読み、書き込みが完了した最後の行の直後から追記する
+	err = l.scan(func(e *Event, offset int64) error {
+		l.last, l.end = e.Seq, offset
+		return nil
+	})
+	if err == nil {
+		err = l.rewind()
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("eventlog: failed to read %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// Append writes events in one write and syncs the file.
+func (l *FileLog) Append(events []*Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var buf bytes.Buffer
+	for i, e := range events {
+		e.Seq = l.last + int64(i) + 1
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+	//途中まで書き込まれた場合は、書き込む前の位置まで戻す
+	if _, err := l.f.Write(buf.Bytes()); err != nil {
+		l.rewind()
+		return err
+	}
+	if err := l.f.Sync(); err != nil {
+		l.rewind()
+		return err
+	}
+	l.last += int64(len(events))
+	l.end += int64(buf.Len())
+	return nil
+}
+
+// rewind truncates the file after the last complete line and moves the
+// offset of the writes there.
+func (l *FileLog) rewind() error {
+	if err := l.f.Truncate(l.end); err != nil {
+		return err
+	}
+	_, err := l.f.Seek(l.end, io.SeekStart)
+	return err
+}
+
+// Read reads the events from the file.
+func (l *FileLog) Read(fn func(*Event) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.scan(func(e *Event, _ int64) error {
+		return fn(e)
+	})
+}
+
+// scan calls fn with each complete line of the file and the offset right
+// after it. l.mu must be held or l not yet shared.
+func (l *FileLog) scan(fn func(e *Event, offset int64) error) error {
+	//追記位置を動かさないよう、ReadAtで先頭から読む
+	r := bufio.NewReader(io.NewSectionReader(l.f, 0, math.MaxInt64))
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			//改行で終わらない最後の行は、書き込み途中の行として無視する
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		offset += int64(len(line))
+		e := &Event{}
+		if err := json.Unmarshal(line, e); err != nil {
+			return fmt.Errorf("invalid event at offset %d: %w", offset-int64(len(line)), err)
+		}
+		if err := fn(e, offset); err != nil {
+			return err
+		}
+	}
+}
+
+// Close closes the file.
+func (l *FileLog) Close() error {
+	return l.f.Close()
+}
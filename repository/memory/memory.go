@@ -0,0 +1,1298 @@
+// Package memory implements the repository interfaces in process memory.
+// It needs neither CGO nor a database, which makes it handy for tests, CI and demos.
+// All data is lost when the process exits.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// A Repository implements repository.Repository in memory.
+// It is safe for concurrent use.
+type Repository struct {
+	mu   sync.RWMutex
+	inTx bool
+
+	todos         map[int64]*model.TODO
+	positions     map[int64]int64 //TODOのIDごとの手動の並び順
+	comments      map[int64]*model.Comment
+	projects      map[int64]*model.Project
+	apiKeys       map[int64]*apiKey
+	workspaces    map[int64]*model.Workspace
+	members       map[workspaceMember]bool
+	undo          []*undoEntry         //古い順
+	tombstones    map[int64]*tombstone //削除されたTODOのIDごと
+	templates     map[int64]*model.Template
+	locks         map[int64]*lock //TODOのIDごと
+	lastTODOID    int64
+	lastCommentID int64
+	lastProjectID int64
+	lastAPIKeyID  int64
+	lastWSID      int64
+	lastUndoID    int64
+	lastTmplID    int64
+}
+
+var _ repository.Repository = (*Repository)(nil)
+
+// New returns an empty Repository.
+func New() *Repository {
+	return &Repository{
+		todos:      map[int64]*model.TODO{},
+		positions:  map[int64]int64{},
+		comments:   map[int64]*model.Comment{},
+		projects:   map[int64]*model.Project{},
+		apiKeys:    map[int64]*apiKey{},
+		workspaces: map[int64]*model.Workspace{},
+		members:    map[workspaceMember]bool{},
+		templates:  map[int64]*model.Template{},
+		tombstones: map[int64]*tombstone{},
+		locks:      map[int64]*lock{},
+	}
+}
+
+// TODOs returns the TODORepository.
+func (r *Repository) TODOs() repository.TODORepository {
+	return todoRepository{r}
+}
+
+// Comments returns the CommentRepository.
+func (r *Repository) Comments() repository.CommentRepository {
+	return commentRepository{r}
+}
+
+// Projects returns the ProjectRepository.
+func (r *Repository) Projects() repository.ProjectRepository {
+	return projectRepository{r}
+}
+
+// APIKeys returns the APIKeyRepository.
+func (r *Repository) APIKeys() repository.APIKeyRepository {
+	return apiKeyRepository{r}
+}
+
+// Workspaces returns the WorkspaceRepository.
+func (r *Repository) Workspaces() repository.WorkspaceRepository {
+	return workspaceRepository{r}
+}
+
+// Undo returns the UndoRepository.
+func (r *Repository) Undo() repository.UndoRepository {
+	return undoRepository{r}
+}
+
+// Templates returns the TemplateRepository.
+func (r *Repository) Templates() repository.TemplateRepository {
+	return templateRepository{r}
+}
+
+// Locks returns the LockRepository.
+func (r *Repository) Locks() repository.LockRepository {
+	return lockRepository{r}
+}
+
+// WithTx runs fn against a copy of the data and replaces the data with the
+// copy only if fn succeeds. Other operations wait until fn returns, so
+// transactions are serializable.
+func (r *Repository) WithTx(ctx context.Context, fn func(r repository.Repository) error) error {
+	//既にトランザクション中の場合は、そのトランザクションに参加する
+	if r.inTx {
+		return fn(r)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	//パニックした場合もコピーが破棄されるだけで、元のデータは変更されない
+	tx := r.clone()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.todos, r.positions, r.comments, r.projects, r.apiKeys = tx.todos, tx.positions, tx.comments, tx.projects, tx.apiKeys
+	r.workspaces, r.members = tx.workspaces, tx.members
+	r.lastTODOID, r.lastCommentID, r.lastProjectID, r.lastAPIKeyID = tx.lastTODOID, tx.lastCommentID, tx.lastProjectID, tx.lastAPIKeyID
+	r.lastWSID = tx.lastWSID
+	r.undo, r.lastUndoID = tx.undo, tx.lastUndoID
+	r.templates, r.lastTmplID = tx.templates, tx.lastTmplID
+	r.tombstones, r.locks = tx.tombstones, tx.locks
+	return nil
+}
+
+// clone returns a deep copy of r for a transaction. r.mu must be held.
+func (r *Repository) clone() *Repository {
+	c := &Repository{
+		inTx:          true,
+		todos:         make(map[int64]*model.TODO, len(r.todos)),
+		positions:     make(map[int64]int64, len(r.positions)),
+		comments:      make(map[int64]*model.Comment, len(r.comments)),
+		projects:      make(map[int64]*model.Project, len(r.projects)),
+		apiKeys:       make(map[int64]*apiKey, len(r.apiKeys)),
+		workspaces:    make(map[int64]*model.Workspace, len(r.workspaces)),
+		members:       make(map[workspaceMember]bool, len(r.members)),
+		lastTODOID:    r.lastTODOID,
+		lastCommentID: r.lastCommentID,
+		lastProjectID: r.lastProjectID,
+		lastAPIKeyID:  r.lastAPIKeyID,
+		lastWSID:      r.lastWSID,
+		undo:          append([]*undoEntry(nil), r.undo...),
+		lastUndoID:    r.lastUndoID,
+		templates:     make(map[int64]*model.Template, len(r.templates)),
+		tombstones:    make(map[int64]*tombstone, len(r.tombstones)),
+		locks:         make(map[int64]*lock, len(r.locks)),
+		lastTmplID:    r.lastTmplID,
+	}
+	for id, todo := range r.todos {
+		t := *todo
+		c.todos[id] = &t
+	}
+	for id, pos := range r.positions {
+		c.positions[id] = pos
+	}
+	for id, comment := range r.comments {
+		cm := *comment
+		c.comments[id] = &cm
+	}
+	for id, project := range r.projects {
+		p := *project
+		c.projects[id] = &p
+	}
+	for id, key := range r.apiKeys {
+		k := *key
+		c.apiKeys[id] = &k
+	}
+	for id, ws := range r.workspaces {
+		w := *ws
+		c.workspaces[id] = &w
+	}
+	for m := range r.members {
+		c.members[m] = true
+	}
+	//テンプレートの項目は作成後に変更されないため、スライスを共有する
+	for id, template := range r.templates {
+		t := *template
+		c.templates[id] = &t
+	}
+	for id, ts := range r.tombstones {
+		t := *ts
+		c.tombstones[id] = &t
+	}
+	for id, l := range r.locks {
+		c.locks[id] = l
+	}
+	return c
+}
+
+type nowContextKey struct{}
+
+// WithNow returns a copy of ctx with which the Repository stamps the changes
+// with t instead of the current time, so that recorded changes can be applied
+// again with their original times.
+func WithNow(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, nowContextKey{}, t)
+}
+
+// clock returns the time set by WithNow, or the current time.
+func clock(ctx context.Context) time.Time {
+	if t, ok := ctx.Value(nowContextKey{}).(time.Time); ok {
+		return t
+	}
+	return time.Now()
+}
+
+// now returns the time of clock in the precision stored by the SQL databases.
+func now(ctx context.Context) time.Time {
+	return clock(ctx).UTC().Truncate(time.Second)
+}
+
+// Errors returned for values the SQL schema rejects with a constraint.
+var (
+	errEmptySubject = errors.New("memory: subject must not be empty")
+	errEmptyBody    = errors.New("memory: body must not be empty")
+	errEmptyName    = errors.New("memory: name must not be empty")
+	errDuplicateKey = errors.New("memory: key hash must be unique")
+)
+
+type todoRepository struct {
+	*Repository
+}
+
+func (r todoRepository) Create(ctx context.Context, todo *model.TODO) (*model.TODO, error) {
+	if todo.Subject == "" {
+		return nil, errEmptySubject
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastTODOID++
+	t := now(ctx)
+	created := &model.TODO{
+		ID:          r.lastTODOID,
+		Subject:     todo.Subject,
+		Description: todo.Description,
+		CreatedAt:   t,
+		UpdatedAt:   t,
+		Done:        todo.Done,
+		Priority:    todo.Priority,
+		ProjectID:   todo.ProjectID,
+		WorkspaceID: repository.WorkspaceID(ctx),
+	}
+	//新しいTODOは手動の並び順でもワークスペースの先頭に置く
+	first := int64(0)
+	for id, pos := range r.positions {
+		if r.todos[id].WorkspaceID == created.WorkspaceID {
+			first = min(first, pos)
+		}
+	}
+	r.todos[created.ID] = created
+	r.positions[created.ID] = first - repository.PositionGap
+	return r.copyTODO(created), nil
+}
+
+func (r todoRepository) Find(ctx context.Context, id int64) (*model.TODO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todo, ok := r.todo(ctx, id)
+	if !ok {
+		return nil, &model.ErrNotFound{Resource: "TODO"}
+	}
+	return r.copyTODO(todo), nil
+}
+
+// todo returns the TODO of id if it belongs to the workspace of ctx. r.mu must be held.
+func (r *Repository) todo(ctx context.Context, id int64) (*model.TODO, bool) {
+	todo, ok := r.todos[id]
+	if !ok || todo.WorkspaceID != repository.WorkspaceID(ctx) {
+		return nil, false
+	}
+	return todo, true
+}
+
+func (r todoRepository) List(ctx context.Context, q *model.TODOQuery) ([]*model.TODO, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	less, err := lessTODO(q.Sort, r.positions)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := repository.WorkspaceID(ctx)
+	todos := []*model.TODO{}
+	for _, todo := range r.todos {
+		if todo.WorkspaceID == ws && matchTODO(todo, q) {
+			todos = append(todos, r.copyTODO(todo))
+		}
+	}
+	sort.Slice(todos, func(i, j int) bool { return less(todos[i], todos[j]) })
+	if q.Offset > 0 {
+		todos = todos[min(q.Offset, int64(len(todos))):]
+	}
+	if int64(len(todos)) > q.Size && q.Size >= 0 {
+		todos = todos[:q.Size]
+	}
+	return todos, nil
+}
+
+// Stream calls fn with a snapshot of the TODOs taken by List, so fn runs
+// without holding the lock.
+func (r todoRepository) Stream(ctx context.Context, q *model.TODOQuery, fn func(*model.TODO) error) error {
+	all := *q
+	if all.Size == 0 {
+		all.Size, all.Offset = -1, 0
+	}
+	todos, err := r.List(ctx, &all)
+	if err != nil {
+		return err
+	}
+	for _, todo := range todos {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(todo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r todoRepository) Count(ctx context.Context, q *model.TODOQuery) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	//ページの指定は無視して数える
+	cond := *q
+	cond.PrevID = 0
+	ws := repository.WorkspaceID(ctx)
+	var count int64
+	for _, todo := range r.todos {
+		if todo.WorkspaceID == ws && matchTODO(todo, &cond) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// matchTODO reports whether todo matches the conditions of q.
+func matchTODO(todo *model.TODO, q *model.TODOQuery) bool {
+	if q.PrevID > 0 && todo.ID >= q.PrevID {
+		return false
+	}
+	if q.Query != "" {
+		//大文字小文字を区別せずに部分一致で検索する
+		query := strings.ToLower(q.Query)
+		if !strings.Contains(strings.ToLower(todo.Subject), query) &&
+			!strings.Contains(strings.ToLower(todo.Description), query) {
+			return false
+		}
+	}
+	if todo.Archived != q.Archived {
+		return false
+	}
+	if q.Status != "" && todo.Done != (q.Status == model.TODOStatusDone) {
+		return false
+	}
+	if q.Priority != "" && todo.Priority != q.Priority {
+		return false
+	}
+	if q.ProjectID != 0 && todo.ProjectID != q.ProjectID {
+		return false
+	}
+	if q.AssigneeID != nil && todo.AssigneeID != *q.AssigneeID {
+		return false
+	}
+	if !q.CreatedAfter.IsZero() && todo.CreatedAt.Before(q.CreatedAfter) {
+		return false
+	}
+	if !q.CreatedBefore.IsZero() && !todo.CreatedAt.Before(q.CreatedBefore) {
+		return false
+	}
+	if !q.UpdatedAfter.IsZero() && todo.UpdatedAt.Before(q.UpdatedAfter) {
+		return false
+	}
+	if !q.UpdatedBefore.IsZero() && !todo.UpdatedAt.Before(q.UpdatedBefore) {
+		return false
+	}
+	return true
+}
+
+// lessTODO returns the less function of sorts, falling back to ID descending.
+func lessTODO(sorts []model.TODOSort, positions map[int64]int64) (func(a, b *model.TODO) bool, error) {
+	cmps := make([]func(a, b *model.TODO) int, 0, len(sorts))
+	for _, s := range sorts {
+		compare, ok := todoFields[s.Field]
+		if s.Field == "position" {
+			compare, ok = func(a, b *model.TODO) int { return cmp.Compare(positions[a.ID], positions[b.ID]) }, true
+		}
+		if !ok {
+			return nil, fmt.Errorf("memory: unknown sort field %q", s.Field)
+		}
+		if s.Desc {
+			asc := compare
+			compare = func(a, b *model.TODO) int { return -asc(a, b) }
+		}
+		cmps = append(cmps, compare)
+	}
+	return func(a, b *model.TODO) bool {
+		for _, compare := range cmps {
+			if c := compare(a, b); c != 0 {
+				return c < 0
+			}
+		}
+		return a.ID > b.ID
+	}, nil
+}
+
+// todoFields compares TODOs by the sortable fields.
+var todoFields = map[string]func(a, b *model.TODO) int{
+	"id":      func(a, b *model.TODO) int { return cmp.Compare(a.ID, b.ID) },
+	"subject": func(a, b *model.TODO) int { return strings.Compare(a.Subject, b.Subject) },
+	"priority": func(a, b *model.TODO) int {
+		return cmp.Compare(model.PriorityRank(a.Priority), model.PriorityRank(b.Priority))
+	},
+	"created_at": func(a, b *model.TODO) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"updated_at": func(a, b *model.TODO) int { return a.UpdatedAt.Compare(b.UpdatedAt) },
+}
+
+func (r todoRepository) Update(ctx context.Context, id int64, patch *model.TODOPatch) (*model.TODO, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todo(ctx, id)
+	if !ok {
+		return nil, &model.ErrNotFound{Resource: "TODO"}
+	}
+	if patch.Subject != nil && *patch.Subject == "" {
+		return nil, errEmptySubject
+	}
+	if patch.Subject != nil {
+		todo.Subject = *patch.Subject
+	}
+	if patch.Description != nil {
+		todo.Description = *patch.Description
+	}
+	if patch.Done != nil {
+		todo.Done = *patch.Done
+	}
+	if patch.Priority != nil {
+		todo.Priority = *patch.Priority
+	}
+	if patch.Archived != nil {
+		todo.Archived = *patch.Archived
+	}
+	if patch.ProjectID != nil {
+		todo.ProjectID = *patch.ProjectID
+	}
+	if patch.AssigneeID != nil {
+		todo.AssigneeID = *patch.AssigneeID
+	}
+	todo.UpdatedAt = now(ctx)
+	return r.copyTODO(todo), nil
+}
+
+func (r todoRepository) Delete(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	now := clock(ctx)
+	for _, id := range ids {
+		if _, ok := r.todo(ctx, id); !ok {
+			continue
+		}
+		delete(r.todos, id)
+		delete(r.positions, id)
+		r.tombstones[id] = &tombstone{workspaceID: repository.WorkspaceID(ctx), deletedAt: now}
+		deleted++
+		//TODOに付いているコメントも削除する
+		for cid, c := range r.comments {
+			if c.TODOID == id {
+				delete(r.comments, cid)
+			}
+		}
+	}
+	if deleted == 0 {
+		return &model.ErrNotFound{Resource: "TODO"}
+	}
+	return nil
+}
+
+func (r todoRepository) Positions(ctx context.Context, ids []int64) (map[int64]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	positions := make(map[int64]int64, len(ids))
+	for _, id := range ids {
+		if _, ok := r.todo(ctx, id); !ok {
+			return nil, &model.ErrNotFound{Resource: "TODO"}
+		}
+		positions[id] = r.positions[id]
+	}
+	return positions, nil
+}
+
+func (r todoRepository) Neighbor(ctx context.Context, pos int64, after bool, exclude int64) (int64, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ws := repository.WorkspaceID(ctx)
+	var neighbor int64
+	found := false
+	for id, p := range r.positions {
+		if id == exclude || r.todos[id].WorkspaceID != ws || (after && p <= pos) || (!after && p >= pos) {
+			continue
+		}
+		if !found || (after && p < neighbor) || (!after && p > neighbor) {
+			neighbor, found = p, true
+		}
+	}
+	return neighbor, found, nil
+}
+
+func (r todoRepository) SetPositions(ctx context.Context, positions map[int64]int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id := range positions {
+		if _, ok := r.todo(ctx, id); !ok {
+			return &model.ErrNotFound{Resource: "TODO"}
+		}
+	}
+	t := now(ctx)
+	for id, pos := range positions {
+		r.positions[id] = pos
+		r.todos[id].UpdatedAt = t
+	}
+	return nil
+}
+
+func (r todoRepository) Renumber(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ws := repository.WorkspaceID(ctx)
+	ids := make([]int64, 0, len(r.todos))
+	for id, todo := range r.todos {
+		if todo.WorkspaceID == ws {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if pi, pj := r.positions[ids[i]], r.positions[ids[j]]; pi != pj {
+			return pi < pj
+		}
+		return ids[i] > ids[j]
+	})
+	t := now(ctx)
+	for i, id := range ids {
+		r.positions[id] = int64(i+1) * repository.PositionGap
+		r.todos[id].UpdatedAt = t
+	}
+	return nil
+}
+
+func (r todoRepository) Restore(ctx context.Context, todo *model.TODO, position int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.todos[todo.ID]; ok {
+		return fmt.Errorf("memory: TODO %d already exists", todo.ID)
+	}
+	restored := *todo
+	restored.CommentCount = 0
+	restored.WorkspaceID = repository.WorkspaceID(ctx)
+	r.todos[todo.ID] = &restored
+	r.positions[todo.ID] = position
+	if ts, ok := r.tombstones[todo.ID]; ok {
+		ts.restoredAt = clock(ctx)
+	}
+	return nil
+}
+
+func (r todoRepository) Version(ctx context.Context) (*model.TODOVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ws := repository.WorkspaceID(ctx)
+	v := &model.TODOVersion{}
+	for _, todo := range r.todos {
+		if todo.WorkspaceID != ws {
+			continue
+		}
+		v.Count++
+		if todo.UpdatedAt.After(v.LastModified) {
+			v.LastModified = todo.UpdatedAt
+		}
+	}
+	for _, comment := range r.comments {
+		if comment.WorkspaceID != ws {
+			continue
+		}
+		v.CommentCount++
+		if comment.CreatedAt.After(v.LastModified) {
+			v.LastModified = comment.CreatedAt
+		}
+	}
+	return v, nil
+}
+
+// A tombstone records a deleted TODO. restoredAt is zero unless the deletion was undone.
+type tombstone struct {
+	workspaceID int64
+	deletedAt   time.Time
+	restoredAt  time.Time
+}
+
+func (r todoRepository) Changes(ctx context.Context, since time.Time) (*model.TODOChanges, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ws := repository.WorkspaceID(ctx)
+	c := &model.TODOChanges{Created: []int64{}, Updated: []int64{}, Deleted: []model.TODOTombstone{}}
+	for id, todo := range r.todos {
+		if todo.WorkspaceID != ws {
+			continue
+		}
+		//削除を取り消したTODOは、作成日時が古くても作成として扱う
+		ts, restored := r.tombstones[id]
+		restored = restored && !ts.restoredAt.Before(since)
+		switch {
+		case !todo.CreatedAt.Before(since) || restored:
+			c.Created = append(c.Created, id)
+		case !todo.UpdatedAt.Before(since):
+			c.Updated = append(c.Updated, id)
+		}
+	}
+	for id, ts := range r.tombstones {
+		if ts.workspaceID == ws && ts.restoredAt.IsZero() && !ts.deletedAt.Before(since) {
+			c.Deleted = append(c.Deleted, model.TODOTombstone{ID: id, DeletedAt: ts.deletedAt})
+		}
+	}
+	sort.Slice(c.Created, func(i, j int) bool { return c.Created[i] < c.Created[j] })
+	sort.Slice(c.Updated, func(i, j int) bool { return c.Updated[i] < c.Updated[j] })
+	sort.Slice(c.Deleted, func(i, j int) bool {
+		a, b := c.Deleted[i], c.Deleted[j]
+		if !a.DeletedAt.Equal(b.DeletedAt) {
+			return a.DeletedAt.Before(b.DeletedAt)
+		}
+		return a.ID < b.ID
+	})
+	return c, nil
+}
+
+func (r todoRepository) Stats(ctx context.Context, since time.Time) (*model.TODOStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ws := repository.WorkspaceID(ctx)
+	s := &model.TODOStats{ByPriority: map[string]int64{}, ByProject: []model.TODOProjectCount{}, CreatedPerDay: []model.TODODayCount{}}
+	projects := map[int64]int64{}
+	days := map[string]int64{}
+	for _, todo := range r.todos {
+		if todo.WorkspaceID != ws {
+			continue
+		}
+		s.Total++
+		if todo.Done {
+			s.Done++
+		}
+		if todo.Archived {
+			s.Archived++
+		}
+		s.ByPriority[todo.Priority]++
+		projects[todo.ProjectID]++
+		if !todo.CreatedAt.Before(since) {
+			days[todo.CreatedAt.UTC().Format(time.DateOnly)]++
+		}
+	}
+	for id, count := range projects {
+		s.ByProject = append(s.ByProject, model.TODOProjectCount{ProjectID: id, Count: count})
+	}
+	sort.Slice(s.ByProject, func(i, j int) bool { return s.ByProject[i].ProjectID < s.ByProject[j].ProjectID })
+	for date, count := range days {
+		s.CreatedPerDay = append(s.CreatedPerDay, model.TODODayCount{Date: date, Count: count})
+	}
+	sort.Slice(s.CreatedPerDay, func(i, j int) bool { return s.CreatedPerDay[i].Date < s.CreatedPerDay[j].Date })
+	return s, nil
+}
+
+// copyTODO returns a copy of todo with CommentCount filled. r.mu must be held.
+func (r *Repository) copyTODO(todo *model.TODO) *model.TODO {
+	c := *todo
+	c.CommentCount = 0
+	for _, comment := range r.comments {
+		if comment.TODOID == todo.ID {
+			c.CommentCount++
+		}
+	}
+	return &c
+}
+
+type commentRepository struct {
+	*Repository
+}
+
+func (r commentRepository) Create(ctx context.Context, todoID int64, body string) (*model.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todo(ctx, todoID)
+	if !ok {
+		return nil, &model.ErrNotFound{Resource: "TODO"}
+	}
+	if body == "" {
+		return nil, errEmptyBody
+	}
+
+	r.lastCommentID++
+	comment := &model.Comment{
+		ID:          r.lastCommentID,
+		TODOID:      todoID,
+		Body:        body,
+		CreatedAt:   now(ctx),
+		WorkspaceID: todo.WorkspaceID,
+	}
+	r.comments[comment.ID] = comment
+	c := *comment
+	return &c, nil
+}
+
+func (r commentRepository) Restore(ctx context.Context, comment *model.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todo, ok := r.todo(ctx, comment.TODOID)
+	if !ok {
+		return &model.ErrNotFound{Resource: "TODO"}
+	}
+	if _, ok := r.comments[comment.ID]; ok {
+		return fmt.Errorf("memory: comment %d already exists", comment.ID)
+	}
+	restored := *comment
+	restored.WorkspaceID = todo.WorkspaceID
+	r.comments[comment.ID] = &restored
+	return nil
+}
+
+func (r commentRepository) Read(ctx context.Context, todoID, prevID, size int64) ([]*model.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.todo(ctx, todoID); !ok {
+		return nil, &model.ErrNotFound{Resource: "TODO"}
+	}
+
+	comments := []*model.Comment{}
+	for _, comment := range r.comments {
+		if comment.TODOID != todoID || (prevID > 0 && comment.ID >= prevID) {
+			continue
+		}
+		c := *comment
+		comments = append(comments, &c)
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].ID > comments[j].ID })
+	if int64(len(comments)) > size && size >= 0 {
+		comments = comments[:size]
+	}
+	return comments, nil
+}
+
+func (r commentRepository) Delete(ctx context.Context, todoID, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment, ok := r.comments[id]
+	if !ok || comment.TODOID != todoID || comment.WorkspaceID != repository.WorkspaceID(ctx) {
+		return &model.ErrNotFound{Resource: "Comment"}
+	}
+	delete(r.comments, id)
+	return nil
+}
+
+type projectRepository struct {
+	*Repository
+}
+
+func (r projectRepository) Create(ctx context.Context, name, description string) (*model.Project, error) {
+	if name == "" {
+		return nil, errEmptyName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastProjectID++
+	t := now(ctx)
+	project := &model.Project{
+		ID:          r.lastProjectID,
+		Name:        name,
+		Description: description,
+		CreatedAt:   t,
+		UpdatedAt:   t,
+		WorkspaceID: repository.WorkspaceID(ctx),
+	}
+	r.projects[project.ID] = project
+	return r.copyProject(project), nil
+}
+
+func (r projectRepository) Read(ctx context.Context, prevID, size int64) ([]*model.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ws := repository.WorkspaceID(ctx)
+	projects := []*model.Project{}
+	for _, project := range r.projects {
+		if project.WorkspaceID != ws || (prevID > 0 && project.ID >= prevID) {
+			continue
+		}
+		projects = append(projects, r.copyProject(project))
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].ID > projects[j].ID })
+	if int64(len(projects)) > size && size >= 0 {
+		projects = projects[:size]
+	}
+	return projects, nil
+}
+
+func (r projectRepository) Find(ctx context.Context, id int64) (*model.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.project(ctx, id)
+	if !ok {
+		return nil, &model.ErrNotFound{Resource: "Project"}
+	}
+	return r.copyProject(project), nil
+}
+
+func (r projectRepository) Update(ctx context.Context, id int64, name, description string) (*model.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, ok := r.project(ctx, id)
+	if !ok {
+		return nil, &model.ErrNotFound{Resource: "Project"}
+	}
+	if name == "" {
+		return nil, errEmptyName
+	}
+	project.Name = name
+	project.Description = description
+	project.UpdatedAt = now(ctx)
+	return r.copyProject(project), nil
+}
+
+func (r projectRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.project(ctx, id); !ok {
+		return &model.ErrNotFound{Resource: "Project"}
+	}
+	delete(r.projects, id)
+	//属していたTODOはプロジェクトなしにする
+	t := now(ctx)
+	for _, todo := range r.todos {
+		if todo.ProjectID == id {
+			todo.ProjectID = 0
+			todo.UpdatedAt = t
+		}
+	}
+	return nil
+}
+
+// project returns the Project of id if it belongs to the workspace of ctx. r.mu must be held.
+func (r *Repository) project(ctx context.Context, id int64) (*model.Project, bool) {
+	project, ok := r.projects[id]
+	if !ok || project.WorkspaceID != repository.WorkspaceID(ctx) {
+		return nil, false
+	}
+	return project, true
+}
+
+// copyProject returns a copy of project with the counts filled. r.mu must be held.
+func (r *Repository) copyProject(project *model.Project) *model.Project {
+	c := *project
+	c.OpenCount, c.DoneCount = 0, 0
+	for _, todo := range r.todos {
+		if todo.ProjectID != project.ID {
+			continue
+		}
+		if todo.Done {
+			c.DoneCount++
+		} else {
+			c.OpenCount++
+		}
+	}
+	return &c
+}
+
+// An apiKey is a stored APIKey with the hash of the key.
+type apiKey struct {
+	model.APIKey
+	hash string
+}
+
+type apiKeyRepository struct {
+	*Repository
+}
+
+func (r apiKeyRepository) Create(ctx context.Context, key *model.APIKey, hash string) (*model.APIKey, error) {
+	if key.Name == "" {
+		return nil, errEmptyName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	//key_hashの一意制約に合わせる
+	for _, k := range r.apiKeys {
+		if k.hash == hash {
+			return nil, errDuplicateKey
+		}
+	}
+	r.lastAPIKeyID++
+	stored := &apiKey{
+		APIKey: model.APIKey{
+			ID:        r.lastAPIKeyID,
+			Name:      key.Name,
+			Prefix:    key.Prefix,
+			Role:      key.Role,
+			Scopes:    append([]string(nil), key.Scopes...),
+			CreatedAt: now(ctx),
+		},
+		hash: hash,
+	}
+	r.apiKeys[stored.ID] = stored
+	return stored.copy(), nil
+}
+
+func (r apiKeyRepository) Find(ctx context.Context, id int64) (*model.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	k, ok := r.apiKeys[id]
+	if !ok {
+		return nil, &model.ErrNotFound{Resource: "APIKey"}
+	}
+	return k.copy(), nil
+}
+
+func (r apiKeyRepository) FindByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, k := range r.apiKeys {
+		if k.hash == hash {
+			return k.copy(), nil
+		}
+	}
+	return nil, &model.ErrNotFound{Resource: "APIKey"}
+}
+
+func (r apiKeyRepository) Read(ctx context.Context) ([]*model.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]*model.APIKey, 0, len(r.apiKeys))
+	for _, k := range r.apiKeys {
+		keys = append(keys, k.copy())
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID > keys[j].ID })
+	return keys, nil
+}
+
+func (r apiKeyRepository) Touch(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if k, ok := r.apiKeys[id]; ok {
+		t := now(ctx)
+		k.LastUsedAt = &t
+	}
+	return nil
+}
+
+func (r apiKeyRepository) Revoke(ctx context.Context, id int64) (*model.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k, ok := r.apiKeys[id]
+	if !ok {
+		return nil, &model.ErrNotFound{Resource: "APIKey"}
+	}
+	if k.RevokedAt == nil {
+		t := now(ctx)
+		k.RevokedAt = &t
+	}
+	return k.copy(), nil
+}
+
+// copy returns a copy of the APIKey which shares nothing with k.
+func (k *apiKey) copy() *model.APIKey {
+	c := k.APIKey
+	c.Scopes = append([]string(nil), k.Scopes...)
+	return &c
+}
+
+// A workspaceMember is the key of Repository.members.
+type workspaceMember struct {
+	workspaceID, apiKeyID int64
+}
+
+type workspaceRepository struct {
+	*Repository
+}
+
+func (r workspaceRepository) Create(ctx context.Context, name string) (*model.Workspace, error) {
+	if name == "" {
+		return nil, errEmptyName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastWSID++
+	ws := &model.Workspace{
+		ID:        r.lastWSID,
+		Name:      name,
+		CreatedAt: now(ctx),
+	}
+	r.workspaces[ws.ID] = ws
+	c := *ws
+	return &c, nil
+}
+
+func (r workspaceRepository) Read(ctx context.Context) ([]*model.Workspace, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	workspaces := make([]*model.Workspace, 0, len(r.workspaces))
+	for _, ws := range r.workspaces {
+		c := *ws
+		workspaces = append(workspaces, &c)
+	}
+	sort.Slice(workspaces, func(i, j int) bool { return workspaces[i].ID > workspaces[j].ID })
+	return workspaces, nil
+}
+
+func (r workspaceRepository) Find(ctx context.Context, id int64) (*model.Workspace, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ws, ok := r.workspaces[id]
+	if !ok {
+		return nil, &model.ErrNotFound{Resource: "Workspace"}
+	}
+	c := *ws
+	return &c, nil
+}
+
+func (r workspaceRepository) AddMember(ctx context.Context, workspaceID, apiKeyID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.members[workspaceMember{workspaceID, apiKeyID}] = true
+	return nil
+}
+
+func (r workspaceRepository) RemoveMember(ctx context.Context, workspaceID, apiKeyID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := workspaceMember{workspaceID, apiKeyID}
+	if !r.members[m] {
+		return &model.ErrNotFound{Resource: "WorkspaceMember"}
+	}
+	delete(r.members, m)
+	return nil
+}
+
+func (r workspaceRepository) IsMember(ctx context.Context, workspaceID, apiKeyID int64) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.members[workspaceMember{workspaceID, apiKeyID}], nil
+}
+
+// An undoEntry is an UndoEntry with the workspace it belongs to.
+// The TODOs and comments of an entry are never modified once pushed, so
+// transactions share them.
+type undoEntry struct {
+	model.UndoEntry
+	workspaceID int64
+}
+
+type undoRepository struct {
+	*Repository
+}
+
+func (r undoRepository) Push(ctx context.Context, entry *model.UndoEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastUndoID++
+	e := &undoEntry{UndoEntry: *entry, workspaceID: repository.WorkspaceID(ctx)}
+	e.ID = r.lastUndoID
+	e.CreatedAt = now(ctx)
+	e.TODOs = make([]*model.TODO, len(entry.TODOs))
+	for i, todo := range entry.TODOs {
+		t := *todo
+		e.TODOs[i] = &t
+	}
+	e.Comments = make([]*model.Comment, len(entry.Comments))
+	for i, comment := range entry.Comments {
+		c := *comment
+		e.Comments[i] = &c
+	}
+	r.undo = append(r.undo, e)
+	return nil
+}
+
+func (r undoRepository) Pop(ctx context.Context, apiKeyID int64, since time.Time) (*model.UndoEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ws := repository.WorkspaceID(ctx)
+	for i := len(r.undo) - 1; i >= 0; i-- {
+		e := r.undo[i]
+		if e.workspaceID != ws || e.APIKeyID != apiKeyID || e.CreatedAt.Before(since) {
+			continue
+		}
+		//トランザクションのコピーと配列を共有しないよう、新しいスライスを作る
+		r.undo = append(r.undo[:i:i], r.undo[i+1:]...)
+		entry := e.UndoEntry
+		return &entry, nil
+	}
+	return nil, &model.ErrNotFound{Resource: "Undo entry"}
+}
+
+func (r undoRepository) Prune(ctx context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ws := repository.WorkspaceID(ctx)
+	kept := make([]*undoEntry, 0, len(r.undo))
+	for _, e := range r.undo {
+		if e.workspaceID != ws || !e.CreatedAt.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	r.undo = kept
+	return nil
+}
+
+// A lock is a TODOLock with the workspace it belongs to. Locks are never
+// modified once set, so transactions share them.
+type lock struct {
+	model.TODOLock
+	workspaceID int64
+}
+
+type lockRepository struct {
+	*Repository
+}
+
+func (r lockRepository) Find(ctx context.Context, todoID int64) (*model.TODOLock, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	l, ok := r.locks[todoID]
+	if !ok || l.workspaceID != repository.WorkspaceID(ctx) {
+		return nil, &model.ErrNotFound{Resource: "Lock"}
+	}
+	found := l.TODOLock
+	return &found, nil
+}
+
+func (r lockRepository) Set(ctx context.Context, l *model.TODOLock) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.locks[l.TODOID] = &lock{TODOLock: *l, workspaceID: repository.WorkspaceID(ctx)}
+	return nil
+}
+
+func (r lockRepository) Delete(ctx context.Context, ids []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ws := repository.WorkspaceID(ctx)
+	for _, id := range ids {
+		if l, ok := r.locks[id]; ok && l.workspaceID == ws {
+			delete(r.locks, id)
+		}
+	}
+	return nil
+}
+
+type templateRepository struct {
+	*Repository
+}
+
+func (r templateRepository) Create(ctx context.Context, template *model.Template) (*model.Template, error) {
+	if template.Name == "" {
+		return nil, errEmptyName
+	}
+	for _, item := range template.Items {
+		if item.Subject == "" {
+			return nil, errEmptySubject
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastTmplID++
+	created := &model.Template{
+		ID:          r.lastTmplID,
+		Name:        template.Name,
+		Description: template.Description,
+		Items:       append([]model.TemplateItem{}, template.Items...),
+		CreatedAt:   now(ctx),
+		WorkspaceID: repository.WorkspaceID(ctx),
+	}
+	r.templates[created.ID] = created
+	return copyTemplate(created), nil
+}
+
+func (r templateRepository) Read(ctx context.Context) ([]*model.Template, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ws := repository.WorkspaceID(ctx)
+	templates := []*model.Template{}
+	for _, template := range r.templates {
+		if template.WorkspaceID == ws {
+			templates = append(templates, copyTemplate(template))
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].ID > templates[j].ID })
+	return templates, nil
+}
+
+func (r templateRepository) Find(ctx context.Context, id int64) (*model.Template, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	template, ok := r.templates[id]
+	if !ok || template.WorkspaceID != repository.WorkspaceID(ctx) {
+		return nil, &model.ErrNotFound{Resource: "Template"}
+	}
+	return copyTemplate(template), nil
+}
+
+func (r templateRepository) UpdateItems(ctx context.Context, id int64, items []model.TemplateItem) error {
+	for _, item := range items {
+		if item.Subject == "" {
+			return errEmptySubject
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	template, ok := r.templates[id]
+	if !ok || template.WorkspaceID != repository.WorkspaceID(ctx) {
+		return &model.ErrNotFound{Resource: "Template"}
+	}
+	template.Items = append([]model.TemplateItem{}, items...)
+	return nil
+}
+
+func (r templateRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	template, ok := r.templates[id]
+	if !ok || template.WorkspaceID != repository.WorkspaceID(ctx) {
+		return &model.ErrNotFound{Resource: "Template"}
+	}
+	delete(r.templates, id)
+	return nil
+}
+
+// copyTemplate returns a copy of template which does not share the items.
+func copyTemplate(template *model.Template) *model.Template {
+	c := *template
+	c.Items = append([]model.TemplateItem{}, template.Items...)
+	return &c
+}
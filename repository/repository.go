@@ -0,0 +1,172 @@
+// Package repository defines the storage interfaces the service layer depends on.
+// Implementations live in the sub packages (e.g. sqlrepo).
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+// A Repository gives access to the repositories of one store.
+// TODOs, comments and projects are scoped to the workspace of the ctx passed
+// to each method; see WithWorkspace.
+type Repository interface {
+	TODOs() TODORepository
+	Comments() CommentRepository
+	Projects() ProjectRepository
+	APIKeys() APIKeyRepository
+	Workspaces() WorkspaceRepository
+	Undo() UndoRepository
+	Templates() TemplateRepository
+	Locks() LockRepository
+
+	// WithTx runs fn in a transaction. The Repository passed to fn operates
+	// inside the transaction, which is committed if fn returns nil and rolled
+	// back if fn returns an error, panics or ctx is canceled. The error of fn
+	// is returned as is. Calling WithTx inside fn joins the running transaction.
+	WithTx(ctx context.Context, fn func(r Repository) error) error
+}
+
+// PositionGap is the gap left between the sort positions of adjacent TODOs,
+// so a TODO can be moved between two others by updating only itself.
+const PositionGap = 1024
+
+// A TODORepository stores TODO entities.
+// Update and Delete return *model.ErrNotFound if no TODO matches.
+type TODORepository interface {
+	// Create stores a new TODO with the subject, description, done, priority
+	// and project of todo and returns it. The project is not checked.
+	Create(ctx context.Context, todo *model.TODO) (*model.TODO, error)
+	// List returns the TODOs matching q, newest first unless q.Sort is set.
+	// Archived TODOs are only returned if q.Archived is true.
+	// q.PrevID must only be used with the default sort.
+	List(ctx context.Context, q *model.TODOQuery) ([]*model.TODO, error)
+	// Stream calls fn with the TODOs matching q in the order of List, one at
+	// a time as they are read, and stops with the error of fn or when ctx is
+	// done. A q.Size of 0 means no limit; q.Offset is ignored without a size.
+	// fn must not use the repository, which may hold a connection meanwhile.
+	Stream(ctx context.Context, q *model.TODOQuery, fn func(*model.TODO) error) error
+	// Count returns the number of TODOs matching q, ignoring the page
+	// (PrevID, Offset and Size).
+	Count(ctx context.Context, q *model.TODOQuery) (int64, error)
+	// Find returns the TODO of id, or *model.ErrNotFound.
+	Find(ctx context.Context, id int64) (*model.TODO, error)
+	// Update applies the non-nil fields of patch to the TODO and returns it.
+	Update(ctx context.Context, id int64, patch *model.TODOPatch) (*model.TODO, error)
+	// Delete deletes the TODOs of ids.
+	Delete(ctx context.Context, ids []int64) error
+	// Restore stores a deleted TODO again with its ID, timestamps, fields
+	// and the sort position. The project is not checked.
+	Restore(ctx context.Context, todo *model.TODO, position int64) error
+	// Positions returns the sort positions of the TODOs of ids, lower first.
+	// It returns *model.ErrNotFound if any of them does not exist.
+	Positions(ctx context.Context, ids []int64) (map[int64]int64, error)
+	// Neighbor returns the position closest to pos in the direction of after
+	// (greater if true), ignoring the TODO of exclude. ok is false if there is none.
+	Neighbor(ctx context.Context, pos int64, after bool, exclude int64) (neighbor int64, ok bool, err error)
+	// SetPositions updates the sort positions of the TODOs.
+	SetPositions(ctx context.Context, positions map[int64]int64) error
+	// Renumber rewrites the positions of all TODOs keeping their order, with
+	// PositionGap between them. It is only needed once the gaps run out.
+	Renumber(ctx context.Context) error
+	// Version returns the counts and the last modification time of all TODOs
+	// and comments, which change whenever a list result may have changed.
+	Version(ctx context.Context) (*model.TODOVersion, error)
+	// Stats returns the counts of all TODOs. ByPriority is keyed by the raw
+	// priority ("" if not set), ByProject is ordered by project ID and
+	// CreatedPerDay only has the dates (UTC) since the time with TODOs, oldest first.
+	// Open is left 0.
+	Stats(ctx context.Context, since time.Time) (*model.TODOStats, error)
+	// Changes returns the IDs of the TODOs created (or restored) and updated
+	// at or after since, each ascending and in only one of them, and the
+	// TODOs deleted at or after since and not restored, oldest first.
+	// NextSince is left empty.
+	Changes(ctx context.Context, since time.Time) (*model.TODOChanges, error)
+}
+
+// A ProjectRepository stores Project entities with the counts of their TODOs.
+// Find, Update and Delete return *model.ErrNotFound if no Project matches.
+type ProjectRepository interface {
+	// Create stores a new Project and returns it.
+	Create(ctx context.Context, name, description string) (*model.Project, error)
+	// Read returns up to size Projects whose ID is less than prevID (all if prevID is 0), newest first.
+	Read(ctx context.Context, prevID, size int64) ([]*model.Project, error)
+	// Find returns the Project of id.
+	Find(ctx context.Context, id int64) (*model.Project, error)
+	// Update replaces the name and description of the Project and returns it.
+	Update(ctx context.Context, id int64, name, description string) (*model.Project, error)
+	// Delete deletes the Project. Its TODOs are kept without a project.
+	Delete(ctx context.Context, id int64) error
+}
+
+// A TemplateRepository stores Template entities with their items.
+// Find, UpdateItems and Delete return *model.ErrNotFound if no Template matches.
+type TemplateRepository interface {
+	// Create stores a new Template with the name, description and items of
+	// template and returns it. Items keep their order.
+	Create(ctx context.Context, template *model.Template) (*model.Template, error)
+	// Read returns all Templates, newest first.
+	Read(ctx context.Context) ([]*model.Template, error)
+	// Find returns the Template of id.
+	Find(ctx context.Context, id int64) (*model.Template, error)
+	// UpdateItems replaces the items of the Template of id with items,
+	// keeping their order.
+	UpdateItems(ctx context.Context, id int64, items []model.TemplateItem) error
+	// Delete deletes the Template and its items.
+	Delete(ctx context.Context, id int64) error
+}
+
+// An APIKeyRepository stores APIKey entities by the hash of the key.
+type APIKeyRepository interface {
+	// Create stores a new APIKey with the name, prefix, role and scopes of key and returns it.
+	Create(ctx context.Context, key *model.APIKey, hash string) (*model.APIKey, error)
+	// Find returns the APIKey of id, or *model.ErrNotFound.
+	Find(ctx context.Context, id int64) (*model.APIKey, error)
+	// FindByHash returns the APIKey of the hash, or *model.ErrNotFound.
+	FindByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	// Read returns all APIKeys including revoked ones, newest first.
+	Read(ctx context.Context) ([]*model.APIKey, error)
+	// Touch sets the last used time of the APIKey to now.
+	Touch(ctx context.Context, id int64) error
+	// Revoke marks the APIKey as revoked and returns it. Revoking twice keeps
+	// the first revocation time. It returns *model.ErrNotFound if no APIKey matches.
+	Revoke(ctx context.Context, id int64) (*model.APIKey, error)
+}
+
+// A CommentRepository stores Comment entities.
+// Every method returns *model.ErrNotFound if the TODO or Comment does not exist.
+type CommentRepository interface {
+	// Create stores a new Comment on the TODO and returns it.
+	Create(ctx context.Context, todoID int64, body string) (*model.Comment, error)
+	// Read returns up to size Comments of the TODO whose ID is less than prevID, newest first.
+	Read(ctx context.Context, todoID, prevID, size int64) ([]*model.Comment, error)
+	// Delete deletes the Comment of the TODO.
+	Delete(ctx context.Context, todoID, id int64) error
+	// Restore stores a deleted Comment again with its ID and creation time.
+	Restore(ctx context.Context, comment *model.Comment) error
+}
+
+// A LockRepository stores the locks of claimed TODOs, at most one per TODO.
+// Expired locks are kept until they are replaced or deleted.
+type LockRepository interface {
+	// Find returns the lock of the TODO, or *model.ErrNotFound if it has none.
+	Find(ctx context.Context, todoID int64) (*model.TODOLock, error)
+	// Set stores lock, replacing the lock of its TODO if any.
+	Set(ctx context.Context, lock *model.TODOLock) error
+	// Delete deletes the locks of the TODOs of ids, if any.
+	Delete(ctx context.Context, ids []int64) error
+}
+
+// An UndoRepository stores the undo log: snapshots of TODOs taken right
+// before they were updated or deleted, per workspace and API key.
+type UndoRepository interface {
+	// Push appends entry to the log of entry.APIKeyID.
+	Push(ctx context.Context, entry *model.UndoEntry) error
+	// Pop removes and returns the newest entry of the API key created at or
+	// after since. It returns *model.ErrNotFound if there is none.
+	Pop(ctx context.Context, apiKeyID int64, since time.Time) (*model.UndoEntry, error)
+	// Prune deletes the entries created before before.
+	Prune(ctx context.Context, before time.Time) error
+}
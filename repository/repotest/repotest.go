@@ -0,0 +1,1202 @@
+// Package repotest provides the behavioral test suite every repository
+// implementation must pass, so the storage backends stay interchangeable.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// Run runs the suite. newRepo must return an empty Repository on every call.
+func Run(t *testing.T, newRepo func(t *testing.T) repository.Repository) {
+	t.Helper()
+
+	cases := map[string]func(t *testing.T, repo repository.Repository){
+		"TODO create and read":       testTODOCreateRead,
+		"TODO pagination":            testTODOPagination,
+		"TODO offset and count":      testTODOOffsetCount,
+		"TODO search":                testTODOSearch,
+		"TODO stream":                testTODOStream,
+		"TODO update":                testTODOUpdate,
+		"TODO filter and sort":       testTODOFilterSort,
+		"TODO archive":               testTODOArchive,
+		"TODO assignee":              testTODOAssignee,
+		"TODO positions":             testTODOPositions,
+		"TODO delete":                testTODODelete,
+		"Comment create read delete": testComment,
+		"Project":                    testProject,
+		"APIKey":                     testAPIKey,
+		"Workspace":                  testWorkspace,
+		"Workspace isolation":        testWorkspaceIsolation,
+		"Transaction":                testTransaction,
+		"TODO version":               testTODOVersion,
+		"TODO stats":                 testTODOStats,
+		"TODO changes":               testTODOChanges,
+		"Undo log and restore":       testUndo,
+		"Template":                   testTemplate,
+		"Lock":                       testLock,
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			c(t, newRepo(t))
+		})
+	}
+}
+
+func mustCreate(t *testing.T, repo repository.Repository, subject, description string) *model.TODO {
+	t.Helper()
+	todo, err := repo.TODOs().Create(context.Background(), &model.TODO{Subject: subject, Description: description})
+	if err != nil {
+		t.Fatalf("failed to create TODO %q, err = %v", subject, err)
+	}
+	return todo
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func ids(todos []*model.TODO) []int64 {
+	ids := make([]int64, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	return ids
+}
+
+func equalIDs(got []*model.TODO, want ...int64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i].ID != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testTODOCreateRead(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	todo := mustCreate(t, repo, "subject", "description")
+	if todo.ID == 0 || todo.Subject != "subject" || todo.Description != "description" {
+		t.Errorf("unexpected created TODO, got = %+v", todo)
+	}
+	if todo.CreatedAt.IsZero() || todo.UpdatedAt.IsZero() {
+		t.Errorf("timestamps must be set, got = %+v", todo)
+	}
+
+	if _, err := repo.TODOs().Create(ctx, &model.TODO{Subject: "", Description: "description"}); err == nil {
+		t.Error("empty subject must be rejected")
+	}
+
+	todos, err := repo.TODOs().List(ctx, &model.TODOQuery{Size: 5})
+	if err != nil {
+		t.Fatal("failed to read TODOs, err =", err)
+	}
+	if !equalIDs(todos, todo.ID) || todos[0].Subject != "subject" {
+		t.Errorf("unexpected TODOs, got = %+v", todos)
+	}
+
+	found, err := repo.TODOs().Find(ctx, todo.ID)
+	if err != nil {
+		t.Fatal("failed to find TODO, err =", err)
+	}
+	if found.ID != todo.ID || found.Subject != "subject" || !found.CreatedAt.Equal(todo.CreatedAt) {
+		t.Errorf("unexpected found TODO, got = %+v", found)
+	}
+	_, err = repo.TODOs().Find(ctx, todo.ID+1)
+	if _, ok := err.(*model.ErrNotFound); !ok {
+		t.Errorf("unexpected error for unknown ID, got = %v", err)
+	}
+}
+
+func testTODOPagination(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	var created []int64
+	for _, s := range []string{"1", "2", "3", "4"} {
+		created = append(created, mustCreate(t, repo, s, "").ID)
+	}
+
+	todos, err := repo.TODOs().List(ctx, &model.TODOQuery{Size: 3})
+	if err != nil {
+		t.Fatal("failed to read TODOs, err =", err)
+	}
+	if !equalIDs(todos, created[3], created[2], created[1]) {
+		t.Errorf("unexpected first page, got = %v", ids(todos))
+	}
+
+	todos, err = repo.TODOs().List(ctx, &model.TODOQuery{PrevID: created[1], Size: 3})
+	if err != nil {
+		t.Fatal("failed to read TODOs, err =", err)
+	}
+	if !equalIDs(todos, created[0]) {
+		t.Errorf("unexpected second page, got = %v", ids(todos))
+	}
+}
+
+func testTODOOffsetCount(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	var created []int64
+	for _, s := range []string{"1", "2", "3", "4", "5"} {
+		created = append(created, mustCreate(t, repo, s, "").ID)
+	}
+
+	todos, err := repo.TODOs().List(ctx, &model.TODOQuery{Offset: 2, Size: 2})
+	if err != nil {
+		t.Fatal("failed to read TODOs, err =", err)
+	}
+	if !equalIDs(todos, created[2], created[1]) {
+		t.Errorf("unexpected page, got = %v", ids(todos))
+	}
+	todos, err = repo.TODOs().List(ctx, &model.TODOQuery{Offset: 10, Size: 2})
+	if err != nil || len(todos) != 0 {
+		t.Errorf("page beyond the end must be empty, got = %v, err = %v", ids(todos), err)
+	}
+
+	count, err := repo.TODOs().Count(ctx, &model.TODOQuery{PrevID: created[1], Offset: 2, Size: 1})
+	if err != nil || count != 5 {
+		t.Errorf("count must ignore the page, got = %d, err = %v", count, err)
+	}
+	count, err = repo.TODOs().Count(ctx, &model.TODOQuery{Query: "3"})
+	if err != nil || count != 1 {
+		t.Errorf("unexpected count of search, got = %d, err = %v", count, err)
+	}
+}
+
+func testTODOSearch(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	milk := mustCreate(t, repo, "Buy milk", "")
+	mustCreate(t, repo, "Write report", "quarterly")
+	tea := mustCreate(t, repo, "Shopping", "milk tea 100%")
+
+	todos, err := repo.TODOs().List(ctx, &model.TODOQuery{Query: "MILK", Size: 5})
+	if err != nil {
+		t.Fatal("failed to search TODOs, err =", err)
+	}
+	if !equalIDs(todos, tea.ID, milk.ID) {
+		t.Errorf("unexpected search result, got = %v", ids(todos))
+	}
+
+	todos, err = repo.TODOs().List(ctx, &model.TODOQuery{Query: "MILK", PrevID: tea.ID, Size: 5})
+	if err != nil {
+		t.Fatal("failed to search TODOs, err =", err)
+	}
+	if !equalIDs(todos, milk.ID) {
+		t.Errorf("unexpected search result with prev_id, got = %v", ids(todos))
+	}
+
+	// ワイルドカードはエスケープされる
+	todos, err = repo.TODOs().List(ctx, &model.TODOQuery{Query: "0%", Size: 5})
+	if err != nil {
+		t.Fatal("failed to search TODOs, err =", err)
+	}
+	if !equalIDs(todos, tea.ID) {
+		t.Errorf("unexpected search result for wildcard, got = %v", ids(todos))
+	}
+	if todos, err := repo.TODOs().List(ctx, &model.TODOQuery{Query: "_", Size: 5}); err != nil || len(todos) != 0 {
+		t.Errorf("unexpected search result for _, got = %v, err = %v", ids(todos), err)
+	}
+}
+
+func testTODOStream(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	a := mustCreate(t, repo, "a", "buy milk")
+	b := mustCreate(t, repo, "b", "")
+	c := mustCreate(t, repo, "c", "milk tea")
+
+	stream := func(ctx context.Context, q *model.TODOQuery) ([]*model.TODO, error) {
+		var todos []*model.TODO
+		err := repo.TODOs().Stream(ctx, q, func(todo *model.TODO) error {
+			todos = append(todos, todo)
+			return nil
+		})
+		return todos, err
+	}
+	for _, tt := range []struct {
+		q    model.TODOQuery
+		want []int64
+	}{
+		{model.TODOQuery{}, []int64{c.ID, b.ID, a.ID}},
+		{model.TODOQuery{Size: 2}, []int64{c.ID, b.ID}},
+		{model.TODOQuery{PrevID: c.ID}, []int64{b.ID, a.ID}},
+		{model.TODOQuery{Query: "MILK"}, []int64{c.ID, a.ID}},
+		{model.TODOQuery{Query: "milk", Size: 1, Offset: 1}, []int64{a.ID}},
+	} {
+		todos, err := stream(ctx, &tt.q)
+		if err != nil {
+			t.Fatalf("failed to stream TODOs of %+v, err = %v", tt.q, err)
+		}
+		if !equalIDs(todos, tt.want...) {
+			t.Errorf("unexpected TODOs of %+v, got = %v", tt.q, ids(todos))
+		}
+	}
+	if todos, _ := stream(ctx, &model.TODOQuery{Query: "milk"}); len(todos) == 2 && todos[1].Description != "buy milk" {
+		t.Errorf("unexpected description, got = %q", todos[1].Description)
+	}
+
+	//fnのエラーで読み込みを打ち切る
+	stop := errors.New("stop")
+	n := 0
+	err := repo.TODOs().Stream(ctx, &model.TODOQuery{}, func(*model.TODO) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("unexpected stop, n = %d, err = %v", n, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := stream(canceled, &model.TODOQuery{}); err == nil {
+		t.Error("streaming with a canceled context succeeded")
+	}
+}
+
+func testTODOUpdate(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	todo := mustCreate(t, repo, "subject", "description")
+	updated, err := repo.TODOs().Update(ctx, todo.ID, &model.TODOPatch{Subject: ptr("updated"), Description: ptr("")})
+	if err != nil {
+		t.Fatal("failed to update TODO, err =", err)
+	}
+	if updated.ID != todo.ID || updated.Subject != "updated" || updated.Description != "" {
+		t.Errorf("unexpected updated TODO, got = %+v", updated)
+	}
+	if !updated.CreatedAt.Equal(todo.CreatedAt) || updated.UpdatedAt.Before(todo.UpdatedAt) {
+		t.Errorf("unexpected timestamps, got = %+v, before = %+v", updated, todo)
+	}
+
+	_, err = repo.TODOs().Update(ctx, todo.ID+1, &model.TODOPatch{Subject: ptr("subject"), Description: ptr("")})
+	if _, ok := err.(*model.ErrNotFound); !ok {
+		t.Errorf("unexpected error for unknown ID, got = %v", err)
+	}
+	if _, err := repo.TODOs().Update(ctx, todo.ID, &model.TODOPatch{Subject: ptr(""), Description: ptr("")}); err == nil {
+		t.Error("empty subject must be rejected")
+	}
+
+	//nilのフィールドは変更されない
+	patched, err := repo.TODOs().Update(ctx, todo.ID, &model.TODOPatch{Done: ptr(true), Priority: ptr(model.PriorityHigh)})
+	if err != nil {
+		t.Fatal("failed to patch TODO, err =", err)
+	}
+	if patched.Subject != "updated" || !patched.Done || patched.Priority != model.PriorityHigh {
+		t.Errorf("unexpected patched TODO, got = %+v", patched)
+	}
+}
+
+func testTODOArchive(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	kept := mustCreate(t, repo, "kept", "")
+	archived := mustCreate(t, repo, "archived", "")
+	updated, err := repo.TODOs().Update(ctx, archived.ID, &model.TODOPatch{Archived: ptr(true)})
+	if err != nil {
+		t.Fatal("failed to archive TODO, err =", err)
+	}
+	if !updated.Archived || updated.Subject != "archived" {
+		t.Errorf("unexpected archived TODO, got = %+v", updated)
+	}
+
+	todos, err := repo.TODOs().List(ctx, &model.TODOQuery{Size: 5})
+	if err != nil || !equalIDs(todos, kept.ID) {
+		t.Errorf("archived TODOs must be excluded by default, got = %v, err = %v", ids(todos), err)
+	}
+	todos, err = repo.TODOs().List(ctx, &model.TODOQuery{Size: 5, Archived: true})
+	if err != nil || !equalIDs(todos, archived.ID) {
+		t.Errorf("unexpected archived TODOs, got = %v, err = %v", ids(todos), err)
+	}
+	if count, err := repo.TODOs().Count(ctx, &model.TODOQuery{}); err != nil || count != 1 {
+		t.Errorf("archived TODOs must not be counted by default, got = %d, err = %v", count, err)
+	}
+
+	//アーカイブ済みでもIDでは取得できる
+	if found, err := repo.TODOs().Find(ctx, archived.ID); err != nil || !found.Archived {
+		t.Errorf("unexpected found TODO, got = %+v, err = %v", found, err)
+	}
+}
+
+func testTODOAssignee(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	mine := mustCreate(t, repo, "mine", "")
+	theirs := mustCreate(t, repo, "theirs", "")
+	nobody := mustCreate(t, repo, "nobody", "")
+	for id, assignee := range map[int64]int64{mine.ID: 1, theirs.ID: 2} {
+		updated, err := repo.TODOs().Update(ctx, id, &model.TODOPatch{AssigneeID: ptr(assignee)})
+		if err != nil || updated.AssigneeID != assignee {
+			t.Fatalf("failed to assign TODO %d, got = %+v, err = %v", id, updated, err)
+		}
+	}
+
+	for assignee, want := range map[int64][]int64{1: {mine.ID}, 2: {theirs.ID}, 0: {nobody.ID}} {
+		todos, err := repo.TODOs().List(ctx, &model.TODOQuery{Size: 5, AssigneeID: ptr(assignee)})
+		if err != nil || !equalIDs(todos, want...) {
+			t.Errorf("unexpected TODOs of assignee %d, got = %v, err = %v", assignee, ids(todos), err)
+		}
+	}
+	if count, err := repo.TODOs().Count(ctx, &model.TODOQuery{AssigneeID: ptr(int64(1))}); err != nil || count != 1 {
+		t.Errorf("unexpected count of assignee 1, got = %d, err = %v", count, err)
+	}
+
+	//0を指定すると担当者を外す
+	updated, err := repo.TODOs().Update(ctx, mine.ID, &model.TODOPatch{AssigneeID: ptr(int64(0))})
+	if err != nil || updated.AssigneeID != 0 {
+		t.Errorf("failed to unassign TODO, got = %+v, err = %v", updated, err)
+	}
+}
+
+func testTODOPositions(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	a := mustCreate(t, repo, "a", "").ID
+	b := mustCreate(t, repo, "b", "").ID
+	c := mustCreate(t, repo, "c", "").ID
+
+	byPosition := &model.TODOQuery{Size: 5, Sort: []model.TODOSort{{Field: "position"}}}
+	todos, err := repo.TODOs().List(ctx, byPosition)
+	if err != nil || !equalIDs(todos, c, b, a) {
+		t.Errorf("new TODOs must come first, got = %v, err = %v", ids(todos), err)
+	}
+
+	positions, err := repo.TODOs().Positions(ctx, []int64{a, b, c})
+	if err != nil {
+		t.Fatal("failed to read positions, err =", err)
+	}
+	if !(positions[c] < positions[b] && positions[b] < positions[a]) {
+		t.Errorf("unexpected positions, got = %v", positions)
+	}
+	if _, err := repo.TODOs().Positions(ctx, []int64{a, c + 1}); err == nil {
+		t.Error("unknown IDs must be rejected")
+	}
+
+	neighbor, ok, err := repo.TODOs().Neighbor(ctx, positions[c], true, b)
+	if err != nil || !ok || neighbor != positions[a] {
+		t.Errorf("unexpected neighbor, got = %d, %v, err = %v", neighbor, ok, err)
+	}
+	if _, ok, err := repo.TODOs().Neighbor(ctx, positions[c], false, 0); err != nil || ok {
+		t.Errorf("the first TODO must have no neighbor before it, got = %v, err = %v", ok, err)
+	}
+
+	if err := repo.TODOs().SetPositions(ctx, map[int64]int64{a: positions[c] - 1}); err != nil {
+		t.Fatal("failed to set positions, err =", err)
+	}
+	if err := repo.TODOs().Renumber(ctx); err != nil {
+		t.Fatal("failed to renumber, err =", err)
+	}
+	todos, err = repo.TODOs().List(ctx, byPosition)
+	if err != nil || !equalIDs(todos, a, c, b) {
+		t.Errorf("unexpected order, got = %v, err = %v", ids(todos), err)
+	}
+	positions, err = repo.TODOs().Positions(ctx, []int64{a, b, c})
+	if err != nil || positions[c]-positions[a] != repository.PositionGap || positions[b]-positions[c] != repository.PositionGap {
+		t.Errorf("unexpected positions after renumbering, got = %v, err = %v", positions, err)
+	}
+}
+
+func testTODOFilterSort(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	create := func(subject, priority string, done bool) int64 {
+		t.Helper()
+		todo, err := repo.TODOs().Create(ctx, &model.TODO{Subject: subject, Priority: priority, Done: done})
+		if err != nil {
+			t.Fatalf("failed to create TODO %q, err = %v", subject, err)
+		}
+		if todo.Priority != priority || todo.Done != done {
+			t.Errorf("unexpected created TODO, got = %+v", todo)
+		}
+		return todo.ID
+	}
+	a := create("a", model.PriorityLow, true)
+	b := create("b", model.PriorityHigh, false)
+	c := create("c", model.PriorityMedium, false)
+	d := create("d", "", false)
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	tests := map[string]struct {
+		query *model.TODOQuery
+		want  []int64
+	}{
+		"done":            {&model.TODOQuery{Status: model.TODOStatusDone}, []int64{a}},
+		"open":            {&model.TODOQuery{Status: model.TODOStatusOpen}, []int64{d, c, b}},
+		"priority":        {&model.TODOQuery{Priority: model.PriorityHigh}, []int64{b}},
+		"created after":   {&model.TODOQuery{CreatedAfter: past, Status: model.TODOStatusOpen}, []int64{d, c, b}},
+		"created before":  {&model.TODOQuery{CreatedBefore: past}, []int64{}},
+		"updated after":   {&model.TODOQuery{UpdatedAfter: future}, []int64{}},
+		"updated before":  {&model.TODOQuery{UpdatedBefore: future, Priority: model.PriorityLow}, []int64{a}},
+		"sort by subject": {&model.TODOQuery{Sort: []model.TODOSort{{Field: "subject"}}}, []int64{a, b, c, d}},
+		"sort by priority": {
+			&model.TODOQuery{Sort: []model.TODOSort{{Field: "priority", Desc: true}}},
+			[]int64{b, c, a, d},
+		},
+		"sort with limit": {
+			&model.TODOQuery{Sort: []model.TODOSort{{Field: "created_at"}, {Field: "id"}}, Size: 2},
+			[]int64{a, b},
+		},
+	}
+	for name, tt := range tests {
+		if tt.query.Size == 0 {
+			tt.query.Size = 10
+		}
+		todos, err := repo.TODOs().List(ctx, tt.query)
+		if err != nil {
+			t.Errorf("%s: failed to list TODOs, err = %v", name, err)
+			continue
+		}
+		if !equalIDs(todos, tt.want...) {
+			t.Errorf("%s: unexpected TODOs, got = %v, want = %v", name, ids(todos), tt.want)
+		}
+	}
+
+	if _, err := repo.TODOs().List(ctx, &model.TODOQuery{Size: 10, Sort: []model.TODOSort{{Field: "description"}}}); err == nil {
+		t.Error("unknown sort field must be rejected")
+	}
+}
+
+func testTODODelete(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	first := mustCreate(t, repo, "1", "")
+	second := mustCreate(t, repo, "2", "")
+	third := mustCreate(t, repo, "3", "")
+
+	if err := repo.TODOs().Delete(ctx, nil); err != nil {
+		t.Error("deleting no IDs must succeed, err =", err)
+	}
+	if err := repo.TODOs().Delete(ctx, []int64{first.ID, third.ID}); err != nil {
+		t.Fatal("failed to delete TODOs, err =", err)
+	}
+	err := repo.TODOs().Delete(ctx, []int64{first.ID})
+	if _, ok := err.(*model.ErrNotFound); !ok {
+		t.Errorf("unexpected error for deleted ID, got = %v", err)
+	}
+
+	todos, err := repo.TODOs().List(ctx, &model.TODOQuery{Size: 5})
+	if err != nil {
+		t.Fatal("failed to read TODOs, err =", err)
+	}
+	if !equalIDs(todos, second.ID) {
+		t.Errorf("unexpected TODOs after delete, got = %v", ids(todos))
+	}
+}
+
+func testComment(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	todo := mustCreate(t, repo, "subject", "")
+	other := mustCreate(t, repo, "other", "")
+
+	first, err := repo.Comments().Create(ctx, todo.ID, "first")
+	if err != nil {
+		t.Fatal("failed to create comment, err =", err)
+	}
+	if first.TODOID != todo.ID || first.Body != "first" || first.CreatedAt.IsZero() {
+		t.Errorf("unexpected comment, got = %+v", first)
+	}
+	second, err := repo.Comments().Create(ctx, todo.ID, "second")
+	if err != nil {
+		t.Fatal("failed to create comment, err =", err)
+	}
+	if _, err := repo.Comments().Create(ctx, todo.ID, ""); err == nil {
+		t.Error("empty body must be rejected")
+	}
+	_, err = repo.Comments().Create(ctx, other.ID+100, "body")
+	if _, ok := err.(*model.ErrNotFound); !ok {
+		t.Errorf("unexpected error for unknown TODO, got = %v", err)
+	}
+
+	comments, err := repo.Comments().Read(ctx, todo.ID, 0, 1)
+	if err != nil {
+		t.Fatal("failed to read comments, err =", err)
+	}
+	if len(comments) != 1 || comments[0].ID != second.ID {
+		t.Errorf("unexpected comments, got = %+v", comments)
+	}
+	comments, err = repo.Comments().Read(ctx, todo.ID, second.ID, 5)
+	if err != nil {
+		t.Fatal("failed to read comments, err =", err)
+	}
+	if len(comments) != 1 || comments[0].ID != first.ID {
+		t.Errorf("unexpected comments with prev_id, got = %+v", comments)
+	}
+
+	todos, err := repo.TODOs().List(ctx, &model.TODOQuery{Size: 5})
+	if err != nil {
+		t.Fatal("failed to read TODOs, err =", err)
+	}
+	if !equalIDs(todos, other.ID, todo.ID) || todos[0].CommentCount != 0 || todos[1].CommentCount != 2 {
+		t.Errorf("unexpected comment counts, got = %+v", todos)
+	}
+
+	// 別のTODOのコメントは削除できない
+	err = repo.Comments().Delete(ctx, other.ID, first.ID)
+	if _, ok := err.(*model.ErrNotFound); !ok {
+		t.Errorf("unexpected error for comment of other TODO, got = %v", err)
+	}
+	if err := repo.Comments().Delete(ctx, todo.ID, first.ID); err != nil {
+		t.Error("failed to delete comment, err =", err)
+	}
+
+	// TODOを削除するとコメントも削除される
+	if err := repo.TODOs().Delete(ctx, []int64{todo.ID}); err != nil {
+		t.Fatal("failed to delete TODO, err =", err)
+	}
+	_, err = repo.Comments().Read(ctx, todo.ID, 0, 5)
+	if _, ok := err.(*model.ErrNotFound); !ok {
+		t.Errorf("unexpected error for deleted TODO, got = %v", err)
+	}
+}
+
+func testProject(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	project, err := repo.Projects().Create(ctx, "work", "description")
+	if err != nil {
+		t.Fatal("failed to create project, err =", err)
+	}
+	if project.ID == 0 || project.Name != "work" || project.CreatedAt.IsZero() {
+		t.Errorf("unexpected created project, got = %+v", project)
+	}
+	if _, err := repo.Projects().Create(ctx, "", ""); err == nil {
+		t.Error("empty name must be rejected")
+	}
+	other, err := repo.Projects().Create(ctx, "home", "")
+	if err != nil {
+		t.Fatal("failed to create project, err =", err)
+	}
+
+	open, err := repo.TODOs().Create(ctx, &model.TODO{Subject: "open", ProjectID: project.ID})
+	if err != nil || open.ProjectID != project.ID {
+		t.Fatalf("failed to create TODO in project, got = %+v, err = %v", open, err)
+	}
+	done, err := repo.TODOs().Create(ctx, &model.TODO{Subject: "done", Done: true, ProjectID: project.ID})
+	if err != nil {
+		t.Fatal("failed to create TODO, err =", err)
+	}
+	mustCreate(t, repo, "no project", "")
+
+	found, err := repo.Projects().Find(ctx, project.ID)
+	if err != nil || found.OpenCount != 1 || found.DoneCount != 1 {
+		t.Errorf("unexpected counts, got = %+v, err = %v", found, err)
+	}
+	projects, err := repo.Projects().Read(ctx, 0, 5)
+	if err != nil || len(projects) != 2 || projects[0].ID != other.ID || projects[1].OpenCount != 1 {
+		t.Errorf("unexpected projects, got = %+v, err = %v", projects, err)
+	}
+	if projects, err := repo.Projects().Read(ctx, other.ID, 5); err != nil || len(projects) != 1 {
+		t.Errorf("unexpected projects after prev_id, got = %+v, err = %v", projects, err)
+	}
+
+	todos, err := repo.TODOs().List(ctx, &model.TODOQuery{Size: 5, ProjectID: project.ID})
+	if err != nil || !equalIDs(todos, done.ID, open.ID) {
+		t.Errorf("unexpected TODOs of project, got = %v, err = %v", ids(todos), err)
+	}
+
+	//プロジェクトを移動すると件数も変わる
+	if _, err := repo.TODOs().Update(ctx, done.ID, &model.TODOPatch{ProjectID: ptr(other.ID)}); err != nil {
+		t.Fatal("failed to move TODO, err =", err)
+	}
+	if found, err := repo.Projects().Find(ctx, other.ID); err != nil || found.DoneCount != 1 {
+		t.Errorf("unexpected counts after move, got = %+v, err = %v", found, err)
+	}
+
+	updated, err := repo.Projects().Update(ctx, project.ID, "renamed", "")
+	if err != nil || updated.Name != "renamed" || updated.OpenCount != 1 {
+		t.Errorf("unexpected updated project, got = %+v, err = %v", updated, err)
+	}
+	if _, err := repo.Projects().Update(ctx, other.ID+1, "x", ""); err == nil {
+		t.Error("updating unknown project must fail")
+	}
+
+	//プロジェクトを削除してもTODOは残る
+	if err := repo.Projects().Delete(ctx, project.ID); err != nil {
+		t.Fatal("failed to delete project, err =", err)
+	}
+	if _, ok := repo.Projects().Delete(ctx, project.ID).(*model.ErrNotFound); !ok {
+		t.Error("deleting unknown project must return ErrNotFound")
+	}
+	if todo, err := repo.TODOs().Find(ctx, open.ID); err != nil || todo.ProjectID != 0 {
+		t.Errorf("TODO must be kept without project, got = %+v, err = %v", todo, err)
+	}
+}
+
+func testAPIKey(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	key, err := repo.APIKeys().Create(ctx, &model.APIKey{Name: "ci", Prefix: "gs_0000", Role: model.RoleAdmin, Scopes: []string{model.ScopeRead, model.ScopeWrite}}, "hash1")
+	if err != nil {
+		t.Fatal("failed to create API key, err =", err)
+	}
+	if key.ID == 0 || key.Prefix != "gs_0000" || !key.HasScope(model.ScopeWrite) || key.LastUsedAt != nil || key.Revoked() {
+		t.Errorf("unexpected created API key, got = %+v", key)
+	}
+	if _, err := repo.APIKeys().Create(ctx, &model.APIKey{Name: "dup", Prefix: "gs_0001", Scopes: []string{model.ScopeRead}}, "hash1"); err == nil {
+		t.Error("duplicate hash must be rejected")
+	}
+	other, err := repo.APIKeys().Create(ctx, &model.APIKey{Name: "reader", Prefix: "gs_0002", Role: model.RoleReadonly, Scopes: []string{model.ScopeRead}}, "hash2")
+	if err != nil {
+		t.Fatal("failed to create API key, err =", err)
+	}
+
+	found, err := repo.APIKeys().FindByHash(ctx, "hash2")
+	if err != nil || found.ID != other.ID || found.Role != model.RoleReadonly || found.HasScope(model.ScopeWrite) {
+		t.Errorf("unexpected API key, got = %+v, err = %v", found, err)
+	}
+	if _, err := repo.APIKeys().FindByHash(ctx, "unknown"); err == nil {
+		t.Error("unknown hash must not be found")
+	} else if _, ok := err.(*model.ErrNotFound); !ok {
+		t.Error("unknown hash must return ErrNotFound, err =", err)
+	}
+
+	if err := repo.APIKeys().Touch(ctx, key.ID); err != nil {
+		t.Fatal("failed to touch API key, err =", err)
+	}
+	revoked, err := repo.APIKeys().Revoke(ctx, key.ID)
+	if err != nil || !revoked.Revoked() || revoked.LastUsedAt == nil {
+		t.Errorf("unexpected revoked API key, got = %+v, err = %v", revoked, err)
+	}
+	//二度目の失効でも最初の失効日時が保たれる
+	again, err := repo.APIKeys().Revoke(ctx, key.ID)
+	if err != nil || !again.RevokedAt.Equal(*revoked.RevokedAt) {
+		t.Errorf("revoking twice must keep the time, got = %+v, err = %v", again, err)
+	}
+	if _, err := repo.APIKeys().Revoke(ctx, other.ID+1); err == nil {
+		t.Error("revoking unknown API key must fail")
+	}
+
+	keys, err := repo.APIKeys().Read(ctx)
+	if err != nil || len(keys) != 2 || keys[0].ID != other.ID || !keys[1].Revoked() {
+		t.Errorf("unexpected API keys, got = %+v, err = %v", keys, err)
+	}
+}
+
+func testWorkspace(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	ws, err := repo.Workspaces().Create(ctx, "team")
+	if err != nil || ws.ID == 0 || ws.Name != "team" {
+		t.Fatalf("failed to create workspace, got = %+v, err = %v", ws, err)
+	}
+	if _, err := repo.Workspaces().Create(ctx, ""); err == nil {
+		t.Error("empty name must be rejected")
+	}
+	if found, err := repo.Workspaces().Find(ctx, ws.ID); err != nil || found.Name != "team" {
+		t.Errorf("unexpected workspace, got = %+v, err = %v", found, err)
+	}
+	if _, err := repo.Workspaces().Find(ctx, ws.ID+1); err == nil {
+		t.Error("unknown workspace must not be found")
+	}
+
+	//メンバーの追加は冪等
+	for i := 0; i < 2; i++ {
+		if err := repo.Workspaces().AddMember(ctx, ws.ID, 7); err != nil {
+			t.Fatal("failed to add member, err =", err)
+		}
+	}
+	if ok, err := repo.Workspaces().IsMember(ctx, ws.ID, 7); err != nil || !ok {
+		t.Errorf("API key must be a member, got = %v, err = %v", ok, err)
+	}
+	if ok, err := repo.Workspaces().IsMember(ctx, ws.ID, 8); err != nil || ok {
+		t.Errorf("API key must not be a member, got = %v, err = %v", ok, err)
+	}
+	if err := repo.Workspaces().RemoveMember(ctx, ws.ID, 7); err != nil {
+		t.Error("failed to remove member, err =", err)
+	}
+	if _, ok := repo.Workspaces().RemoveMember(ctx, ws.ID, 7).(*model.ErrNotFound); !ok {
+		t.Error("removing non member must return ErrNotFound")
+	}
+	if workspaces, err := repo.Workspaces().Read(ctx); err != nil || len(workspaces) != 1 {
+		t.Errorf("unexpected workspaces, got = %+v, err = %v", workspaces, err)
+	}
+}
+
+// testWorkspaceIsolation proves that no method reads or writes the rows of
+// another workspace, even when given their IDs.
+func testWorkspaceIsolation(t *testing.T, repo repository.Repository) {
+	a := repository.WithWorkspace(context.Background(), 1)
+	b := repository.WithWorkspace(context.Background(), 2)
+
+	todoA, err := repo.TODOs().Create(a, &model.TODO{Subject: "a"})
+	if err != nil || todoA.WorkspaceID != 1 {
+		t.Fatalf("failed to create TODO, got = %+v, err = %v", todoA, err)
+	}
+	todoB, err := repo.TODOs().Create(b, &model.TODO{Subject: "b"})
+	if err != nil {
+		t.Fatal("failed to create TODO, err =", err)
+	}
+	if _, err := repo.Comments().Create(a, todoA.ID, "comment"); err != nil {
+		t.Fatal("failed to create comment, err =", err)
+	}
+	projectA, err := repo.Projects().Create(a, "project", "")
+	if err != nil {
+		t.Fatal("failed to create project, err =", err)
+	}
+
+	//一覧・件数・バージョンには他のワークスペースのデータが含まれない
+	if todos, err := repo.TODOs().List(b, &model.TODOQuery{Size: 10}); err != nil || !equalIDs(todos, todoB.ID) {
+		t.Errorf("unexpected TODOs of b, got = %v, err = %v", ids(todos), err)
+	}
+	if todos, err := repo.TODOs().List(context.Background(), &model.TODOQuery{Size: 10}); err != nil || len(todos) != 0 {
+		t.Errorf("default workspace must be empty, got = %v, err = %v", ids(todos), err)
+	}
+	if count, err := repo.TODOs().Count(b, &model.TODOQuery{}); err != nil || count != 1 {
+		t.Errorf("unexpected count of b, got = %d, err = %v", count, err)
+	}
+	if v, err := repo.TODOs().Version(b); err != nil || v.Count != 1 || v.CommentCount != 0 {
+		t.Errorf("unexpected version of b, got = %+v, err = %v", v, err)
+	}
+	if projects, err := repo.Projects().Read(b, 0, 10); err != nil || len(projects) != 0 {
+		t.Errorf("projects of a must not be listed in b, got = %+v, err = %v", projects, err)
+	}
+
+	//IDを指定しても他のワークスペースのデータには触れられない
+	notFound := func(name string, err error) {
+		t.Helper()
+		if _, ok := err.(*model.ErrNotFound); !ok {
+			t.Errorf("%s across workspaces must return ErrNotFound, err = %v", name, err)
+		}
+	}
+	_, err = repo.TODOs().Find(b, todoA.ID)
+	notFound("Find", err)
+	_, err = repo.TODOs().Update(b, todoA.ID, &model.TODOPatch{Subject: ptr("stolen")})
+	notFound("Update", err)
+	notFound("Delete", repo.TODOs().Delete(b, []int64{todoA.ID}))
+	_, err = repo.TODOs().Positions(b, []int64{todoA.ID})
+	notFound("Positions", err)
+	notFound("SetPositions", repo.TODOs().SetPositions(b, map[int64]int64{todoA.ID: 1}))
+	_, err = repo.Comments().Create(b, todoA.ID, "comment")
+	notFound("Comments().Create", err)
+	_, err = repo.Comments().Read(b, todoA.ID, 0, 10)
+	notFound("Comments().Read", err)
+	notFound("Comments().Delete", repo.Comments().Delete(b, todoA.ID, 1))
+	_, err = repo.Projects().Find(b, projectA.ID)
+	notFound("Projects().Find", err)
+	_, err = repo.Projects().Update(b, projectA.ID, "stolen", "")
+	notFound("Projects().Update", err)
+	notFound("Projects().Delete", repo.Projects().Delete(b, projectA.ID))
+	if _, ok, err := repo.TODOs().Neighbor(b, todoB.ID, true, todoB.ID); err != nil || ok {
+		t.Errorf("Neighbor must not see TODOs of a, got = %v, err = %v", ok, err)
+	}
+	if err := repo.TODOs().Renumber(b); err != nil {
+		t.Fatal("failed to renumber, err =", err)
+	}
+
+	//元のワークスペースでは変更されずに残っている
+	todo, err := repo.TODOs().Find(a, todoA.ID)
+	if err != nil || todo.Subject != "a" || todo.CommentCount != 1 {
+		t.Errorf("TODO of a must be intact, got = %+v, err = %v", todo, err)
+	}
+	if project, err := repo.Projects().Find(a, projectA.ID); err != nil || project.Name != "project" {
+		t.Errorf("project of a must be intact, got = %+v, err = %v", project, err)
+	}
+}
+
+func testTransaction(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+
+	// エラーを返した場合はロールバックされ、エラーはそのまま返される
+	err := repo.WithTx(ctx, func(r repository.Repository) error {
+		if _, err := r.TODOs().Create(ctx, &model.TODO{Subject: "rolled back"}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if err != errRollback {
+		t.Errorf("unexpected error, got = %v, want = %v", err, errRollback)
+	}
+
+	// コンテキストがキャンセルされた場合はコミットされない
+	canceled, cancel := context.WithCancel(ctx)
+	err = repo.WithTx(canceled, func(r repository.Repository) error {
+		if _, err := r.TODOs().Create(ctx, &model.TODO{Subject: "canceled"}); err != nil {
+			return err
+		}
+		cancel()
+		return nil
+	})
+	if err == nil {
+		t.Error("canceled transaction must return an error")
+	}
+
+	// パニックした場合もロールバックされる
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic must be propagated")
+			}
+		}()
+		repo.WithTx(ctx, func(r repository.Repository) error {
+			if _, err := r.TODOs().Create(ctx, &model.TODO{Subject: "panicked"}); err != nil {
+				return err
+			}
+			panic("panic in transaction")
+		})
+	}()
+
+	// 成功した場合はコミットされ、入れ子のWithTxは同じトランザクションに参加する
+	var committed *model.TODO
+	err = repo.WithTx(ctx, func(r repository.Repository) error {
+		return r.WithTx(ctx, func(r repository.Repository) error {
+			var err error
+			committed, err = r.TODOs().Create(ctx, &model.TODO{Subject: "committed"})
+			return err
+		})
+	})
+	if err != nil {
+		t.Fatal("failed to commit transaction, err =", err)
+	}
+
+	todos, err := repo.TODOs().List(ctx, &model.TODOQuery{Size: 5})
+	if err != nil {
+		t.Fatal("failed to read TODOs, err =", err)
+	}
+	if len(todos) != 1 || todos[0].ID != committed.ID {
+		t.Errorf("only the committed TODO must exist, got = %+v", todos)
+	}
+}
+
+func testTODOVersion(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	version := func() model.TODOVersion {
+		t.Helper()
+		v, err := repo.TODOs().Version(ctx)
+		if err != nil {
+			t.Fatal("failed to read version, err =", err)
+		}
+		return *v
+	}
+
+	if v := version(); v.Count != 0 || v.CommentCount != 0 || !v.LastModified.IsZero() {
+		t.Errorf("unexpected version of empty repository, got = %+v", v)
+	}
+
+	todo := mustCreate(t, repo, "subject", "")
+	v := version()
+	if v.Count != 1 || !v.LastModified.Equal(todo.UpdatedAt) {
+		t.Errorf("unexpected version after create, got = %+v", v)
+	}
+
+	comment, err := repo.Comments().Create(ctx, todo.ID, "comment")
+	if err != nil {
+		t.Fatal("failed to create comment, err =", err)
+	}
+	v = version()
+	if v.CommentCount != 1 || v.LastModified.Before(comment.CreatedAt) {
+		t.Errorf("unexpected version after comment, got = %+v", v)
+	}
+
+	if err := repo.TODOs().Delete(ctx, []int64{todo.ID}); err != nil {
+		t.Fatal("failed to delete TODO, err =", err)
+	}
+	if v := version(); v.Count != 0 || v.CommentCount != 0 {
+		t.Errorf("unexpected version after delete, got = %+v", v)
+	}
+}
+
+func testTODOStats(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	project, err := repo.Projects().Create(ctx, "work", "")
+	if err != nil {
+		t.Fatal("failed to create project, err =", err)
+	}
+	for _, todo := range []*model.TODO{
+		{Subject: "a", Priority: "high", ProjectID: project.ID},
+		{Subject: "b", Priority: "high", Done: true},
+		{Subject: "c"},
+	} {
+		if _, err := repo.TODOs().Create(ctx, todo); err != nil {
+			t.Fatal("failed to create TODO, err =", err)
+		}
+	}
+	archived := mustCreate(t, repo, "d", "")
+	if _, err := repo.TODOs().Update(ctx, archived.ID, &model.TODOPatch{Archived: ptr(true)}); err != nil {
+		t.Fatal("failed to archive TODO, err =", err)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	s, err := repo.TODOs().Stats(ctx, today.AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatal("failed to read stats, err =", err)
+	}
+	if s.Total != 4 || s.Done != 1 || s.Archived != 1 {
+		t.Errorf("unexpected totals, got = %+v", s)
+	}
+	if len(s.ByPriority) != 2 || s.ByPriority["high"] != 2 || s.ByPriority[""] != 2 {
+		t.Errorf("unexpected counts by priority, got = %v", s.ByPriority)
+	}
+	want := []model.TODOProjectCount{{ProjectID: 0, Count: 3}, {ProjectID: project.ID, Count: 1}}
+	if len(s.ByProject) != 2 || s.ByProject[0] != want[0] || s.ByProject[1] != want[1] {
+		t.Errorf("unexpected counts by project, got = %v, want = %v", s.ByProject, want)
+	}
+	if len(s.CreatedPerDay) != 1 || s.CreatedPerDay[0].Date != today.Format(time.DateOnly) || s.CreatedPerDay[0].Count != 4 {
+		t.Errorf("unexpected counts per day, got = %v", s.CreatedPerDay)
+	}
+
+	//期間より前に作成されたTODOは日ごとの件数に含めない
+	s, err = repo.TODOs().Stats(ctx, today.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal("failed to read stats, err =", err)
+	}
+	if s.Total != 4 || len(s.CreatedPerDay) != 0 {
+		t.Errorf("unexpected stats for future period, got = %+v", s)
+	}
+}
+
+func testTODOChanges(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	since := time.Now().Add(-time.Minute)
+	old := since.Add(-time.Hour)
+	created := mustCreate(t, repo, "created", "")
+	gone := mustCreate(t, repo, "gone", "")
+	//作成日時を過去にするため、削除されていないIDでRestoreする
+	for _, todo := range []*model.TODO{
+		{ID: 1000, Subject: "unchanged", CreatedAt: old, UpdatedAt: old},
+		{ID: 1001, Subject: "updated", CreatedAt: old, UpdatedAt: time.Now()},
+		{ID: 1002, Subject: "restored", CreatedAt: old, UpdatedAt: old},
+	} {
+		if err := repo.TODOs().Restore(ctx, todo, 0); err != nil {
+			t.Fatal("failed to insert TODO, err =", err)
+		}
+	}
+	if err := repo.TODOs().Delete(ctx, []int64{gone.ID, 1002}); err != nil {
+		t.Fatal("failed to delete TODOs, err =", err)
+	}
+	if err := repo.TODOs().Restore(ctx, &model.TODO{ID: 1002, Subject: "restored", CreatedAt: old, UpdatedAt: old}, 0); err != nil {
+		t.Fatal("failed to restore TODO, err =", err)
+	}
+
+	c, err := repo.TODOs().Changes(ctx, since)
+	if err != nil {
+		t.Fatal("failed to read changes, err =", err)
+	}
+	if len(c.Created) != 2 || c.Created[0] != created.ID || c.Created[1] != 1002 {
+		t.Errorf("unexpected created TODOs, got = %v, want = %v", c.Created, []int64{created.ID, 1002})
+	}
+	if len(c.Updated) != 1 || c.Updated[0] != 1001 {
+		t.Errorf("unexpected updated TODOs, got = %v, want = [1001]", c.Updated)
+	}
+	if len(c.Deleted) != 1 || c.Deleted[0].ID != gone.ID || c.Deleted[0].DeletedAt.Before(since) {
+		t.Errorf("unexpected deleted TODOs, got = %+v", c.Deleted)
+	}
+
+	//もう一度削除すると、取り消す前の記録は残らない
+	if err := repo.TODOs().Delete(ctx, []int64{1002}); err != nil {
+		t.Fatal("failed to delete TODO, err =", err)
+	}
+	c, err = repo.TODOs().Changes(ctx, since)
+	if err != nil {
+		t.Fatal("failed to read changes, err =", err)
+	}
+	if len(c.Created) != 1 || len(c.Deleted) != 2 {
+		t.Errorf("unexpected changes after second delete, got = %+v", c)
+	}
+
+	c, err = repo.TODOs().Changes(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal("failed to read changes, err =", err)
+	}
+	if len(c.Created) != 0 || len(c.Updated) != 0 || len(c.Deleted) != 0 {
+		t.Errorf("unexpected future changes, got = %+v", c)
+	}
+}
+
+func testUndo(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	todo, err := repo.TODOs().Create(ctx, &model.TODO{Subject: "subject", Description: "description", Priority: "high", Done: true})
+	if err != nil {
+		t.Fatal("failed to create TODO, err =", err)
+	}
+	comment, err := repo.Comments().Create(ctx, todo.ID, "comment")
+	if err != nil {
+		t.Fatal("failed to create comment, err =", err)
+	}
+	positions, err := repo.TODOs().Positions(ctx, []int64{todo.ID})
+	if err != nil {
+		t.Fatal("failed to read positions, err =", err)
+	}
+
+	start := time.Now().Add(-time.Second)
+	for _, entry := range []*model.UndoEntry{
+		{Action: model.UndoActionUpdate, APIKeyID: 1, TODOs: []*model.TODO{{ID: 99, Subject: "older"}}},
+		{Action: model.UndoActionDelete, APIKeyID: 1, TODOs: []*model.TODO{todo}, Positions: positions, Comments: []*model.Comment{comment}},
+		{Action: model.UndoActionUpdate, APIKeyID: 2, TODOs: []*model.TODO{{ID: 98}}},
+	} {
+		if err := repo.Undo().Push(ctx, entry); err != nil {
+			t.Fatal("failed to push undo entry, err =", err)
+		}
+	}
+	if err := repo.TODOs().Delete(ctx, []int64{todo.ID}); err != nil {
+		t.Fatal("failed to delete TODO, err =", err)
+	}
+
+	//最新のエントリから順に、APIキーごとに取り出される
+	entry, err := repo.Undo().Pop(ctx, 1, start)
+	if err != nil {
+		t.Fatal("failed to pop undo entry, err =", err)
+	}
+	if entry.Action != model.UndoActionDelete || len(entry.TODOs) != 1 || len(entry.Comments) != 1 || entry.Positions[todo.ID] != positions[todo.ID] {
+		t.Fatalf("unexpected undo entry, got = %+v", entry)
+	}
+	if err := repo.TODOs().Restore(ctx, entry.TODOs[0], entry.Positions[todo.ID]); err != nil {
+		t.Fatal("failed to restore TODO, err =", err)
+	}
+	if err := repo.Comments().Restore(ctx, entry.Comments[0]); err != nil {
+		t.Fatal("failed to restore comment, err =", err)
+	}
+	restored, err := repo.TODOs().Find(ctx, todo.ID)
+	if err != nil {
+		t.Fatal("failed to find restored TODO, err =", err)
+	}
+	if restored.Subject != todo.Subject || restored.CommentCount != 1 || !restored.Done || restored.Priority != "high" ||
+		!restored.CreatedAt.Equal(todo.CreatedAt) || !restored.UpdatedAt.Equal(todo.UpdatedAt) {
+		t.Errorf("unexpected restored TODO, got = %+v", restored)
+	}
+	if got, err := repo.TODOs().Positions(ctx, []int64{todo.ID}); err != nil || got[todo.ID] != positions[todo.ID] {
+		t.Errorf("unexpected restored position, got = %v, err = %v", got, err)
+	}
+
+	entry, err = repo.Undo().Pop(ctx, 1, start)
+	if err != nil || entry.TODOs[0].Subject != "older" {
+		t.Fatalf("unexpected second undo entry, got = %+v, err = %v", entry, err)
+	}
+	var nf *model.ErrNotFound
+	if _, err := repo.Undo().Pop(ctx, 1, start); !errors.As(err, &nf) {
+		t.Errorf("expected ErrNotFound for empty log, got = %v", err)
+	}
+
+	//期限切れのエントリは取り出されず、Pruneで削除される
+	if _, err := repo.Undo().Pop(ctx, 2, time.Now().Add(time.Hour)); !errors.As(err, &nf) {
+		t.Errorf("expected ErrNotFound for expired entry, got = %v", err)
+	}
+	if err := repo.Undo().Prune(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal("failed to prune undo log, err =", err)
+	}
+	if _, err := repo.Undo().Pop(ctx, 2, start); !errors.As(err, &nf) {
+		t.Errorf("expected ErrNotFound for pruned entry, got = %v", err)
+	}
+}
+
+func testTemplate(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+
+	items := []model.TemplateItem{{Subject: "first", Description: "d", Priority: "high"}, {Subject: "second"}}
+	template, err := repo.Templates().Create(ctx, &model.Template{Name: "weekly", Description: "description", Items: items})
+	if err != nil {
+		t.Fatal("failed to create template, err =", err)
+	}
+	if template.ID == 0 || template.Name != "weekly" || template.CreatedAt.IsZero() || len(template.Items) != 2 ||
+		template.Items[0] != items[0] || template.Items[1] != items[1] {
+		t.Errorf("unexpected created template, got = %+v", template)
+	}
+	if _, err := repo.Templates().Create(ctx, &model.Template{Name: ""}); err == nil {
+		t.Error("empty name must be rejected")
+	}
+	empty, err := repo.Templates().Create(ctx, &model.Template{Name: "empty"})
+	if err != nil {
+		t.Fatal("failed to create template, err =", err)
+	}
+
+	templates, err := repo.Templates().Read(ctx)
+	if err != nil {
+		t.Fatal("failed to read templates, err =", err)
+	}
+	if len(templates) != 2 || templates[0].ID != empty.ID || len(templates[0].Items) != 0 || len(templates[1].Items) != 2 || templates[1].Items[1].Subject != "second" {
+		t.Errorf("unexpected templates, got = %+v", templates)
+	}
+
+	//他のワークスペースからは見えない
+	other := repository.WithWorkspace(ctx, 1)
+	if _, err := repo.Templates().Find(other, template.ID); !errors.As(err, new(*model.ErrNotFound)) {
+		t.Errorf("expected ErrNotFound from other workspace, got = %v", err)
+	}
+	if err := repo.Templates().UpdateItems(other, template.ID, items); !errors.As(err, new(*model.ErrNotFound)) {
+		t.Errorf("expected ErrNotFound for updating items from other workspace, got = %v", err)
+	}
+
+	//項目は全て置き換えられ、指定した順に並ぶ
+	replaced := []model.TemplateItem{{Subject: "third", Description: "e"}, {Subject: "first", Priority: "low"}}
+	if err := repo.Templates().UpdateItems(ctx, template.ID, replaced); err != nil {
+		t.Fatal("failed to update template items, err =", err)
+	}
+	if found, err := repo.Templates().Find(ctx, template.ID); err != nil || !reflect.DeepEqual(found.Items, replaced) {
+		t.Errorf("unexpected items after update, got = %+v, err = %v", found, err)
+	}
+
+	if err := repo.Templates().Delete(ctx, template.ID); err != nil {
+		t.Fatal("failed to delete template, err =", err)
+	}
+	if _, err := repo.Templates().Find(ctx, template.ID); !errors.As(err, new(*model.ErrNotFound)) {
+		t.Errorf("expected ErrNotFound after delete, got = %v", err)
+	}
+	if err := repo.Templates().Delete(ctx, template.ID); !errors.As(err, new(*model.ErrNotFound)) {
+		t.Errorf("expected ErrNotFound for deleting twice, got = %v", err)
+	}
+}
+
+func testLock(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	var nf *model.ErrNotFound
+
+	a := mustCreate(t, repo, "a", "")
+	b := mustCreate(t, repo, "b", "")
+	if _, err := repo.Locks().Find(ctx, a.ID); !errors.As(err, &nf) {
+		t.Fatalf("expected ErrNotFound before locking, got = %v", err)
+	}
+
+	expires := time.Now().Add(time.Minute).Truncate(time.Second)
+	for _, lock := range []*model.TODOLock{
+		{TODOID: a.ID, HolderID: 1, ExpiresAt: expires.Add(-time.Hour)},
+		//同じTODOのロックは置き換える
+		{TODOID: a.ID, HolderID: 2, ExpiresAt: expires},
+		{TODOID: b.ID, HolderID: 0, ExpiresAt: expires},
+	} {
+		if err := repo.Locks().Set(ctx, lock); err != nil {
+			t.Fatal("failed to set lock, err =", err)
+		}
+	}
+	lock, err := repo.Locks().Find(ctx, a.ID)
+	if err != nil {
+		t.Fatal("failed to find lock, err =", err)
+	}
+	if lock.TODOID != a.ID || lock.HolderID != 2 || !lock.ExpiresAt.Equal(expires) {
+		t.Errorf("unexpected lock, got = %+v", lock)
+	}
+
+	//他のワークスペースからは見えない
+	other := repository.WithWorkspace(ctx, 1)
+	if _, err := repo.Locks().Find(other, a.ID); !errors.As(err, &nf) {
+		t.Errorf("expected ErrNotFound in another workspace, got = %v", err)
+	}
+	if err := repo.Locks().Delete(other, []int64{a.ID}); err != nil {
+		t.Fatal("failed to delete locks in another workspace, err =", err)
+	}
+
+	if err := repo.Locks().Delete(ctx, []int64{a.ID, b.ID, 999}); err != nil {
+		t.Fatal("failed to delete locks, err =", err)
+	}
+	for _, id := range []int64{a.ID, b.ID} {
+		if _, err := repo.Locks().Find(ctx, id); !errors.As(err, &nf) {
+			t.Errorf("expected ErrNotFound after deleting lock of %d, got = %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/db/dialect"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// selectAPIKey selects the columns scanned by scanAPIKey.
+const selectAPIKey = `SELECT id, name, prefix, role, scopes, created_at, last_used_at, revoked_at FROM api_keys`
+
+// An APIKeyRepository implements repository.APIKeyRepository.
+type APIKeyRepository struct {
+	q       dialect.Queryer
+	dialect dialect.Dialect
+}
+
+var _ repository.APIKeyRepository = (*APIKeyRepository)(nil)
+
+// Create creates an APIKey on DB.
+func (r *APIKeyRepository) Create(ctx context.Context, key *model.APIKey, hash string) (*model.APIKey, error) {
+	const insert = `INSERT INTO api_keys(name, prefix, role, key_hash, scopes) VALUES(?, ?, ?, ?, ?)`
+
+	//スコープはカンマ区切りで保存する
+	id, err := r.dialect.Insert(ctx, r.q, insert, key.Name, key.Prefix, key.Role, hash, strings.Join(key.Scopes, ","))
+	if err != nil {
+		return nil, err
+	}
+	return r.find(ctx, `id = ?`, id)
+}
+
+// Find reads the APIKey by id.
+func (r *APIKeyRepository) Find(ctx context.Context, id int64) (*model.APIKey, error) {
+	return r.find(ctx, `id = ?`, id)
+}
+
+// FindByHash reads the APIKey by the hash of the key.
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	return r.find(ctx, `key_hash = ?`, hash)
+}
+
+// Read reads APIKeys on DB.
+func (r *APIKeyRepository) Read(ctx context.Context) ([]*model.APIKey, error) {
+	rows, err := r.q.QueryContext(ctx, selectAPIKey+` ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //rowsを必ず閉じる
+
+	keys := []*model.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Touch updates the last used time of the APIKey on DB.
+func (r *APIKeyRepository) Touch(ctx context.Context, id int64) error {
+	const updateFmt = `UPDATE api_keys SET last_used_at = %s WHERE id = ?`
+
+	_, err := r.q.ExecContext(ctx, r.dialect.Rebind(fmt.Sprintf(updateFmt, r.dialect.Now())), id)
+	return err
+}
+
+// Revoke revokes the APIKey on DB.
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int64) (*model.APIKey, error) {
+	const updateFmt = `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, %s) WHERE id = ?`
+
+	//MySQLは値が変わらない行を件数に含めないため、RowsAffectedではなく再読み込みで存在を確認する
+	if _, err := r.q.ExecContext(ctx, r.dialect.Rebind(fmt.Sprintf(updateFmt, r.dialect.Now())), id); err != nil {
+		return nil, err
+	}
+	return r.find(ctx, `id = ?`, id)
+}
+
+func (r *APIKeyRepository) find(ctx context.Context, cond string, arg interface{}) (*model.APIKey, error) {
+	row := r.q.QueryRowContext(ctx, r.dialect.Rebind(selectAPIKey+` WHERE `+cond), arg)
+	key, err := scanAPIKey(row)
+	if err == sql.ErrNoRows {
+		return nil, &model.ErrNotFound{Resource: "APIKey"}
+	}
+	return key, err
+}
+
+func scanAPIKey(s scanner) (*model.APIKey, error) {
+	var (
+		key              model.APIKey
+		scopes           string
+		lastUsed, revoke sql.NullTime
+	)
+	if err := s.Scan(&key.ID, &key.Name, &key.Prefix, &key.Role, &scopes, &key.CreatedAt, &lastUsed, &revoke); err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Split(scopes, ",")
+	if lastUsed.Valid {
+		key.LastUsedAt = &lastUsed.Time
+	}
+	if revoke.Valid {
+		key.RevokedAt = &revoke.Time
+	}
+	return &key, nil
+}
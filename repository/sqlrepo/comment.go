@@ -0,0 +1,130 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/TechBowl-japan/go-stations/db/dialect"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// A CommentRepository implements repository.CommentRepository.
+type CommentRepository struct {
+	q       dialect.Queryer
+	dialect dialect.Dialect
+}
+
+var _ repository.CommentRepository = (*CommentRepository)(nil)
+
+// Create creates a Comment on the TODO.
+func (r *CommentRepository) Create(ctx context.Context, todoID int64, body string) (*model.Comment, error) {
+	const (
+		insert  = `INSERT INTO comments(todo_id, body, workspace_id) VALUES(?, ?, ?)`
+		confirm = `SELECT body, created_at FROM comments WHERE id = ?`
+	)
+	//コメント先のTODOが存在するかを確認
+	if err := r.existsTODO(ctx, todoID); err != nil {
+		return nil, err
+	}
+
+	//コメントを挿入
+	ws := repository.WorkspaceID(ctx)
+	id, err := r.dialect.Insert(ctx, r.q, insert, todoID, body, ws)
+	if err != nil {
+		return nil, err
+	}
+
+	//IDを使用してコメントを取得
+	comment := &model.Comment{
+		ID:          id,
+		TODOID:      todoID,
+		WorkspaceID: ws,
+	}
+	if err := r.q.QueryRowContext(ctx, r.dialect.Rebind(confirm), id).Scan(&comment.Body, &comment.CreatedAt); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// Read reads Comments of the TODO.
+func (r *CommentRepository) Read(ctx context.Context, todoID, prevID, size int64) ([]*model.Comment, error) {
+	const (
+		read       = `SELECT id, body, created_at FROM comments WHERE todo_id = ? AND workspace_id = ? ORDER BY id DESC LIMIT ?`
+		readWithID = `SELECT id, body, created_at FROM comments WHERE todo_id = ? AND workspace_id = ? AND id < ? ORDER BY id DESC LIMIT ?`
+	)
+	if err := r.existsTODO(ctx, todoID); err != nil {
+		return nil, err
+	}
+
+	ws := repository.WorkspaceID(ctx)
+	var rows *sql.Rows
+	var err error
+	if prevID > 0 {
+		rows, err = r.q.QueryContext(ctx, r.dialect.Rebind(readWithID), todoID, ws, prevID, size)
+	} else {
+		rows, err = r.q.QueryContext(ctx, r.dialect.Rebind(read), todoID, ws, size)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //rowsを必ず閉じる
+
+	comments := []*model.Comment{}
+	for rows.Next() {
+		comment := &model.Comment{
+			TODOID:      todoID,
+			WorkspaceID: ws,
+		}
+		if err := rows.Scan(&comment.ID, &comment.Body, &comment.CreatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// Delete deletes the Comment of the TODO.
+func (r *CommentRepository) Delete(ctx context.Context, todoID, id int64) error {
+	const deleteComment = `DELETE FROM comments WHERE todo_id = ? AND id = ? AND workspace_id = ?`
+
+	result, err := r.q.ExecContext(ctx, r.dialect.Rebind(deleteComment), todoID, id, repository.WorkspaceID(ctx))
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	//削除対象が見つからなかった場合は、ErrNotFoundを返す
+	if rowsAffected == 0 {
+		return &model.ErrNotFound{Resource: "Comment"}
+	}
+	return nil
+}
+
+// Restore inserts the deleted Comment with its original ID on DB.
+func (r *CommentRepository) Restore(ctx context.Context, comment *model.Comment) error {
+	const insert = `INSERT INTO comments(id, todo_id, body, workspace_id, created_at) VALUES(?, ?, ?, ?, ?)`
+
+	if err := r.existsTODO(ctx, comment.TODOID); err != nil {
+		return err
+	}
+	_, err := r.q.ExecContext(ctx, r.dialect.Rebind(insert), comment.ID, comment.TODOID, comment.Body, repository.WorkspaceID(ctx), r.dialect.Time(comment.CreatedAt))
+	return err
+}
+
+// existsTODO returns ErrNotFound if the TODO does not exist in the workspace.
+func (r *CommentRepository) existsTODO(ctx context.Context, todoID int64) error {
+	const exists = `SELECT 1 FROM todos WHERE id = ? AND workspace_id = ?`
+
+	var one int
+	err := r.q.QueryRowContext(ctx, r.dialect.Rebind(exists), todoID, repository.WorkspaceID(ctx)).Scan(&one)
+	if err == sql.ErrNoRows {
+		return &model.ErrNotFound{Resource: "TODO"}
+	}
+	return err
+}
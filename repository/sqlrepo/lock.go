@@ -0,0 +1,61 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/TechBowl-japan/go-stations/db/dialect"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// A LockRepository implements repository.LockRepository.
+type LockRepository struct {
+	q       dialect.Queryer
+	dialect dialect.Dialect
+}
+
+var _ repository.LockRepository = (*LockRepository)(nil)
+
+// Find reads the lock of the TODO on DB.
+func (r *LockRepository) Find(ctx context.Context, todoID int64) (*model.TODOLock, error) {
+	const find = `SELECT api_key_id, expires_at FROM todo_locks WHERE todo_id = ? AND workspace_id = ?`
+
+	lock := &model.TODOLock{TODOID: todoID}
+	err := r.q.QueryRowContext(ctx, r.dialect.Rebind(find), todoID, repository.WorkspaceID(ctx)).Scan(&lock.HolderID, &lock.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, &model.ErrNotFound{Resource: "Lock"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// Set replaces the lock of the TODO on DB.
+func (r *LockRepository) Set(ctx context.Context, lock *model.TODOLock) error {
+	const (
+		remove = `DELETE FROM todo_locks WHERE todo_id = ?`
+		insert = `INSERT INTO todo_locks(todo_id, workspace_id, api_key_id, expires_at) VALUES(?, ?, ?, ?)`
+	)
+
+	//UPSERTの構文はデータベースごとに異なるため、削除してから追加する
+	if _, err := r.q.ExecContext(ctx, r.dialect.Rebind(remove), lock.TODOID); err != nil {
+		return err
+	}
+	_, err := r.q.ExecContext(ctx, r.dialect.Rebind(insert), lock.TODOID, repository.WorkspaceID(ctx), lock.HolderID, r.dialect.Time(lock.ExpiresAt))
+	return err
+}
+
+// Delete deletes the locks of the TODOs on DB.
+func (r *LockRepository) Delete(ctx context.Context, ids []int64) error {
+	const deleteFmt = `DELETE FROM todo_locks WHERE todo_id IN (%s) AND workspace_id = ?`
+
+	if len(ids) == 0 {
+		return nil
+	}
+	marks, args := placeholders(ids)
+	_, err := r.q.ExecContext(ctx, r.dialect.Rebind(fmt.Sprintf(deleteFmt, marks)), append(args, repository.WorkspaceID(ctx))...)
+	return err
+}
@@ -0,0 +1,113 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// Positions reads the sort positions of the TODOs of ids on DB.
+func (r *TODORepository) Positions(ctx context.Context, ids []int64) (map[int64]int64, error) {
+	const selectFmt = `SELECT id, sort_order FROM todos WHERE id IN (%s) AND workspace_id = ?`
+
+	positions := make(map[int64]int64, len(ids))
+	if len(ids) == 0 {
+		return positions, nil
+	}
+	marks, args := placeholders(ids)
+	args = append(args, repository.WorkspaceID(ctx))
+	rows, err := r.q.QueryContext(ctx, r.dialect.Rebind(fmt.Sprintf(selectFmt, marks)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, pos int64
+		if err := rows.Scan(&id, &pos); err != nil {
+			return nil, err
+		}
+		positions[id] = pos
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	//一つでも存在しないTODOがあればErrNotFoundを返す
+	for _, id := range ids {
+		if _, ok := positions[id]; !ok {
+			return nil, &model.ErrNotFound{Resource: "TODO"}
+		}
+	}
+	return positions, nil
+}
+
+// Neighbor reads the position next to pos on DB.
+func (r *TODORepository) Neighbor(ctx context.Context, pos int64, after bool, exclude int64) (int64, bool, error) {
+	query := `SELECT sort_order FROM todos WHERE sort_order < ? AND id <> ? AND workspace_id = ? ORDER BY sort_order DESC LIMIT 1`
+	if after {
+		query = `SELECT sort_order FROM todos WHERE sort_order > ? AND id <> ? AND workspace_id = ? ORDER BY sort_order ASC LIMIT 1`
+	}
+
+	var neighbor int64
+	err := r.q.QueryRowContext(ctx, r.dialect.Rebind(query), pos, exclude, repository.WorkspaceID(ctx)).Scan(&neighbor)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return neighbor, true, nil
+}
+
+// SetPositions updates the sort positions of the TODOs on DB.
+// updated_at is also updated so conditional requests notice the new order.
+func (r *TODORepository) SetPositions(ctx context.Context, positions map[int64]int64) error {
+	update := `UPDATE todos SET sort_order = ?, updated_at = ` + r.dialect.Now() + ` WHERE id = ? AND workspace_id = ?`
+
+	ws := repository.WorkspaceID(ctx)
+	for id, pos := range positions {
+		result, err := r.q.ExecContext(ctx, r.dialect.Rebind(update), pos, id, ws)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return &model.ErrNotFound{Resource: "TODO"}
+		}
+	}
+	return nil
+}
+
+// Renumber rewrites the sort positions of all TODOs of the workspace on DB.
+func (r *TODORepository) Renumber(ctx context.Context) error {
+	const query = `SELECT id FROM todos WHERE workspace_id = ? ORDER BY sort_order ASC, id DESC`
+
+	rows, err := r.q.QueryContext(ctx, r.dialect.Rebind(query), repository.WorkspaceID(ctx))
+	if err != nil {
+		return err
+	}
+	//更新前に全て読み込んでから閉じる(同じ接続でUPDATEを実行するため)
+	positions := map[int64]int64{}
+	var pos int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		pos += repository.PositionGap
+		positions[id] = pos
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return r.SetPositions(ctx, positions)
+}
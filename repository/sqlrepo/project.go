@@ -0,0 +1,123 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/TechBowl-japan/go-stations/db/dialect"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// selectProject selects the columns scanned by scanProject.
+const selectProject = `SELECT id, name, description, created_at, updated_at, workspace_id, ` +
+	`(SELECT COUNT(*) FROM todos WHERE todos.project_id = projects.id AND done = ?), ` +
+	`(SELECT COUNT(*) FROM todos WHERE todos.project_id = projects.id AND done = ?) FROM projects`
+
+// A ProjectRepository implements repository.ProjectRepository.
+type ProjectRepository struct {
+	q       dialect.Queryer
+	dialect dialect.Dialect
+}
+
+var _ repository.ProjectRepository = (*ProjectRepository)(nil)
+
+// Create creates a Project on DB.
+func (r *ProjectRepository) Create(ctx context.Context, name, description string) (*model.Project, error) {
+	const insert = `INSERT INTO projects(name, description, workspace_id) VALUES(?, ?, ?)`
+
+	id, err := r.dialect.Insert(ctx, r.q, insert, name, description, repository.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return r.Find(ctx, id)
+}
+
+// Read reads Projects on DB.
+func (r *ProjectRepository) Read(ctx context.Context, prevID, size int64) ([]*model.Project, error) {
+	//件数のサブクエリの引数(未完了、完了済み)を先頭に置く
+	query := selectProject + ` WHERE workspace_id = ?`
+	args := []interface{}{false, true, repository.WorkspaceID(ctx)}
+	if prevID > 0 {
+		query += ` AND id < ?`
+		args = append(args, prevID)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, size)
+
+	rows, err := r.q.QueryContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //rowsを必ず閉じる
+
+	projects := []*model.Project{}
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// Find reads the Project by id.
+func (r *ProjectRepository) Find(ctx context.Context, id int64) (*model.Project, error) {
+	row := r.q.QueryRowContext(ctx, r.dialect.Rebind(selectProject+` WHERE id = ? AND workspace_id = ?`), false, true, id, repository.WorkspaceID(ctx))
+	project, err := scanProject(row)
+	if err == sql.ErrNoRows {
+		return nil, &model.ErrNotFound{Resource: "Project"}
+	}
+	return project, err
+}
+
+// Update updates the Project on DB.
+func (r *ProjectRepository) Update(ctx context.Context, id int64, name, description string) (*model.Project, error) {
+	const updateFmt = `UPDATE projects SET name = ?, description = ?, updated_at = %s WHERE id = ? AND workspace_id = ?`
+
+	update := fmt.Sprintf(updateFmt, r.dialect.Now())
+	result, err := r.q.ExecContext(ctx, r.dialect.Rebind(update), name, description, id, repository.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, &model.ErrNotFound{Resource: "Project"}
+	}
+	return r.Find(ctx, id)
+}
+
+// Delete deletes the Project on DB.
+func (r *ProjectRepository) Delete(ctx context.Context, id int64) error {
+	const deleteProject = `DELETE FROM projects WHERE id = ? AND workspace_id = ?`
+
+	result, err := r.q.ExecContext(ctx, r.dialect.Rebind(deleteProject), id, repository.WorkspaceID(ctx))
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	//削除対象が見つからなかった場合は、ErrNotFoundを返す
+	if rowsAffected == 0 {
+		return &model.ErrNotFound{Resource: "Project"}
+	}
+	return nil
+}
+
+func scanProject(s scanner) (*model.Project, error) {
+	project := &model.Project{}
+	if err := s.Scan(&project.ID, &project.Name, &project.Description, &project.CreatedAt, &project.UpdatedAt, &project.WorkspaceID, &project.OpenCount, &project.DoneCount); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
@@ -0,0 +1,94 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/db/dialect"
+)
+
+const (
+	// maxBusyAttempts is the number of attempts made while the database is busy.
+	maxBusyAttempts = 5
+	// busyBackoff is the base delay between the attempts, doubled on each retry.
+	busyBackoff = 20 * time.Millisecond
+)
+
+// busyMetrics are published at /debug/vars under "sqlrepo_busy":
+// "retries" counts the retried attempts, "recovered" the operations that
+// succeeded after retrying and "exhausted" those that gave up.
+var busyMetrics = expvar.NewMap("sqlrepo_busy")
+
+// retryBusy calls fn until it succeeds, fails with an error other than a busy
+// error of d, ctx is done or maxBusyAttempts is reached.
+// 待ち時間は指数的に増やし、同時に失敗したリクエストが揃って再試行しないようランダムにずらす
+func retryBusy(ctx context.Context, d dialect.Dialect, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !d.Busy(err) {
+			if err == nil && attempt > 1 {
+				busyMetrics.Add("recovered", 1)
+			}
+			return err
+		}
+		if attempt == maxBusyAttempts {
+			busyMetrics.Add("exhausted", 1)
+			slog.WarnContext(ctx, "Database is busy, giving up", "attempts", attempt, "err", err)
+			return err
+		}
+
+		busyMetrics.Add("retries", 1)
+		backoff := busyBackoff << (attempt - 1)
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// A retryQueryer retries the statements run outside transactions while the
+// database is busy. Statements in transactions are not retried one by one;
+// WithTx retries the whole transaction instead.
+type retryQueryer struct {
+	db      *sql.DB
+	dialect dialect.Dialect
+}
+
+var _ dialect.Queryer = (*retryQueryer)(nil)
+
+func (q *retryQueryer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := retryBusy(ctx, q.dialect, func() (err error) {
+		result, err = q.db.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+func (q *retryQueryer) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := retryBusy(ctx, q.dialect, func() (err error) {
+		rows, err = q.db.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowContext retries if the query itself fails as busy.
+// Errors occurring later in Scan are returned as they are.
+func (q *retryQueryer) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	var row *sql.Row
+	retryBusy(ctx, q.dialect, func() error {
+		row = q.db.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
@@ -0,0 +1,56 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/db/dialect"
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestRetryBusy(t *testing.T) {
+	busy := sqlite3.Error{Code: sqlite3.ErrBusy}
+	other := errors.New("constraint failed")
+
+	cases := map[string]struct {
+		Errs         []error
+		WantAttempts int
+		WantErr      error
+	}{
+		"Success":         {Errs: []error{nil}, WantAttempts: 1},
+		"Recovered":       {Errs: []error{busy, busy, nil}, WantAttempts: 3},
+		"Other error":     {Errs: []error{other}, WantAttempts: 1, WantErr: other},
+		"Busy then other": {Errs: []error{busy, other}, WantAttempts: 2, WantErr: other},
+		"Exhausted":       {Errs: []error{busy, busy, busy, busy, busy, nil}, WantAttempts: maxBusyAttempts, WantErr: busy},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			attempts := 0
+			err := retryBusy(context.Background(), dialect.SQLite, func() error {
+				attempts++
+				return c.Errs[attempts-1]
+			})
+			if attempts != c.WantAttempts {
+				t.Errorf("unexpected attempts, got = %d", attempts)
+			}
+			if !errors.Is(err, c.WantErr) {
+				t.Errorf("unexpected error, got = %v", err)
+			}
+		})
+	}
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		attempts := 0
+		err := retryBusy(ctx, dialect.SQLite, func() error {
+			attempts++
+			return busy
+		})
+		if attempts != 1 || !dialect.SQLite.Busy(err) {
+			t.Errorf("must stop retrying, attempts = %d, err = %v", attempts, err)
+		}
+	})
+}
@@ -0,0 +1,150 @@
+// Package sqlrepo implements the repository interfaces on database/sql.
+// It works with every database supported by the dialect package.
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+
+	"github.com/TechBowl-japan/go-stations/db/dialect"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// A Repository implements repository.Repository on *sql.DB.
+type Repository struct {
+	db         *sql.DB
+	tx         *sql.Tx //トランザクション中の場合のみ設定される
+	dialect    dialect.Dialect
+	todos      *TODORepository
+	comments   *CommentRepository
+	projects   *ProjectRepository
+	apiKeys    *APIKeyRepository
+	workspaces *WorkspaceRepository
+	undo       *UndoRepository
+	templates  *TemplateRepository
+	locks      *LockRepository
+}
+
+var _ repository.Repository = (*Repository)(nil)
+
+// New returns a Repository on db. The dialect is detected from the driver of db.
+func New(db *sql.DB) *Repository {
+	return newRepository(db, nil, dialect.Detect(db))
+}
+
+func newRepository(db *sql.DB, tx *sql.Tx, d dialect.Dialect) *Repository {
+	var q dialect.Queryer = &retryQueryer{db: db, dialect: d}
+	if tx != nil {
+		q = tx
+	}
+	return &Repository{
+		db:         db,
+		tx:         tx,
+		dialect:    d,
+		todos:      &TODORepository{q: q, dialect: d},
+		comments:   &CommentRepository{q: q, dialect: d},
+		projects:   &ProjectRepository{q: q, dialect: d},
+		apiKeys:    &APIKeyRepository{q: q, dialect: d},
+		workspaces: &WorkspaceRepository{q: q, dialect: d},
+		undo:       &UndoRepository{q: q, dialect: d},
+		templates:  &TemplateRepository{q: q, dialect: d},
+		locks:      &LockRepository{q: q, dialect: d},
+	}
+}
+
+// WithTx runs fn in a database transaction.
+// If the database is busy, the transaction is rolled back and fn is run again
+// in a new transaction, so fn must not have side effects outside of it.
+func (r *Repository) WithTx(ctx context.Context, fn func(r repository.Repository) error) error {
+	//既にトランザクション中の場合は、そのトランザクションに参加する
+	if r.tx != nil {
+		return fn(r)
+	}
+	return retryBusy(ctx, r.dialect, func() error {
+		return r.withTx(ctx, fn)
+	})
+}
+
+func (r *Repository) withTx(ctx context.Context, fn func(r repository.Repository) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		//パニックした場合もロールバックしてから再度パニックさせる
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(newRepository(r.db, tx, r.dialect)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && rbErr != sql.ErrTxDone {
+			slog.ErrorContext(ctx, "Rollback failed", "err", rbErr)
+		}
+		return err
+	}
+	//コンテキストがキャンセルされた場合はコミットしない
+	if err := ctx.Err(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// TODOs returns the TODORepository.
+func (r *Repository) TODOs() repository.TODORepository {
+	return r.todos
+}
+
+// Comments returns the CommentRepository.
+func (r *Repository) Comments() repository.CommentRepository {
+	return r.comments
+}
+
+// Projects returns the ProjectRepository.
+func (r *Repository) Projects() repository.ProjectRepository {
+	return r.projects
+}
+
+// APIKeys returns the APIKeyRepository.
+func (r *Repository) APIKeys() repository.APIKeyRepository {
+	return r.apiKeys
+}
+
+// Workspaces returns the WorkspaceRepository.
+func (r *Repository) Workspaces() repository.WorkspaceRepository {
+	return r.workspaces
+}
+
+// Undo returns the UndoRepository.
+func (r *Repository) Undo() repository.UndoRepository {
+	return r.undo
+}
+
+// Templates returns the TemplateRepository.
+func (r *Repository) Templates() repository.TemplateRepository {
+	return r.templates
+}
+
+// Locks returns the LockRepository.
+func (r *Repository) Locks() repository.LockRepository {
+	return r.locks
+}
+
+// placeholders returns "?,?,?" for n arguments and converts ids into []interface{}.
+// ExecContextは引数に[]interface{}型を必要とするため、変換して返す
+func placeholders(ids []int64) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","), args
+}
+
+// escapeLike escapes the LIKE wildcards in s using ! as the escape character.
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
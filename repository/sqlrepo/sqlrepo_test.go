@@ -0,0 +1,22 @@
+package sqlrepo_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/repository/repotest"
+	"github.com/TechBowl-japan/go-stations/repository/sqlrepo"
+)
+
+func TestRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.Repository {
+		d, err := db.NewDB(filepath.Join(t.TempDir(), "sqlrepo_test.db"))
+		if err != nil {
+			t.Fatal("failed to open database, err =", err)
+		}
+		t.Cleanup(func() { d.Close() })
+		return sqlrepo.New(d)
+	})
+}
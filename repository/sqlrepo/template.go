@@ -0,0 +1,170 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/TechBowl-japan/go-stations/db/dialect"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// A TemplateRepository implements repository.TemplateRepository.
+type TemplateRepository struct {
+	q       dialect.Queryer
+	dialect dialect.Dialect
+}
+
+var _ repository.TemplateRepository = (*TemplateRepository)(nil)
+
+// Create creates a Template and its items on DB.
+func (r *TemplateRepository) Create(ctx context.Context, template *model.Template) (*model.Template, error) {
+	const (
+		insert     = `INSERT INTO templates(name, description, workspace_id) VALUES(?, ?, ?)`
+		insertItem = `INSERT INTO template_items(template_id, subject, description, priority) VALUES(?, ?, ?, ?)`
+	)
+
+	id, err := r.dialect.Insert(ctx, r.q, insert, template.Name, template.Description, repository.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	//項目はidの順に並べるため、指定された順に挿入する
+	for _, item := range template.Items {
+		if _, err := r.q.ExecContext(ctx, r.dialect.Rebind(insertItem), id, item.Subject, item.Description, item.Priority); err != nil {
+			return nil, err
+		}
+	}
+	return r.Find(ctx, id)
+}
+
+// Read reads Templates on DB.
+func (r *TemplateRepository) Read(ctx context.Context) ([]*model.Template, error) {
+	const (
+		read      = `SELECT id, name, description, created_at, workspace_id FROM templates WHERE workspace_id = ? ORDER BY id DESC`
+		readItems = `SELECT template_id, subject, description, priority FROM template_items ` +
+			`WHERE template_id IN (SELECT id FROM templates WHERE workspace_id = ?) ORDER BY id`
+	)
+
+	ws := repository.WorkspaceID(ctx)
+	rows, err := r.q.QueryContext(ctx, r.dialect.Rebind(read), ws)
+	if err != nil {
+		return nil, err
+	}
+	templates := []*model.Template{}
+	byID := map[int64]*model.Template{}
+	for rows.Next() {
+		template := &model.Template{Items: []model.TemplateItem{}}
+		if err := rows.Scan(&template.ID, &template.Name, &template.Description, &template.CreatedAt, &template.WorkspaceID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		templates = append(templates, template)
+		byID[template.ID] = template
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+
+	//項目は1回のクエリでまとめて読み出し、テンプレートごとに振り分ける
+	rows, err = r.q.QueryContext(ctx, r.dialect.Rebind(readItems), ws)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var templateID int64
+		var item model.TemplateItem
+		if err := rows.Scan(&templateID, &item.Subject, &item.Description, &item.Priority); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if template, ok := byID[templateID]; ok {
+			template.Items = append(template.Items, item)
+		}
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// Find reads the Template by id with its items.
+func (r *TemplateRepository) Find(ctx context.Context, id int64) (*model.Template, error) {
+	const (
+		find      = `SELECT id, name, description, created_at, workspace_id FROM templates WHERE id = ? AND workspace_id = ?`
+		readItems = `SELECT subject, description, priority FROM template_items WHERE template_id = ? ORDER BY id`
+	)
+
+	template := &model.Template{Items: []model.TemplateItem{}}
+	err := r.q.QueryRowContext(ctx, r.dialect.Rebind(find), id, repository.WorkspaceID(ctx)).
+		Scan(&template.ID, &template.Name, &template.Description, &template.CreatedAt, &template.WorkspaceID)
+	if err == sql.ErrNoRows {
+		return nil, &model.ErrNotFound{Resource: "Template"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.q.QueryContext(ctx, r.dialect.Rebind(readItems), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //rowsを必ず閉じる
+
+	for rows.Next() {
+		var item model.TemplateItem
+		if err := rows.Scan(&item.Subject, &item.Description, &item.Priority); err != nil {
+			return nil, err
+		}
+		template.Items = append(template.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// UpdateItems replaces the items of the Template on DB.
+func (r *TemplateRepository) UpdateItems(ctx context.Context, id int64, items []model.TemplateItem) error {
+	const (
+		exists      = `SELECT 1 FROM templates WHERE id = ? AND workspace_id = ?`
+		deleteItems = `DELETE FROM template_items WHERE template_id = ?`
+		insertItem  = `INSERT INTO template_items(template_id, subject, description, priority) VALUES(?, ?, ?, ?)`
+	)
+
+	var one int
+	err := r.q.QueryRowContext(ctx, r.dialect.Rebind(exists), id, repository.WorkspaceID(ctx)).Scan(&one)
+	if err == sql.ErrNoRows {
+		return &model.ErrNotFound{Resource: "Template"}
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := r.q.ExecContext(ctx, r.dialect.Rebind(deleteItems), id); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if _, err := r.q.ExecContext(ctx, r.dialect.Rebind(insertItem), id, item.Subject, item.Description, item.Priority); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete deletes the Template on DB. Its items are deleted by the foreign key.
+func (r *TemplateRepository) Delete(ctx context.Context, id int64) error {
+	const deleteTemplate = `DELETE FROM templates WHERE id = ? AND workspace_id = ?`
+
+	result, err := r.q.ExecContext(ctx, r.dialect.Rebind(deleteTemplate), id, repository.WorkspaceID(ctx))
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	//削除対象が見つからなかった場合は、ErrNotFoundを返す
+	if rowsAffected == 0 {
+		return &model.ErrNotFound{Resource: "Template"}
+	}
+	return nil
+}
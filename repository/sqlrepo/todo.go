@@ -0,0 +1,523 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/db/dialect"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// selectTODO selects the columns scanned by scanTODO.
+const selectTODO = `SELECT id, subject, description, created_at, updated_at, (SELECT COUNT(*) FROM comments WHERE comments.todo_id = todos.id), done, priority, archived, project_id, workspace_id, assignee_id FROM todos`
+
+// sortColumns maps the sortable fields to SQL expressions. Fields not listed
+// here are rejected, so user input is never written into ORDER BY.
+var sortColumns = map[string]string{
+	"id":         `id`,
+	"subject":    `subject`,
+	"priority":   `CASE priority WHEN 'high' THEN 3 WHEN 'medium' THEN 2 WHEN 'low' THEN 1 ELSE 0 END`,
+	"created_at": `created_at`,
+	"updated_at": `updated_at`,
+	"position":   `sort_order`,
+}
+
+// A TODORepository implements repository.TODORepository.
+type TODORepository struct {
+	q       dialect.Queryer
+	dialect dialect.Dialect
+}
+
+var _ repository.TODORepository = (*TODORepository)(nil)
+
+// Create creates a TODO on DB.
+func (r *TODORepository) Create(ctx context.Context, todo *model.TODO) (*model.TODO, error) {
+	//新しいTODOは手動の並び順でも先頭に置く
+	//(MySQLは挿入先のテーブルを直接参照できないため、導出テーブルを経由する)
+	const insert = `INSERT INTO todos(subject, description, done, priority, project_id, workspace_id, sort_order) VALUES(?, ?, ?, ?, ?, ?, ` +
+		`(SELECT p FROM (SELECT COALESCE(MIN(sort_order), 0) - ? AS p FROM todos WHERE workspace_id = ?) AS first))`
+
+	//TODOを挿入し、新しく作成されたTODOのIDを取得
+	ws := repository.WorkspaceID(ctx)
+	id, err := r.dialect.Insert(ctx, r.q, insert, todo.Subject, todo.Description, todo.Done, todo.Priority, nullID(todo.ProjectID), ws, repository.PositionGap, ws)
+	if err != nil {
+		return nil, err
+	}
+	//IDを使用してTODOを取得
+	return r.Find(ctx, id)
+}
+
+// List reads TODOs matching q on DB.
+func (r *TODORepository) List(ctx context.Context, q *model.TODOQuery) ([]*model.TODO, error) {
+	slog.DebugContext(ctx, "Listing TODOs", "prev_id", q.PrevID, "size", q.Size)
+
+	query, args, err := r.listQuery(ctx, q, true)
+	if err != nil {
+		return nil, err
+	}
+	//TODOリストを格納するスライス
+	todos := []*model.TODO{}
+	err = r.each(ctx, query, args, func(todo *model.TODO) error {
+		todos = append(todos, todo)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// Stream reads TODOs matching q on DB from a cursor, without loading them all.
+func (r *TODORepository) Stream(ctx context.Context, q *model.TODOQuery, fn func(*model.TODO) error) error {
+	slog.DebugContext(ctx, "Streaming TODOs", "prev_id", q.PrevID, "size", q.Size)
+
+	query, args, err := r.listQuery(ctx, q, q.Size > 0)
+	if err != nil {
+		return err
+	}
+	return r.each(ctx, query, args, fn)
+}
+
+// listQuery builds the query of the TODOs matching q, limited to the page
+// of q if limit is true.
+func (r *TODORepository) listQuery(ctx context.Context, q *model.TODOQuery, limit bool) (string, []interface{}, error) {
+	conds, args := r.conditions(ctx, q)
+	if q.PrevID > 0 {
+		conds = append(conds, `id < ?`)
+		args = append(args, q.PrevID)
+	}
+
+	orderBy, err := orderBy(q.Sort)
+	if err != nil {
+		return "", nil, err
+	}
+	query := selectTODO + ` WHERE ` + strings.Join(conds, ` AND `) + ` ORDER BY ` + orderBy
+	if !limit {
+		return query, args, nil
+	}
+	query += ` LIMIT ?`
+	args = append(args, q.Size)
+	if q.Offset > 0 {
+		query += ` OFFSET ?`
+		args = append(args, q.Offset)
+	}
+	return query, args, nil
+}
+
+// each runs query and calls fn with each TODO read. ctx being done cancels
+// the query.
+func (r *TODORepository) each(ctx context.Context, query string, args []interface{}, fn func(*model.TODO) error) error {
+	rows, err := r.q.QueryContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		//クエリ実行中にエラーが発生した場合
+		slog.ErrorContext(ctx, "Query execution failed", "err", err)
+		return err
+	}
+	defer rows.Close() //rowsを必ず閉じる
+
+	for rows.Next() {
+		todo, err := scanTODO(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "Row scanning failed", "err", err)
+			return err
+		}
+		if err := fn(todo); err != nil {
+			return err
+		}
+	}
+
+	//繰り返し処理後のエラーを確認
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Rows iteration failed", "err", err)
+		return err
+	}
+	return nil
+}
+
+// Count counts TODOs matching the conditions of q on DB.
+func (r *TODORepository) Count(ctx context.Context, q *model.TODOQuery) (int64, error) {
+	conds, args := r.conditions(ctx, q)
+	query := `SELECT COUNT(*) FROM todos WHERE ` + strings.Join(conds, ` AND `)
+
+	var count int64
+	if err := r.q.QueryRowContext(ctx, r.dialect.Rebind(query), args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// conditions builds the WHERE conditions of q except the page, limited to the workspace of ctx.
+func (r *TODORepository) conditions(ctx context.Context, q *model.TODOQuery) ([]string, []interface{}) {
+	//条件は全てプレースホルダで渡し、値をSQLに埋め込まない
+	conds := []string{`workspace_id = ?`, `archived = ?`}
+	args := []interface{}{repository.WorkspaceID(ctx), q.Archived}
+	if q.Query != "" {
+		//大文字小文字を区別せずに部分一致で検索する
+		pattern := "%" + strings.ToLower(escapeLike(q.Query)) + "%"
+		conds = append(conds, `(LOWER(subject) LIKE ? ESCAPE '!' OR LOWER(description) LIKE ? ESCAPE '!')`)
+		args = append(args, pattern, pattern)
+	}
+	if q.Status != "" {
+		conds = append(conds, `done = ?`)
+		args = append(args, q.Status == model.TODOStatusDone)
+	}
+	if q.Priority != "" {
+		conds = append(conds, `priority = ?`)
+		args = append(args, q.Priority)
+	}
+	if q.ProjectID != 0 {
+		conds = append(conds, `project_id = ?`)
+		args = append(args, q.ProjectID)
+	}
+	if q.AssigneeID != nil {
+		if *q.AssigneeID == 0 {
+			conds = append(conds, `assignee_id IS NULL`)
+		} else {
+			conds = append(conds, `assignee_id = ?`)
+			args = append(args, *q.AssigneeID)
+		}
+	}
+	for _, c := range []struct {
+		cond string
+		t    time.Time
+	}{
+		{`created_at >= ?`, q.CreatedAfter},
+		{`created_at < ?`, q.CreatedBefore},
+		{`updated_at >= ?`, q.UpdatedAfter},
+		{`updated_at < ?`, q.UpdatedBefore},
+	} {
+		if !c.t.IsZero() {
+			conds = append(conds, c.cond)
+			args = append(args, r.dialect.Time(c.t))
+		}
+	}
+	return conds, args
+}
+
+// orderBy builds the ORDER BY clause of sorts. The ID is always the last key
+// so the order is stable.
+func orderBy(sorts []model.TODOSort) (string, error) {
+	keys := make([]string, 0, len(sorts)+1)
+	byID := false
+	for _, s := range sorts {
+		col, ok := sortColumns[s.Field]
+		if !ok {
+			return "", fmt.Errorf("sqlrepo: unknown sort field %q", s.Field)
+		}
+		dir := ` ASC`
+		if s.Desc {
+			dir = ` DESC`
+		}
+		keys = append(keys, col+dir)
+		byID = byID || s.Field == "id"
+	}
+	if !byID {
+		keys = append(keys, `id DESC`)
+	}
+	return strings.Join(keys, `, `), nil
+}
+
+// Update updates the TODO on DB.
+func (r *TODORepository) Update(ctx context.Context, id int64, patch *model.TODOPatch) (*model.TODO, error) {
+	//指定されたフィールドのみを更新する(updated_atはDBごとの現在時刻の式で更新する)
+	sets := []string{`updated_at = ` + r.dialect.Now()}
+	var args []interface{}
+	if patch.Subject != nil {
+		sets = append(sets, `subject = ?`)
+		args = append(args, *patch.Subject)
+	}
+	if patch.Description != nil {
+		sets = append(sets, `description = ?`)
+		args = append(args, *patch.Description)
+	}
+	if patch.Done != nil {
+		sets = append(sets, `done = ?`)
+		args = append(args, *patch.Done)
+	}
+	if patch.Priority != nil {
+		sets = append(sets, `priority = ?`)
+		args = append(args, *patch.Priority)
+	}
+	if patch.Archived != nil {
+		sets = append(sets, `archived = ?`)
+		args = append(args, *patch.Archived)
+	}
+	if patch.ProjectID != nil {
+		sets = append(sets, `project_id = ?`)
+		args = append(args, nullID(*patch.ProjectID))
+	}
+	if patch.AssigneeID != nil {
+		sets = append(sets, `assignee_id = ?`)
+		args = append(args, nullID(*patch.AssigneeID))
+	}
+	update := `UPDATE todos SET ` + strings.Join(sets, `, `) + ` WHERE id = ? AND workspace_id = ?`
+	args = append(args, id, repository.WorkspaceID(ctx))
+
+	result, err := r.q.ExecContext(ctx, r.dialect.Rebind(update), args...)
+	if err != nil {
+		//更新処理中にエラーが発生すれば、そのエラーを返す
+		return nil, err
+	}
+
+	//影響を受けた行数を確認
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	//もし更新された行が0のとき、「対象のTODOが見つかりませんでした」と返す。
+	if rowsAffected == 0 {
+		return nil, &model.ErrNotFound{Resource: "TODO"}
+	}
+	//更新されたTODOを返す
+	return r.Find(ctx, id)
+}
+
+// Delete deletes TODOs on DB by ids.
+func (r *TODORepository) Delete(ctx context.Context, ids []int64) error {
+	//DELETE文のフォーマット文字列
+	//プレースホルダ―は後で埋め込む
+	const (
+		deleteFmt           = `DELETE FROM todos WHERE id IN (%s) AND workspace_id = ?`
+		clearTombstonesFmt  = `DELETE FROM todo_tombstones WHERE todo_id IN (SELECT id FROM todos WHERE id IN (%s) AND workspace_id = ?)`
+		insertTombstonesFmt = `INSERT INTO todo_tombstones(todo_id, workspace_id) SELECT id, workspace_id FROM todos WHERE id IN (%s) AND workspace_id = ?`
+	)
+
+	//削除対象のIDリストが空の場合は、何もせずに終了
+	if len(ids) == 0 {
+		return nil
+	}
+
+	marks, args := placeholders(ids)
+	query := fmt.Sprintf(deleteFmt, marks)
+	args = append(args, repository.WorkspaceID(ctx))
+
+	//削除するTODOの記録を残す。以前に削除を取り消したTODOの古い記録は置き換える
+	for _, f := range []string{clearTombstonesFmt, insertTombstonesFmt} {
+		if _, err := r.q.ExecContext(ctx, r.dialect.Rebind(fmt.Sprintf(f, marks)), args...); err != nil {
+			return err
+		}
+	}
+
+	//DELETEクエリを実行
+	result, err := r.q.ExecContext(ctx, r.dialect.Rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	//削除された行数を取得
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	//削除対象が見るからなかった場合は、ErrNotFoundを返す
+	if rowsAffected == 0 {
+		return &model.ErrNotFound{Resource: "TODO"}
+	}
+	return nil
+}
+
+// Restore inserts the deleted TODO with its original ID on DB and marks its
+// tombstone restored.
+func (r *TODORepository) Restore(ctx context.Context, todo *model.TODO, position int64) error {
+	const insert = `INSERT INTO todos(id, subject, description, done, priority, archived, project_id, assignee_id, workspace_id, sort_order, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	restored := `UPDATE todo_tombstones SET restored_at = ` + r.dialect.Now() + ` WHERE todo_id = ?`
+
+	_, err := r.q.ExecContext(ctx, r.dialect.Rebind(insert), todo.ID, todo.Subject, todo.Description, todo.Done, todo.Priority, todo.Archived,
+		nullID(todo.ProjectID), nullID(todo.AssigneeID), repository.WorkspaceID(ctx), position, r.dialect.Time(todo.CreatedAt), r.dialect.Time(todo.UpdatedAt))
+	if err != nil {
+		return err
+	}
+	_, err = r.q.ExecContext(ctx, r.dialect.Rebind(restored), todo.ID)
+	return err
+}
+
+// Version reads the counts and the latest timestamps of todos and comments of the workspace.
+func (r *TODORepository) Version(ctx context.Context) (*model.TODOVersion, error) {
+	//MAX()ではSQLiteが日時型として返さないため、ORDER BY ... LIMIT 1で最新の日時を取得する
+	const (
+		todos    = `SELECT updated_at, (SELECT COUNT(*) FROM todos WHERE workspace_id = ?) FROM todos WHERE workspace_id = ? ORDER BY updated_at DESC LIMIT 1`
+		comments = `SELECT created_at, (SELECT COUNT(*) FROM comments WHERE workspace_id = ?) FROM comments WHERE workspace_id = ? ORDER BY created_at DESC LIMIT 1`
+	)
+
+	ws := repository.WorkspaceID(ctx)
+	v := &model.TODOVersion{}
+	var updatedAt, createdAt time.Time
+	if err := r.q.QueryRowContext(ctx, r.dialect.Rebind(todos), ws, ws).Scan(&updatedAt, &v.Count); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err := r.q.QueryRowContext(ctx, r.dialect.Rebind(comments), ws, ws).Scan(&createdAt, &v.CommentCount); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	v.LastModified = updatedAt
+	if createdAt.After(updatedAt) {
+		v.LastModified = createdAt
+	}
+	return v, nil
+}
+
+// Stats counts the TODOs with grouped queries.
+func (r *TODORepository) Stats(ctx context.Context, since time.Time) (*model.TODOStats, error) {
+	const (
+		totals     = `SELECT COUNT(*), COALESCE(SUM(CASE WHEN done = ? THEN 1 ELSE 0 END), 0), COALESCE(SUM(CASE WHEN archived = ? THEN 1 ELSE 0 END), 0) FROM todos WHERE workspace_id = ?`
+		byPriority = `SELECT priority, COUNT(*) FROM todos WHERE workspace_id = ? GROUP BY priority`
+		byProject  = `SELECT COALESCE(project_id, 0), COUNT(*) FROM todos WHERE workspace_id = ? GROUP BY COALESCE(project_id, 0) ORDER BY 1`
+	)
+	//日付の書式はデータベースごとに異なるため、方言から式を組み立てる
+	perDay := `SELECT ` + r.dialect.Date(`created_at`) + `, COUNT(*) FROM todos WHERE workspace_id = ? AND created_at >= ? GROUP BY 1 ORDER BY 1`
+
+	ws := repository.WorkspaceID(ctx)
+	s := &model.TODOStats{ByPriority: map[string]int64{}, ByProject: []model.TODOProjectCount{}, CreatedPerDay: []model.TODODayCount{}}
+	if err := r.q.QueryRowContext(ctx, r.dialect.Rebind(totals), true, true, ws).Scan(&s.Total, &s.Done, &s.Archived); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.q.QueryContext(ctx, r.dialect.Rebind(byPriority), ws)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var priority string
+		var count int64
+		if err := rows.Scan(&priority, &count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		s.ByPriority[priority] = count
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.q.QueryContext(ctx, r.dialect.Rebind(byProject), ws)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var c model.TODOProjectCount
+		if err := rows.Scan(&c.ProjectID, &c.Count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		s.ByProject = append(s.ByProject, c)
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.q.QueryContext(ctx, r.dialect.Rebind(perDay), ws, r.dialect.Time(since))
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var c model.TODODayCount
+		if err := rows.Scan(&c.Date, &c.Count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		s.CreatedPerDay = append(s.CreatedPerDay, c)
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Changes reads the changed TODOs and the tombstones of the deleted ones.
+func (r *TODORepository) Changes(ctx context.Context, since time.Time) (*model.TODOChanges, error) {
+	const (
+		//削除を取り消したTODOは、作成日時が古くても作成として扱う
+		restored = `SELECT todo_id FROM todo_tombstones WHERE restored_at >= ?`
+		created  = `SELECT id FROM todos WHERE workspace_id = ? AND (created_at >= ? OR id IN (` + restored + `)) ORDER BY id`
+		updated  = `SELECT id FROM todos WHERE workspace_id = ? AND updated_at >= ? AND created_at < ? AND id NOT IN (` + restored + `) ORDER BY id`
+		deleted  = `SELECT todo_id, deleted_at FROM todo_tombstones WHERE workspace_id = ? AND deleted_at >= ? AND restored_at IS NULL ORDER BY deleted_at, todo_id`
+	)
+
+	ws, t := repository.WorkspaceID(ctx), r.dialect.Time(since)
+	c := &model.TODOChanges{Created: []int64{}, Updated: []int64{}, Deleted: []model.TODOTombstone{}}
+	for _, q := range []struct {
+		query string
+		args  []interface{}
+		ids   *[]int64
+	}{
+		{created, []interface{}{ws, t, t}, &c.Created},
+		{updated, []interface{}{ws, t, t, t}, &c.Updated},
+	} {
+		rows, err := r.q.QueryContext(ctx, r.dialect.Rebind(q.query), q.args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			*q.ids = append(*q.ids, id)
+		}
+		if err := closeRows(rows); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := r.q.QueryContext(ctx, r.dialect.Rebind(deleted), ws, t)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var d model.TODOTombstone
+		if err := rows.Scan(&d.ID, &d.DeletedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		c.Deleted = append(c.Deleted, d)
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// closeRows closes rows and returns the error of the iteration, if any.
+func closeRows(rows *sql.Rows) error {
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	return rows.Close()
+}
+
+// Find reads the TODO by id.
+func (r *TODORepository) Find(ctx context.Context, id int64) (*model.TODO, error) {
+	row := r.q.QueryRowContext(ctx, r.dialect.Rebind(selectTODO+` WHERE id = ? AND workspace_id = ?`), id, repository.WorkspaceID(ctx))
+	todo, err := scanTODO(row)
+	if err == sql.ErrNoRows {
+		return nil, &model.ErrNotFound{Resource: "TODO"}
+	}
+	return todo, err
+}
+
+// A scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTODO(s scanner) (*model.TODO, error) {
+	todo := &model.TODO{}
+	var projectID, assigneeID sql.NullInt64
+	if err := s.Scan(&todo.ID, &todo.Subject, &todo.Description, &todo.CreatedAt, &todo.UpdatedAt, &todo.CommentCount, &todo.Done, &todo.Priority, &todo.Archived, &projectID, &todo.WorkspaceID, &assigneeID); err != nil {
+		return nil, err
+	}
+	todo.ProjectID = projectID.Int64
+	todo.AssigneeID = assigneeID.Int64
+	return todo, nil
+}
+
+// nullID converts the ID 0, which means none, into NULL.
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
@@ -0,0 +1,68 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/db/dialect"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// An UndoRepository implements repository.UndoRepository.
+// The TODOs, positions and comments of an entry are stored as JSON in the snapshot column.
+type UndoRepository struct {
+	q       dialect.Queryer
+	dialect dialect.Dialect
+}
+
+var _ repository.UndoRepository = (*UndoRepository)(nil)
+
+// Push inserts the entry on DB.
+func (r *UndoRepository) Push(ctx context.Context, entry *model.UndoEntry) error {
+	const insert = `INSERT INTO undo_log(workspace_id, api_key_id, action, snapshot) VALUES(?, ?, ?, ?)`
+
+	snapshot, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = r.q.ExecContext(ctx, r.dialect.Rebind(insert), repository.WorkspaceID(ctx), entry.APIKeyID, entry.Action, string(snapshot))
+	return err
+}
+
+// Pop reads and deletes the newest entry of the API key on DB.
+func (r *UndoRepository) Pop(ctx context.Context, apiKeyID int64, since time.Time) (*model.UndoEntry, error) {
+	const (
+		find   = `SELECT id, action, snapshot, created_at FROM undo_log WHERE workspace_id = ? AND api_key_id = ? AND created_at >= ? ORDER BY id DESC LIMIT 1`
+		remove = `DELETE FROM undo_log WHERE id = ?`
+	)
+
+	entry := &model.UndoEntry{APIKeyID: apiKeyID}
+	var snapshot string
+	err := r.q.QueryRowContext(ctx, r.dialect.Rebind(find), repository.WorkspaceID(ctx), apiKeyID, r.dialect.Time(since)).
+		Scan(&entry.ID, &entry.Action, &snapshot, &entry.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, &model.ErrNotFound{Resource: "Undo entry"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(snapshot), entry); err != nil {
+		return nil, err
+	}
+	//同じ操作を二度元に戻さないよう、読み出したエントリは削除する
+	if _, err := r.q.ExecContext(ctx, r.dialect.Rebind(remove), entry.ID); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Prune deletes the expired entries of the workspace on DB.
+func (r *UndoRepository) Prune(ctx context.Context, before time.Time) error {
+	const prune = `DELETE FROM undo_log WHERE workspace_id = ? AND created_at < ?`
+
+	_, err := r.q.ExecContext(ctx, r.dialect.Rebind(prune), repository.WorkspaceID(ctx), r.dialect.Time(before))
+	return err
+}
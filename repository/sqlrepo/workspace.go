@@ -0,0 +1,110 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/TechBowl-japan/go-stations/db/dialect"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// A WorkspaceRepository implements repository.WorkspaceRepository.
+type WorkspaceRepository struct {
+	q       dialect.Queryer
+	dialect dialect.Dialect
+}
+
+var _ repository.WorkspaceRepository = (*WorkspaceRepository)(nil)
+
+// Create creates a Workspace on DB.
+func (r *WorkspaceRepository) Create(ctx context.Context, name string) (*model.Workspace, error) {
+	const insert = `INSERT INTO workspaces(name) VALUES(?)`
+
+	id, err := r.dialect.Insert(ctx, r.q, insert, name)
+	if err != nil {
+		return nil, err
+	}
+	return r.Find(ctx, id)
+}
+
+// Read reads Workspaces on DB.
+func (r *WorkspaceRepository) Read(ctx context.Context) ([]*model.Workspace, error) {
+	const read = `SELECT id, name, created_at FROM workspaces ORDER BY id DESC`
+
+	rows, err := r.q.QueryContext(ctx, read)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //rowsを必ず閉じる
+
+	workspaces := []*model.Workspace{}
+	for rows.Next() {
+		ws := &model.Workspace{}
+		if err := rows.Scan(&ws.ID, &ws.Name, &ws.CreatedAt); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, ws)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+// Find reads the Workspace by id.
+func (r *WorkspaceRepository) Find(ctx context.Context, id int64) (*model.Workspace, error) {
+	const find = `SELECT id, name, created_at FROM workspaces WHERE id = ?`
+
+	ws := &model.Workspace{}
+	err := r.q.QueryRowContext(ctx, r.dialect.Rebind(find), id).Scan(&ws.ID, &ws.Name, &ws.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, &model.ErrNotFound{Resource: "Workspace"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// AddMember adds the APIKey to the members of the Workspace on DB.
+func (r *WorkspaceRepository) AddMember(ctx context.Context, workspaceID, apiKeyID int64) error {
+	//既にメンバーの場合は何もしない(DBごとに異なるUPSERT構文を避ける)
+	ok, err := r.IsMember(ctx, workspaceID, apiKeyID)
+	if err != nil || ok {
+		return err
+	}
+	const insert = `INSERT INTO workspace_members(workspace_id, api_key_id) VALUES(?, ?)`
+	_, err = r.q.ExecContext(ctx, r.dialect.Rebind(insert), workspaceID, apiKeyID)
+	return err
+}
+
+// RemoveMember removes the APIKey from the members of the Workspace on DB.
+func (r *WorkspaceRepository) RemoveMember(ctx context.Context, workspaceID, apiKeyID int64) error {
+	const remove = `DELETE FROM workspace_members WHERE workspace_id = ? AND api_key_id = ?`
+
+	result, err := r.q.ExecContext(ctx, r.dialect.Rebind(remove), workspaceID, apiKeyID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return &model.ErrNotFound{Resource: "WorkspaceMember"}
+	}
+	return nil
+}
+
+// IsMember checks the membership of the APIKey on DB.
+func (r *WorkspaceRepository) IsMember(ctx context.Context, workspaceID, apiKeyID int64) (bool, error) {
+	const exists = `SELECT 1 FROM workspace_members WHERE workspace_id = ? AND api_key_id = ?`
+
+	var one int
+	err := r.q.QueryRowContext(ctx, r.dialect.Rebind(exists), workspaceID, apiKeyID).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+type workspaceContextKey struct{}
+
+// WithWorkspace returns a copy of ctx scoped to the workspace. Every
+// TODORepository, CommentRepository and ProjectRepository method reads and
+// writes only the rows of the workspace of its ctx, so data never leaks
+// between workspaces even if an ID of another workspace is given.
+func WithWorkspace(ctx context.Context, workspaceID int64) context.Context {
+	return context.WithValue(ctx, workspaceContextKey{}, workspaceID)
+}
+
+// WorkspaceID returns the workspace of ctx, or model.DefaultWorkspace.
+func WorkspaceID(ctx context.Context) int64 {
+	id, _ := ctx.Value(workspaceContextKey{}).(int64)
+	return id
+}
+
+// A WorkspaceRepository stores Workspace entities and their members.
+// Workspaces themselves are not scoped by WithWorkspace.
+type WorkspaceRepository interface {
+	// Create stores a new Workspace and returns it.
+	Create(ctx context.Context, name string) (*model.Workspace, error)
+	// Read returns all Workspaces, newest first.
+	Read(ctx context.Context) ([]*model.Workspace, error)
+	// Find returns the Workspace of id, or *model.ErrNotFound.
+	Find(ctx context.Context, id int64) (*model.Workspace, error)
+	// AddMember lets the APIKey access the Workspace. Adding a member twice is not an error.
+	AddMember(ctx context.Context, workspaceID, apiKeyID int64) error
+	// RemoveMember revokes the access of the APIKey. It returns
+	// *model.ErrNotFound if the APIKey is not a member.
+	RemoveMember(ctx context.Context, workspaceID, apiKeyID int64) error
+	// IsMember reports whether the APIKey is a member of the Workspace.
+	IsMember(ctx context.Context, workspaceID, apiKeyID int64) (bool, error)
+}
@@ -0,0 +1,114 @@
+// Package seed loads the fixture projects, TODOs and comments embedded in
+// fixtures.json, for demos and frontend development.
+package seed
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+//go:embed fixtures.json
+var fixturesJSON []byte
+
+type (
+	fixtures struct {
+		Projects []projectFixture `json:"projects"`
+		// TODOs are the fixtures not belonging to any project.
+		TODOs []todoFixture `json:"todos"`
+	}
+
+	projectFixture struct {
+		Name        string        `json:"name"`
+		Description string        `json:"description"`
+		TODOs       []todoFixture `json:"todos"`
+	}
+
+	todoFixture struct {
+		Subject     string   `json:"subject"`
+		Description string   `json:"description"`
+		Priority    string   `json:"priority"`
+		Done        bool     `json:"done"`
+		Archived    bool     `json:"archived"`
+		Comments    []string `json:"comments"`
+	}
+)
+
+// Load creates the fixtures through the services on repo, in the workspace of ctx.
+// The fixtures are added to the existing data every time Load is called.
+func Load(ctx context.Context, repo repository.Repository) (*model.SeedResponse, error) {
+	var f fixtures
+	if err := json.Unmarshal(fixturesJSON, &f); err != nil {
+		return nil, fmt.Errorf("seed: invalid fixtures: %w", err)
+	}
+
+	//途中で失敗した場合に一部だけ作成されないよう、1つのトランザクションで作成する
+	var l *loader
+	err := repo.WithTx(ctx, func(r repository.Repository) error {
+		l = &loader{
+			todos:    service.NewTODOServiceWithRepository(r),
+			projects: service.NewProjectServiceWithRepository(r),
+			comments: service.NewCommentServiceWithRepository(r),
+		}
+		for _, p := range f.Projects {
+			project, err := l.projects.CreateProject(ctx, p.Name, p.Description)
+			if err != nil {
+				return err
+			}
+			l.res.Projects++
+			for _, t := range p.TODOs {
+				if err := l.createTODO(ctx, project.ID, &t); err != nil {
+					return err
+				}
+			}
+		}
+		for _, t := range f.TODOs {
+			if err := l.createTODO(ctx, 0, &t); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &l.res, nil
+}
+
+type loader struct {
+	todos    *service.TODOService
+	projects *service.ProjectService
+	comments *service.CommentService
+	res      model.SeedResponse
+}
+
+func (l *loader) createTODO(ctx context.Context, projectID int64, t *todoFixture) error {
+	todo, err := l.todos.InsertTODO(ctx, &model.TODO{
+		Subject:     t.Subject,
+		Description: t.Description,
+		Priority:    t.Priority,
+		Done:        t.Done,
+		ProjectID:   projectID,
+	})
+	if err != nil {
+		return fmt.Errorf("seed: %q: %w", t.Subject, err)
+	}
+	l.res.TODOs++
+	if t.Archived {
+		if _, err := l.todos.ArchiveTODO(ctx, todo.ID, true); err != nil {
+			return err
+		}
+	}
+	for _, body := range t.Comments {
+		if _, err := l.comments.CreateComment(ctx, todo.ID, body); err != nil {
+			return err
+		}
+		l.res.Comments++
+	}
+	return nil
+}
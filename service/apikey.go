@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// ErrInvalidAPIKey is returned by Authenticate for unknown and revoked keys.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// apiKeyPrefix is the fixed beginning of every key, which makes leaked keys easy to find.
+const apiKeyPrefix = "gs_"
+
+// touchInterval is the minimum interval between updates of the last used time of a key.
+const touchInterval = time.Minute
+
+// An APIKeyService implements minting, authenticating and revoking API keys.
+type APIKeyService struct {
+	repo repository.Repository
+}
+
+// NewAPIKeyServiceWithRepository returns new APIKeyService backed by repo.
+func NewAPIKeyServiceWithRepository(repo repository.Repository) *APIKeyService {
+	return &APIKeyService{
+		repo: repo,
+	}
+}
+
+// CreateAPIKey mints a new key with the role and scopes. An empty role means
+// model.RoleMember. It returns the APIKey and the key itself, which cannot be
+// retrieved again.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, name, role string, scopes []string) (*model.APIKey, string, error) {
+	if name == "" {
+		return nil, "", &model.ErrValidation{Field: "name", Reason: "must not be empty"}
+	}
+	if role == "" {
+		role = model.RoleMember
+	}
+	if !model.ValidRole(role) {
+		return nil, "", &model.ErrValidation{Field: "role", Reason: `must be "admin", "member" or "readonly"`}
+	}
+	if len(scopes) == 0 {
+		return nil, "", &model.ErrValidation{Field: "scopes", Reason: "must not be empty"}
+	}
+	for _, scope := range scopes {
+		if scope != model.ScopeRead && scope != model.ScopeWrite {
+			return nil, "", &model.ErrValidation{Field: "scopes", Reason: `must be "read" or "write"`}
+		}
+	}
+
+	//キーは "gs_<プレフィックス>_<秘密部分>" の形式で、DBにはハッシュのみを保存する
+	id, err := randomBytes(4)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomBytes(24)
+	if err != nil {
+		return nil, "", err
+	}
+	prefix := apiKeyPrefix + hex.EncodeToString(id)
+	key := prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	created, err := s.repo.APIKeys().Create(ctx, &model.APIKey{
+		Name:   name,
+		Prefix: prefix,
+		Role:   role,
+		Scopes: scopes,
+	}, hashAPIKey(key))
+	if err != nil {
+		return nil, "", err
+	}
+	return created, key, nil
+}
+
+// ReadAPIKeys reads all APIKeys including revoked ones.
+func (s *APIKeyService) ReadAPIKeys(ctx context.Context) ([]*model.APIKey, error) {
+	return s.repo.APIKeys().Read(ctx)
+}
+
+// RevokeAPIKey revokes the APIKey of id. It returns *model.ErrNotFound if the APIKey does not exist.
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id int64) (*model.APIKey, error) {
+	return s.repo.APIKeys().Revoke(ctx, id)
+}
+
+// Authenticate returns the APIKey of key, or ErrInvalidAPIKey if the key is
+// unknown or revoked. It records the use of the key at most once per touchInterval.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*model.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	apiKey, err := s.repo.APIKeys().FindByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		if _, ok := err.(*model.ErrNotFound); ok {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if apiKey.Revoked() {
+		return nil, ErrInvalidAPIKey
+	}
+
+	//リクエストごとに書き込まないよう、前回の記録から一定時間経った場合のみ更新する
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) >= touchInterval {
+		if err := s.repo.APIKeys().Touch(ctx, apiKey.ID); err != nil {
+			return nil, err
+		}
+	}
+	return apiKey, nil
+}
+
+// hashAPIKey returns the hex encoded SHA-256 of key. Keys are random enough
+// that a slow password hash is unnecessary.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomBytes returns n bytes from crypto/rand.
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// Values of the assignee filter accepted by ParseAssignee besides API key IDs.
+const (
+	AssigneeMe   = "me"
+	AssigneeNone = "none"
+)
+
+// An AssignHook is notified of a change of the assignee of a TODO made with
+// AssignTODO, after the change is committed. Hooks must not block for long
+// since they run on the request; errors are theirs to handle.
+type AssignHook func(ctx context.Context, a *model.TODOAssignment)
+
+// AssignTODO sets the assignee of the TODO of id to the API key of
+// assigneeID, or removes it if assigneeID is 0, and calls the AssignHooks
+// if the assignee changed. The assignee must be an unrevoked API key which
+// may enter the workspace of ctx (see WorkspaceService.Enter).
+// It returns *model.ErrValidation if the assignee is not such a key,
+// *model.ErrNotFound if the TODO does not exist, and *model.ErrLocked if
+// another API key claimed the TODO (see ClaimTODO).
+func (s *TODOService) AssignTODO(ctx context.Context, id, assigneeID int64) (*model.TODO, error) {
+	var (
+		todo *model.TODO
+		from int64
+	)
+	err := s.WithTx(ctx, func(r repository.Repository) error {
+		if err := validateAssignee(ctx, r, assigneeID); err != nil {
+			return err
+		}
+		before, err := r.TODOs().Find(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkLock(ctx, r, id, time.Now()); err != nil {
+			return err
+		}
+		from = before.AssigneeID
+		if err := s.pushUndo(ctx, r, &model.UndoEntry{Action: model.UndoActionUpdate, TODOs: []*model.TODO{before}}); err != nil {
+			return err
+		}
+		todo, err = r.TODOs().Update(ctx, id, &model.TODOPatch{AssigneeID: &assigneeID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	//担当者が変わった場合のみ、コミット後に通知する
+	if from != assigneeID {
+		a := &model.TODOAssignment{TODO: *todo, From: from, To: assigneeID, AssignedBy: apiKeyID(ctx)}
+		for _, hook := range s.opts.AssignHooks {
+			hook(ctx, a)
+		}
+	}
+	return todo, nil
+}
+
+// validateAssignee returns *model.ErrValidation unless id is 0 or an
+// unrevoked API key which may access the workspace of ctx: the default
+// workspace is open to every key, the others to their members and admins.
+func validateAssignee(ctx context.Context, r repository.Repository, id int64) error {
+	if id == 0 {
+		return nil
+	}
+	invalid := &model.ErrValidation{Field: "assignee_id", Reason: "must be an active API key of the workspace"}
+	key, err := r.APIKeys().Find(ctx, id)
+	if _, ok := err.(*model.ErrNotFound); ok {
+		return invalid
+	}
+	if err != nil {
+		return err
+	}
+	if key.Revoked() {
+		return invalid
+	}
+	if ws := repository.WorkspaceID(ctx); ws != model.DefaultWorkspace && !key.Can(model.PermissionAdmin) {
+		ok, err := r.Workspaces().IsMember(ctx, ws, id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return invalid
+		}
+	}
+	return nil
+}
+
+// ParseAssignee parses the assignee filter of TODO lists into
+// model.TODOQuery.AssigneeID: "" for no filter, AssigneeMe for the API key
+// of ctx, AssigneeNone for the TODOs without an assignee, or an API key ID.
+func ParseAssignee(ctx context.Context, s string) (*int64, error) {
+	var id int64
+	switch s {
+	case "":
+		return nil, nil
+	case AssigneeMe:
+		if id = apiKeyID(ctx); id == 0 {
+			return nil, &model.ErrValidation{Field: "assignee", Reason: "me requires an API key"}
+		}
+	case AssigneeNone:
+	default:
+		var err error
+		if id, err = strconv.ParseInt(s, 10, 64); err != nil || id <= 0 {
+			return nil, &model.ErrValidation{Field: "assignee", Reason: "must be me, none or an API key ID"}
+		}
+	}
+	return &id, nil
+}
+
+// LogAssignment is an AssignHook which logs the change of the assignee.
+func LogAssignment(ctx context.Context, a *model.TODOAssignment) {
+	slog.InfoContext(ctx, "TODO assignee changed", "todo_id", a.TODO.ID, "from", a.From, "to", a.To, "assigned_by", a.AssignedBy)
+}
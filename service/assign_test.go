@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/repository/memory"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestTODOService_AssignTODO(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.New()
+	var notified []model.TODOAssignment
+	svc := service.NewTODOServiceWithOptions(repo, service.TODOOptions{
+		AssignHooks: []service.AssignHook{func(ctx context.Context, a *model.TODOAssignment) {
+			notified = append(notified, *a)
+		}},
+	})
+	keys := service.NewAPIKeyServiceWithRepository(repo)
+	scopes := []string{model.ScopeRead, model.ScopeWrite}
+
+	alice, _, err := keys.CreateAPIKey(ctx, "alice", model.RoleMember, scopes)
+	if err != nil {
+		t.Fatal("failed to create API key, err =", err)
+	}
+	bob, _, err := keys.CreateAPIKey(ctx, "bob", model.RoleMember, scopes)
+	if err != nil {
+		t.Fatal("failed to create API key, err =", err)
+	}
+	todo, err := svc.CreateTODO(ctx, "subject", "")
+	if err != nil {
+		t.Fatal("failed to create TODO, err =", err)
+	}
+
+	asAlice := service.WithAPIKey(ctx, alice)
+	assigned, err := svc.AssignTODO(asAlice, todo.ID, bob.ID)
+	if err != nil || assigned.AssigneeID != bob.ID {
+		t.Fatalf("failed to assign TODO, got = %+v, err = %v", assigned, err)
+	}
+	//同じ担当者を指定しても通知しない
+	if _, err := svc.AssignTODO(asAlice, todo.ID, bob.ID); err != nil {
+		t.Fatal("failed to assign TODO again, err =", err)
+	}
+	if len(notified) != 1 || notified[0].From != 0 || notified[0].To != bob.ID || notified[0].AssignedBy != alice.ID {
+		t.Errorf("unexpected notifications, got = %+v", notified)
+	}
+
+	//assignee=meはリクエストのAPIキーの担当分になる
+	me, err := service.ParseAssignee(service.WithAPIKey(ctx, bob), service.AssigneeMe)
+	if err != nil {
+		t.Fatal("failed to parse assignee, err =", err)
+	}
+	todos, err := svc.ListTODO(ctx, &model.TODOQuery{Size: 5, AssigneeID: me})
+	if err != nil || len(todos) != 1 || todos[0].ID != todo.ID {
+		t.Errorf("unexpected TODOs of bob, got = %v, err = %v", todos, err)
+	}
+	if _, err := service.ParseAssignee(ctx, service.AssigneeMe); err == nil {
+		t.Error("assignee=me without an API key must be rejected")
+	}
+
+	//存在しない・失効した・ワークスペースのメンバーでないAPIキーは担当者にできない
+	assertInvalid := func(name string, ctx context.Context, assigneeID int64) {
+		t.Helper()
+		if _, err := svc.AssignTODO(ctx, todo.ID, assigneeID); err == nil {
+			t.Errorf("%s: assigning succeeded", name)
+		} else if _, ok := err.(*model.ErrValidation); !ok {
+			t.Errorf("%s: expected ErrValidation, got = %v", name, err)
+		}
+	}
+	assertInvalid("unknown", ctx, 999)
+	if _, err := keys.RevokeAPIKey(ctx, alice.ID); err != nil {
+		t.Fatal("failed to revoke API key, err =", err)
+	}
+	assertInvalid("revoked", ctx, alice.ID)
+	ws, err := service.NewWorkspaceServiceWithRepository(repo).CreateWorkspace(ctx, "team")
+	if err != nil {
+		t.Fatal("failed to create workspace, err =", err)
+	}
+	wsCtx := repository.WithWorkspace(ctx, ws.ID)
+	wsTODO, err := svc.CreateTODO(wsCtx, "team", "")
+	if err != nil {
+		t.Fatal("failed to create TODO, err =", err)
+	}
+	if _, err := svc.AssignTODO(wsCtx, wsTODO.ID, bob.ID); err == nil {
+		t.Error("assigning a non-member succeeded")
+	}
+
+	//0を指定すると担当者を外して通知する
+	if unassigned, err := svc.AssignTODO(ctx, todo.ID, 0); err != nil || unassigned.AssigneeID != 0 {
+		t.Errorf("failed to unassign TODO, got = %+v, err = %v", unassigned, err)
+	}
+	if len(notified) != 2 || notified[1].From != bob.ID || notified[1].To != 0 {
+		t.Errorf("unexpected notifications, got = %+v", notified)
+	}
+}
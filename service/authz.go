@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"github.com/TechBowl-japan/go-stations/model"
+)
+
+type apiKeyContextKey struct{}
+
+// WithAPIKey returns a copy of ctx carrying the authenticated APIKey.
+func WithAPIKey(ctx context.Context, key *model.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, key)
+}
+
+// APIKeyFromContext returns the APIKey stored by WithAPIKey, if any.
+func APIKeyFromContext(ctx context.Context) (*model.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(*model.APIKey)
+	return key, ok
+}
+
+// Authorize returns *model.ErrForbidden if the APIKey of ctx lacks the
+// permission. Requests without an APIKey are trusted as before API keys
+// existed; the server rejects them up front when keys are required.
+func Authorize(ctx context.Context, p model.Permission) error {
+	key, ok := APIKeyFromContext(ctx)
+	if !ok || key.Can(p) {
+		return nil
+	}
+	return &model.ErrForbidden{Permission: p}
+}
@@ -0,0 +1,88 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository/memory"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestTODOService_DeleteTODO_BulkNeedsAdmin(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewTODOServiceWithRepository(memory.New())
+
+	var ids []int64
+	for _, s := range []string{"a", "b", "c"} {
+		todo, err := svc.CreateTODO(ctx, s, "")
+		if err != nil {
+			t.Fatal("failed to create TODO, err =", err)
+		}
+		ids = append(ids, todo.ID)
+	}
+
+	member := service.WithAPIKey(ctx, &model.APIKey{Role: model.RoleMember, Scopes: []string{model.ScopeRead, model.ScopeWrite}})
+	if _, ok := svc.DeleteTODO(member, ids[:2]).(*model.ErrForbidden); !ok {
+		t.Error("member must not delete multiple TODOs")
+	}
+	//1件ずつであればメンバーも削除できる
+	if err := svc.DeleteTODO(member, ids[:1]); err != nil {
+		t.Error("member must delete a TODO, err =", err)
+	}
+
+	admin := service.WithAPIKey(ctx, &model.APIKey{Role: model.RoleAdmin, Scopes: []string{model.ScopeRead, model.ScopeWrite}})
+	if err := svc.DeleteTODO(admin, ids[1:]); err != nil {
+		t.Error("admin must delete multiple TODOs, err =", err)
+	}
+}
+
+func TestWorkspaceService_Enter(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.New()
+	svc := service.NewWorkspaceServiceWithRepository(repo)
+	keys := service.NewAPIKeyServiceWithRepository(repo)
+
+	ws, err := svc.CreateWorkspace(ctx, "team")
+	if err != nil {
+		t.Fatal("failed to create workspace, err =", err)
+	}
+	member, _, err := keys.CreateAPIKey(ctx, "member", model.RoleMember, []string{model.ScopeRead})
+	if err != nil {
+		t.Fatal("failed to create API key, err =", err)
+	}
+	outsider, _, err := keys.CreateAPIKey(ctx, "outsider", model.RoleMember, []string{model.ScopeRead})
+	if err != nil {
+		t.Fatal("failed to create API key, err =", err)
+	}
+	if err := svc.AddMember(ctx, ws.ID, member.ID); err != nil {
+		t.Fatal("failed to add member, err =", err)
+	}
+	if _, ok := svc.AddMember(ctx, ws.ID, outsider.ID+1).(*model.ErrNotFound); !ok {
+		t.Error("adding unknown API key must return ErrNotFound")
+	}
+
+	if _, err := svc.Enter(service.WithAPIKey(ctx, member), ws.ID); err != nil {
+		t.Error("member must enter the workspace, err =", err)
+	}
+	if _, err := svc.Enter(service.WithAPIKey(ctx, outsider), ws.ID); err == nil {
+		t.Error("outsider must not enter the workspace")
+	} else if _, ok := err.(*model.ErrForbidden); !ok {
+		t.Error("outsider must get ErrForbidden, err =", err)
+	}
+	if _, err := svc.Enter(service.WithAPIKey(ctx, outsider), model.DefaultWorkspace); err != nil {
+		t.Error("everyone must enter the default workspace, err =", err)
+	}
+	if _, err := svc.Enter(ctx, ws.ID+1); err == nil {
+		t.Error("unknown workspace must not be entered")
+	}
+	//APIキーのないリクエストは既定のワークスペースにしか入れない
+	if _, err := svc.Enter(ctx, ws.ID); err == nil {
+		t.Error("request without an API key must not enter the workspace")
+	} else if _, ok := err.(*model.ErrForbidden); !ok {
+		t.Error("request without an API key must get ErrForbidden, err =", err)
+	}
+	if _, err := svc.Enter(ctx, model.DefaultWorkspace); err != nil {
+		t.Error("request without an API key must enter the default workspace, err =", err)
+	}
+}
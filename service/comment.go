@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/repository/sqlrepo"
+)
+
+// A CommentService implements CRUD of Comment entities.
+type CommentService struct {
+	repo repository.Repository
+}
+
+// NewCommentService returns new CommentService backed by db.
+func NewCommentService(db *sql.DB) *CommentService {
+	return NewCommentServiceWithRepository(sqlrepo.New(db))
+}
+
+// NewCommentServiceWithRepository returns new CommentService backed by repo.
+func NewCommentServiceWithRepository(repo repository.Repository) *CommentService {
+	return &CommentService{
+		repo: repo,
+	}
+}
+
+// CreateComment creates a Comment on the TODO.
+func (s *CommentService) CreateComment(ctx context.Context, todoID int64, body string) (*model.Comment, error) {
+	//TODOの存在確認と挿入を同じトランザクションで行う
+	var comment *model.Comment
+	err := s.repo.WithTx(ctx, func(r repository.Repository) error {
+		var err error
+		comment, err = r.Comments().Create(ctx, todoID, body)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// ReadComments reads Comments of the TODO.
+func (s *CommentService) ReadComments(ctx context.Context, todoID, prevID, size int64) ([]*model.Comment, error) {
+	return s.repo.Comments().Read(ctx, todoID, prevID, size)
+}
+
+// DeleteComment deletes the Comment of the TODO.
+func (s *CommentService) DeleteComment(ctx context.Context, todoID, id int64) error {
+	return s.repo.WithTx(ctx, func(r repository.Repository) error {
+		return r.Comments().Delete(ctx, todoID, id)
+	})
+}
+
+// commentPageSize is the number of comments readComments reads at once.
+const commentPageSize = 100
+
+// readComments returns all Comments of the TODO, newest first.
+func readComments(ctx context.Context, r repository.Repository, todoID int64) ([]*model.Comment, error) {
+	var all []*model.Comment
+	var prevID int64
+	for {
+		comments, err := r.Comments().Read(ctx, todoID, prevID, commentPageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, comments...)
+		if len(comments) < commentPageSize {
+			return all, nil
+		}
+		prevID = comments[len(comments)-1].ID
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+const (
+	// DefaultLockTTL is how long ClaimTODO locks a TODO unless told otherwise.
+	DefaultLockTTL = 15 * time.Minute
+	// MaxLockTTL is the longest lock ClaimTODO accepts, so that a forgotten
+	// claim does not block the others for long.
+	MaxLockTTL = 24 * time.Hour
+)
+
+// ClaimTODO locks the TODO of id for the API key of ctx for ttl
+// (DefaultLockTTL if 0). Until the lock expires or is released, the others
+// cannot update or delete the TODO. Claiming a TODO the caller holds again
+// extends the lock. It returns *model.ErrLocked if another API key holds
+// the TODO, *model.ErrNotFound if the TODO does not exist, and
+// *model.ErrValidation if ttl is negative or longer than MaxLockTTL.
+func (s *TODOService) ClaimTODO(ctx context.Context, id int64, ttl time.Duration) (*model.TODOLock, error) {
+	if ttl == 0 {
+		ttl = DefaultLockTTL
+	}
+	if ttl < 0 || ttl > MaxLockTTL {
+		return nil, &model.ErrValidation{Field: "ttl_seconds", Reason: "must be between 1 and 86400"}
+	}
+
+	var lock *model.TODOLock
+	err := s.WithTx(ctx, func(r repository.Repository) error {
+		if _, err := r.TODOs().Find(ctx, id); err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := checkLock(ctx, r, id, now); err != nil {
+			return err
+		}
+		lock = &model.TODOLock{TODOID: id, HolderID: apiKeyID(ctx), ExpiresAt: now.Add(ttl).UTC()}
+		return r.Locks().Set(ctx, lock)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// ReleaseTODO removes the lock of the caller on the TODO of id. Releasing a
+// TODO which is not locked or whose lock expired succeeds. It returns
+// *model.ErrLocked if another API key holds the TODO, and
+// *model.ErrNotFound if the TODO does not exist.
+func (s *TODOService) ReleaseTODO(ctx context.Context, id int64) error {
+	return s.WithTx(ctx, func(r repository.Repository) error {
+		if _, err := r.TODOs().Find(ctx, id); err != nil {
+			return err
+		}
+		if err := checkLock(ctx, r, id, time.Now()); err != nil {
+			return err
+		}
+		return r.Locks().Delete(ctx, []int64{id})
+	})
+}
+
+// checkLock returns *model.ErrLocked if the TODO of id has a lock held by
+// another API key than the one of ctx and unexpired at now.
+func checkLock(ctx context.Context, r repository.Repository, id int64, now time.Time) error {
+	lock, err := r.Locks().Find(ctx, id)
+	if _, ok := err.(*model.ErrNotFound); ok {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if lock.HolderID == apiKeyID(ctx) || !lock.ExpiresAt.After(now) {
+		return nil
+	}
+	return &model.ErrLocked{Lock: *lock}
+}
@@ -0,0 +1,77 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestTODOService_Claim(t *testing.T) {
+	for driver, d := range openBackends(t) {
+		d := d
+		t.Run(driver, func(t *testing.T) {
+			svc := service.NewTODOService(d)
+			alice := service.WithAPIKey(context.Background(), &model.APIKey{ID: 1, Role: model.RoleMember})
+			bob := service.WithAPIKey(context.Background(), &model.APIKey{ID: 2, Role: model.RoleMember})
+
+			todo, err := svc.CreateTODO(alice, "subject", "")
+			if err != nil {
+				t.Fatal("failed to create TODO, err =", err)
+			}
+			lock, err := svc.ClaimTODO(alice, todo.ID, 0)
+			if err != nil {
+				t.Fatal("failed to claim TODO, err =", err)
+			}
+			if lock.HolderID != 1 || lock.ExpiresAt.Before(time.Now().Add(service.DefaultLockTTL-time.Minute)) {
+				t.Errorf("unexpected lock, got = %+v", lock)
+			}
+
+			//ロックしていないAPIキーからは更新・削除・ロック・解除できない
+			assertLocked := func(name string, err error) {
+				t.Helper()
+				if locked, ok := err.(*model.ErrLocked); !ok || locked.Lock.HolderID != 1 {
+					t.Errorf("%s: expected ErrLocked, got = %v", name, err)
+				}
+			}
+			_, err = svc.UpdateTODO(bob, todo.ID, "bob", "")
+			assertLocked("update", err)
+			_, err = svc.ArchiveTODO(bob, todo.ID, true)
+			assertLocked("archive", err)
+			assertLocked("delete", svc.DeleteTODO(bob, []int64{todo.ID}))
+			_, err = svc.ClaimTODO(bob, todo.ID, time.Minute)
+			assertLocked("claim", err)
+			assertLocked("release", svc.ReleaseTODO(bob, todo.ID))
+
+			if _, err := svc.UpdateTODO(alice, todo.ID, "alice", ""); err != nil {
+				t.Error("failed to update TODO by the holder, err =", err)
+			}
+			if err := svc.ReleaseTODO(alice, todo.ID); err != nil {
+				t.Fatal("failed to release TODO, err =", err)
+			}
+			if _, err := svc.UpdateTODO(bob, todo.ID, "bob", ""); err != nil {
+				t.Error("failed to update released TODO, err =", err)
+			}
+
+			//期限切れのロックは他のAPIキーが取得できる
+			if _, err := svc.ClaimTODO(alice, todo.ID, time.Nanosecond); err != nil {
+				t.Fatal("failed to claim TODO, err =", err)
+			}
+			time.Sleep(time.Millisecond)
+			if _, err := svc.ClaimTODO(bob, todo.ID, time.Minute); err != nil {
+				t.Error("failed to claim TODO whose lock expired, err =", err)
+			}
+
+			if _, err := svc.ClaimTODO(alice, todo.ID, 25*time.Hour); err == nil {
+				t.Error("claiming for longer than MaxLockTTL succeeded")
+			}
+			if _, err := svc.ClaimTODO(alice, 999, 0); err == nil {
+				t.Error("claiming a missing TODO succeeded")
+			} else if _, ok := err.(*model.ErrNotFound); !ok {
+				t.Errorf("unexpected error type, got = %T", err)
+			}
+		})
+	}
+}
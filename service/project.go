@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/repository/sqlrepo"
+)
+
+// A ProjectService implements CRUD of Project entities.
+type ProjectService struct {
+	repo repository.Repository
+}
+
+// NewProjectService returns new ProjectService backed by db.
+func NewProjectService(db *sql.DB) *ProjectService {
+	return NewProjectServiceWithRepository(sqlrepo.New(db))
+}
+
+// NewProjectServiceWithRepository returns new ProjectService backed by repo.
+func NewProjectServiceWithRepository(repo repository.Repository) *ProjectService {
+	return &ProjectService{
+		repo: repo,
+	}
+}
+
+// CreateProject creates a Project.
+func (s *ProjectService) CreateProject(ctx context.Context, name, description string) (*model.Project, error) {
+	var project *model.Project
+	err := s.repo.WithTx(ctx, func(r repository.Repository) error {
+		var err error
+		project, err = r.Projects().Create(ctx, name, description)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// ReadProjects reads Projects with the counts of their TODOs.
+func (s *ProjectService) ReadProjects(ctx context.Context, prevID, size int64) ([]*model.Project, error) {
+	return s.repo.Projects().Read(ctx, prevID, size)
+}
+
+// ReadProject reads the Project of id. It returns *model.ErrNotFound if the Project does not exist.
+func (s *ProjectService) ReadProject(ctx context.Context, id int64) (*model.Project, error) {
+	return s.repo.Projects().Find(ctx, id)
+}
+
+// UpdateProject updates the name and description of the Project.
+func (s *ProjectService) UpdateProject(ctx context.Context, id int64, name, description string) (*model.Project, error) {
+	var project *model.Project
+	err := s.repo.WithTx(ctx, func(r repository.Repository) error {
+		var err error
+		project, err = r.Projects().Update(ctx, id, name, description)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// DeleteProject deletes the Project. Its TODOs are kept without a project.
+func (s *ProjectService) DeleteProject(ctx context.Context, id int64) error {
+	return s.repo.WithTx(ctx, func(r repository.Repository) error {
+		return r.Projects().Delete(ctx, id)
+	})
+}
+
+// existsProject returns *model.ErrNotFound if the Project of id does not exist.
+// The ID 0 means no project and always succeeds.
+func existsProject(ctx context.Context, r repository.Repository, id int64) error {
+	if id == 0 {
+		return nil
+	}
+	_, err := r.Projects().Find(ctx, id)
+	return err
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+var errNoGap = errors.New("service: no gap left to move the TODO")
+
+// ReorderTODO places the TODOs of ids in the given order. The TODOs take
+// over the positions they occupied before, so other TODOs do not move and
+// only the listed rows are written.
+// It returns *model.ErrValidation if ids is empty or has duplicates.
+func (s *TODOService) ReorderTODO(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return &model.ErrValidation{Field: "ids", Reason: "must not be empty"}
+	}
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return &model.ErrValidation{Field: "ids", Reason: "must not have duplicates"}
+		}
+		seen[id] = true
+	}
+
+	return s.WithTx(ctx, func(r repository.Repository) error {
+		positions, err := r.TODOs().Positions(ctx, ids)
+		if err != nil {
+			return err
+		}
+		slots := sortedPositions(positions)
+
+		//同じ位置のTODOがある場合は順序を決められないため、全体を振り直してから読み直す
+		if hasDuplicates(slots) {
+			if err := r.TODOs().Renumber(ctx); err != nil {
+				return err
+			}
+			if positions, err = r.TODOs().Positions(ctx, ids); err != nil {
+				return err
+			}
+			slots = sortedPositions(positions)
+		}
+
+		//位置が変わるTODOのみを更新する
+		changed := map[int64]int64{}
+		for i, id := range ids {
+			if positions[id] != slots[i] {
+				changed[id] = slots[i]
+			}
+		}
+		return r.TODOs().SetPositions(ctx, changed)
+	})
+}
+
+// MoveTODO moves the TODO of id right before the TODO of target, or right
+// after it if after is true. The moved TODO gets a position in the gap
+// between target and its neighbor, so usually only one row is written;
+// all positions are renumbered once the gap runs out.
+func (s *TODOService) MoveTODO(ctx context.Context, id, target int64, after bool) error {
+	if id == target {
+		return &model.ErrValidation{Field: "id", Reason: "must differ from the target"}
+	}
+
+	return s.WithTx(ctx, func(r repository.Repository) error {
+		for renumbered := false; ; renumbered = true {
+			positions, err := r.TODOs().Positions(ctx, []int64{id, target})
+			if err != nil {
+				return err
+			}
+			pos, ok, err := between(ctx, r.TODOs(), positions[target], after, id)
+			if err != nil {
+				return err
+			}
+			if ok {
+				return r.TODOs().SetPositions(ctx, map[int64]int64{id: pos})
+			}
+			//振り直した後は必ず隙間があるが、念のため無限ループを避ける
+			if renumbered {
+				return errNoGap
+			}
+			if err := r.TODOs().Renumber(ctx); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// between returns a position between target and its neighbor in the
+// direction of after. ok is false if there is no room left between them.
+func between(ctx context.Context, todos repository.TODORepository, target int64, after bool, exclude int64) (pos int64, ok bool, err error) {
+	neighbor, found, err := todos.Neighbor(ctx, target, after, exclude)
+	if err != nil {
+		return 0, false, err
+	}
+	if !found {
+		//端に移動する場合は間隔を空けて置く
+		if after {
+			return target + repository.PositionGap, true, nil
+		}
+		return target - repository.PositionGap, true, nil
+	}
+	if d := neighbor - target; d > 1 || d < -1 {
+		return target + d/2, true, nil
+	}
+	return 0, false, nil
+}
+
+func sortedPositions(positions map[int64]int64) []int64 {
+	sorted := make([]int64, 0, len(positions))
+	for _, pos := range positions {
+		sorted = append(sorted, pos)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func hasDuplicates(sorted []int64) bool {
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i] == sorted[i-1] {
+			return true
+		}
+	}
+	return false
+}
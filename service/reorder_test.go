@@ -0,0 +1,84 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository/memory"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestTODOService_Reorder(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewTODOServiceWithRepository(memory.New())
+
+	ids := map[string]int64{}
+	for _, s := range []string{"a", "b", "c", "d"} {
+		todo, err := svc.CreateTODO(ctx, s, "")
+		if err != nil {
+			t.Fatal("failed to create TODO, err =", err)
+		}
+		ids[s] = todo.ID
+	}
+
+	order := func() string {
+		t.Helper()
+		todos, err := svc.ListTODO(ctx, &model.TODOQuery{Size: 10, Sort: []model.TODOSort{{Field: "position"}}})
+		if err != nil {
+			t.Fatal("failed to list TODOs, err =", err)
+		}
+		var got string
+		for _, todo := range todos {
+			got += todo.Subject
+		}
+		return got
+	}
+
+	//新しいTODOほど先頭に置かれる
+	if got := order(); got != "dcba" {
+		t.Errorf("unexpected initial order, got = %s", got)
+	}
+
+	if err := svc.ReorderTODO(ctx, []int64{ids["a"], ids["d"]}); err != nil {
+		t.Fatal("failed to reorder TODOs, err =", err)
+	}
+	if got := order(); got != "acbd" {
+		t.Errorf("unexpected order after reorder, got = %s", got)
+	}
+
+	if err := svc.MoveTODO(ctx, ids["d"], ids["a"], false); err != nil {
+		t.Fatal("failed to move TODO, err =", err)
+	}
+	if err := svc.MoveTODO(ctx, ids["c"], ids["b"], true); err != nil {
+		t.Fatal("failed to move TODO, err =", err)
+	}
+	if got := order(); got != "dabc" {
+		t.Errorf("unexpected order after move, got = %s", got)
+	}
+
+	//同じ隙間に移動し続けると、隙間がなくなった時点で全体が振り直される
+	for i := 0; i < 40; i++ {
+		moved, target := ids["b"], ids["a"]
+		if i%2 == 1 {
+			moved = ids["a"]
+			target = ids["b"]
+		}
+		if err := svc.MoveTODO(ctx, moved, target, false); err != nil {
+			t.Fatal("failed to move TODO, err =", err)
+		}
+	}
+	if got := order(); got != "dabc" {
+		t.Errorf("unexpected order after renumbering, got = %s", got)
+	}
+
+	if err := svc.MoveTODO(ctx, ids["a"], ids["a"], true); err == nil {
+		t.Error("moving a TODO next to itself must be rejected")
+	}
+	if err := svc.ReorderTODO(ctx, []int64{ids["a"], ids["a"]}); err == nil {
+		t.Error("duplicate IDs must be rejected")
+	}
+	if _, ok := svc.MoveTODO(ctx, ids["a"], 999, true).(*model.ErrNotFound); !ok {
+		t.Error("moving next to an unknown TODO must return ErrNotFound")
+	}
+}
@@ -0,0 +1,187 @@
+// Package servicetest provides a fake of the TODO service, so that handlers
+// can be tested without a store and with errors a real store cannot easily
+// be made to return.
+package servicetest
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// ErrNotImplemented is returned by the methods of TODOService whose function
+// is not set.
+var ErrNotImplemented = errors.New("servicetest: method not implemented")
+
+// A TODOService is a service.TODOServicer calling the function set for each
+// method, e.g. ListTODOFunc for ListTODO. Methods whose function is nil
+// return ErrNotImplemented.
+type TODOService struct {
+	InsertTODOFunc   func(ctx context.Context, todo *model.TODO) (*model.TODO, error)
+	ReadTODOByIDFunc func(ctx context.Context, id int64) (*model.TODO, error)
+	ListTODOFunc     func(ctx context.Context, q *model.TODOQuery) ([]*model.TODO, error)
+	StreamTODOFunc   func(ctx context.Context, q *model.TODOQuery, fn func(*model.TODO) error) error
+	CountTODOFunc    func(ctx context.Context, q *model.TODOQuery) (int64, error)
+	TODOVersionFunc  func(ctx context.Context) (*model.TODOVersion, error)
+	TODOStatsFunc    func(ctx context.Context) (*model.TODOStats, error)
+	TODOChangesFunc  func(ctx context.Context, since time.Time) (*model.TODOChanges, error)
+	PatchTODOFunc    func(ctx context.Context, id int64, patch *model.TODOPatch) (*model.TODO, error)
+	CloneTODOFunc    func(ctx context.Context, id int64, withComments bool) (*model.TODO, error)
+	ArchiveTODOFunc  func(ctx context.Context, id int64, archived bool) (*model.TODO, error)
+	ReorderTODOFunc  func(ctx context.Context, ids []int64) error
+	MoveTODOFunc     func(ctx context.Context, id, target int64, after bool) error
+	UndoTODOFunc     func(ctx context.Context) (string, []*model.TODO, error)
+	DeleteTODOFunc   func(ctx context.Context, ids []int64) error
+	ClaimTODOFunc    func(ctx context.Context, id int64, ttl time.Duration) (*model.TODOLock, error)
+	ReleaseTODOFunc  func(ctx context.Context, id int64) error
+	AssignTODOFunc   func(ctx context.Context, id, assigneeID int64) (*model.TODO, error)
+}
+
+var _ service.TODOServicer = (*TODOService)(nil)
+
+// InsertTODO calls InsertTODOFunc.
+func (s *TODOService) InsertTODO(ctx context.Context, todo *model.TODO) (*model.TODO, error) {
+	if s.InsertTODOFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return s.InsertTODOFunc(ctx, todo)
+}
+
+// ReadTODOByID calls ReadTODOByIDFunc.
+func (s *TODOService) ReadTODOByID(ctx context.Context, id int64) (*model.TODO, error) {
+	if s.ReadTODOByIDFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return s.ReadTODOByIDFunc(ctx, id)
+}
+
+// ListTODO calls ListTODOFunc.
+func (s *TODOService) ListTODO(ctx context.Context, q *model.TODOQuery) ([]*model.TODO, error) {
+	if s.ListTODOFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return s.ListTODOFunc(ctx, q)
+}
+
+// StreamTODO calls StreamTODOFunc.
+func (s *TODOService) StreamTODO(ctx context.Context, q *model.TODOQuery, fn func(*model.TODO) error) error {
+	if s.StreamTODOFunc == nil {
+		return ErrNotImplemented
+	}
+	return s.StreamTODOFunc(ctx, q, fn)
+}
+
+// CountTODO calls CountTODOFunc.
+func (s *TODOService) CountTODO(ctx context.Context, q *model.TODOQuery) (int64, error) {
+	if s.CountTODOFunc == nil {
+		return 0, ErrNotImplemented
+	}
+	return s.CountTODOFunc(ctx, q)
+}
+
+// TODOVersion calls TODOVersionFunc.
+func (s *TODOService) TODOVersion(ctx context.Context) (*model.TODOVersion, error) {
+	if s.TODOVersionFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return s.TODOVersionFunc(ctx)
+}
+
+// TODOStats calls TODOStatsFunc.
+func (s *TODOService) TODOStats(ctx context.Context) (*model.TODOStats, error) {
+	if s.TODOStatsFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return s.TODOStatsFunc(ctx)
+}
+
+// TODOChanges calls TODOChangesFunc.
+func (s *TODOService) TODOChanges(ctx context.Context, since time.Time) (*model.TODOChanges, error) {
+	if s.TODOChangesFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return s.TODOChangesFunc(ctx, since)
+}
+
+// PatchTODO calls PatchTODOFunc.
+func (s *TODOService) PatchTODO(ctx context.Context, id int64, patch *model.TODOPatch) (*model.TODO, error) {
+	if s.PatchTODOFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return s.PatchTODOFunc(ctx, id, patch)
+}
+
+// CloneTODO calls CloneTODOFunc.
+func (s *TODOService) CloneTODO(ctx context.Context, id int64, withComments bool) (*model.TODO, error) {
+	if s.CloneTODOFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return s.CloneTODOFunc(ctx, id, withComments)
+}
+
+// ArchiveTODO calls ArchiveTODOFunc.
+func (s *TODOService) ArchiveTODO(ctx context.Context, id int64, archived bool) (*model.TODO, error) {
+	if s.ArchiveTODOFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return s.ArchiveTODOFunc(ctx, id, archived)
+}
+
+// ReorderTODO calls ReorderTODOFunc.
+func (s *TODOService) ReorderTODO(ctx context.Context, ids []int64) error {
+	if s.ReorderTODOFunc == nil {
+		return ErrNotImplemented
+	}
+	return s.ReorderTODOFunc(ctx, ids)
+}
+
+// MoveTODO calls MoveTODOFunc.
+func (s *TODOService) MoveTODO(ctx context.Context, id, target int64, after bool) error {
+	if s.MoveTODOFunc == nil {
+		return ErrNotImplemented
+	}
+	return s.MoveTODOFunc(ctx, id, target, after)
+}
+
+// UndoTODO calls UndoTODOFunc.
+func (s *TODOService) UndoTODO(ctx context.Context) (string, []*model.TODO, error) {
+	if s.UndoTODOFunc == nil {
+		return "", nil, ErrNotImplemented
+	}
+	return s.UndoTODOFunc(ctx)
+}
+
+// DeleteTODO calls DeleteTODOFunc.
+func (s *TODOService) DeleteTODO(ctx context.Context, ids []int64) error {
+	if s.DeleteTODOFunc == nil {
+		return ErrNotImplemented
+	}
+	return s.DeleteTODOFunc(ctx, ids)
+}
+
+// ClaimTODO calls ClaimTODOFunc.
+func (s *TODOService) ClaimTODO(ctx context.Context, id int64, ttl time.Duration) (*model.TODOLock, error) {
+	if s.ClaimTODOFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return s.ClaimTODOFunc(ctx, id, ttl)
+}
+
+// ReleaseTODO calls ReleaseTODOFunc.
+func (s *TODOService) ReleaseTODO(ctx context.Context, id int64) error {
+	if s.ReleaseTODOFunc == nil {
+		return ErrNotImplemented
+	}
+	return s.ReleaseTODOFunc(ctx, id)
+}
+
+// AssignTODO calls AssignTODOFunc.
+func (s *TODOService) AssignTODO(ctx context.Context, id, assigneeID int64) (*model.TODO, error) {
+	if s.AssignTODOFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return s.AssignTODOFunc(ctx, id, assigneeID)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// A TemplateService implements saving sets of TODOs as Templates and creating TODOs from them.
+type TemplateService struct {
+	repo repository.Repository
+}
+
+// NewTemplateServiceWithRepository returns new TemplateService backed by repo.
+func NewTemplateServiceWithRepository(repo repository.Repository) *TemplateService {
+	return &TemplateService{
+		repo: repo,
+	}
+}
+
+// CreateTemplate creates a Template with the items, or with the subject,
+// description and priority of the TODOs of todoIDs in that order. Exactly one
+// of items and todoIDs must be given.
+// It returns *model.ErrValidation if an item is invalid, and
+// *model.ErrNotFound if a TODO does not exist.
+func (s *TemplateService) CreateTemplate(ctx context.Context, name, description string, items []model.TemplateItem, todoIDs []int64) (*model.Template, error) {
+	if name == "" {
+		return nil, &model.ErrValidation{Field: "name", Reason: "must not be empty"}
+	}
+	if (len(items) == 0) == (len(todoIDs) == 0) {
+		return nil, &model.ErrValidation{Field: "items", Reason: "either items or todo_ids is required"}
+	}
+	for _, item := range items {
+		if item.Subject == "" {
+			return nil, &model.ErrValidation{Field: "items", Reason: "subject must not be empty"}
+		}
+		if err := validatePriority(item.Priority); err != nil {
+			return nil, err
+		}
+	}
+
+	var template *model.Template
+	err := s.repo.WithTx(ctx, func(r repository.Repository) error {
+		//既存のTODOから作成する場合は、件名・説明・優先度だけを写す
+		for _, id := range todoIDs {
+			todo, err := r.TODOs().Find(ctx, id)
+			if err != nil {
+				return err
+			}
+			items = append(items, model.TemplateItem{Subject: todo.Subject, Description: todo.Description, Priority: todo.Priority})
+		}
+		var err error
+		template, err = r.Templates().Create(ctx, &model.Template{Name: name, Description: description, Items: items})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// ReadTemplates reads all Templates, newest first.
+func (s *TemplateService) ReadTemplates(ctx context.Context) ([]*model.Template, error) {
+	return s.repo.Templates().Read(ctx)
+}
+
+// ReadTemplate reads the Template of id. It returns *model.ErrNotFound if the Template does not exist.
+func (s *TemplateService) ReadTemplate(ctx context.Context, id int64) (*model.Template, error) {
+	return s.repo.Templates().Find(ctx, id)
+}
+
+// DeleteTemplate deletes the Template. TODOs created from it are kept.
+func (s *TemplateService) DeleteTemplate(ctx context.Context, id int64) error {
+	return s.repo.WithTx(ctx, func(r repository.Repository) error {
+		return r.Templates().Delete(ctx, id)
+	})
+}
+
+// InstantiateTemplate creates a TODO for each item of the Template in the
+// project (none if 0), all or nothing, and returns them in the order of the items.
+// It returns *model.ErrNotFound if the Template or the project does not exist.
+func (s *TemplateService) InstantiateTemplate(ctx context.Context, id, projectID int64) ([]*model.TODO, error) {
+	var todos []*model.TODO
+	err := s.repo.WithTx(ctx, func(r repository.Repository) error {
+		template, err := r.Templates().Find(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := existsProject(ctx, r, projectID); err != nil {
+			return err
+		}
+
+		//新しいTODOほど先頭に並ぶため、最初の項目が先頭になるよう逆順に作成する
+		todos = make([]*model.TODO, len(template.Items))
+		for i := len(template.Items) - 1; i >= 0; i-- {
+			item := template.Items[i]
+			todos[i], err = r.TODOs().Create(ctx, &model.TODO{
+				Subject:     item.Subject,
+				Description: item.Description,
+				Priority:    item.Priority,
+				ProjectID:   projectID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
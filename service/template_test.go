@@ -0,0 +1,50 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository/sqlrepo"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestTemplateService_Instantiate(t *testing.T) {
+	for driver, d := range openBackends(t) {
+		d := d
+		t.Run(driver, func(t *testing.T) {
+			ctx := context.Background()
+			svc := service.NewTemplateServiceWithRepository(sqlrepo.New(d))
+			todos := service.NewTODOService(d)
+
+			template, err := svc.CreateTemplate(ctx, "weekly", "", []model.TemplateItem{{Subject: "first"}, {Subject: "second", Priority: "low"}}, nil)
+			if err != nil {
+				t.Fatal("failed to create template, err =", err)
+			}
+
+			//プロジェクトが存在しない場合は、1件も作成しない
+			if _, err := svc.InstantiateTemplate(ctx, template.ID, 999); err == nil {
+				t.Error("expected ErrNotFound for unknown project")
+			}
+			if count, err := todos.CountTODO(ctx, &model.TODOQuery{}); err != nil || count != 0 {
+				t.Errorf("no TODO must be created on failure, got = %d, err = %v", count, err)
+			}
+
+			created, err := svc.InstantiateTemplate(ctx, template.ID, 0)
+			if err != nil {
+				t.Fatal("failed to instantiate template, err =", err)
+			}
+			if len(created) != 2 || created[0].Subject != "first" || created[1].Priority != "low" {
+				t.Errorf("unexpected TODOs, got = %+v", created)
+			}
+			//最初の項目が一覧の先頭に並ぶ
+			list, err := todos.ReadTODO(ctx, 0, 5)
+			if err != nil {
+				t.Fatal("failed to read TODOs, err =", err)
+			}
+			if len(list) != 2 || list[0].ID != created[0].ID {
+				t.Errorf("unexpected order, got = %+v", list)
+			}
+		})
+	}
+}
@@ -3,182 +3,450 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+	"github.com/TechBowl-japan/go-stations/repository/sqlrepo"
 )
 
 // A TODOService implements CRUD of TODO entities.
 type TODOService struct {
-	db *sql.DB
+	repo repository.Repository
+	opts TODOOptions
 }
 
-// NewTODOService returns new TODOService.
+// A TODOServicer is the part of TODOService used by the TODO handler, so that
+// the handler can be tested against a fake such as servicetest.TODOService.
+// The methods are documented on TODOService.
+type TODOServicer interface {
+	InsertTODO(ctx context.Context, todo *model.TODO) (*model.TODO, error)
+	ReadTODOByID(ctx context.Context, id int64) (*model.TODO, error)
+	ListTODO(ctx context.Context, q *model.TODOQuery) ([]*model.TODO, error)
+	StreamTODO(ctx context.Context, q *model.TODOQuery, fn func(*model.TODO) error) error
+	CountTODO(ctx context.Context, q *model.TODOQuery) (int64, error)
+	TODOVersion(ctx context.Context) (*model.TODOVersion, error)
+	TODOStats(ctx context.Context) (*model.TODOStats, error)
+	TODOChanges(ctx context.Context, since time.Time) (*model.TODOChanges, error)
+	PatchTODO(ctx context.Context, id int64, patch *model.TODOPatch) (*model.TODO, error)
+	CloneTODO(ctx context.Context, id int64, withComments bool) (*model.TODO, error)
+	ArchiveTODO(ctx context.Context, id int64, archived bool) (*model.TODO, error)
+	ReorderTODO(ctx context.Context, ids []int64) error
+	MoveTODO(ctx context.Context, id, target int64, after bool) error
+	UndoTODO(ctx context.Context) (string, []*model.TODO, error)
+	DeleteTODO(ctx context.Context, ids []int64) error
+	ClaimTODO(ctx context.Context, id int64, ttl time.Duration) (*model.TODOLock, error)
+	ReleaseTODO(ctx context.Context, id int64) error
+	AssignTODO(ctx context.Context, id, assigneeID int64) (*model.TODO, error)
+}
+
+var _ TODOServicer = (*TODOService)(nil)
+
+// TODOOptions configures a TODOService.
+type TODOOptions struct {
+	// UndoWindow is how long updates and deletes can be undone with UndoTODO.
+	// If 0, DefaultUndoWindow is used.
+	UndoWindow time.Duration
+	// AssignHooks are called in order whenever AssignTODO changes the
+	// assignee of a TODO, e.g. to notify the new assignee.
+	AssignHooks []AssignHook
+}
+
+// NewTODOService returns new TODOService backed by db.
 func NewTODOService(db *sql.DB) *TODOService {
+	return NewTODOServiceWithRepository(sqlrepo.New(db))
+}
+
+// NewTODOServiceWithRepository returns new TODOService backed by repo with the default TODOOptions.
+func NewTODOServiceWithRepository(repo repository.Repository) *TODOService {
+	return NewTODOServiceWithOptions(repo, TODOOptions{})
+}
+
+// NewTODOServiceWithOptions returns new TODOService backed by repo.
+func NewTODOServiceWithOptions(repo repository.Repository, opts TODOOptions) *TODOService {
+	if opts.UndoWindow == 0 {
+		opts.UndoWindow = DefaultUndoWindow
+	}
 	return &TODOService{
-		db: db,
+		repo: repo,
+		opts: opts,
 	}
 }
 
+// WithTx runs fn in a transaction of the underlying repository.
+// The transaction is rolled back if fn returns an error or ctx is canceled.
+func (s *TODOService) WithTx(ctx context.Context, fn func(r repository.Repository) error) error {
+	return s.repo.WithTx(ctx, fn)
+}
+
 // CreateTODO creates a TODO on DB.
 func (s *TODOService) CreateTODO(ctx context.Context, subject, description string) (*model.TODO, error) {
-	const (
-		insert  = `INSERT INTO todos(subject, description) VALUES(?, ?)`
-		confirm = `SELECT subject, description, created_at, updated_at FROM todos WHERE id = ?`
-	)
-	//TODOを挿入
-	result, err := s.db.ExecContext(ctx, insert, subject, description)
-	if err != nil {
+	return s.InsertTODO(ctx, &model.TODO{Subject: subject, Description: description})
+}
+
+// InsertTODO creates a TODO with the subject, description, done, priority and project of todo.
+// It returns *model.ErrValidation if the priority is unknown, and
+// *model.ErrNotFound if the project does not exist.
+func (s *TODOService) InsertTODO(ctx context.Context, todo *model.TODO) (*model.TODO, error) {
+	if err := validatePriority(todo.Priority); err != nil {
 		return nil, err
 	}
-	//resultの新しく作成されたTODOのIDを取得
-	id, err := result.LastInsertId()
+
+	var created *model.TODO
+	err := s.WithTx(ctx, func(r repository.Repository) error {
+		if err := existsProject(ctx, r, todo.ProjectID); err != nil {
+			return err
+		}
+		var err error
+		created, err = r.TODOs().Create(ctx, todo)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	//IDを使用してTODOを取得
-	row := s.db.QueryRowContext(ctx, confirm, id)
-	todo := &model.TODO{
-		ID: id,
+	return created, nil
+}
+
+// ReadTODO reads TODOs on DB.
+func (s *TODOService) ReadTODO(ctx context.Context, prevID, size int64) ([]*model.TODO, error) {
+	return s.ListTODO(ctx, &model.TODOQuery{PrevID: prevID, Size: size})
+}
+
+// ReadTODOByID reads the TODO of id. It returns *model.ErrNotFound if the TODO does not exist.
+func (s *TODOService) ReadTODOByID(ctx context.Context, id int64) (*model.TODO, error) {
+	return s.repo.TODOs().Find(ctx, id)
+}
+
+// SearchTODO reads TODOs whose subject or description contains query.
+func (s *TODOService) SearchTODO(ctx context.Context, query string, prevID, size int64) ([]*model.TODO, error) {
+	return s.ListTODO(ctx, &model.TODOQuery{Query: query, PrevID: prevID, Size: size})
+}
+
+// ListTODO reads TODOs matching q. It returns *model.ErrValidation if q has
+// an unknown status, priority or sort field, or combines PrevID with a sort
+// or an offset, and *model.ErrNotFound if the project of q does not exist.
+func (s *TODOService) ListTODO(ctx context.Context, q *model.TODOQuery) ([]*model.TODO, error) {
+	if err := validateQuery(q); err != nil {
+		return nil, err
 	}
-	err = row.Scan(&todo.Subject, &todo.Description, &todo.CreatedAt, &todo.UpdatedAt)
+	//存在しないプロジェクトで絞り込んだ場合は、空の一覧ではなくErrNotFoundを返す
+	if err := existsProject(ctx, s.repo, q.ProjectID); err != nil {
+		return nil, err
+	}
+	todos, err := s.repo.TODOs().List(ctx, q)
 	if err != nil {
 		return nil, err
 	}
-	//成功した場合、新しいTODOを返す
-	return todo, nil
+	//結果を返す
+	slog.DebugContext(ctx, "Retrieved TODOs", "count", len(todos))
+	return todos, nil
 }
 
-// ReadTODO reads TODOs on DB.
-func (s *TODOService) ReadTODO(ctx context.Context, prevID, size int64) ([]*model.TODO, error) {
-	const (
-		read       = `SELECT id, subject, description, created_at, updated_at FROM todos ORDER BY id DESC LIMIT ?`
-		readWithID = `SELECT id, subject, description, created_at, updated_at FROM todos WHERE id < ? ORDER BY id DESC LIMIT ?`
-	)
-	var rows *sql.Rows
-	var err error
-	log.Printf("Received prevID=%d, size=%d", prevID, size)
-
-	if prevID > 0 {
-		log.Printf("Executing query with prevID=%d and size=%d", prevID, size)
-		rows, err = s.db.QueryContext(ctx, readWithID, prevID, size)
-	} else {
-		log.Printf("Executing query: %s with size=%d", read, size)
-		rows, err = s.db.QueryContext(ctx, read, size)
+// StreamTODO calls fn with the TODOs matching q one at a time, as they are
+// read from the store, so that all of them need not be held in memory. It
+// validates q as ListTODO does before reading, and stops when ctx is done
+// or fn returns an error, which it returns.
+func (s *TODOService) StreamTODO(ctx context.Context, q *model.TODOQuery, fn func(*model.TODO) error) error {
+	if err := validateQuery(q); err != nil {
+		return err
 	}
+	if err := existsProject(ctx, s.repo, q.ProjectID); err != nil {
+		return err
+	}
+	return s.repo.TODOs().Stream(ctx, q, fn)
+}
 
-	if err != nil {
-		//クエリ実行中にエラーが発生した場合
-		log.Printf("Query execution failed: %v", err)
-		return nil, err
+// CountTODO returns the number of TODOs matching q, ignoring the page.
+func (s *TODOService) CountTODO(ctx context.Context, q *model.TODOQuery) (int64, error) {
+	if err := validateQuery(q); err != nil {
+		return 0, err
 	}
-	defer rows.Close() //rowsを必ず閉じる
+	return s.repo.TODOs().Count(ctx, q)
+}
 
-	//TODOリストを格納するスライス
-	todos := []*model.TODO{}
-	for rows.Next() {
-		todo := &model.TODO{}
-		//結果セットの行をスキャン
-		if err = rows.Scan(&todo.ID, &todo.Subject, &todo.Description, &todo.CreatedAt, &todo.UpdatedAt); err != nil {
-			log.Printf("Row scanning failed: %v", err)
-			return nil, err
+// ParseTODOSort parses a comma separated list of fields such as "-updated_at,subject".
+// A leading - sorts the field in descending order. Only the fields in
+// model.TODOSortFields are accepted.
+func ParseTODOSort(s string) ([]model.TODOSort, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var sorts []model.TODOSort
+	for _, field := range strings.Split(s, ",") {
+		sort := model.TODOSort{Field: strings.TrimSpace(field)}
+		if strings.HasPrefix(sort.Field, "-") {
+			sort.Field, sort.Desc = sort.Field[1:], true
 		}
-		todos = append(todos, todo)
+		if !model.TODOSortFields[sort.Field] {
+			return nil, &model.ErrValidation{Field: "sort", Reason: fmt.Sprintf("unknown field %q", sort.Field)}
+		}
+		sorts = append(sorts, sort)
 	}
+	return sorts, nil
+}
 
-	//繰り返し処理後のエラーを確認
-	if err := rows.Err(); err != nil {
-		log.Printf("Rows iteration failed: %v", err)
-		return nil, err
+func validateQuery(q *model.TODOQuery) error {
+	switch q.Status {
+	case "", model.TODOStatusOpen, model.TODOStatusDone:
+	default:
+		return &model.ErrValidation{Field: "status", Reason: "must be open or done"}
+	}
+	if err := validatePriority(q.Priority); err != nil {
+		return err
+	}
+	for _, sort := range q.Sort {
+		if !model.TODOSortFields[sort.Field] {
+			return &model.ErrValidation{Field: "sort", Reason: fmt.Sprintf("unknown field %q", sort.Field)}
+		}
 	}
+	if q.Offset < 0 {
+		return &model.ErrValidation{Field: "page", Reason: "must be positive"}
+	}
+	if q.PrevID > 0 && q.Offset > 0 {
+		return &model.ErrValidation{Field: "prev_id", Reason: "cannot be used with page"}
+	}
+	//prev_idはIDの降順でしか意味を持たないため、他の並び順とは併用できない
+	if q.PrevID > 0 && !q.DefaultSort() {
+		return &model.ErrValidation{Field: "prev_id", Reason: "cannot be used with sort"}
+	}
+	return nil
+}
 
-	//結果を返す
-	log.Printf("Retrieved todos count: %d, details: %v", len(todos), todos)
-	return todos, nil
+func validatePriority(priority string) error {
+	switch priority {
+	case "", model.PriorityLow, model.PriorityMedium, model.PriorityHigh:
+		return nil
+	}
+	return &model.ErrValidation{Field: "priority", Reason: "must be low, medium or high"}
 }
 
-// UpdateTODO updates the TODO on DB.
-func (s *TODOService) UpdateTODO(ctx context.Context, id int64, subject, description string) (*model.TODO, error) {
-	const (
-		update  = `UPDATE todos SET subject = ?, description = ? WHERE id = ?`
-		confirm = `SELECT subject, description, created_at, updated_at FROM todos WHERE id = ?`
-	)
-	//TODOを更新
-	result, err := s.db.ExecContext(ctx, update, subject, description, id)
+// TODOVersion returns the version of the TODO list used for conditional requests.
+func (s *TODOService) TODOVersion(ctx context.Context) (*model.TODOVersion, error) {
+	return s.repo.TODOs().Version(ctx)
+}
+
+// statsDays is the number of days TODOStats counts created TODOs for, including today.
+const statsDays = 30
+
+// TODOStats returns the aggregate counts of the TODOs. CreatedPerDay has an
+// entry for each of the last statsDays days (UTC), oldest first, and TODOs
+// without priority are counted as "none".
+func (s *TODOService) TODOStats(ctx context.Context) (*model.TODOStats, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(statsDays - 1))
+	stats, err := s.repo.TODOs().Stats(ctx, since)
 	if err != nil {
-		//更新処理中にエラーが発生すれば、そのエラーを返す
 		return nil, err
 	}
 
-	//影響を受けた行数を確認
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		//行数取得中にエラーが発生すれば、そのエラーを返す
-		return nil, err
+	stats.Open = stats.Total - stats.Done
+	if n, ok := stats.ByPriority[""]; ok {
+		delete(stats.ByPriority, "")
+		stats.ByPriority["none"] = n
 	}
-	//もし更新された行が0のとき
-	if rowsAffected == 0 {
-		//エラーとして、「対象のTODOが見つかりませんでした」と返す。
-		return nil, &model.ErrNotFound{Resource: "TODO"}
+
+	//TODOが作成されなかった日も0件として埋める
+	counts := make(map[string]int64, len(stats.CreatedPerDay))
+	for _, c := range stats.CreatedPerDay {
+		counts[c.Date] = c.Count
 	}
-	//更新されたTODOを取得
-	row := s.db.QueryRowContext(ctx, confirm, id)
-	todo := &model.TODO{
-		ID: id,
+	stats.CreatedPerDay = make([]model.TODODayCount, 0, statsDays)
+	for d := since; !d.After(today); d = d.AddDate(0, 0, 1) {
+		date := d.Format(time.DateOnly)
+		stats.CreatedPerDay = append(stats.CreatedPerDay, model.TODODayCount{Date: date, Count: counts[date]})
 	}
-	err = row.Scan(&todo.Subject, &todo.Description, &todo.CreatedAt, &todo.UpdatedAt)
+	return stats, nil
+}
+
+// TODOChanges returns the TODOs created, updated and deleted at or after
+// since, for clients keeping a local copy. NextSince is the cursor to pass as
+// since to the following call. It is the time of this call truncated to the
+// second, as some databases do not store finer timestamps, so a change may
+// be returned again by the following call but is never missed.
+func (s *TODOService) TODOChanges(ctx context.Context, since time.Time) (*model.TODOChanges, error) {
+	//取得より前の時刻を次のカーソルにするため、先に現在時刻を取る
+	next := time.Now().Truncate(time.Second)
+	changes, err := s.repo.TODOs().Changes(ctx, since)
 	if err != nil {
-		//データ取得中にエラーが発生すれば、そのエラーを返す
 		return nil, err
 	}
-	//更新されたTODOを返す
-	return todo, nil
+	changes.NextSince = base64.RawURLEncoding.EncodeToString([]byte(next.UTC().Format(time.RFC3339)))
+	return changes, nil
 }
 
-// DeleteTODO deletes TODOs on DB by ids.
-func (s *TODOService) DeleteTODO(ctx context.Context, ids []int64) error {
-	//DELETE文のフォーマット文字列
-	//プレースホルダ―は後で埋め込む
-	const deleteFmt = `DELETE FROM todos WHERE id IN (%s)`
-
-	//削除対象のIDリストが空の場合は、何もせずに終了
-	if len(ids) == 0 {
-		return nil
+// ParseChangesCursor parses the since of TODOChanges, either the NextSince of a
+// previous call or an RFC 3339 timestamp. It returns *model.ErrValidation
+// if s is neither.
+func ParseChangesCursor(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
 	}
+	if b, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		if t, err := time.Parse(time.RFC3339, string(b)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, &model.ErrValidation{Field: "since", Reason: "must be a timestamp (RFC 3339) or the next token of a previous response"}
+}
 
-	//"?,?,?"の形式のプレースホルダ―文字列を作成
-	Formats := strings.Repeat("?,", len(ids)) //IDの数だけ"?,"
-	Formats = Formats[:len(Formats)-1]        //最後のカンマを削除
-	query := fmt.Sprintf(deleteFmt, Formats)  //フォーマット文字列に埋め込む
-
-	//ids([]int64)を[]interface{}に変換する
-	//ExecContextは引数に[]interface{}型を必要とするため
+// UpdateTODO updates the TODO on DB.
+func (s *TODOService) UpdateTODO(ctx context.Context, id int64, subject, description string) (*model.TODO, error) {
+	return s.PatchTODO(ctx, id, &model.TODOPatch{Subject: &subject, Description: &description})
+}
 
-	inter := make([]interface{}, len(ids))
-	for i, id := range ids {
-		inter[i] = id
+// PatchTODO applies the non-nil fields of patch to the TODO.
+// It returns *model.ErrValidation if the priority is unknown,
+// *model.ErrNotFound if the TODO or the project does not exist, and
+// *model.ErrLocked if another API key claimed the TODO (see ClaimTODO).
+func (s *TODOService) PatchTODO(ctx context.Context, id int64, patch *model.TODOPatch) (*model.TODO, error) {
+	if patch.Priority != nil {
+		if err := validatePriority(*patch.Priority); err != nil {
+			return nil, err
+		}
 	}
 
-	//DELETEクエリを実行
-	result, err := s.db.ExecContext(ctx, query, inter...)
-	if err != nil {
-		//クエリ実行中にエラーが発生した場合
+	var todo *model.TODO
+	err := s.WithTx(ctx, func(r repository.Repository) error {
+		if patch.ProjectID != nil {
+			if err := existsProject(ctx, r, *patch.ProjectID); err != nil {
+				return err
+			}
+		}
+		//元に戻せるよう、更新前の状態を記録する
+		before, err := r.TODOs().Find(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkLock(ctx, r, id, time.Now()); err != nil {
+			return err
+		}
+		if err := s.pushUndo(ctx, r, &model.UndoEntry{Action: model.UndoActionUpdate, TODOs: []*model.TODO{before}}); err != nil {
+			return err
+		}
+		todo, err = r.TODOs().Update(ctx, id, patch)
 		return err
-	}
-	//削除された行数を取得
-	rowsAffected, err := result.RowsAffected()
+	})
 	if err != nil {
-		//行数取得中にエラーが発生した場合
+		return nil, err
+	}
+	return todo, nil
+}
+
+// CloneTODO creates a copy of the TODO of id with a new ID and timestamps.
+// The subject, description, priority and project are copied; the copy is open
+// and not archived. If withComments is true, the comments are copied too, oldest first.
+// It returns *model.ErrNotFound if the TODO does not exist.
+func (s *TODOService) CloneTODO(ctx context.Context, id int64, withComments bool) (*model.TODO, error) {
+	var clone *model.TODO
+	err := s.WithTx(ctx, func(r repository.Repository) error {
+		src, err := r.TODOs().Find(ctx, id)
+		if err != nil {
+			return err
+		}
+		clone, err = r.TODOs().Create(ctx, &model.TODO{
+			Subject:     src.Subject,
+			Description: src.Description,
+			Priority:    src.Priority,
+			ProjectID:   src.ProjectID,
+		})
+		if err != nil || !withComments {
+			return err
+		}
+
+		comments, err := readComments(ctx, r, id)
+		if err != nil {
+			return err
+		}
+		//コメントは新しい順に読み出されるため、古い順に作成し直す
+		for i := len(comments) - 1; i >= 0; i-- {
+			if _, err := r.Comments().Create(ctx, clone.ID, comments[i].Body); err != nil {
+				return err
+			}
+		}
+		clone, err = r.TODOs().Find(ctx, clone.ID)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return clone, nil
+}
 
-	//削除対象が見るからなかった場合は、ErrNotFoundを返す
-	if rowsAffected == 0 {
-		return &model.ErrNotFound{Resource: "TODO"}
+// ArchiveTODO archives the TODO, or unarchives it if archived is false.
+// Archived TODOs are kept but excluded from the default list.
+func (s *TODOService) ArchiveTODO(ctx context.Context, id int64, archived bool) (*model.TODO, error) {
+	return s.PatchTODO(ctx, id, &model.TODOPatch{Archived: &archived})
+}
+
+// purgePageSize is the number of TODOs PurgeArchivedTODO deletes in a transaction.
+const purgePageSize = 100
+
+// PurgeArchivedTODO deletes the archived TODOs which were not updated since
+// before, with their comments, and returns how many were deleted.
+// Purged TODOs are not recorded in the undo log.
+func (s *TODOService) PurgeArchivedTODO(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	for {
+		//長時間ロックしないよう、一定件数ずつ別のトランザクションで削除する
+		var n int
+		err := s.WithTx(ctx, func(r repository.Repository) error {
+			todos, err := r.TODOs().List(ctx, &model.TODOQuery{Archived: true, UpdatedBefore: before, Size: purgePageSize})
+			if err != nil || len(todos) == 0 {
+				return err
+			}
+			ids := make([]int64, len(todos))
+			for i, todo := range todos {
+				ids[i] = todo.ID
+			}
+			n = len(ids)
+			if err := r.Locks().Delete(ctx, ids); err != nil {
+				return err
+			}
+			return r.TODOs().Delete(ctx, ids)
+		})
+		if err != nil {
+			return purged, err
+		}
+		purged += int64(n)
+		if n < purgePageSize {
+			return purged, nil
+		}
 	}
+}
 
-	//正常終了
-	return nil
+// DeleteTODO deletes TODOs on DB by ids, with their locks. It returns
+// *model.ErrLocked if another API key claimed any of them.
+func (s *TODOService) DeleteTODO(ctx context.Context, ids []int64) error {
+	//複数件の一括削除は管理者のみ許可する
+	if len(ids) > 1 {
+		if err := Authorize(ctx, model.PermissionBulkDelete); err != nil {
+			return err
+		}
+	}
+	//コメントの削除も含めて、全て削除されるか何も削除されないかのどちらかにする
+	return s.WithTx(ctx, func(r repository.Repository) error {
+		now := time.Now()
+		for _, id := range ids {
+			if err := checkLock(ctx, r, id, now); err != nil {
+				return err
+			}
+		}
+		entry, err := snapshotDelete(ctx, r, ids)
+		if err != nil {
+			return err
+		}
+		if len(entry.TODOs) > 0 {
+			if err := s.pushUndo(ctx, r, entry); err != nil {
+				return err
+			}
+		}
+		if err := r.Locks().Delete(ctx, ids); err != nil {
+			return err
+		}
+		return r.TODOs().Delete(ctx, ids)
+	})
 }
@@ -0,0 +1,120 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/db"
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+// openBackends returns the databases to run the integration tests against.
+// PostgreSQL and MySQL are tested only when TEST_POSTGRES_DSN / TEST_MYSQL_DSN are set.
+func openBackends(t *testing.T) map[string]*sql.DB {
+	t.Helper()
+
+	backends := map[string]string{
+		"sqlite3":  filepath.Join(t.TempDir(), "service_test.db"),
+		"postgres": os.Getenv("TEST_POSTGRES_DSN"),
+		"mysql":    os.Getenv("TEST_MYSQL_DSN"),
+	}
+
+	dbs := make(map[string]*sql.DB, len(backends))
+	for driver, dsn := range backends {
+		if dsn == "" {
+			continue
+		}
+		d, err := db.Open(driver, dsn)
+		if err != nil {
+			t.Fatalf("failed to open %s, err = %v", driver, err)
+		}
+		t.Cleanup(func() { d.Close() })
+
+		// 前回のテストデータを削除する
+		for _, q := range []string{`DELETE FROM comments`, `DELETE FROM todos`, `DELETE FROM projects`, `DELETE FROM undo_log`, `DELETE FROM template_items`, `DELETE FROM templates`} {
+			if _, err := d.Exec(q); err != nil {
+				t.Fatalf("failed to cleanup %s, err = %v", driver, err)
+			}
+		}
+		dbs[driver] = d
+	}
+	return dbs
+}
+
+func TestTODOService(t *testing.T) {
+	for driver, d := range openBackends(t) {
+		d := d
+		t.Run(driver, func(t *testing.T) {
+			ctx := context.Background()
+			svc := service.NewTODOService(d)
+			comments := service.NewCommentService(d)
+
+			created, err := svc.CreateTODO(ctx, "subject", "description")
+			if err != nil {
+				t.Fatal("failed to create TODO, err =", err)
+			}
+			if created.ID == 0 || created.CreatedAt.IsZero() {
+				t.Errorf("unexpected created TODO, got = %+v", created)
+			}
+			second, err := svc.CreateTODO(ctx, "second", "")
+			if err != nil {
+				t.Fatal("failed to create TODO, err =", err)
+			}
+
+			if _, err := comments.CreateComment(ctx, created.ID, "comment"); err != nil {
+				t.Fatal("failed to create comment, err =", err)
+			}
+
+			todos, err := svc.ReadTODO(ctx, 0, 5)
+			if err != nil {
+				t.Fatal("failed to read TODOs, err =", err)
+			}
+			if len(todos) != 2 || todos[0].ID != second.ID || todos[1].CommentCount != 1 {
+				t.Errorf("unexpected TODOs, got = %+v", todos)
+			}
+
+			todos, err = svc.ReadTODO(ctx, second.ID, 5)
+			if err != nil {
+				t.Fatal("failed to read TODOs, err =", err)
+			}
+			if len(todos) != 1 || todos[0].ID != created.ID {
+				t.Errorf("unexpected TODOs with prev_id, got = %+v", todos)
+			}
+
+			found, err := svc.SearchTODO(ctx, "SECOND", 0, 5)
+			if err != nil {
+				t.Fatal("failed to search TODOs, err =", err)
+			}
+			if len(found) != 1 || found[0].ID != second.ID {
+				t.Errorf("unexpected search result, got = %+v", found)
+			}
+			if found, err := svc.SearchTODO(ctx, "%", 0, 5); err != nil || len(found) != 0 {
+				t.Errorf("wildcards must be escaped, got = %+v, err = %v", found, err)
+			}
+
+			updated, err := svc.UpdateTODO(ctx, created.ID, "updated", "updated description")
+			if err != nil {
+				t.Fatal("failed to update TODO, err =", err)
+			}
+			if updated.Subject != "updated" || updated.UpdatedAt.Before(created.UpdatedAt) {
+				t.Errorf("unexpected updated TODO, got = %+v", updated)
+			}
+			if _, err := svc.UpdateTODO(ctx, second.ID+100, "subject", ""); err == nil {
+				t.Error("expected ErrNotFound for unknown TODO")
+			} else if _, ok := err.(*model.ErrNotFound); !ok {
+				t.Errorf("unexpected error type, got = %T", err)
+			}
+
+			if err := svc.DeleteTODO(ctx, []int64{created.ID, second.ID}); err != nil {
+				t.Fatal("failed to delete TODOs, err =", err)
+			}
+			if err := svc.DeleteTODO(ctx, []int64{created.ID}); err == nil {
+				t.Error("expected ErrNotFound for deleted TODO")
+			}
+		})
+	}
+}
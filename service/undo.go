@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// DefaultUndoWindow is the UndoWindow used if TODOOptions does not set one.
+const DefaultUndoWindow = 5 * time.Minute
+
+// UndoTODO reverts the newest update or delete of TODOs made with the API key
+// of ctx within the undo window, and returns the action and the restored TODOs.
+// Requests without an API key share one undo log per workspace.
+// It returns *model.ErrNotFound if there is nothing to undo, and
+// *model.ErrLocked, keeping the entry, if an updated TODO is claimed by
+// another API key.
+func (s *TODOService) UndoTODO(ctx context.Context) (string, []*model.TODO, error) {
+	var (
+		action string
+		todos  []*model.TODO
+	)
+	err := s.WithTx(ctx, func(r repository.Repository) error {
+		entry, err := r.Undo().Pop(ctx, apiKeyID(ctx), time.Now().Add(-s.opts.UndoWindow))
+		if err != nil {
+			return err
+		}
+		action = entry.Action
+		switch entry.Action {
+		case model.UndoActionUpdate:
+			todos, err = undoUpdate(ctx, r, entry)
+		case model.UndoActionDelete:
+			todos, err = undoDelete(ctx, r, entry)
+		}
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return action, todos, nil
+}
+
+// undoUpdate writes back the fields of the TODOs of entry. Projects deleted
+// since the update are not restored.
+func undoUpdate(ctx context.Context, r repository.Repository, entry *model.UndoEntry) ([]*model.TODO, error) {
+	//他のAPIキーが確保したTODOは、元に戻す場合も書き換えない
+	now := time.Now()
+	for _, before := range entry.TODOs {
+		if err := checkLock(ctx, r, before.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	todos := make([]*model.TODO, 0, len(entry.TODOs))
+	for _, before := range entry.TODOs {
+		projectID := before.ProjectID
+		if err := existsProject(ctx, r, projectID); err != nil {
+			if _, ok := err.(*model.ErrNotFound); !ok {
+				return nil, err
+			}
+			projectID = 0
+		}
+		todo, err := r.TODOs().Update(ctx, before.ID, &model.TODOPatch{
+			Subject:     &before.Subject,
+			Description: &before.Description,
+			Done:        &before.Done,
+			Priority:    &before.Priority,
+			Archived:    &before.Archived,
+			ProjectID:   &projectID,
+			AssigneeID:  &before.AssigneeID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, nil
+}
+
+// undoDelete inserts the TODOs and comments of entry again with their IDs.
+func undoDelete(ctx context.Context, r repository.Repository, entry *model.UndoEntry) ([]*model.TODO, error) {
+	for _, todo := range entry.TODOs {
+		//削除後にプロジェクトも削除されていた場合は、プロジェクトなしで復元する
+		if err := existsProject(ctx, r, todo.ProjectID); err != nil {
+			if _, ok := err.(*model.ErrNotFound); !ok {
+				return nil, err
+			}
+			todo.ProjectID = 0
+		}
+		if err := r.TODOs().Restore(ctx, todo, entry.Positions[todo.ID]); err != nil {
+			return nil, err
+		}
+	}
+	for _, comment := range entry.Comments {
+		if err := r.Comments().Restore(ctx, comment); err != nil {
+			return nil, err
+		}
+	}
+
+	todos := make([]*model.TODO, 0, len(entry.TODOs))
+	for _, t := range entry.TODOs {
+		todo, err := r.TODOs().Find(ctx, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, nil
+}
+
+// snapshotDelete returns the undo entry of deleting the TODOs of ids with
+// their positions and comments. TODOs which do not exist are skipped.
+func snapshotDelete(ctx context.Context, r repository.Repository, ids []int64) (*model.UndoEntry, error) {
+	entry := &model.UndoEntry{Action: model.UndoActionDelete}
+	found := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		todo, err := r.TODOs().Find(ctx, id)
+		if _, ok := err.(*model.ErrNotFound); ok {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		comments, err := readComments(ctx, r, id)
+		if err != nil {
+			return nil, err
+		}
+		entry.TODOs = append(entry.TODOs, todo)
+		entry.Comments = append(entry.Comments, comments...)
+		found = append(found, id)
+	}
+	if len(found) == 0 {
+		return entry, nil
+	}
+
+	positions, err := r.TODOs().Positions(ctx, found)
+	if err != nil {
+		return nil, err
+	}
+	entry.Positions = positions
+	return entry, nil
+}
+
+// ExpireUndo deletes the entries of the undo log of the workspace of ctx
+// which are older than the undo window.
+func (s *TODOService) ExpireUndo(ctx context.Context) error {
+	return s.WithTx(ctx, func(r repository.Repository) error {
+		return r.Undo().Prune(ctx, time.Now().Add(-s.opts.UndoWindow))
+	})
+}
+
+// pushUndo records entry for the API key of ctx and drops the expired entries.
+func (s *TODOService) pushUndo(ctx context.Context, r repository.Repository, entry *model.UndoEntry) error {
+	if err := r.Undo().Prune(ctx, time.Now().Add(-s.opts.UndoWindow)); err != nil {
+		return err
+	}
+	entry.APIKeyID = apiKeyID(ctx)
+	return r.Undo().Push(ctx, entry)
+}
+
+// apiKeyID returns the ID of the API key of ctx, or 0 if there is none.
+// Undo logs and TODO locks are kept per API key, shared by the requests
+// without one.
+func apiKeyID(ctx context.Context) int64 {
+	if key, ok := APIKeyFromContext(ctx); ok {
+		return key.ID
+	}
+	return 0
+}
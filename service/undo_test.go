@@ -0,0 +1,57 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/service"
+)
+
+func TestTODOService_Undo(t *testing.T) {
+	for driver, d := range openBackends(t) {
+		d := d
+		t.Run(driver, func(t *testing.T) {
+			ctx := context.Background()
+			svc := service.NewTODOService(d)
+			comments := service.NewCommentService(d)
+
+			todo, err := svc.CreateTODO(ctx, "subject", "description")
+			if err != nil {
+				t.Fatal("failed to create TODO, err =", err)
+			}
+			if _, err := comments.CreateComment(ctx, todo.ID, "comment"); err != nil {
+				t.Fatal("failed to create comment, err =", err)
+			}
+			if _, err := svc.UpdateTODO(ctx, todo.ID, "updated", ""); err != nil {
+				t.Fatal("failed to update TODO, err =", err)
+			}
+			if err := svc.DeleteTODO(ctx, []int64{todo.ID}); err != nil {
+				t.Fatal("failed to delete TODO, err =", err)
+			}
+
+			//削除、更新の順に新しい操作から元に戻す
+			action, todos, err := svc.UndoTODO(ctx)
+			if err != nil {
+				t.Fatal("failed to undo delete, err =", err)
+			}
+			if action != model.UndoActionDelete || len(todos) != 1 || todos[0].ID != todo.ID || todos[0].Subject != "updated" || todos[0].CommentCount != 1 {
+				t.Errorf("unexpected undo of delete, action = %s, got = %+v", action, todos)
+			}
+
+			action, todos, err = svc.UndoTODO(ctx)
+			if err != nil {
+				t.Fatal("failed to undo update, err =", err)
+			}
+			if action != model.UndoActionUpdate || len(todos) != 1 || todos[0].Subject != "subject" || todos[0].Description != "description" {
+				t.Errorf("unexpected undo of update, action = %s, got = %+v", action, todos)
+			}
+
+			if _, _, err := svc.UndoTODO(ctx); err == nil {
+				t.Error("expected ErrNotFound when nothing is left to undo")
+			} else if _, ok := err.(*model.ErrNotFound); !ok {
+				t.Errorf("unexpected error type, got = %T", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+
+	"github.com/TechBowl-japan/go-stations/model"
+	"github.com/TechBowl-japan/go-stations/repository"
+)
+
+// A WorkspaceService implements workspaces and their members.
+type WorkspaceService struct {
+	repo repository.Repository
+}
+
+// NewWorkspaceServiceWithRepository returns new WorkspaceService backed by repo.
+func NewWorkspaceServiceWithRepository(repo repository.Repository) *WorkspaceService {
+	return &WorkspaceService{
+		repo: repo,
+	}
+}
+
+// CreateWorkspace creates a Workspace.
+func (s *WorkspaceService) CreateWorkspace(ctx context.Context, name string) (*model.Workspace, error) {
+	if name == "" {
+		return nil, &model.ErrValidation{Field: "name", Reason: "must not be empty"}
+	}
+	return s.repo.Workspaces().Create(ctx, name)
+}
+
+// ReadWorkspaces reads all Workspaces.
+func (s *WorkspaceService) ReadWorkspaces(ctx context.Context) ([]*model.Workspace, error) {
+	return s.repo.Workspaces().Read(ctx)
+}
+
+// AddMember lets the APIKey access the Workspace. It returns
+// *model.ErrNotFound if either does not exist.
+func (s *WorkspaceService) AddMember(ctx context.Context, workspaceID, apiKeyID int64) error {
+	return s.repo.WithTx(ctx, func(r repository.Repository) error {
+		if _, err := r.Workspaces().Find(ctx, workspaceID); err != nil {
+			return err
+		}
+		if _, err := r.APIKeys().Find(ctx, apiKeyID); err != nil {
+			return err
+		}
+		return r.Workspaces().AddMember(ctx, workspaceID, apiKeyID)
+	})
+}
+
+// RemoveMember revokes the access of the APIKey to the Workspace.
+func (s *WorkspaceService) RemoveMember(ctx context.Context, workspaceID, apiKeyID int64) error {
+	return s.repo.Workspaces().RemoveMember(ctx, workspaceID, apiKeyID)
+}
+
+// Enter returns a copy of ctx scoped to the Workspace of id, after checking
+// that the APIKey of ctx may access it. Admin keys may access every
+// workspace, other keys only those they are members of, and requests
+// without a key none. The default workspace is open to everyone.
+func (s *WorkspaceService) Enter(ctx context.Context, id int64) (context.Context, error) {
+	if id == model.DefaultWorkspace {
+		return repository.WithWorkspace(ctx, id), nil
+	}
+	if _, err := s.repo.Workspaces().Find(ctx, id); err != nil {
+		return nil, err
+	}
+	key, ok := APIKeyFromContext(ctx)
+	//APIキーのないリクエストは誰かを確かめられないため、既定のワークスペースに限る
+	if !ok {
+		return nil, &model.ErrForbidden{Permission: model.PermissionWorkspace}
+	}
+	if !key.Can(model.PermissionAdmin) {
+		member, err := s.repo.Workspaces().IsMember(ctx, id, key.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !member {
+			return nil, &model.ErrForbidden{Permission: model.PermissionWorkspace}
+		}
+	}
+	return repository.WithWorkspace(ctx, id), nil
+}